@@ -1,12 +1,15 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"local-pruebas/pkg/pdf" // Asegúrate de que esta ruta de importación sea correcta para tu proyecto
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"syscall"
 )
 
 // Estructura y función para cargar la página HTML (sin cambios significativos, solo manejo de errores)
@@ -25,40 +28,144 @@ func loadPage(filename string) (*Page, error) {
 	return &Page{Title: "PDF Access View", Body: body}, nil // Título más descriptivo
 }
 
-// viewHandler: Sirve la página HTML que contiene los formularios de acceso
-func viewHandler(w http.ResponseWriter, r *http.Request) {
-	path, _ := os.Getwd() // Obtiene el directorio de trabajo actual
-	// Nota: Esta ruta asume que main.go se ejecuta desde la raíz del módulo
-	// y que pkg/pdf está en esa raíz.
-	viewFilePath := filepath.Join(path, "pkg", "pdf", "view.html")
+// newViewHandler: Sirve la página HTML que contiene los formularios de acceso.
+// cookieName es la cookie de sesión configurada (COOKIE_NAME).
+func newViewHandler(cookieName string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		path, _ := os.Getwd() // Obtiene el directorio de trabajo actual
+		// Nota: Esta ruta asume que main.go se ejecuta desde la raíz del módulo
+		// y que pkg/pdf está en esa raíz.
+		viewFilePath := filepath.Join(path, "pkg", "pdf", "view.html")
 
-	// Intentar obtener la cookie de autenticación
-	_, err := r.Cookie("auth_code")
-	if err != nil {
-		// Cookie no encontrada o error al leerla
-		// Código de acceso en la cookie no válido
-		viewFilePath = filepath.Join(path, "pkg", "pdf", "view_home.html")
-		// http.Error(w, "No autenticado. Por favor, inicie sesión.", http.StatusUnauthorized)
-		// return
+		// Intentar obtener la cookie de autenticación
+		_, err := r.Cookie(cookieName)
+		if err != nil {
+			// Cookie no encontrada o error al leerla
+			// Código de acceso en la cookie no válido
+			viewFilePath = filepath.Join(path, "pkg", "pdf", "view_home.html")
+			// http.Error(w, "No autenticado. Por favor, inicie sesión.", http.StatusUnauthorized)
+			// return
+		}
+		p, _ := loadPage(viewFilePath)
+		fmt.Fprintf(w, "%s", p.Body)
 	}
-	p, _ := loadPage(viewFilePath)
-	fmt.Fprintf(w, "%s", p.Body)
 }
 
 func main() {
-	http.HandleFunc("/view/", viewHandler)
-	http.HandleFunc("/generate-code", pdf.GenerateCodeHandler)
-	http.HandleFunc("/login", pdf.LoginHandler)
+	// Leer y validar la configuración antes de nada; con valores inválidos no se arranca
+	cfg, err := pdf.LoadConfig()
+	if err != nil {
+		log.Fatalf("Configuración inválida:\n%v", err)
+	}
+	pdf.Configure(cfg)
+	codes, err := pdf.NewCodeStore(cfg)
+	if err != nil {
+		log.Fatalf("No se pudo cargar el almacén de códigos: %v", err)
+	}
+	srv := pdf.NewServer(cfg, codes)
+
+	http.HandleFunc("/view/", newViewHandler(cfg.CookieName))
+	http.HandleFunc("/generate-code", srv.CORSMiddleware(srv.GenerateCodeHandler))
+	http.HandleFunc("/login", srv.CORSMiddleware(srv.LoginHandler))
 	// --- Handlers de PDF (Ahora protegidos por el Middleware de Autenticación) ---
 	// Envolvemos cada handler con el AuthMiddleware.
 	// El middleware se ejecutará primero, verificará la cookie, y si es válida,
 	// llamará al handler original (UploadHandler, ListHandler, etc.)
-	http.HandleFunc("/upload", pdf.AuthMiddleware(pdf.UploadHandler))
-	http.HandleFunc("/list", pdf.AuthMiddleware(pdf.ListHandler))
-	http.HandleFunc("/generate", pdf.AuthMiddleware(pdf.GenerateHandler))
-	http.HandleFunc("/download", pdf.AuthMiddleware(pdf.DownloadHandler))
-	http.HandleFunc("/delete", pdf.AuthMiddleware(pdf.DeleteFilesHandler))
+	// CORSMiddleware va por fuera para que el preflight OPTIONS no exija la cookie.
+	authed := func(h http.HandlerFunc) http.HandlerFunc {
+		return srv.CORSMiddleware(srv.AuthMiddleware(h))
+	}
+	// Las rutas de administración exigen además un código de ADMIN_CODES
+	admin := func(h http.HandlerFunc) http.HandlerFunc {
+		return srv.CORSMiddleware(srv.AdminMiddleware(h))
+	}
+	http.HandleFunc("/upload", authed(srv.UploadHandler))
+	http.HandleFunc("/upload-chunk", authed(srv.UploadChunkHandler))
+	http.HandleFunc("/upload-chunk/complete", authed(srv.UploadChunkCompleteHandler))
+	http.HandleFunc("/list", authed(srv.ListHandler))
+	http.HandleFunc("/generate", authed(srv.GenerateHandler))
+	http.HandleFunc("/merge-urls", authed(srv.MergeURLsHandler))
+	http.HandleFunc("/merge-folders", authed(srv.MergeFoldersHandler))
+	http.HandleFunc("/download", authed(srv.DownloadHandler))
+	http.HandleFunc("/delete", authed(srv.DeleteFilesHandler))
+	http.HandleFunc("/trash", authed(srv.ListTrashHandler))
+	http.HandleFunc("/restore", authed(srv.RestoreHandler))
+	http.HandleFunc("/count", authed(srv.CountHandler))
+	http.HandleFunc("/next-index", authed(srv.NextIndexHandler))
+	http.HandleFunc("/exists", authed(srv.ExistsHandler))
+	http.HandleFunc("/clear", authed(srv.ClearFolderHandler))
+	http.HandleFunc("/rename-folder", authed(srv.RenameFolderHandler))
+	http.HandleFunc("/copy-folder", authed(srv.CopyFolderHandler))
+	http.HandleFunc("/append", authed(srv.AppendHandler))
+	http.HandleFunc("/extract", authed(srv.ExtractHandler))
+	http.HandleFunc("/insert", authed(srv.InsertHandler))
+	http.HandleFunc("/remove-pages", authed(srv.RemovePagesHandler))
+	http.HandleFunc("/split-bookmarks", authed(srv.SplitByBookmarksHandler))
+	http.HandleFunc("/manifest", authed(srv.ManifestHandler))
+	http.HandleFunc("/repair", authed(srv.RepairHandler))
+	http.HandleFunc("/thumbnail", authed(srv.ThumbnailHandler))
+	http.HandleFunc("/preview", authed(srv.PreviewHandler))
+	http.HandleFunc("/job-status", authed(srv.JobStatusHandler))
+	http.HandleFunc("/events", authed(srv.EventsHandler))
+	http.HandleFunc("/me", authed(srv.WhoAmIHandler))
+	http.HandleFunc("/preferences", authed(srv.PreferencesHandler))
+	http.HandleFunc("/quota", authed(srv.QuotaHandler))
+	http.HandleFunc("/admin/generate-codes", admin(srv.GenerateCodesBatchHandler))
+	http.HandleFunc("/admin/usage", admin(srv.AdminUsageHandler))
+	http.HandleFunc("/admin/gc", admin(srv.AdminGCHandler))
+	http.HandleFunc("/admin/config", admin(srv.AdminConfigHandler))
+	http.HandleFunc("/metrics", admin(srv.MetricsHandler))
+
+	// Dirección de escucha configurable con LISTEN_ADDR (ej: 0.0.0.0:9000); por defecto :8080
+	addr := cfg.ListenAddr
 
-	fmt.Println("Server starting on :8080") // Mensaje de inicio del servidor
-	log.Fatal(http.ListenAndServe(":8080", nil))
+	// Sin timeouts una conexión lenta o colgada ocuparía el servidor indefinidamente
+	// Todas las rutas pasan por RequestIDMiddleware, también los errores de CORS y de sesión
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           srv.RequestIDMiddleware(http.DefaultServeMux.ServeHTTP),
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		ReadTimeout:       cfg.ReadTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+	}
+
+	// Arrancar el servidor en segundo plano para poder escuchar las señales de apagado.
+	// Con TLS_CERT y TLS_KEY sirve HTTPS directamente, sin proxy delante.
+	go func() {
+		var err error
+		if cfg.TLSEnabled() {
+			fmt.Println("Server starting on", addr, "(HTTPS)")
+			err = server.ListenAndServeTLS(cfg.TLSCert, cfg.TLSKey)
+		} else {
+			fmt.Println("Server starting on", addr) // Mensaje de inicio del servidor
+			err = server.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
+			log.Fatal(err)
+		}
+	}()
+
+	// Esperar SIGINT/SIGTERM y drenar las peticiones en curso antes de salir,
+	// así no se corta una unión o una subida a mitad de escritura
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Vaciar periódicamente lo que lleva en la papelera más de TRASH_MAX_AGE
+	go srv.PurgeTrashLoop(ctx)
+	// y las subidas por fragmentos abandonadas hace más de CHUNK_UPLOAD_MAX_AGE
+	go srv.PurgeChunkUploadsLoop(ctx)
+	<-ctx.Done()
+
+	fmt.Println("Shutting down server...")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Println("Error during shutdown:", err)
+	}
+	if err := srv.WaitMergeJobs(shutdownCtx); err != nil {
+		log.Println("Async merges still running at shutdown:", err)
+	}
+	fmt.Println("Server stopped")
 }
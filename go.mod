@@ -5,6 +5,7 @@ go 1.23.0
 require (
 	github.com/pdfcpu/pdfcpu v0.9.1
 	github.com/stretchr/testify v1.9.0
+	golang.org/x/image v0.21.0
 )
 
 require (
@@ -16,7 +17,6 @@ require (
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	github.com/rivo/uniseg v0.4.7 // indirect
 	github.com/stretchr/objx v0.5.2 // indirect
-	golang.org/x/image v0.21.0 // indirect
 	golang.org/x/text v0.19.0 // indirect
 	gopkg.in/yaml.v2 v2.4.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
@@ -0,0 +1,99 @@
+package pdf
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"sort"
+	"time"
+)
+
+// --- Fecha de alta de los archivos ---
+// La fecha de modificación cambia cada vez que un archivo se reescribe (repair,
+// grayscale...). Por eso al subir se guarda aparte cuándo se agregó cada archivo, en un
+// manifiesto oculto por carpeta, y sort=added en ListHandler ordena por esa fecha.
+
+// Orden alternativo de ListHandler (sort=added)
+const sortByAdded = "added"
+
+// Manifiesto de fechas de alta de una carpeta; no termina en .pdf, así que los listados no lo ven
+const addedManifestName = ".added.json"
+
+// Tamaño máximo que se lee del manifiesto
+const maxAddedManifestBytes = 4 << 20
+
+// readAddedTimes devuelve la fecha de alta registrada de cada archivo de folder. Sin
+// manifiesto devuelve un mapa vacío.
+func readAddedTimes(store Storage, folder string) (map[string]time.Time, error) {
+	added := map[string]time.Time{}
+	file, err := store.Open(folder, addedManifestName)
+	if errors.Is(err, fs.ErrNotExist) {
+		return added, nil
+	}
+	if err != nil {
+		return added, err
+	}
+	defer file.Close()
+	err = json.NewDecoder(io.LimitReader(file, maxAddedManifestBytes)).Decode(&added)
+	return added, err
+}
+
+// recordAdded registra at como fecha de alta de names en folder. Quien llama debe tener
+// el lock de escritura de la carpeta. Un archivo que se vuelve a subir con el mismo
+// nombre recibe la fecha nueva.
+func recordAdded(store Storage, folder string, names []string, at time.Time) error {
+	times := make(map[string]time.Time, len(names))
+	for _, name := range names {
+		times[name] = at
+	}
+	return recordAddedTimes(store, folder, times)
+}
+
+// recordAddedTimes es recordAdded con una fecha de alta distinta para cada archivo
+func recordAddedTimes(store Storage, folder string, times map[string]time.Time) error {
+	if len(times) == 0 {
+		return nil
+	}
+	// Un manifiesto ilegible se reemplaza: perderlo solo hace volver a la fecha de modificación
+	added, _ := readAddedTimes(store, folder)
+	for name, at := range times {
+		added[name] = at
+	}
+	data, err := json.Marshal(added)
+	if err != nil {
+		return err
+	}
+	_, err = store.Save(folder, addedManifestName, bytes.NewReader(data))
+	return err
+}
+
+// sortFilesByAdded ordena los archivos de folder del más antiguo al más reciente según
+// su fecha de alta o, si no está registrada, su fecha de modificación. Los empates
+// mantienen el orden numérico. Si el manifiesto no se puede leer ordena igual, por la
+// fecha de modificación, y devuelve el error para registrarlo.
+func sortFilesByAdded(store Storage, folder string, files []string) error {
+	added, err := readAddedTimes(store, folder)
+	if err != nil {
+		added = map[string]time.Time{}
+	}
+	times := make(map[string]time.Time, len(files))
+	for _, name := range files {
+		if at, ok := added[name]; ok {
+			times[name] = at
+			continue
+		}
+		if info, err := store.Stat(folder, name); err == nil {
+			times[name] = info.ModTime()
+		}
+	}
+	sort.SliceStable(files, func(i, j int) bool {
+		timeI, timeJ := times[files[i]], times[files[j]]
+		if !timeI.Equal(timeJ) {
+			return timeI.Before(timeJ)
+		}
+		return lessByNumber(files[i], files[j])
+	})
+	return err
+}
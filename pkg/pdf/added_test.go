@@ -0,0 +1,136 @@
+package pdf
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestListHandlerSortByAdded(t *testing.T) {
+	// Arrange
+	userPath := t.TempDir()
+	folderPath := filepath.Join(userPath, "test-folder")
+	os.MkdirAll(folderPath, os.ModePerm)
+	base := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	for _, f := range []string{"1-a.pdf", "2-b.pdf", "3-c.pdf", "4-d.pdf"} {
+		os.WriteFile(filepath.Join(folderPath, f), []byte("%PDF"), 0o644)
+	}
+	store := NewLocalStorage(userPath)
+	if err := recordAdded(store, "test-folder", []string{"2-b.pdf"}, base); err != nil {
+		t.Fatal(err)
+	}
+	if err := recordAdded(store, "test-folder", []string{"1-a.pdf", "3-c.pdf"}, base.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	// 1-a se reescribió después (repair, grayscale...): su fecha de alta no cambia
+	os.Chtimes(filepath.Join(folderPath, "1-a.pdf"), base.Add(48*time.Hour), base.Add(48*time.Hour))
+	// 4-d no está en el manifiesto: se usa su fecha de modificación
+	os.Chtimes(filepath.Join(folderPath, "4-d.pdf"), base.Add(30*time.Minute), base.Add(30*time.Minute))
+	srv := newTestServer(userPath)
+	req := httptest.NewRequest(http.MethodGet, "/list?folder=test-folder&sort=added", nil)
+	req = req.WithContext(context.WithValue(req.Context(), userCodeKey, "testUser"))
+	rr := httptest.NewRecorder()
+
+	// Act
+	srv.ListHandler(rr, req)
+
+	// Assert
+	if rr.Code != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+	}
+	var files []string
+	if err := json.NewDecoder(rr.Body).Decode(&files); err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"2-b.pdf", "4-d.pdf", "1-a.pdf", "3-c.pdf"}
+	if !reflect.DeepEqual(files, expected) {
+		t.Errorf("expected %v, got %v", expected, files)
+	}
+}
+
+func TestUploadHandlerRecordsAddedTime(t *testing.T) {
+	// Arrange
+	userPath := t.TempDir()
+	srv := newTestServer(userPath)
+	req, rr := NewUploadRequestBuilder().WithFolder("test-folder").WithFile("informe.pdf", []byte("%PDF-1.4")).Build(t)
+	before := time.Now()
+
+	// Act
+	srv.UploadHandler(rr, req)
+
+	// Assert
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	added, err := readAddedTimes(NewLocalStorage(userPath), "test-folder")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if at, ok := added["1-informe.pdf"]; !ok || at.Before(before) {
+		t.Errorf("expected an added time for 1-informe.pdf, got %v", added)
+	}
+}
+
+func TestRestoreHandlerRecordsAddedTime(t *testing.T) {
+	// Arrange
+	userPath := t.TempDir()
+	id := trashTestFile(t, userPath, "facturas", "1-a.pdf", "%PDF-a", time.Now())
+	srv := newTestServer(userPath)
+	req, rr := newRestoreRequest(`{"id":"` + id + `"}`)
+	before := time.Now()
+
+	// Act
+	srv.RestoreHandler(rr, req)
+
+	// Assert
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	added, err := readAddedTimes(NewLocalStorage(userPath), "facturas")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if at, ok := added["1-a.pdf"]; !ok || at.Before(before) {
+		t.Errorf("expected an added time for 1-a.pdf, got %v", added)
+	}
+}
+
+func TestCopyFolderHandlerKeepsAddedTimes(t *testing.T) {
+	// Arrange
+	userPath := t.TempDir()
+	folderPath := filepath.Join(userPath, "facturas")
+	os.MkdirAll(folderPath, os.ModePerm)
+	base := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	for _, f := range []string{"1-a.pdf", "2-b.pdf"} {
+		os.WriteFile(filepath.Join(folderPath, f), []byte("%PDF"), 0o644)
+	}
+	store := NewLocalStorage(userPath)
+	if err := recordAdded(store, "facturas", []string{"2-b.pdf"}, base); err != nil {
+		t.Fatal(err)
+	}
+	// 1-a no está en el manifiesto: la copia registra su fecha de modificación
+	os.Chtimes(filepath.Join(folderPath, "1-a.pdf"), base.Add(time.Hour), base.Add(time.Hour))
+	srv := newTestServer(userPath)
+	req, rr := newCopyFolderRequest(`{"from":"facturas","to":"facturas-prueba"}`)
+
+	// Act
+	srv.CopyFolderHandler(rr, req)
+
+	// Assert
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	added, err := readAddedTimes(store, "facturas-prueba")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !added["2-b.pdf"].Equal(base) || !added["1-a.pdf"].Equal(base.Add(time.Hour)) {
+		t.Errorf("expected the source added times, got %v", added)
+	}
+}
@@ -0,0 +1,125 @@
+package pdf
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// --- Códigos de administrador ---
+// Se cargan al arrancar desde ADMIN_CODES (separados por comas) en Server.adminCodes.
+// Sin la variable ningún código es administrador y las rutas de admin responden 403.
+
+func newCodeSet(codes []string) map[string]bool {
+	set := make(map[string]bool, len(codes))
+	for _, code := range codes {
+		set[code] = true
+	}
+	return set
+}
+
+// isAdmin indica si el código de acceso pertenece al conjunto de administradores
+func (s *Server) isAdmin(code string) bool {
+	return code != "" && s.adminCodes[code]
+}
+
+// --- Middleware de Administración ---
+// Aplica primero la autenticación normal (cookie válida, código en el contexto)
+// y después exige que el código esté en el conjunto de administradores.
+func (s *Server) AdminMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return s.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		userCode, _ := r.Context().Value(userCodeKey).(string)
+		if !s.isAdmin(userCode) {
+			writeJSONError(w, http.StatusForbidden, "Acceso restringido a administradores")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// AdminUsageHandler: Informa, por cada usuario, el total de bytes y de carpetas.
+// Solo devuelve tamaños agregados, nunca nombres ni contenido de archivos, y cada usuario
+// aparece con userLabel en lugar de su código de acceso.
+func (s *Server) AdminUsageHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, http.MethodGet)
+		return
+	}
+
+	usage, err := collectUsage(s.cfg.StorageRoot, s.userLabel)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Error al calcular el uso de almacenamiento")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, usage)
+}
+
+// userLabel identifica al usuario de la carpeta code sin revelar el código, que es su
+// credencial de login: devuelve el nombre asociado o, si no lo hay o coincide con el
+// propio código, un resumen corto del código que permite distinguir usuarios.
+func (s *Server) userLabel(code string) string {
+	if info, ok := s.codes.Get(code); ok && info.Name != "" && info.Name != code {
+		return info.Name
+	}
+	sum := sha256.Sum256([]byte(code))
+	return "sha256:" + hex.EncodeToString(sum[:6])
+}
+
+// collectUsage recorre la raíz de almacenamiento; si todavía no existe devuelve una lista vacía.
+// label convierte el nombre de cada carpeta de usuario (su código) en lo que se informa.
+func collectUsage(root string, label func(code string) string) ([]UserUsage, error) {
+	usage := []UserUsage{}
+
+	entries, err := os.ReadDir(root)
+	if os.IsNotExist(err) {
+		return usage, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// os.ReadDir ya devuelve las entradas ordenadas por nombre
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		userUsage, err := userDirUsage(filepath.Join(root, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		userUsage.User = label(entry.Name())
+		usage = append(usage, userUsage)
+	}
+	return usage, nil
+}
+
+// userDirUsage suma los bytes de todos los archivos del usuario y cuenta sus carpetas de primer nivel.
+// La papelera no cuenta: lo borrado deja de ocupar cuota aunque todavía se pueda restaurar.
+func userDirUsage(userPath string) (UserUsage, error) {
+	var usage UserUsage
+	err := filepath.WalkDir(userPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if filepath.Dir(path) == userPath {
+				if d.Name() == trashFolder {
+					return filepath.SkipDir
+				}
+				usage.Folders++
+			}
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		usage.Bytes += info.Size()
+		return nil
+	})
+	return usage, err
+}
@@ -0,0 +1,165 @@
+package pdf
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAdminUsageHandler(t *testing.T) {
+	tests := []struct {
+		name          string
+		setupRoot     func(t *testing.T, root string)
+		expectedUsage []UserUsage
+	}{
+		{
+			name:          "Raíz inexistente devuelve lista vacía",
+			setupRoot:     func(t *testing.T, root string) {},
+			expectedUsage: []UserUsage{},
+		},
+		{
+			name: "Suma bytes y carpetas por usuario",
+			setupRoot: func(t *testing.T, root string) {
+				os.MkdirAll(filepath.Join(root, "alex", "a"), os.ModePerm)
+				os.MkdirAll(filepath.Join(root, "alex", "b"), os.ModePerm)
+				os.MkdirAll(filepath.Join(root, "bea"), os.ModePerm)
+				os.WriteFile(filepath.Join(root, "alex", "a", "1-doc.pdf"), make([]byte, 10), 0o644)
+				os.WriteFile(filepath.Join(root, "alex", "a.pdf"), make([]byte, 5), 0o644)
+				// La papelera no cuenta ni como bytes ni como carpeta
+				os.MkdirAll(filepath.Join(root, "alex", trashFolder, "a"), os.ModePerm)
+				os.WriteFile(filepath.Join(root, "alex", trashFolder, "a", "1-borrado.pdf"), make([]byte, 100), 0o644)
+			},
+			// alex tiene nombre en el almacén de códigos; bea no y aparece resumida
+			expectedUsage: []UserUsage{
+				{User: "Alex Pérez", Bytes: 15, Folders: 2},
+				{User: "sha256:b6e1557a1ed3", Bytes: 0, Folders: 0},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			root := filepath.Join(t.TempDir(), "archivos")
+			tt.setupRoot(t, root)
+
+			cfg := DefaultConfig()
+			cfg.StorageRoot = root
+			srv := NewServer(cfg, NewMemoryCodeStore(GeneratedCode{Name: "Alex Pérez", Code: "alex"}))
+
+			req := httptest.NewRequest(http.MethodGet, "/admin/usage", nil)
+			rr := httptest.NewRecorder()
+
+			// Act
+			srv.AdminUsageHandler(rr, req)
+
+			// Assert
+			if rr.Code != http.StatusOK {
+				t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+			}
+			var usage []UserUsage
+			json.NewDecoder(rr.Body).Decode(&usage)
+			if !reflect.DeepEqual(usage, tt.expectedUsage) {
+				t.Errorf("expected %v, got %v", tt.expectedUsage, usage)
+			}
+		})
+	}
+}
+
+func TestIsAdmin(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.AdminCodes = envList("UNSET_ADMIN_CODES_FOR_TEST")
+	if NewServer(cfg, newDefaultCodeStore()).isAdmin("alex") {
+		t.Errorf("without ADMIN_CODES no code must be admin")
+	}
+
+	t.Setenv("ADMIN_CODES", " alex , ,root")
+	cfg.AdminCodes = envList("ADMIN_CODES")
+	srv := NewServer(cfg, newDefaultCodeStore())
+	for code, expected := range map[string]bool{"alex": true, "root": true, "bea": false, "": false} {
+		if got := srv.isAdmin(code); got != expected {
+			t.Errorf("isAdmin(%q) = %v, want %v", code, got, expected)
+		}
+	}
+}
+
+func TestAdminMiddleware(t *testing.T) {
+	tests := []struct {
+		name           string
+		cookie         string
+		expectedStatus int
+		expectNext     bool
+	}{
+		{name: "Sin cookie", cookie: "", expectedStatus: http.StatusUnauthorized},
+		{name: "Código válido que no es admin", cookie: "alex", expectedStatus: http.StatusForbidden},
+		{name: "Código admin", cookie: "root", expectedStatus: http.StatusOK, expectNext: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			cfg := DefaultConfig()
+			cfg.AdminCodes = []string{"root"}
+			srv := NewServer(cfg, newDefaultCodeStore())
+			srv.codes.Add(GeneratedCode{Name: "root", Code: "root"})
+
+			called := false
+			handler := srv.AdminMiddleware(func(w http.ResponseWriter, r *http.Request) {
+				called = true
+				if code, _ := r.Context().Value(userCodeKey).(string); code != "root" {
+					t.Errorf("expected user code in context, got %q", code)
+				}
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/admin/usage", nil)
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: "auth_code", Value: tt.cookie})
+			}
+			rr := httptest.NewRecorder()
+
+			// Act
+			handler(rr, req)
+
+			// Assert
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, tt.expectedStatus)
+			}
+			if called != tt.expectNext {
+				t.Errorf("expected next handler called=%v, got %v", tt.expectNext, called)
+			}
+		})
+	}
+}
+
+func TestUserLabelHidesAccessCode(t *testing.T) {
+	tests := []struct {
+		name  string
+		store *MemoryCodeStore
+	}{
+		{name: "Código desconocido", store: NewMemoryCodeStore()},
+		{name: "Nombre igual al código", store: newDefaultCodeStore()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			srv := NewServer(DefaultConfig(), tt.store)
+
+			// Act
+			label := srv.userLabel("alex")
+
+			// Assert
+			if strings.Contains(label, "alex") {
+				t.Errorf("expected the access code to stay hidden, got %q", label)
+			}
+			if label != srv.userLabel("alex") || label == srv.userLabel("bea") {
+				t.Errorf("expected a stable label that tells users apart, got %q", label)
+			}
+		})
+	}
+}
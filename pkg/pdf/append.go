@@ -0,0 +1,156 @@
+package pdf
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+)
+
+// AppendHandler: Agrega un archivo al final del folder.pdf ya generado sin volver a unir toda la carpeta.
+// El archivo puede subirse en el campo "pdf" (se guarda en la carpeta con el prefijo normal)
+// o indicarse por nombre en "file" si ya existe dentro de la carpeta.
+// Si todavía no hay salida, se hace una unión completa.
+func (s *Server) AppendHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w, http.MethodPost)
+		return
+	}
+
+	// Obtener la ruta base de almacenamiento del usuario
+	userStoragePath, err := s.storagePath(r)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Error interno de autenticación")
+		return
+	}
+	store, err := s.storageFor(r)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Error interno de autenticación")
+		return
+	}
+
+	form, cleanupForm, err := parseMultipartForm(r, multipartMaxMemory)
+	defer cleanupForm()
+	if err != nil && err != http.ErrNotMultipart {
+		writeJSONError(w, http.StatusBadRequest, "Error al parsear el formulario")
+		return
+	}
+
+	var problems validationErrors
+	folder := r.FormValue("folder")
+	problems.checkFolder(folder)
+	if problems.respond(w) {
+		return
+	}
+	// Un archivo nuevo pasa las mismas comprobaciones que en /upload; debe ser un PDF
+	fileHeader := appendUpload(form)
+	if fileHeader != nil {
+		if err := checkUploadExtension(fileHeader.Filename, false); err != nil {
+			writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("%s: %v", fileHeader.Filename, err))
+			return
+		}
+		if !validFileName(fileHeader.Filename) || filepath.Ext(fileHeader.Filename) != ".pdf" {
+			writeJSONError(w, http.StatusBadRequest, errInvalidFileName.Error())
+			return
+		}
+	}
+	folderPath := filepath.Join(userStoragePath, folder)
+
+	unlock := lockFolder(userStoragePath, folder)
+	defer unlock()
+
+	var filename string
+	if fileHeader != nil {
+		// Numerado y guardado igual que en UploadHandler
+		counter, err := countStoredFiles(store, folder)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			writeJSONError(w, http.StatusInternalServerError, "Error leyendo el directorio")
+			return
+		}
+		if folderLimitExceeded(w, counter, 1) {
+			return
+		}
+		filename, err = saveUploadedFile(fileHeader, store, folder, counter+1, false, "")
+		if err != nil {
+			writeJSONError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		discardChecksumSidecar(store, folder, filename)
+		if err := recordAdded(store, folder, []string{filename}, time.Now()); err != nil {
+			logf(r.Context(), "Error registrando la fecha de alta en %s: %v", folder, err)
+		}
+	} else if filename, err = existingAppendSource(r, store, folder); err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Ocupar un espacio del pool de uniones igual que GenerateHandler
+	if err := s.merges.acquire(r.Context()); err != nil {
+		w.Header().Set("Retry-After", strconv.Itoa(int(mergeQueueTimeout.Seconds())+1))
+		writeJSONError(w, http.StatusTooManyRequests, "Servidor ocupado: "+err.Error())
+		return
+	}
+	defer s.merges.release()
+
+	outputPath := mergeOutputPath(userStoragePath, folder+".pdf")
+	resp := AppendResponse{Folder: folder, File: filename, Mode: "append"}
+
+	if _, err := os.Stat(outputPath); os.IsNotExist(err) {
+		// Sin salida previa: unir la carpeta completa (ya incluye el archivo nuevo)
+		resp.Mode = "full"
+		if _, err := joinPDFs(r.Context(), userStoragePath, folder, MergeOptions{}); err != nil {
+			writeJSONError(w, http.StatusInternalServerError, "Error al unir PDFs: "+err.Error())
+			return
+		}
+	} else {
+		resp.PagesBefore, err = api.PageCountFile(outputPath)
+		if err != nil {
+			writeJSONError(w, http.StatusInternalServerError, "Error al leer el PDF unido: "+err.Error())
+			return
+		}
+		if err := api.MergeAppendFile([]string{filepath.Join(folderPath, filename)}, outputPath, false, nil); err != nil {
+			writeJSONError(w, http.StatusInternalServerError, "Error al agregar el PDF: "+err.Error())
+			return
+		}
+		// El manifiesto de auditoría ya no describe la salida con la página agregada
+		discardOutputManifest(outputPath)
+	}
+
+	resp.PagesAfter, err = api.PageCountFile(outputPath)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Error al leer el PDF unido: "+err.Error())
+		return
+	}
+	resp.PagesAdded = resp.PagesAfter - resp.PagesBefore
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// appendUpload devuelve el archivo subido en el campo "pdf", o nil si no se subió ninguno
+func appendUpload(form *uploadForm) *uploadedFile {
+	if len(form.File["pdf"]) == 0 {
+		return nil
+	}
+	return form.File["pdf"][0]
+}
+
+// existingAppendSource devuelve el nombre indicado en "file" si es un PDF de la carpeta
+func existingAppendSource(r *http.Request, store Storage, folder string) (string, error) {
+	filename := r.FormValue("file")
+	if filename == "" {
+		return "", errMissingFile
+	}
+	if !validFileName(filename) || filepath.Ext(filename) != ".pdf" {
+		return "", errInvalidFileName
+	}
+	if exists, err := store.Exists(folder, filename); err != nil || !exists {
+		return "", errFileNotFound
+	}
+	return filename, nil
+}
@@ -0,0 +1,132 @@
+package pdf
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAppendHandler(t *testing.T) {
+	tests := []struct {
+		name          string
+		withOutput    bool
+		expectedMode  string
+		expectedAdded int
+		expectedAfter int
+	}{
+		{
+			name:          "Agregar al PDF unido existente",
+			withOutput:    true,
+			expectedMode:  "append",
+			expectedAdded: 2,
+			expectedAfter: 5,
+		},
+		{
+			name:          "Unión completa cuando no existe salida",
+			withOutput:    false,
+			expectedMode:  "full",
+			expectedAdded: 3,
+			expectedAfter: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			userPath := t.TempDir()
+			folderPath := filepath.Join(userPath, "test-folder")
+			os.MkdirAll(folderPath, os.ModePerm)
+			writeTestPDF(t, filepath.Join(folderPath, "1-document.pdf"), 1)
+			writeTestPDF(t, filepath.Join(folderPath, "2-document.pdf"), 2)
+			if tt.withOutput {
+				writeTestPDF(t, filepath.Join(userPath, "test-folder.pdf"), 3)
+			}
+
+			srv := newTestServer(userPath)
+
+			req := httptest.NewRequest(http.MethodPost, "/append", strings.NewReader("folder=test-folder&file=2-document.pdf"))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			req = req.WithContext(context.WithValue(req.Context(), userCodeKey, "testUser"))
+			rr := httptest.NewRecorder()
+
+			// Act
+			srv.AppendHandler(rr, req)
+
+			// Assert
+			if rr.Code != http.StatusOK {
+				t.Fatalf("handler returned wrong status code: got %v want %v (%s)", rr.Code, http.StatusOK, rr.Body.String())
+			}
+			var resp AppendResponse
+			json.NewDecoder(rr.Body).Decode(&resp)
+			if resp.Mode != tt.expectedMode {
+				t.Errorf("expected mode %s, got %s", tt.expectedMode, resp.Mode)
+			}
+			if resp.PagesAdded != tt.expectedAdded || resp.PagesAfter != tt.expectedAfter {
+				t.Errorf("expected %d pages added (%d total), got %d (%d total)", tt.expectedAdded, tt.expectedAfter, resp.PagesAdded, resp.PagesAfter)
+			}
+		})
+	}
+}
+
+func TestAppendHandlerNumbersAfterStoredUploads(t *testing.T) {
+	// Arrange: la carpeta ya tiene una imagen subida sin convertir
+	userPath := t.TempDir()
+	folderPath := filepath.Join(userPath, "test-folder")
+	os.MkdirAll(folderPath, os.ModePerm)
+	os.WriteFile(filepath.Join(folderPath, "1-scan.png"), pngBytes(t), 0o644)
+	writeTestPDF(t, filepath.Join(userPath, "test-folder.pdf"), 1)
+	pdfPath := filepath.Join(t.TempDir(), "nuevo.pdf")
+	writeTestPDF(t, pdfPath, 2)
+	content, _ := os.ReadFile(pdfPath)
+	srv := newTestServer(userPath)
+	req, rr := NewUploadRequestBuilder().WithFieldFile("pdf", "nuevo.pdf", content).Build(t)
+
+	// Act
+	srv.AppendHandler(rr, req)
+
+	// Assert
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var resp AppendResponse
+	json.NewDecoder(rr.Body).Decode(&resp)
+	if resp.File != "2-nuevo.pdf" || resp.PagesAdded != 2 {
+		t.Errorf("expected 2-nuevo.pdf with 2 pages added, got %+v", resp)
+	}
+	added, err := readAddedTimes(NewLocalStorage(userPath), "test-folder")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := added["2-nuevo.pdf"]; !ok {
+		t.Errorf("expected an added time for 2-nuevo.pdf, got %v", added)
+	}
+}
+
+func TestAppendHandlerMaxFilesPerFolder(t *testing.T) {
+	// Arrange
+	original := maxFilesPerFolder
+	defer func() { maxFilesPerFolder = original }()
+	maxFilesPerFolder = 1
+	userPath := t.TempDir()
+	folderPath := filepath.Join(userPath, "test-folder")
+	os.MkdirAll(folderPath, os.ModePerm)
+	writeTestPDF(t, filepath.Join(folderPath, "1-document.pdf"), 1)
+	srv := newTestServer(userPath)
+	req, rr := NewUploadRequestBuilder().WithFieldFile("pdf", "nuevo.pdf", []byte("%PDF-1.4")).Build(t)
+
+	// Act
+	srv.AppendHandler(rr, req)
+
+	// Assert
+	if rr.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected 413, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if _, err := os.Stat(filepath.Join(folderPath, "2-nuevo.pdf")); !os.IsNotExist(err) {
+		t.Errorf("expected nothing to be stored over the limit")
+	}
+}
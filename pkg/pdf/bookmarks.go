@@ -0,0 +1,86 @@
+package pdf
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
+)
+
+// Prefijo numérico "N-" que UploadHandler agrega a los nombres
+var numericPrefixRe = regexp.MustCompile(`^\d+-`)
+
+// bookmarkTitle limpia el nombre del archivo para usarlo como título del marcador
+func bookmarkTitle(filename string) string {
+	name := strings.TrimSuffix(filename, filepath.Ext(filename))
+	return numericPrefixRe.ReplaceAllString(name, "")
+}
+
+// Longitud máxima de label_template
+const maxLabelTemplateLength = 200
+
+// Marcadores de posición de label_template: {index} es la posición del archivo (desde 1)
+// y {name} su nombre limpio (ver bookmarkTitle)
+var labelPlaceholderRe = regexp.MustCompile(`\{[^{}]*\}`)
+
+// validateLabelTemplate rechaza plantillas demasiado largas, con marcadores desconocidos
+// o con llaves sueltas. La plantilla vacía es válida y equivale a "{name}".
+func validateLabelTemplate(tmpl string) error {
+	if len(tmpl) > maxLabelTemplateLength {
+		return fmt.Errorf("label_template supera los %d caracteres", maxLabelTemplateLength)
+	}
+	for _, placeholder := range labelPlaceholderRe.FindAllString(tmpl, -1) {
+		if placeholder != "{index}" && placeholder != "{name}" {
+			return fmt.Errorf("marcador desconocido en label_template: %s (use {index} o {name})", placeholder)
+		}
+	}
+	if strings.ContainsAny(labelPlaceholderRe.ReplaceAllString(tmpl, ""), "{}") {
+		return fmt.Errorf("label_template tiene llaves sin cerrar")
+	}
+	return nil
+}
+
+// sourceLabel aplica la plantilla (ya validada) al archivo en la posición index. El
+// reemplazo es de una pasada: un nombre que contenga "{index}" no se vuelve a expandir.
+func sourceLabel(tmpl string, index int, filename string) string {
+	if tmpl == "" {
+		return bookmarkTitle(filename)
+	}
+	return strings.NewReplacer("{index}", strconv.Itoa(index), "{name}", bookmarkTitle(filename)).Replace(tmpl)
+}
+
+// buildBookmarks crea un marcador de primer nivel por archivo, apuntando a su primera página
+// dentro del PDF unido y con el título de labelTemplate (ver sourceLabel). Los archivos deben
+// venir en el mismo orden usado para la unión. Los de generated (portada y separadores, ver
+// withCoverAndSeparators) ocupan páginas pero no llevan marcador ni cuentan para {index}.
+func buildBookmarks(filePaths []string, generated map[string]bool, labelTemplate string) ([]pdfcpu.Bookmark, error) {
+	bookmarks := make([]pdfcpu.Bookmark, 0, len(filePaths))
+	page := 1
+	for _, filePath := range filePaths {
+		pages, err := api.PageCountFile(filePath)
+		if err != nil {
+			return nil, err
+		}
+		if !generated[filePath] {
+			bookmarks = append(bookmarks, pdfcpu.Bookmark{
+				Title:    sourceLabel(labelTemplate, len(bookmarks)+1, filepath.Base(filePath)),
+				PageFrom: page,
+			})
+		}
+		page += pages
+	}
+	return bookmarks, nil
+}
+
+// addSourceBookmarks reemplaza el índice del PDF unido por uno con un marcador por archivo fuente
+func addSourceBookmarks(outputPath string, filePaths []string, generated map[string]bool, labelTemplate string) error {
+	bookmarks, err := buildBookmarks(filePaths, generated, labelTemplate)
+	if err != nil {
+		return err
+	}
+	return api.AddBookmarksFile(outputPath, "", bookmarks, true, nil)
+}
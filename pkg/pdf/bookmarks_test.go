@@ -0,0 +1,182 @@
+package pdf
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+)
+
+func TestBookmarkTitle(t *testing.T) {
+	tests := map[string]string{
+		"1-informe.pdf":      "informe",
+		"12-anexo-final.pdf": "anexo-final",
+		"sin-prefijo.pdf":    "sin-prefijo",
+	}
+	for filename, expected := range tests {
+		if got := bookmarkTitle(filename); got != expected {
+			t.Errorf("bookmarkTitle(%q) = %q, want %q", filename, got, expected)
+		}
+	}
+}
+
+func TestJoinPDFsWithBookmarks(t *testing.T) {
+	// Arrange
+	userPath := t.TempDir()
+	folderPath := filepath.Join(userPath, "test-folder")
+	os.MkdirAll(folderPath, os.ModePerm)
+	writeTestPDF(t, filepath.Join(folderPath, "1-intro.pdf"), 2)
+	writeTestPDF(t, filepath.Join(folderPath, "2-body.pdf"), 3)
+
+	// Act
+	_, err := joinPDFs(context.Background(), userPath, "test-folder", MergeOptions{Bookmarks: true})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	f, err := os.Open(filepath.Join(userPath, "test-folder.pdf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	bookmarks, err := api.Bookmarks(f, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bookmarks) != 2 {
+		t.Fatalf("expected 2 bookmarks, got %d", len(bookmarks))
+	}
+	if bookmarks[0].Title != "intro" || bookmarks[0].PageFrom != 1 {
+		t.Errorf("unexpected first bookmark: %+v", bookmarks[0])
+	}
+	if bookmarks[1].Title != "body" || bookmarks[1].PageFrom != 3 {
+		t.Errorf("unexpected second bookmark: %+v", bookmarks[1])
+	}
+}
+
+func TestValidateLabelTemplate(t *testing.T) {
+	tests := []struct {
+		name        string
+		template    string
+		expectError bool
+	}{
+		{name: "Vacía usa el nombre", template: "", expectError: false},
+		{name: "Índice y nombre", template: "{index}. {name}", expectError: false},
+		{name: "Texto fijo", template: "Anexo {index}", expectError: false},
+		{name: "Marcador desconocido", template: "{index} - {fecha}", expectError: true},
+		{name: "Llave sin cerrar", template: "{index. {name}", expectError: true},
+		{name: "Llave de cierre suelta", template: "{name}}", expectError: true},
+		{name: "Demasiado larga", template: strings.Repeat("a", maxLabelTemplateLength+1), expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			err := validateLabelTemplate(tt.template)
+
+			// Assert
+			if (err != nil) != tt.expectError {
+				t.Errorf("expected error %v, got %v", tt.expectError, err)
+			}
+		})
+	}
+}
+
+func TestSourceLabel(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		index    int
+		filename string
+		expected string
+	}{
+		{name: "Sin plantilla", template: "", index: 2, filename: "2-anexo.pdf", expected: "anexo"},
+		{name: "Índice y nombre", template: "{index}. {name}", index: 2, filename: "2-anexo.pdf", expected: "2. anexo"},
+		{name: "El nombre no se vuelve a expandir", template: "{name}", index: 1, filename: "1-{index}.pdf", expected: "{index}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			got := sourceLabel(tt.template, tt.index, tt.filename)
+
+			// Assert
+			if got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestJoinPDFsWithLabelTemplate(t *testing.T) {
+	// Arrange
+	userPath := t.TempDir()
+	folderPath := filepath.Join(userPath, "test-folder")
+	os.MkdirAll(folderPath, os.ModePerm)
+	writeTestPDF(t, filepath.Join(folderPath, "1-intro.pdf"), 1)
+	writeTestPDF(t, filepath.Join(folderPath, "2-body.pdf"), 1)
+
+	// Act
+	_, err := joinPDFs(context.Background(), userPath, "test-folder", MergeOptions{Bookmarks: true, Cover: "Informe", LabelTemplate: "{index}. {name}"})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	f, err := os.Open(filepath.Join(userPath, "test-folder.pdf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	bookmarks, err := api.Bookmarks(f, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var titles []string
+	for _, b := range bookmarks {
+		titles = append(titles, b.Title)
+	}
+	// La portada ocupa una página pero no cuenta para {index}
+	if !reflect.DeepEqual(titles, []string{"1. intro", "2. body"}) {
+		t.Errorf("expected numbered titles, got %v", titles)
+	}
+}
+
+func TestJoinPDFsBookmarksHiddenSourceFiles(t *testing.T) {
+	// Arrange
+	userPath := t.TempDir()
+	folderPath := filepath.Join(userPath, "test-folder")
+	os.MkdirAll(folderPath, os.ModePerm)
+	writeTestPDF(t, filepath.Join(folderPath, "1-intro.pdf"), 1)
+	writeTestPDF(t, filepath.Join(folderPath, ".draft.pdf"), 2)
+
+	// Act
+	_, err := joinPDFs(context.Background(), userPath, "test-folder", MergeOptions{Cover: "Informe", Separators: true, Bookmarks: true})
+
+	// Assert: un archivo del usuario que empieza por "." lleva marcador; la portada no
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	f, err := os.Open(filepath.Join(userPath, "test-folder.pdf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	bookmarks, err := api.Bookmarks(f, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var titles []string
+	for _, bookmark := range bookmarks {
+		titles = append(titles, bookmark.Title)
+	}
+	if len(titles) != 2 || !slices.Contains(titles, ".draft") || !slices.Contains(titles, "intro") {
+		t.Errorf("expected bookmarks for .draft and intro, got %v", titles)
+	}
+}
@@ -0,0 +1,92 @@
+package pdf
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// --- Aviso al terminar una unión asíncrona ---
+// Con callback_url, al terminar el trabajo se envía por POST su estado a esa URL en lugar
+// de obligar al cliente a consultar /job-status. Para evitar SSRF solo se admiten URLs
+// http(s) de los hosts de CALLBACK_ALLOWED_HOSTS; sin esa variable no se aceptan avisos.
+var (
+	callbackAllowedHosts = newCodeSet(defaultConfig.CallbackAllowedHosts)
+	callbackTimeout      = defaultConfig.CallbackTimeout
+	callbackAttempts     = defaultConfig.CallbackAttempts
+	callbackRetryBackoff = time.Second
+)
+
+// Estado de la entrega del aviso en MergeJob.CallbackStatus
+const (
+	CallbackPending   = "pending"
+	CallbackDelivered = "delivered"
+	CallbackFailed    = "failed"
+)
+
+// parseCallbackURL valida callback_url: vacía significa sin aviso
+func parseCallbackURL(raw string) (string, error) {
+	if raw == "" {
+		return "", nil
+	}
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return "", fmt.Errorf("callback_url inválida: %q", raw)
+	}
+	if !callbackAllowedHosts[u.Host] && !callbackAllowedHosts[u.Hostname()] {
+		return "", fmt.Errorf("%w: %s", errURLNotAllowed, u.Host)
+	}
+	return u.String(), nil
+}
+
+// newCallbackClient crea un cliente con timeout que no sigue redirecciones, para que el
+// aviso no pueda acabar en un host fuera del allowlist
+func newCallbackClient() *http.Client {
+	return &http.Client{
+		Timeout: callbackTimeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// deliverJobCallback envía payload a callbackURL hasta CALLBACK_ATTEMPTS veces, esperando
+// callbackRetryBackoff entre intentos. Solo una respuesta 2xx cuenta como entregada.
+func deliverJobCallback(callbackURL string, payload MergeJobCallback) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	client := newCallbackClient()
+	for attempt := 1; ; attempt++ {
+		err = postCallback(client, callbackURL, body)
+		if err == nil || attempt >= callbackAttempts {
+			return err
+		}
+		time.Sleep(callbackRetryBackoff)
+	}
+}
+
+func postCallback(client *http.Client, callbackURL string, body []byte) error {
+	resp, err := client.Post(callbackURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("respuesta %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// jobCallbackPayload cuerpo del aviso de un trabajo terminado
+func jobCallbackPayload(job MergeJob) MergeJobCallback {
+	payload := MergeJobCallback{ID: job.ID, Status: job.Status, Folder: job.Folder, Output: job.Output, Error: job.Error}
+	if job.Output != "" {
+		payload.DownloadURL = downloadURL(job.Folder, job.Output)
+	}
+	return payload
+}
@@ -0,0 +1,160 @@
+package pdf
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// callbackRecorder servidor que recibe avisos y responde con status
+type callbackRecorder struct {
+	mu       sync.Mutex
+	status   int
+	attempts int
+	payloads []MergeJobCallback
+}
+
+func (c *callbackRecorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var payload MergeJobCallback
+	json.NewDecoder(r.Body).Decode(&payload)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.attempts++
+	c.payloads = append(c.payloads, payload)
+	w.WriteHeader(c.status)
+}
+
+// allowCallbackServer permite los avisos al servidor de prueba y anula la espera entre intentos
+func allowCallbackServer(t *testing.T, server *httptest.Server) {
+	t.Helper()
+	originalHosts, originalBackoff := callbackAllowedHosts, callbackRetryBackoff
+	t.Cleanup(func() { callbackAllowedHosts, callbackRetryBackoff = originalHosts, originalBackoff })
+	u, _ := url.Parse(server.URL)
+	callbackAllowedHosts = newCodeSet([]string{u.Host})
+	callbackRetryBackoff = 0
+}
+
+// waitForCallback espera a que el trabajo deje de tener el aviso pendiente
+func waitForCallback(t *testing.T, srv *Server, id string) MergeJob {
+	t.Helper()
+	var job MergeJob
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		status := httptest.NewRecorder()
+		srv.JobStatusHandler(status, newJobStatusRequest("testUser", id))
+		json.NewDecoder(status.Body).Decode(&job)
+		if job.CallbackStatus != CallbackPending {
+			return job
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("callback still pending: %+v", job)
+	return job
+}
+
+func TestAsyncGenerateCallback(t *testing.T) {
+	tests := []struct {
+		name             string
+		responseStatus   int
+		expectedStatus   string
+		expectedAttempts int
+	}{
+		{name: "Aviso entregado al primer intento", responseStatus: http.StatusNoContent, expectedStatus: CallbackDelivered, expectedAttempts: 1},
+		{name: "Reintenta y registra el fallo", responseStatus: http.StatusInternalServerError, expectedStatus: CallbackFailed, expectedAttempts: 2},
+		{name: "Una redirección no se sigue", responseStatus: http.StatusFound, expectedStatus: CallbackFailed, expectedAttempts: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			recorder := &callbackRecorder{status: tt.responseStatus}
+			server := httptest.NewServer(recorder)
+			defer server.Close()
+			allowCallbackServer(t, server)
+			originalAttempts, originalNewJobID := callbackAttempts, newJobIDFn
+			defer func() { callbackAttempts, newJobIDFn = originalAttempts, originalNewJobID }()
+			callbackAttempts = 2
+			newJobIDFn = func() string { return "job-callback" }
+
+			userPath := t.TempDir()
+			folderPath := filepath.Join(userPath, "test-folder")
+			os.MkdirAll(folderPath, os.ModePerm)
+			writeTestPDF(t, filepath.Join(folderPath, "1-a.pdf"), 1)
+			srv := newTestServer(userPath)
+
+			req, rr := newGenerateRequest(url.Values{"folder": {"test-folder"}, "async": {"true"}, "callback_url": {server.URL + "/hook"}})
+
+			// Act
+			srv.GenerateHandler(rr, req)
+
+			// Assert
+			if rr.Code != http.StatusAccepted {
+				t.Fatalf("expected 202, got %d: %s", rr.Code, rr.Body.String())
+			}
+			job := waitForCallback(t, srv, "job-callback")
+			if job.CallbackStatus != tt.expectedStatus {
+				t.Errorf("expected callback status %q, got %+v", tt.expectedStatus, job)
+			}
+			if tt.expectedStatus == CallbackFailed && job.CallbackError == "" {
+				t.Errorf("expected the delivery error to be recorded")
+			}
+
+			recorder.mu.Lock()
+			defer recorder.mu.Unlock()
+			if recorder.attempts != tt.expectedAttempts {
+				t.Errorf("expected %d attempts, got %d", tt.expectedAttempts, recorder.attempts)
+			}
+			payload := recorder.payloads[0]
+			expected := MergeJobCallback{
+				ID:          "job-callback",
+				Status:      JobDone,
+				Folder:      "test-folder",
+				Output:      "test-folder.pdf",
+				DownloadURL: downloadURL("test-folder", "test-folder.pdf"),
+			}
+			if payload != expected {
+				t.Errorf("expected payload %+v, got %+v", expected, payload)
+			}
+		})
+	}
+}
+
+func TestGenerateHandlerRejectsCallbackURL(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+	allowCallbackServer(t, server)
+
+	tests := []struct {
+		name string
+		form url.Values
+	}{
+		{name: "Host fuera del allowlist", form: url.Values{"async": {"true"}, "callback_url": {"http://169.254.169.254/latest"}}},
+		{name: "Esquema no http", form: url.Values{"async": {"true"}, "callback_url": {"file:///etc/passwd"}}},
+		{name: "Sin async", form: url.Values{"callback_url": {server.URL}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			userPath := t.TempDir()
+			os.MkdirAll(filepath.Join(userPath, "test-folder"), os.ModePerm)
+			srv := newTestServer(userPath)
+			tt.form.Set("folder", "test-folder")
+			req, rr := newGenerateRequest(tt.form)
+
+			// Act
+			srv.GenerateHandler(rr, req)
+
+			// Assert
+			if rr.Code != http.StatusBadRequest {
+				t.Errorf("expected 400, got %d: %s", rr.Code, rr.Body.String())
+			}
+		})
+	}
+}
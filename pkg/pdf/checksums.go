@@ -0,0 +1,83 @@
+package pdf
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Tamaño máximo que se lee de un archivo de caché de checksum
+const maxChecksumSidecarBytes = 256
+
+// checksumSidecarName nombre del archivo oculto con el checksum de name, en su misma
+// carpeta: "sub/1-a.pdf" -> "sub/.1-a.pdf.sha256". No termina en .pdf, así que los
+// listados y contadores no lo ven. Al renombrar la carpeta se mueve con ella; donde un
+// archivo se borra, se mueve o se reemplaza hay que descartarlo (discardChecksumSidecar).
+func checksumSidecarName(name string) string {
+	dir, base := path.Split(name)
+	return dir + "." + base + ".sha256"
+}
+
+// fileChecksum devuelve el SHA-256 en hexadecimal de folder/name. Lo guarda junto al
+// archivo y lo reutiliza mientras no cambien su fecha de modificación ni su tamaño.
+func fileChecksum(store Storage, folder, name string) (string, error) {
+	info, err := store.Stat(folder, name)
+	if err != nil {
+		return "", err
+	}
+	stamp := fmt.Sprintf("%d %d", info.ModTime().UnixNano(), info.Size())
+	sidecar := checksumSidecarName(name)
+	if sum, ok := readChecksumSidecar(store, folder, sidecar, stamp); ok {
+		return sum, nil
+	}
+
+	file, err := store.Open(folder, name)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	sum := hex.EncodeToString(hash.Sum(nil))
+
+	// Si no se puede guardar la caché solo se recalcula en el próximo listado
+	store.Save(folder, sidecar, strings.NewReader(stamp+" "+sum+"\n"))
+	return sum, nil
+}
+
+// discardChecksumSidecar borra la caché de checksum de folder/name, si la hay
+func discardChecksumSidecar(store Storage, folder, name string) {
+	store.Delete(folder, checksumSidecarName(name))
+}
+
+// discardChecksumSidecarFile es discardChecksumSidecar para un archivo del disco
+func discardChecksumSidecarFile(filePath string) {
+	os.Remove(filepath.Join(filepath.Dir(filePath), checksumSidecarName(filepath.Base(filePath))))
+}
+
+// readChecksumSidecar devuelve el checksum guardado si corresponde a stamp
+func readChecksumSidecar(store Storage, folder, sidecar, stamp string) (string, bool) {
+	file, err := store.Open(folder, sidecar)
+	if err != nil {
+		return "", false
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.LimitReader(file, maxChecksumSidecarBytes))
+	if err != nil {
+		return "", false
+	}
+	// Formato: "<modTime en ns> <tamaño> <sha256>"
+	line := strings.TrimSpace(string(data))
+	i := strings.LastIndex(line, " ")
+	if i < 0 || line[:i] != stamp || len(line[i+1:]) != sha256.Size*2 {
+		return "", false
+	}
+	return line[i+1:], true
+}
@@ -0,0 +1,199 @@
+package pdf
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// sha256Hex checksum esperado de content
+func sha256Hex(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// listChecksums llama a ListHandler con checksums=true y decodifica la respuesta
+func listChecksums(t *testing.T, srv *Server) []FileChecksum {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/list?folder=test-folder&checksums=true", nil)
+	rr := httptest.NewRecorder()
+	srv.ListHandler(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var files []FileChecksum
+	if err := json.NewDecoder(rr.Body).Decode(&files); err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+func TestListHandlerChecksums(t *testing.T) {
+	// Arrange
+	userPath := t.TempDir()
+	folderPath := filepath.Join(userPath, "test-folder")
+	os.MkdirAll(folderPath, os.ModePerm)
+	os.WriteFile(filepath.Join(folderPath, "1-a.pdf"), []byte("%PDF-a"), 0o644)
+	os.WriteFile(filepath.Join(folderPath, "2-b.pdf"), []byte("%PDF-b"), 0o644)
+	srv := newTestServer(userPath)
+
+	// Act
+	files := listChecksums(t, srv)
+
+	// Assert
+	if len(files) != 2 || files[0].Name != "1-a.pdf" || files[0].SHA256 != sha256Hex("%PDF-a") || files[1].SHA256 != sha256Hex("%PDF-b") {
+		t.Fatalf("unexpected checksums: %+v", files)
+	}
+	sidecar := filepath.Join(folderPath, ".1-a.pdf.sha256")
+	if _, err := os.Stat(sidecar); err != nil {
+		t.Fatalf("expected a cached checksum: %v", err)
+	}
+
+	// Mientras el archivo no cambie se usa la caché (aquí manipulada para distinguirla)
+	cached, _ := os.ReadFile(sidecar)
+	fake := strings.Repeat("0", 64)
+	line := strings.TrimSpace(string(cached))
+	os.WriteFile(sidecar, []byte(line[:strings.LastIndex(line, " ")+1]+fake+"\n"), 0o644)
+	if files := listChecksums(t, srv); files[0].SHA256 != fake {
+		t.Errorf("expected the cached checksum, got %s", files[0].SHA256)
+	}
+
+	// Al cambiar la fecha de modificación se vuelve a calcular
+	later := time.Now().Add(time.Hour)
+	os.Chtimes(filepath.Join(folderPath, "1-a.pdf"), later, later)
+	if files := listChecksums(t, srv); files[0].SHA256 != sha256Hex("%PDF-a") {
+		t.Errorf("expected a recomputed checksum, got %s", files[0].SHA256)
+	}
+}
+
+// unreadableStorage almacenamiento en memoria que no puede abrir un archivo concreto
+type unreadableStorage struct {
+	*memoryStorage
+	broken string
+}
+
+func (u unreadableStorage) Open(folder, name string) (io.ReadCloser, error) {
+	if name == u.broken {
+		return nil, errors.New("disco no disponible")
+	}
+	return u.memoryStorage.Open(folder, name)
+}
+
+func TestListHandlerChecksumErrorOmitsHash(t *testing.T) {
+	// Arrange
+	store := newMemoryStorage()
+	store.Save("test-folder", "1-a.pdf", strings.NewReader("%PDF-a"))
+	store.Save("test-folder", "2-b.pdf", strings.NewReader("%PDF-b"))
+	srv := newMemoryStorageServer(t, unreadableStorage{memoryStorage: store, broken: "1-a.pdf"})
+
+	// Act
+	files := listChecksums(t, srv)
+
+	// Assert: el listado sigue completo y solo falta el hash que falló
+	if len(files) != 2 {
+		t.Fatalf("expected both files, got %+v", files)
+	}
+	if files[0].SHA256 != "" {
+		t.Errorf("expected no checksum for the unreadable file, got %s", files[0].SHA256)
+	}
+	if files[1].SHA256 != sha256Hex("%PDF-b") {
+		t.Errorf("unexpected checksum for 2-b.pdf: %s", files[1].SHA256)
+	}
+}
+
+func TestChecksumSidecarDiscardedWithFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler func(srv *Server) http.HandlerFunc
+		request func(t *testing.T) (*http.Request, *httptest.ResponseRecorder)
+	}{
+		{
+			name:    "Mover a la papelera",
+			handler: func(srv *Server) http.HandlerFunc { return srv.DeleteFilesHandler },
+			request: func(t *testing.T) (*http.Request, *httptest.ResponseRecorder) {
+				return NewDeleteRequestBuilder().WithFiles([]string{"1-a.pdf"}).Build(t)
+			},
+		},
+		{
+			name:    "Eliminar con purge",
+			handler: func(srv *Server) http.HandlerFunc { return srv.DeleteFilesHandler },
+			request: func(t *testing.T) (*http.Request, *httptest.ResponseRecorder) {
+				return NewDeleteRequestBuilder().WithFiles([]string{"1-a.pdf"}).WithPurge().Build(t)
+			},
+		},
+		{
+			name:    "Vaciar la carpeta",
+			handler: func(srv *Server) http.HandlerFunc { return srv.ClearFolderHandler },
+			request: func(t *testing.T) (*http.Request, *httptest.ResponseRecorder) {
+				req := httptest.NewRequest(http.MethodDelete, "/clear?folder=test-folder", nil)
+				return req, httptest.NewRecorder()
+			},
+		},
+		{
+			name:    "Reemplazar al subir un archivo con el mismo nombre",
+			handler: func(srv *Server) http.HandlerFunc { return srv.UploadHandler },
+			request: func(t *testing.T) (*http.Request, *httptest.ResponseRecorder) {
+				return NewUploadRequestBuilder().WithFile("1-a.pdf", []byte("%PDF-nuevo")).Build(t)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			userPath := t.TempDir()
+			folderPath := filepath.Join(userPath, "test-folder")
+			os.MkdirAll(folderPath, os.ModePerm)
+			os.WriteFile(filepath.Join(folderPath, "1-a.pdf"), []byte("%PDF-a"), 0o644)
+			srv := newTestServer(userPath)
+			listChecksums(t, srv)
+			sidecar := filepath.Join(folderPath, ".1-a.pdf.sha256")
+			if _, err := os.Stat(sidecar); err != nil {
+				t.Fatalf("expected a cached checksum: %v", err)
+			}
+			req, rr := tt.request(t)
+
+			// Act
+			tt.handler(srv)(rr, req)
+
+			// Assert
+			if rr.Code != http.StatusOK {
+				t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+			}
+			if _, err := os.Stat(sidecar); !os.IsNotExist(err) {
+				t.Errorf("expected the cached checksum to be discarded, got %v", err)
+			}
+		})
+	}
+}
+
+func TestRestoreDiscardsStaleChecksumSidecar(t *testing.T) {
+	// Arrange
+	userPath := t.TempDir()
+	id := trashTestFile(t, userPath, "facturas", "1-a.pdf", "%PDF-a", time.Now())
+	// Una caché que quedó de otro archivo con el mismo nombre
+	sidecar := filepath.Join(userPath, "facturas", ".1-a.pdf.sha256")
+	os.WriteFile(sidecar, []byte("0 0 "+strings.Repeat("0", 64)+"\n"), 0o644)
+	srv := newTestServer(userPath)
+	req, rr := newRestoreRequest(`{"id":"` + id + `"}`)
+
+	// Act
+	srv.RestoreHandler(rr, req)
+
+	// Assert
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if _, err := os.Stat(sidecar); !os.IsNotExist(err) {
+		t.Errorf("expected the stale checksum to be discarded, got %v", err)
+	}
+}
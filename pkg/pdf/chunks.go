@@ -0,0 +1,404 @@
+package pdf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// --- Subidas por fragmentos ---
+// Para archivos grandes en conexiones lentas el cliente envía el archivo en fragmentos
+// numerados (/upload-chunk) y al final pide ensamblarlos (/upload-chunk/complete).
+// Los fragmentos se guardan fuera del espacio del usuario hasta completar la subida.
+var (
+	maxChunkBytes       = defaultConfig.MaxChunkBytes
+	maxChunkUploadBytes = defaultConfig.MaxChunkUploadBytes
+	uploadIDRe          = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+)
+
+// Cada cuánto PurgeChunkUploadsLoop busca subidas abandonadas
+var chunkPurgeInterval = time.Hour
+
+// chunkTotalFile guarda, dentro de la carpeta de la subida, el total del primer fragmento
+const chunkTotalFile = "total"
+
+// ErrChunkTotalMismatch indica que una petición trae un total distinto al de la subida
+var ErrChunkTotalMismatch = errors.New("total no coincide con el de la subida")
+
+// Variable para facilitar el testing
+var chunkStorageRootFn = func() string {
+	return filepath.Join(tempRoot, "join-pdf-chunks")
+}
+
+// maxChunks número máximo de fragmentos de una subida: los que hacen falta para
+// MAX_CHUNK_UPLOAD_BYTES con fragmentos de MAX_CHUNK_BYTES
+func maxChunks() int {
+	return int((maxChunkUploadBytes + maxChunkBytes - 1) / maxChunkBytes)
+}
+
+// parseChunkUpload lee y valida upload_id y total, comunes a ambos pasos
+func parseChunkUpload(r *http.Request) (string, int, error) {
+	uploadID := r.FormValue("upload_id")
+	if !uploadIDRe.MatchString(uploadID) {
+		return "", 0, errors.New("upload_id inválido")
+	}
+	total, err := strconv.Atoi(r.FormValue("total"))
+	if err != nil || total < 1 {
+		return "", 0, errors.New("total debe ser un entero positivo")
+	}
+	if total > maxChunks() {
+		return "", 0, fmt.Errorf("total supera el máximo de %d fragmentos (%d bytes)", maxChunks(), maxChunkUploadBytes)
+	}
+	return uploadID, total, nil
+}
+
+// chunkUploadDir devuelve la carpeta temporal de una subida del usuario autenticado
+func chunkUploadDir(r *http.Request, uploadID string) (string, error) {
+	userCode, ok := r.Context().Value(userCodeKey).(string)
+	if !ok || userCode == "" {
+		return "", fmt.Errorf("código de usuario no encontrado en el contexto")
+	}
+	return filepath.Join(chunkStorageRootFn(), userCode, uploadID), nil
+}
+
+// chunkPath ruta del fragmento index dentro de la carpeta de la subida
+func chunkPath(dir string, index int) string {
+	return filepath.Join(dir, strconv.Itoa(index)+".part")
+}
+
+// recordChunkTotal guarda total en dir la primera vez y después exige que coincida, para
+// que ni un fragmento ni la petición de completar cambien el tamaño de la subida.
+// El enlace falla si el archivo ya existe, así dos primeros fragmentos no se pisan.
+func recordChunkTotal(dir string, total int) error {
+	tmp, err := os.CreateTemp(dir, "total-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.WriteString(strconv.Itoa(total))
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.Link(tmp.Name(), filepath.Join(dir, chunkTotalFile)); err == nil || !os.IsExist(err) {
+		return err
+	}
+	return checkChunkTotal(dir, total)
+}
+
+// checkChunkTotal compara total con el guardado por recordChunkTotal
+func checkChunkTotal(dir string, total int) error {
+	data, err := os.ReadFile(filepath.Join(dir, chunkTotalFile))
+	if err != nil {
+		return err
+	}
+	recorded, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return err
+	}
+	if recorded != total {
+		return fmt.Errorf("%w (%d)", ErrChunkTotalMismatch, recorded)
+	}
+	return nil
+}
+
+// chunkStatus cuenta los fragmentos recibidos y lista los que faltan. Lee la carpeta de la
+// subida una sola vez en lugar de buscar cada índice.
+func chunkStatus(uploadID, dir string, total int) ChunkUploadStatus {
+	status := ChunkUploadStatus{UploadID: uploadID, Total: total}
+	received := map[int]bool{}
+	entries, _ := os.ReadDir(dir)
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), ".part")
+		if index, err := strconv.Atoi(name); ok && err == nil && index >= 0 && index < total {
+			received[index] = true
+		}
+	}
+	status.Received = len(received)
+	for i := 0; i < total; i++ {
+		if !received[i] {
+			status.Missing = append(status.Missing, i)
+		}
+	}
+	return status
+}
+
+// UploadChunkHandler guarda un fragmento (campo "chunk") de la subida upload_id.
+// Los fragmentos pueden llegar en cualquier orden y reenviarse: cada índice se
+// escribe en su propio archivo y un reintento simplemente lo reemplaza.
+func (s *Server) UploadChunkHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w, http.MethodPost)
+		return
+	}
+
+	// Margen de 1 MB para los campos y cabeceras del multipart
+	r.Body = http.MaxBytesReader(w, r.Body, maxChunkBytes+1<<20)
+	form, cleanupForm, err := parseMultipartForm(r, maxChunkBytes)
+	defer cleanupForm()
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			writeJSONError(w, http.StatusRequestEntityTooLarge, "El fragmento es demasiado grande")
+			return
+		}
+		writeJSONError(w, http.StatusBadRequest, "Error al parsear el formulario")
+		return
+	}
+
+	uploadID, total, err := parseChunkUpload(r)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	index, err := strconv.Atoi(r.FormValue("index"))
+	if err != nil || index < 0 || index >= total {
+		writeJSONError(w, http.StatusBadRequest, "index debe estar entre 0 y total-1")
+		return
+	}
+
+	if len(form.File["chunk"]) == 0 {
+		writeJSONError(w, http.StatusBadRequest, "Falta el fragmento")
+		return
+	}
+	chunk, err := form.File["chunk"][0].Open()
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Error al leer el fragmento")
+		return
+	}
+	defer chunk.Close()
+
+	dir, err := chunkUploadDir(r, uploadID)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Error interno de autenticación")
+		return
+	}
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "No se pudo crear la carpeta temporal")
+		return
+	}
+	if err := recordChunkTotal(dir, total); errors.Is(err, ErrChunkTotalMismatch) {
+		writeJSONError(w, http.StatusConflict, err.Error())
+		return
+	} else if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Error al guardar el fragmento")
+		return
+	}
+
+	// Escribir en un temporal y renombrar para que un fragmento a medias nunca cuente como recibido
+	tmp, err := os.CreateTemp(dir, "chunk-*.tmp")
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Error al guardar el fragmento")
+		return
+	}
+	defer os.Remove(tmp.Name())
+	_, err = io.Copy(tmp, chunk)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), chunkPath(dir, index))
+	}
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Error al guardar el fragmento")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, chunkStatus(uploadID, dir, total))
+}
+
+// UploadChunkCompleteHandler concatena los fragmentos de upload_id en la carpeta
+// indicada, con el mismo prefijo numérico que UploadHandler, y borra los temporales.
+// Si falta algún fragmento responde 409 con la lista de índices pendientes.
+func (s *Server) UploadChunkCompleteHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w, http.MethodPost)
+		return
+	}
+
+	userStoragePath, err := s.storagePath(r)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Error interno de autenticación")
+		return
+	}
+	store, err := s.storageFor(r)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Error interno de autenticación")
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Error al parsear el formulario")
+		return
+	}
+
+	var problems validationErrors
+	folder := r.FormValue("folder")
+	problems.checkFolder(folder)
+	if problems.respond(w) {
+		return
+	}
+	filename := r.FormValue("filename")
+	if !validFileName(filename) {
+		writeJSONError(w, http.StatusBadRequest, errInvalidFileName.Error())
+		return
+	}
+	if err := checkUploadExtension(filename, false); err != nil {
+		writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("%s: %v", filename, err))
+		return
+	}
+
+	uploadID, total, err := parseChunkUpload(r)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	dir, err := chunkUploadDir(r, uploadID)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Error interno de autenticación")
+		return
+	}
+	if err := checkChunkTotal(dir, total); os.IsNotExist(err) {
+		writeJSONError(w, http.StatusNotFound, "Subida no encontrada")
+		return
+	} else if errors.Is(err, ErrChunkTotalMismatch) {
+		writeJSONError(w, http.StatusConflict, err.Error())
+		return
+	} else if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Error al leer la subida")
+		return
+	}
+
+	status := chunkStatus(uploadID, dir, total)
+	if len(status.Missing) > 0 {
+		writeJSON(w, http.StatusConflict, status)
+		return
+	}
+
+	folderPath := filepath.Join(userStoragePath, folder)
+	unlock := lockFolder(userStoragePath, folder)
+	defer unlock()
+	if err := os.MkdirAll(folderPath, os.ModePerm); err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "No se pudo crear la carpeta del usuario/carpeta")
+		return
+	}
+	counter, err := countStoredFiles(store, folder)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Error leyendo el directorio")
+		return
+	}
+	if folderLimitExceeded(w, counter, 1) {
+		return
+	}
+	name := prefixedName(filename, counter+1)
+
+	if err := assembleChunks(dir, total, filepath.Join(folderPath, name)); err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Error al ensamblar el archivo")
+		return
+	}
+	discardChecksumSidecarFile(filepath.Join(folderPath, name))
+	os.RemoveAll(dir)
+	if err := recordAdded(store, folder, []string{name}, time.Now()); err != nil {
+		logf(r.Context(), "Error registrando la fecha de alta en %s: %v", folder, err)
+	}
+
+	status.File = name
+	writeJSON(w, http.StatusOK, status)
+}
+
+// PurgeChunkUploadsLoop borra cada chunkPurgeInterval las subidas por fragmentos que no
+// reciben nada desde hace más de CHUNK_UPLOAD_MAX_AGE, hasta que se cancele ctx.
+// Con CHUNK_UPLOAD_MAX_AGE=0 no hace nada.
+func (s *Server) PurgeChunkUploadsLoop(ctx context.Context) {
+	if s.cfg.ChunkUploadMaxAge <= 0 {
+		return
+	}
+	ticker := time.NewTicker(chunkPurgeInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if err := purgeChunkUploads(chunkStorageRootFn(), s.cfg.ChunkUploadMaxAge, now); err != nil {
+				fmt.Println("Error al borrar subidas abandonadas:", err)
+			}
+		}
+	}
+}
+
+// purgeChunkUploads borra las carpetas <usuario>/<upload_id> de root modificadas hace más
+// de maxAge. Cada fragmento recibido actualiza la fecha de su carpeta.
+func purgeChunkUploads(root string, maxAge time.Duration, now time.Time) error {
+	users, err := os.ReadDir(root)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, user := range users {
+		if !user.IsDir() {
+			continue
+		}
+		uploads, err := os.ReadDir(filepath.Join(root, user.Name()))
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, upload := range uploads {
+			info, err := upload.Info()
+			if err != nil || !upload.IsDir() || now.Sub(info.ModTime()) <= maxAge {
+				continue
+			}
+			if err := os.RemoveAll(filepath.Join(root, user.Name(), upload.Name())); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// assembleChunks concatena los fragmentos 0..total-1 en dstPath. Se escribe en un
+// temporal sin extensión .pdf para que el archivo no aparezca a medias en los listados.
+func assembleChunks(dir string, total int, dstPath string) error {
+	tmpPath := dstPath + ".tmp"
+	defer os.Remove(tmpPath)
+
+	dst, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+	for i := 0; i < total; i++ {
+		if err := copyFileTo(dst, chunkPath(dir, i)); err != nil {
+			dst.Close()
+			return err
+		}
+	}
+	if err := dst.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, dstPath)
+}
+
+// copyFileTo copia el contenido de path al final de dst
+func copyFileTo(dst io.Writer, path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	_, err = io.Copy(dst, src)
+	return err
+}
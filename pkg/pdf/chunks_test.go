@@ -0,0 +1,237 @@
+package pdf
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newChunkRequest crea una petición multipart para /upload-chunk
+func newChunkRequest(t *testing.T, uploadID string, index, total int, data []byte) (*http.Request, *httptest.ResponseRecorder) {
+	t.Helper()
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	writer.WriteField("upload_id", uploadID)
+	writer.WriteField("index", strconv.Itoa(index))
+	writer.WriteField("total", strconv.Itoa(total))
+	part, err := writer.CreateFormFile("chunk", "blob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write(data)
+	writer.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/upload-chunk", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req = req.WithContext(context.WithValue(req.Context(), userCodeKey, "testUser"))
+	return req, httptest.NewRecorder()
+}
+
+// newChunkCompleteRequest crea una petición de formulario para /upload-chunk/complete
+func newChunkCompleteRequest(form url.Values) (*http.Request, *httptest.ResponseRecorder) {
+	req := httptest.NewRequest(http.MethodPost, "/upload-chunk/complete", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req = req.WithContext(context.WithValue(req.Context(), userCodeKey, "testUser"))
+	return req, httptest.NewRecorder()
+}
+
+func TestChunkedUpload(t *testing.T) {
+	// Arrange
+	userPath := t.TempDir()
+	chunkRoot := t.TempDir()
+
+	originalChunkRoot := chunkStorageRootFn
+	defer func() { chunkStorageRootFn = originalChunkRoot }()
+	srv := newTestServer(userPath)
+	chunkStorageRootFn = func() string { return chunkRoot }
+
+	folderPath := filepath.Join(userPath, "test-folder")
+	os.MkdirAll(folderPath, os.ModePerm)
+	os.WriteFile(filepath.Join(folderPath, "1-existente.pdf"), []byte("%PDF"), 0o644)
+
+	complete := url.Values{"upload_id": {"up-1"}, "total": {"3"}, "folder": {"test-folder"}, "filename": {"scan.pdf"}}
+	chunks := []string{"%PDF-", "1.4 ", "body"}
+
+	// Act: fragmentos desordenados y uno reenviado
+	for _, i := range []int{2, 0, 0} {
+		req, rr := newChunkRequest(t, "up-1", i, 3, []byte(chunks[i]))
+		srv.UploadChunkHandler(rr, req)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("chunk %d: expected 200, got %d: %s", i, rr.Code, rr.Body.String())
+		}
+	}
+
+	// Assert: falta el fragmento 1
+	req, rr := newChunkCompleteRequest(complete)
+	srv.UploadChunkCompleteHandler(rr, req)
+	if rr.Code != http.StatusConflict {
+		t.Fatalf("expected 409 with a missing chunk, got %d", rr.Code)
+	}
+	var pending ChunkUploadStatus
+	json.NewDecoder(rr.Body).Decode(&pending)
+	if pending.Received != 2 || len(pending.Missing) != 1 || pending.Missing[0] != 1 {
+		t.Errorf("unexpected pending status: %+v", pending)
+	}
+
+	req, rr = newChunkRequest(t, "up-1", 1, 3, []byte(chunks[1]))
+	srv.UploadChunkHandler(rr, req)
+
+	req, rr = newChunkCompleteRequest(complete)
+	srv.UploadChunkCompleteHandler(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var done ChunkUploadStatus
+	json.NewDecoder(rr.Body).Decode(&done)
+	if done.File != "2-scan.pdf" {
+		t.Errorf("expected prefixed name 2-scan.pdf, got %q", done.File)
+	}
+	got, err := os.ReadFile(filepath.Join(folderPath, "2-scan.pdf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != strings.Join(chunks, "") {
+		t.Errorf("unexpected assembled content %q", got)
+	}
+	if _, err := os.Stat(filepath.Join(chunkRoot, "testUser", "up-1")); !os.IsNotExist(err) {
+		t.Errorf("expected chunk directory to be removed")
+	}
+
+	// Una segunda confirmación ya no encuentra la subida
+	req, rr = newChunkCompleteRequest(complete)
+	srv.UploadChunkCompleteHandler(rr, req)
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for a completed upload, got %d", rr.Code)
+	}
+}
+
+func TestUploadChunkHandlerValidation(t *testing.T) {
+	srv := NewServer(DefaultConfig(), newDefaultCodeStore())
+
+	tests := []struct {
+		name     string
+		uploadID string
+		index    int
+		total    int
+	}{
+		{name: "upload_id con ruta", uploadID: "../x", index: 0, total: 1},
+		{name: "Índice fuera de rango", uploadID: "up", index: 1, total: 1},
+		{name: "Índice negativo", uploadID: "up", index: -1, total: 1},
+		{name: "Total inválido", uploadID: "up", index: 0, total: 0},
+		{name: "Total por encima de MAX_CHUNK_UPLOAD_BYTES", uploadID: "up", index: 0, total: 1000000000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			originalChunkRoot := chunkStorageRootFn
+			defer func() { chunkStorageRootFn = originalChunkRoot }()
+			chunkRoot := t.TempDir()
+			chunkStorageRootFn = func() string { return chunkRoot }
+
+			req, rr := newChunkRequest(t, tt.uploadID, tt.index, tt.total, []byte("x"))
+
+			// Act
+			srv.UploadChunkHandler(rr, req)
+
+			// Assert
+			if rr.Code != http.StatusBadRequest {
+				t.Errorf("expected 400, got %d", rr.Code)
+			}
+		})
+	}
+}
+
+func TestChunkedUploadRejectsTotalMismatch(t *testing.T) {
+	tests := []struct {
+		name             string
+		chunkTotal       int // total del segundo fragmento
+		completeTotal    int
+		expectedChunk    int
+		expectedComplete int
+	}{
+		{name: "Mismo total", chunkTotal: 3, completeTotal: 3, expectedChunk: http.StatusOK, expectedComplete: http.StatusOK},
+		{name: "Complete con menos fragmentos de los subidos", chunkTotal: 3, completeTotal: 2, expectedChunk: http.StatusOK, expectedComplete: http.StatusConflict},
+		{name: "Complete con más fragmentos de los subidos", chunkTotal: 3, completeTotal: 4, expectedChunk: http.StatusOK, expectedComplete: http.StatusConflict},
+		// El fragmento rechazado queda pendiente
+		{name: "Fragmento con otro total", chunkTotal: 2, completeTotal: 3, expectedChunk: http.StatusConflict, expectedComplete: http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange: la subida empieza con total=3 y recibe los tres fragmentos
+			userPath := t.TempDir()
+			chunkRoot := t.TempDir()
+			originalChunkRoot := chunkStorageRootFn
+			defer func() { chunkStorageRootFn = originalChunkRoot }()
+			chunkStorageRootFn = func() string { return chunkRoot }
+			srv := newTestServer(userPath)
+
+			req, rr := newChunkRequest(t, "up-1", 0, 3, []byte("%PDF-"))
+			srv.UploadChunkHandler(rr, req)
+			req, rr = newChunkRequest(t, "up-1", 1, tt.chunkTotal, []byte("1.4 "))
+			srv.UploadChunkHandler(rr, req)
+			if rr.Code != tt.expectedChunk {
+				t.Fatalf("expected chunk status %d, got %d: %s", tt.expectedChunk, rr.Code, rr.Body.String())
+			}
+			req, rr = newChunkRequest(t, "up-1", 2, 3, []byte("body"))
+			srv.UploadChunkHandler(rr, req)
+
+			// Act
+			req, rr = newChunkCompleteRequest(url.Values{"upload_id": {"up-1"}, "total": {strconv.Itoa(tt.completeTotal)}, "folder": {"test-folder"}, "filename": {"scan.pdf"}})
+			srv.UploadChunkCompleteHandler(rr, req)
+
+			// Assert
+			if rr.Code != tt.expectedComplete {
+				t.Fatalf("expected %d, got %d: %s", tt.expectedComplete, rr.Code, rr.Body.String())
+			}
+			got, err := os.ReadFile(filepath.Join(userPath, "test-folder", "1-scan.pdf"))
+			if tt.expectedComplete != http.StatusOK {
+				if !os.IsNotExist(err) {
+					t.Errorf("expected no assembled file, got %q", got)
+				}
+				return
+			}
+			if string(got) != "%PDF-1.4 body" {
+				t.Errorf("unexpected assembled content %q", got)
+			}
+		})
+	}
+}
+
+func TestPurgeChunkUploads(t *testing.T) {
+	// Arrange: una subida abandonada hace dos días y otra con un fragmento reciente
+	root := t.TempDir()
+	now := time.Now()
+	stale := filepath.Join(root, "testUser", "vieja")
+	fresh := filepath.Join(root, "testUser", "nueva")
+	for _, dir := range []string{stale, fresh} {
+		os.MkdirAll(dir, os.ModePerm)
+		os.WriteFile(chunkPath(dir, 0), []byte("x"), 0o644)
+	}
+	os.Chtimes(stale, now.Add(-48*time.Hour), now.Add(-48*time.Hour))
+
+	// Act
+	err := purgeChunkUploads(root, 24*time.Hour, now)
+
+	// Assert
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Errorf("expected the abandoned upload to be removed")
+	}
+	if _, err := os.Stat(chunkPath(fresh, 0)); err != nil {
+		t.Errorf("expected the recent upload to be kept: %v", err)
+	}
+}
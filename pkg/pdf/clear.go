@@ -0,0 +1,68 @@
+package pdf
+
+import (
+	"errors"
+	"io/fs"
+	"net/http"
+	"time"
+)
+
+// ClearFolderHandler: Mueve a la papelera los PDFs fuente numerados ("N-nombre.pdf") de una
+// carpeta, dejando la carpeta y el folder.pdf ya generado intactos. Con purge=true se
+// borran definitivamente, como en DeleteFilesHandler.
+func (s *Server) ClearFolderHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		methodNotAllowed(w, http.MethodDelete)
+		return
+	}
+
+	// Obtener la ruta base de almacenamiento del usuario
+	userStoragePath, err := s.storagePath(r)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Error interno de autenticación")
+		return
+	}
+	store, err := s.storageFor(r)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Error interno de autenticación")
+		return
+	}
+
+	var problems validationErrors
+	folder := r.FormValue("folder")
+	problems.checkFolder(folder)
+	if problems.respond(w) {
+		return
+	}
+	purge := r.FormValue("purge") == "true"
+
+	unlock := lockFolder(userStoragePath, folder)
+	defer unlock()
+
+	files, err := listStorageFiles(store, folder, ".pdf", "", false)
+	if errors.Is(err, fs.ErrNotExist) {
+		writeJSONError(w, http.StatusNotFound, "Carpeta no encontrada")
+		return
+	}
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Error al listar archivos")
+		return
+	}
+
+	var sources []string
+	for _, filename := range files {
+		if numericPrefixRe.MatchString(filename) { // Solo los archivos fuente llevan el prefijo numérico
+			sources = append(sources, filename)
+		}
+	}
+	if purged, err := removeFiles(r.Context(), store, folder, sources, purge, time.Now()); err != nil {
+		if purge {
+			writePartialDelete(w, folder, purged, err)
+			return
+		}
+		writeJSONError(w, http.StatusInternalServerError, "Error al eliminar archivo "+err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, ClearFolderResponse{Folder: folder, Deleted: len(sources)})
+}
@@ -0,0 +1,87 @@
+package pdf
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestClearFolderHandler(t *testing.T) {
+	tests := []struct {
+		name          string
+		query         string
+		expectedTrash int // Archivos que quedan en la papelera
+	}{
+		{name: "Por defecto van a la papelera", query: "folder=test-folder", expectedTrash: 2},
+		{name: "Con purge se borran definitivamente", query: "folder=test-folder&purge=true", expectedTrash: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			userPath := t.TempDir()
+			folderPath := filepath.Join(userPath, "test-folder")
+			os.MkdirAll(folderPath, os.ModePerm)
+			for _, f := range []string{"1-a.pdf", "2-b.pdf", "notes.pdf"} {
+				os.Create(filepath.Join(folderPath, f))
+			}
+			os.WriteFile(filepath.Join(folderPath, checksumSidecarName("1-a.pdf")), []byte("sha256"), 0o644)
+			os.Create(filepath.Join(userPath, "test-folder.pdf"))
+
+			srv := newTestServer(userPath)
+
+			req := httptest.NewRequest(http.MethodDelete, "/clear?"+tt.query, nil)
+			req = req.WithContext(context.WithValue(req.Context(), userCodeKey, "testUser"))
+			rr := httptest.NewRecorder()
+
+			// Act
+			srv.ClearFolderHandler(rr, req)
+
+			// Assert
+			if rr.Code != http.StatusOK {
+				t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+			}
+			var resp ClearFolderResponse
+			json.NewDecoder(rr.Body).Decode(&resp)
+			if resp.Deleted != 2 {
+				t.Errorf("expected 2 deleted files, got %d", resp.Deleted)
+			}
+
+			remaining, _ := ListFilesWithExtension(folderPath, ".pdf")
+			if !reflect.DeepEqual(remaining, []string{"notes.pdf"}) {
+				t.Errorf("expected only notes.pdf to remain, got %v", remaining)
+			}
+			if _, err := os.Stat(filepath.Join(userPath, "test-folder.pdf")); err != nil {
+				t.Errorf("merged output must be kept: %v", err)
+			}
+			if _, err := os.Stat(filepath.Join(folderPath, checksumSidecarName("1-a.pdf"))); !os.IsNotExist(err) {
+				t.Errorf("expected the checksum sidecar to be discarded")
+			}
+			items, _ := listTrash(NewLocalStorage(userPath), "test-folder")
+			if len(items) != tt.expectedTrash {
+				t.Errorf("expected %d files in the trash, got %v", tt.expectedTrash, items)
+			}
+		})
+	}
+}
+
+func TestClearFolderHandlerFolderNotFound(t *testing.T) {
+	// Arrange
+	srv := newTestServer(t.TempDir())
+	req := httptest.NewRequest(http.MethodDelete, "/clear?folder=no-existe", nil)
+	req = req.WithContext(context.WithValue(req.Context(), userCodeKey, "testUser"))
+	rr := httptest.NewRecorder()
+
+	// Act
+	srv.ClearFolderHandler(rr, req)
+
+	// Assert
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
@@ -0,0 +1,166 @@
+package pdf
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// newGenerateCodeRequest crea una petición de formulario para /generate-code
+func newGenerateCodeRequest(name, date, accept string) *http.Request {
+	form := url.Values{"name": {name}, "date": {date}}
+	req := httptest.NewRequest(http.MethodPost, "/generate-code", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	return req
+}
+
+func TestGenerateCodeHandlerResponseFormat(t *testing.T) {
+	srv := NewServer(DefaultConfig(), newDefaultCodeStore())
+
+	tests := []struct {
+		name                string
+		accept              string
+		expectedContentType string
+	}{
+		{name: "Texto plano por defecto", accept: "", expectedContentType: "text/plain"},
+		{name: "JSON cuando se pide en Accept", accept: "application/json", expectedContentType: "application/json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			req := newGenerateCodeRequest("alex", "2024-03-01", tt.accept)
+			rr := httptest.NewRecorder()
+
+			// Act
+			srv.GenerateCodeHandler(rr, req)
+
+			// Assert
+			if rr.Code != http.StatusOK {
+				t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+			}
+			if got := rr.Header().Get("Content-Type"); got != tt.expectedContentType {
+				t.Errorf("expected Content-Type %s, got %s", tt.expectedContentType, got)
+			}
+
+			var code string
+			if tt.expectedContentType == "application/json" {
+				var resp GeneratedCode
+				json.NewDecoder(rr.Body).Decode(&resp)
+				if resp.Name != "alex" || resp.Created.IsZero() {
+					t.Errorf("unexpected JSON response: %+v", resp)
+				}
+				code = resp.Code
+			} else {
+				code = strings.TrimSpace(rr.Body.String())
+			}
+			if _, err := base64.StdEncoding.DecodeString(code); err != nil || code == "" {
+				t.Errorf("expected a base64 code, got %q", code)
+			}
+		})
+	}
+}
+
+func TestEncodeAccessCodeAvoidsCollisions(t *testing.T) {
+	tests := []struct {
+		name          string
+		first, second [2]string
+	}{
+		{name: "Caso de colisión por concatenación", first: [2]string{"ab", "c"}, second: [2]string{"a", "bc"}},
+		{name: "Nombre que termina en dígitos", first: [2]string{"alex1", "2024-01-01"}, second: [2]string{"alex", "12024-01-01"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			first := encodeAccessCode(tt.first[0], tt.first[1])
+			second := encodeAccessCode(tt.second[0], tt.second[1])
+			if first == second {
+				t.Errorf("expected distinct codes for %v and %v, got %s", tt.first, tt.second, first)
+			}
+		})
+	}
+}
+
+func TestGenerateCodeHandlerDateValidation(t *testing.T) {
+	srv := NewServer(DefaultConfig(), newDefaultCodeStore())
+
+	tests := []struct {
+		name           string
+		date           string
+		expectedStatus int
+	}{
+		{name: "Fecha válida", date: "2024-03-01", expectedStatus: http.StatusOK},
+		{name: "Fecha con espacios se normaliza", date: " 2024-03-01 ", expectedStatus: http.StatusOK},
+		{name: "Formato no soportado", date: "01/03/2024", expectedStatus: http.StatusBadRequest},
+		{name: "Fecha inexistente", date: "2024-02-30", expectedStatus: http.StatusBadRequest},
+		{name: "Texto libre", date: "mañana", expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			srv.GenerateCodeHandler(rr, newGenerateCodeRequest("alex", tt.date, ""))
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, tt.expectedStatus)
+			}
+		})
+	}
+}
+
+func TestGenerateCodeHandlerNormalizesDate(t *testing.T) {
+	srv := NewServer(DefaultConfig(), newDefaultCodeStore())
+	first, second := httptest.NewRecorder(), httptest.NewRecorder()
+	srv.GenerateCodeHandler(first, newGenerateCodeRequest("alex", "2024-03-01", ""))
+	srv.GenerateCodeHandler(second, newGenerateCodeRequest("alex", " 2024-03-01", ""))
+	if first.Body.String() != second.Body.String() {
+		t.Errorf("expected the same code for the same normalized date")
+	}
+}
+
+func TestGenerateCodeHandlerJSONBody(t *testing.T) {
+	srv := NewServer(DefaultConfig(), newDefaultCodeStore())
+
+	tests := []struct {
+		name           string
+		contentType    string
+		body           string
+		expectedStatus int
+	}{
+		{name: "Cuerpo JSON", contentType: "application/json", body: `{"name":"alex","date":"2024-03-01"}`, expectedStatus: http.StatusOK},
+		{name: "Cuerpo JSON con charset", contentType: "application/json; charset=utf-8", body: `{"name":" alex ","date":"2024-03-01"}`, expectedStatus: http.StatusOK},
+		{name: "JSON mal formado", contentType: "application/json", body: `{"name":`, expectedStatus: http.StatusBadRequest},
+		{name: "JSON sin fecha", contentType: "application/json", body: `{"name":"alex"}`, expectedStatus: http.StatusBadRequest},
+	}
+
+	// El mismo nombre y fecha por formulario deben producir el mismo código
+	formRR := httptest.NewRecorder()
+	srv.GenerateCodeHandler(formRR, newGenerateCodeRequest("alex", "2024-03-01", ""))
+	formCode := strings.TrimSpace(formRR.Body.String())
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			req := httptest.NewRequest(http.MethodPost, "/generate-code", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			rr := httptest.NewRecorder()
+
+			// Act
+			srv.GenerateCodeHandler(rr, req)
+
+			// Assert
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("handler returned wrong status code: got %v want %v (%s)", rr.Code, tt.expectedStatus, rr.Body.String())
+			}
+			if tt.expectedStatus == http.StatusOK && strings.TrimSpace(rr.Body.String()) != formCode {
+				t.Errorf("expected the same code as the form request, got %q", rr.Body.String())
+			}
+		})
+	}
+}
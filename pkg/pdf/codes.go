@@ -0,0 +1,204 @@
+package pdf
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// --- Almacén de códigos de acceso ---
+// Los handlers solo conocen la interfaz CodeStore; así los códigos pueden guardarse
+// en memoria, en un archivo o, más adelante, en Redis o una base de datos sin tocar
+// GenerateCodeHandler, LoginHandler ni AuthMiddleware.
+type CodeStore interface {
+	// IsValid indica si el código permite acceder
+	IsValid(code string) bool
+	// Get devuelve los datos asociados a un código válido
+	Get(code string) (GeneratedCode, bool)
+	// Add registra un código como válido (o actualiza sus datos)
+	Add(info GeneratedCode) error
+	// AddAll registra varios códigos de una vez: o se guardan todos o ninguno
+	AddAll(infos []GeneratedCode) error
+	// Revoke invalida un código; revocar uno inexistente no es un error
+	Revoke(code string) error
+	// List devuelve los códigos válidos ordenados por código
+	List() ([]GeneratedCode, error)
+}
+
+// MemoryCodeStore guarda los códigos en un mapa en memoria protegido por un Mutex.
+// Los códigos se pierden al reiniciar el servidor.
+type MemoryCodeStore struct {
+	mu    sync.Mutex
+	codes map[string]GeneratedCode
+}
+
+// NewMemoryCodeStore crea un almacén en memoria con los códigos iniciales dados
+func NewMemoryCodeStore(initial ...GeneratedCode) *MemoryCodeStore {
+	store := &MemoryCodeStore{codes: make(map[string]GeneratedCode, len(initial))}
+	for _, info := range initial {
+		store.codes[info.Code] = info
+	}
+	return store
+}
+
+// newDefaultCodeStore almacén en memoria con el código de desarrollo "alex"
+func newDefaultCodeStore() *MemoryCodeStore {
+	return NewMemoryCodeStore(GeneratedCode{Name: "alex", Code: "alex"})
+}
+
+func (m *MemoryCodeStore) IsValid(code string) bool {
+	_, ok := m.Get(code)
+	return ok
+}
+
+func (m *MemoryCodeStore) Get(code string) (GeneratedCode, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	info, ok := m.codes[code]
+	return info, ok
+}
+
+func (m *MemoryCodeStore) Add(info GeneratedCode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.codes[info.Code] = info
+	return nil
+}
+
+func (m *MemoryCodeStore) AddAll(infos []GeneratedCode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, info := range infos {
+		m.codes[info.Code] = info
+	}
+	return nil
+}
+
+func (m *MemoryCodeStore) Revoke(code string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.codes, code)
+	return nil
+}
+
+func (m *MemoryCodeStore) List() ([]GeneratedCode, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.listLocked(), nil
+}
+
+// listLocked copia los códigos ordenados; quien llama debe tener el Mutex
+func (m *MemoryCodeStore) listLocked() []GeneratedCode {
+	list := make([]GeneratedCode, 0, len(m.codes))
+	for _, info := range m.codes {
+		list = append(list, info)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Code < list[j].Code })
+	return list
+}
+
+// FileCodeStore mantiene los códigos en memoria y los persiste como JSON en un archivo
+// tras cada cambio, así sobreviven a un reinicio. Pensado para una sola instancia:
+// no detecta cambios hechos en el archivo por otro proceso.
+type FileCodeStore struct {
+	MemoryCodeStore
+	path string
+}
+
+// NewFileCodeStore carga los códigos de path; si el archivo no existe empieza vacío
+func NewFileCodeStore(path string) (*FileCodeStore, error) {
+	store := &FileCodeStore{MemoryCodeStore: MemoryCodeStore{codes: map[string]GeneratedCode{}}, path: path}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var list []GeneratedCode
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, err
+	}
+	for _, info := range list {
+		store.codes[info.Code] = info
+	}
+	return store, nil
+}
+
+func (f *FileCodeStore) Add(info GeneratedCode) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	previous, existed := f.codes[info.Code]
+	f.codes[info.Code] = info
+	if err := f.saveLocked(); err != nil {
+		// Sin persistir, el cambio tampoco se aplica en memoria
+		if existed {
+			f.codes[info.Code] = previous
+		} else {
+			delete(f.codes, info.Code)
+		}
+		return err
+	}
+	return nil
+}
+
+// AddAll escribe el archivo una sola vez para todo el lote
+func (f *FileCodeStore) AddAll(infos []GeneratedCode) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	previous := make(map[string]GeneratedCode, len(f.codes))
+	for code, info := range f.codes {
+		previous[code] = info
+	}
+	for _, info := range infos {
+		f.codes[info.Code] = info
+	}
+	if err := f.saveLocked(); err != nil {
+		f.codes = previous
+		return err
+	}
+	return nil
+}
+
+func (f *FileCodeStore) Revoke(code string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	previous, existed := f.codes[code]
+	if !existed {
+		return nil
+	}
+	delete(f.codes, code)
+	if err := f.saveLocked(); err != nil {
+		f.codes[code] = previous
+		return err
+	}
+	return nil
+}
+
+// saveLocked escribe el archivo en un temporal y lo renombra para no dejarlo a medias
+func (f *FileCodeStore) saveLocked() error {
+	data, err := json.MarshalIndent(f.listLocked(), "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(f.path), os.ModePerm); err != nil {
+		return err
+	}
+	tmpPath := f.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, f.path)
+}
+
+// NewCodeStore crea el almacén indicado por la configuración: un FileCodeStore si
+// CODES_FILE está definido y, si no, uno en memoria con el código de desarrollo
+func NewCodeStore(cfg Config) (CodeStore, error) {
+	if cfg.CodesFile != "" {
+		return NewFileCodeStore(cfg.CodesFile)
+	}
+	return newDefaultCodeStore(), nil
+}
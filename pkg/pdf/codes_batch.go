@@ -0,0 +1,65 @@
+package pdf
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// Máximo de entradas y tamaño del cuerpo de GenerateCodesBatchHandler
+const (
+	maxCodeBatchEntries   = 500
+	maxCodeBatchBodyBytes = 256 << 10
+)
+
+// GenerateCodesBatchHandler: Genera los códigos de acceso de un grupo de usuarios a partir
+// de un arreglo JSON de {"name","date"}. Una entrada inválida se informa en su posición
+// sin impedir las demás; las válidas se guardan juntas en una sola operación del almacén.
+// Solo para administradores.
+func (s *Server) GenerateCodesBatchHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w, http.MethodPost)
+		return
+	}
+
+	if !isJSONRequest(r) {
+		writeJSONError(w, http.StatusBadRequest, "Content-Type debe ser application/json")
+		return
+	}
+	var entries []GenerateCodeRequest
+	r.Body = http.MaxBytesReader(w, r.Body, maxCodeBatchBodyBytes)
+	if err := json.NewDecoder(r.Body).Decode(&entries); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Formato JSON inválido: se espera un arreglo de {name, date}")
+		return
+	}
+	if len(entries) == 0 {
+		writeJSONError(w, http.StatusBadRequest, "No hay códigos que generar")
+		return
+	}
+	if len(entries) > maxCodeBatchEntries {
+		writeJSONError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("Como máximo %d códigos por petición", maxCodeBatchEntries))
+		return
+	}
+
+	results := make([]CodeBatchResult, len(entries))
+	var generated []GeneratedCode
+	for i, entry := range entries {
+		results[i].Index = i
+		code, err := newGeneratedCode(entry.Name, entry.Date)
+		if err != nil {
+			results[i].Error = err.Error()
+			continue
+		}
+		results[i].GeneratedCode = &code
+		generated = append(generated, code)
+	}
+
+	if len(generated) > 0 {
+		if err := s.codes.AddAll(generated); err != nil {
+			logf(r.Context(), "Error guardando %d códigos: %v", len(generated), err)
+			writeJSONError(w, http.StatusInternalServerError, "No se pudieron guardar los códigos de acceso")
+			return
+		}
+	}
+	writeJSON(w, http.StatusOK, results)
+}
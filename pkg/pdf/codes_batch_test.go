@@ -0,0 +1,93 @@
+package pdf
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newCodesBatchRequest(body string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/admin/generate-codes", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	return req
+}
+
+func TestGenerateCodesBatchHandler(t *testing.T) {
+	// Arrange
+	store := NewMemoryCodeStore()
+	srv := NewServer(DefaultConfig(), store)
+	body := `[
+		{"name":"ana","date":"2024-03-01"},
+		{"name":"","date":"2024-03-01"},
+		{"name":"bea","date":"01/03/2024"},
+		{"name":" carla ","date":"2024-3-1"}
+	]`
+	rr := httptest.NewRecorder()
+
+	// Act
+	srv.GenerateCodesBatchHandler(rr, newCodesBatchRequest(body))
+
+	// Assert
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var results []CodeBatchResult
+	if err := json.NewDecoder(rr.Body).Decode(&results); err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 4 {
+		t.Fatalf("expected one result per entry, got %d", len(results))
+	}
+	expectedCodes := []string{encodeAccessCode("ana", "2024-03-01"), "", "", ""}
+	expectedErrors := []string{"", errCodeFieldsRequired.Error(), errCodeDateFormat.Error(), errCodeDateFormat.Error()}
+	for i, result := range results {
+		if result.Index != i {
+			t.Errorf("result %d: expected index %d, got %d", i, i, result.Index)
+		}
+		if result.Error != expectedErrors[i] {
+			t.Errorf("result %d: expected error %q, got %q", i, expectedErrors[i], result.Error)
+		}
+		code := ""
+		if result.GeneratedCode != nil {
+			code = result.Code
+		}
+		if code != expectedCodes[i] {
+			t.Errorf("result %d: expected code %q, got %q", i, expectedCodes[i], code)
+		}
+	}
+	if list, _ := store.List(); len(list) != 1 || !store.IsValid(expectedCodes[0]) {
+		t.Errorf("expected only the valid entry to be stored, got %+v", list)
+	}
+}
+
+func TestGenerateCodesBatchHandlerErrors(t *testing.T) {
+	tests := []struct {
+		name           string
+		store          CodeStore
+		body           string
+		expectedStatus int
+	}{
+		{name: "Lote vacío", store: NewMemoryCodeStore(), body: `[]`, expectedStatus: http.StatusBadRequest},
+		{name: "No es un arreglo", store: NewMemoryCodeStore(), body: `{"name":"ana","date":"2024-03-01"}`, expectedStatus: http.StatusBadRequest},
+		{name: "Demasiadas entradas", store: NewMemoryCodeStore(), body: "[" + strings.Repeat(`{"name":"a","date":"2024-03-01"},`, maxCodeBatchEntries) + `{"name":"a","date":"2024-03-01"}]`, expectedStatus: http.StatusRequestEntityTooLarge},
+		{name: "El almacén falla", store: failingCodeStore{NewMemoryCodeStore()}, body: `[{"name":"ana","date":"2024-03-01"}]`, expectedStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			srv := NewServer(DefaultConfig(), tt.store)
+			rr := httptest.NewRecorder()
+
+			// Act
+			srv.GenerateCodesBatchHandler(rr, newCodesBatchRequest(tt.body))
+
+			// Assert
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+			}
+		})
+	}
+}
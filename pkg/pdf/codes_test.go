@@ -0,0 +1,140 @@
+package pdf
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCodeStores(t *testing.T) {
+	stores := map[string]func(t *testing.T) CodeStore{
+		"Memoria": func(t *testing.T) CodeStore { return NewMemoryCodeStore() },
+		"Archivo": func(t *testing.T) CodeStore {
+			store, err := NewFileCodeStore(filepath.Join(t.TempDir(), "codes.json"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			return store
+		},
+	}
+
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			// Arrange
+			store := newStore(t)
+
+			// Act
+			if err := store.Add(GeneratedCode{Name: "bea", Code: "b"}); err != nil {
+				t.Fatal(err)
+			}
+			if err := store.Add(GeneratedCode{Name: "ana", Code: "a"}); err != nil {
+				t.Fatal(err)
+			}
+
+			// Assert
+			if !store.IsValid("a") || store.IsValid("c") {
+				t.Errorf("unexpected validity after Add")
+			}
+			if info, ok := store.Get("b"); !ok || info.Name != "bea" {
+				t.Errorf("expected code b for bea, got %+v", info)
+			}
+			list, err := store.List()
+			if err != nil || len(list) != 2 || list[0].Code != "a" {
+				t.Errorf("expected codes sorted by code, got %+v (err: %v)", list, err)
+			}
+
+			if err := store.Revoke("a"); err != nil {
+				t.Fatal(err)
+			}
+			if err := store.Revoke("inexistente"); err != nil {
+				t.Errorf("revoking an unknown code must not fail: %v", err)
+			}
+			if store.IsValid("a") {
+				t.Errorf("expected code a to be revoked")
+			}
+
+			if err := store.AddAll([]GeneratedCode{{Name: "carla", Code: "c"}, {Name: "dani", Code: "d"}}); err != nil {
+				t.Fatal(err)
+			}
+			if !store.IsValid("c") || !store.IsValid("d") || !store.IsValid("b") {
+				t.Errorf("expected AddAll to add every code and keep the rest")
+			}
+		})
+	}
+}
+
+func TestFileCodeStorePersists(t *testing.T) {
+	// Arrange
+	path := filepath.Join(t.TempDir(), "datos", "codes.json")
+	store, err := NewFileCodeStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.Add(GeneratedCode{Name: "bea", Code: "b"})
+	store.Add(GeneratedCode{Name: "ana", Code: "a"})
+	store.Revoke("a")
+
+	// Act: reabrir como tras un reinicio
+	reopened, err := NewFileCodeStore(path)
+
+	// Assert
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reopened.IsValid("b") || reopened.IsValid("a") {
+		t.Errorf("expected only code b to survive the restart")
+	}
+}
+
+func TestFileCodeStoreInvalidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "codes.json")
+	os.WriteFile(path, []byte("no es JSON"), 0o600)
+
+	if _, err := NewFileCodeStore(path); err == nil {
+		t.Errorf("expected an error for a corrupt codes file")
+	}
+}
+
+func TestNewCodeStore(t *testing.T) {
+	// Sin CODES_FILE: en memoria con el código de desarrollo
+	store, err := NewCodeStore(DefaultConfig())
+	if err != nil || !store.IsValid("alex") {
+		t.Errorf("expected the in-memory store with the development code (err: %v)", err)
+	}
+
+	cfg := DefaultConfig()
+	cfg.CodesFile = filepath.Join(t.TempDir(), "codes.json")
+	store, err = NewCodeStore(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := store.(*FileCodeStore); !ok || store.IsValid("alex") {
+		t.Errorf("expected an empty file store, got %T", store)
+	}
+}
+
+// failingCodeStore simula un almacén que no puede guardar
+type failingCodeStore struct {
+	*MemoryCodeStore
+}
+
+func (failingCodeStore) Add(GeneratedCode) error { return errors.New("almacén no disponible") }
+
+func (failingCodeStore) AddAll([]GeneratedCode) error { return errors.New("almacén no disponible") }
+
+func TestGenerateCodeHandlerStoreError(t *testing.T) {
+	// Arrange
+	srv := NewServer(DefaultConfig(), failingCodeStore{NewMemoryCodeStore()})
+	rr := httptest.NewRecorder()
+
+	// Act
+	srv.GenerateCodeHandler(rr, newGenerateCodeRequest("bea", "2024-03-01", ""))
+
+	// Assert
+	if rr.Code != http.StatusInternalServerError {
+		t.Errorf("expected 500 when the code cannot be stored, got %d", rr.Code)
+	}
+}
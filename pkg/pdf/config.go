@@ -0,0 +1,345 @@
+package pdf
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// --- Configuración ---
+// Toda la configuración sale de variables de entorno y se lee una sola vez al arrancar
+// con LoadConfig, que falla si algún valor no se puede interpretar o no es válido.
+// main aplica el resultado con Configure y crea el Server con NewServer.
+
+// Config valores configurables del servidor; entre paréntesis la variable de entorno
+type Config struct {
+	ListenAddr      string        // Dirección de escucha (LISTEN_ADDR)
+	ShutdownTimeout time.Duration // Espera máxima al apagar (SHUTDOWN_TIMEOUT)
+	StorageRoot     string        // Carpeta con el almacenamiento de todos los usuarios (STORAGE_ROOT)
+	TempDir         string        // Carpeta de los archivos intermedios (TEMP_DIR)
+	Production      bool          // APP_ENV=production activa las validaciones estrictas
+	AuthSecret      string        // Secreto del servidor (AUTH_SECRET); obligatorio en producción
+	CodesFile       string        // Archivo JSON donde persistir los códigos (CODES_FILE); vacío en memoria
+	CookieName      string        // Cookie de sesión con el código de acceso (COOKIE_NAME)
+	CookiePath      string        // Ruta de la cookie de sesión (COOKIE_PATH), p. ej. el prefijo del proxy
+	OutputDir       string        // Carpeta de las salidas unidas, relativa a la del usuario (OUTPUT_DIR); vacío en su raíz
+	TLSCert         string        // Certificado PEM para servir HTTPS directamente (TLS_CERT); vacío HTTP
+	TLSKey          string        // Clave privada PEM del certificado (TLS_KEY)
+
+	AdminCodes           []string // ADMIN_CODES
+	CORSAllowedOrigins   []string // CORS_ALLOWED_ORIGINS
+	MergeURLAllowedHosts []string // MERGE_URL_ALLOWED_HOSTS
+	CallbackAllowedHosts []string // CALLBACK_ALLOWED_HOSTS
+	AllowedExtensions    []string // ALLOWED_EXTENSIONS: extensiones que se pueden guardar al subir
+
+	UploadFieldName        string // UPLOAD_FIELD_NAME
+	MaxFilesPerFolder      int    // MAX_FILES_PER_FOLDER; 0 sin límite
+	MaxTotalPages          int    // MAX_TOTAL_PAGES; 0 sin límite
+	UserQuotaBytes         int64  // USER_QUOTA_BYTES: espacio por usuario; 0 sin límite
+	MultipartMaxMemory     int64  // MULTIPART_MAX_MEMORY: bytes de una subida que se guardan en memoria
+	MultipartTempDir       string // MULTIPART_TEMP_DIR: carpeta del resto; vacío la temporal del sistema
+	MaxChunkBytes          int64  // MAX_CHUNK_BYTES
+	MaxChunkUploadBytes    int64  // MAX_CHUNK_UPLOAD_BYTES: tamaño máximo de un archivo subido por fragmentos
+	MaxZipExtractBytes     int64  // MAX_ZIP_EXTRACT_BYTES
+	MaxDecompressedBytes   int64  // MAX_DECOMPRESSED_BYTES: por archivo de una subida comprimida
+	MaxBase64DownloadBytes int64  // MAX_BASE64_DOWNLOAD_BYTES
+	MaxMergeURLBytes       int64  // MAX_MERGE_URL_BYTES
+	MaxMergeURLs           int    // MAX_MERGE_URLS
+	MaxConcurrentMerges    int    // MAX_CONCURRENT_MERGES
+	IdempotencyMaxKeys     int    // IDEMPOTENCY_MAX_KEYS
+	MergeAttempts          int    // MERGE_ATTEMPTS
+	CallbackAttempts       int    // CALLBACK_ATTEMPTS
+	ThumbnailWidth         int    // THUMBNAIL_WIDTH
+	NormalizePageSize      string // NORMALIZE_PAGE_SIZE
+
+	MergeQueueTimeout time.Duration // MERGE_QUEUE_TIMEOUT
+	MergeRetryBackoff time.Duration // MERGE_RETRY_BACKOFF
+	MergeURLTimeout   time.Duration // MERGE_URL_TIMEOUT
+	CallbackTimeout   time.Duration // CALLBACK_TIMEOUT
+	IdempotencyTTL    time.Duration // IDEMPOTENCY_TTL
+	JobTTL            time.Duration // JOB_TTL: cuánto se conserva un trabajo asíncrono terminado
+	TrashMaxAge       time.Duration // TRASH_MAX_AGE: se vacía lo borrado hace más; 0 nunca
+	ChunkUploadMaxAge time.Duration // CHUNK_UPLOAD_MAX_AGE: se borran las subidas sin completar más antiguas; 0 nunca
+
+	// Timeouts del servidor HTTP. WRITE_TIMEOUT cubre toda la respuesta, incluida la unión
+	// o la descarga, y READ_TIMEOUT todo el cuerpo de una subida: deben ser holgados. Contra
+	// clientes lentos (slowloris) protege sobre todo READ_HEADER_TIMEOUT.
+	ReadHeaderTimeout time.Duration // READ_HEADER_TIMEOUT
+	ReadTimeout       time.Duration // READ_TIMEOUT
+	WriteTimeout      time.Duration // WRITE_TIMEOUT
+	IdleTimeout       time.Duration // IDLE_TIMEOUT
+}
+
+// DefaultConfig valores usados cuando una variable no está definida
+func DefaultConfig() Config {
+	return Config{
+		ListenAddr:      ":8080",
+		ShutdownTimeout: 30 * time.Second,
+		StorageRoot:     defaultStorageRoot(),
+		TempDir:         os.TempDir(),
+		CookieName:      "auth_code",
+		CookiePath:      "/",
+
+		AllowedExtensions: []string{".pdf"},
+
+		UploadFieldName:        "pdfs",
+		MultipartMaxMemory:     32 << 20,
+		MaxChunkBytes:          8 << 20,
+		MaxChunkUploadBytes:    1 << 30,
+		MaxZipExtractBytes:     200 << 20,
+		MaxDecompressedBytes:   200 << 20,
+		MaxBase64DownloadBytes: 20 << 20,
+		MaxMergeURLBytes:       50 << 20,
+		MaxMergeURLs:           20,
+		MaxConcurrentMerges:    4,
+		IdempotencyMaxKeys:     1000,
+		MergeAttempts:          3,
+		CallbackAttempts:       3,
+		ThumbnailWidth:         200,
+		NormalizePageSize:      "A4",
+
+		MergeQueueTimeout: 5 * time.Second,
+		MergeRetryBackoff: 200 * time.Millisecond,
+		MergeURLTimeout:   15 * time.Second,
+		CallbackTimeout:   5 * time.Second,
+		IdempotencyTTL:    10 * time.Minute,
+		JobTTL:            time.Hour,
+		TrashMaxAge:       7 * 24 * time.Hour,
+		ChunkUploadMaxAge: 24 * time.Hour,
+
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       5 * time.Minute,
+		WriteTimeout:      10 * time.Minute,
+		IdleTimeout:       2 * time.Minute,
+	}
+}
+
+// defaultStorageRoot carpeta "archivos" dentro del directorio de trabajo
+func defaultStorageRoot() string {
+	path, _ := os.Getwd() // Obtiene el directorio de trabajo actual
+	return filepath.Join(path, "archivos")
+}
+
+// Configuración con la que arrancan las variables del paquete hasta llamar a Configure
+var defaultConfig = DefaultConfig()
+
+// LoadConfig lee la configuración del entorno sobre DefaultConfig y la valida.
+// Devuelve todos los problemas encontrados juntos para corregirlos de una vez.
+func LoadConfig() (Config, error) {
+	cfg := DefaultConfig()
+	env := &envLoader{}
+
+	cfg.ListenAddr = envString("LISTEN_ADDR", cfg.ListenAddr)
+	cfg.ShutdownTimeout = env.duration("SHUTDOWN_TIMEOUT", cfg.ShutdownTimeout)
+	cfg.ReadHeaderTimeout = env.duration("READ_HEADER_TIMEOUT", cfg.ReadHeaderTimeout)
+	cfg.ReadTimeout = env.duration("READ_TIMEOUT", cfg.ReadTimeout)
+	cfg.WriteTimeout = env.duration("WRITE_TIMEOUT", cfg.WriteTimeout)
+	cfg.IdleTimeout = env.duration("IDLE_TIMEOUT", cfg.IdleTimeout)
+	cfg.StorageRoot = envString("STORAGE_ROOT", cfg.StorageRoot)
+	cfg.TempDir = envString("TEMP_DIR", cfg.TempDir)
+	cfg.OutputDir = os.Getenv("OUTPUT_DIR")
+	cfg.MultipartTempDir = os.Getenv("MULTIPART_TEMP_DIR")
+	cfg.Production = os.Getenv("APP_ENV") == "production"
+	cfg.AuthSecret = os.Getenv("AUTH_SECRET")
+	cfg.CodesFile = os.Getenv("CODES_FILE")
+	cfg.CookieName = envString("COOKIE_NAME", cfg.CookieName)
+	cfg.CookiePath = envString("COOKIE_PATH", cfg.CookiePath)
+	cfg.TLSCert = os.Getenv("TLS_CERT")
+	cfg.TLSKey = os.Getenv("TLS_KEY")
+
+	cfg.AdminCodes = envList("ADMIN_CODES")
+	cfg.CORSAllowedOrigins = envList("CORS_ALLOWED_ORIGINS")
+	cfg.MergeURLAllowedHosts = envList("MERGE_URL_ALLOWED_HOSTS")
+	cfg.CallbackAllowedHosts = envList("CALLBACK_ALLOWED_HOSTS")
+	if extensions := envList("ALLOWED_EXTENSIONS"); len(extensions) > 0 {
+		cfg.AllowedExtensions = normalizeExtensions(extensions)
+	}
+
+	cfg.UploadFieldName = envString("UPLOAD_FIELD_NAME", cfg.UploadFieldName)
+	cfg.MaxFilesPerFolder = env.int("MAX_FILES_PER_FOLDER", cfg.MaxFilesPerFolder)
+	cfg.MaxTotalPages = env.int("MAX_TOTAL_PAGES", cfg.MaxTotalPages)
+	cfg.UserQuotaBytes = env.int64("USER_QUOTA_BYTES", cfg.UserQuotaBytes)
+	cfg.MultipartMaxMemory = env.int64("MULTIPART_MAX_MEMORY", cfg.MultipartMaxMemory)
+	cfg.MaxChunkBytes = env.int64("MAX_CHUNK_BYTES", cfg.MaxChunkBytes)
+	cfg.MaxChunkUploadBytes = env.int64("MAX_CHUNK_UPLOAD_BYTES", cfg.MaxChunkUploadBytes)
+	cfg.MaxZipExtractBytes = env.int64("MAX_ZIP_EXTRACT_BYTES", cfg.MaxZipExtractBytes)
+	cfg.MaxDecompressedBytes = env.int64("MAX_DECOMPRESSED_BYTES", cfg.MaxDecompressedBytes)
+	cfg.MaxBase64DownloadBytes = env.int64("MAX_BASE64_DOWNLOAD_BYTES", cfg.MaxBase64DownloadBytes)
+	cfg.MaxMergeURLBytes = env.int64("MAX_MERGE_URL_BYTES", cfg.MaxMergeURLBytes)
+	cfg.MaxMergeURLs = env.int("MAX_MERGE_URLS", cfg.MaxMergeURLs)
+	cfg.MaxConcurrentMerges = env.int("MAX_CONCURRENT_MERGES", cfg.MaxConcurrentMerges)
+	cfg.IdempotencyMaxKeys = env.int("IDEMPOTENCY_MAX_KEYS", cfg.IdempotencyMaxKeys)
+	cfg.MergeAttempts = env.int("MERGE_ATTEMPTS", cfg.MergeAttempts)
+	cfg.CallbackAttempts = env.int("CALLBACK_ATTEMPTS", cfg.CallbackAttempts)
+	cfg.ThumbnailWidth = env.int("THUMBNAIL_WIDTH", cfg.ThumbnailWidth)
+	cfg.NormalizePageSize = envString("NORMALIZE_PAGE_SIZE", cfg.NormalizePageSize)
+
+	cfg.MergeQueueTimeout = env.duration("MERGE_QUEUE_TIMEOUT", cfg.MergeQueueTimeout)
+	cfg.MergeRetryBackoff = env.duration("MERGE_RETRY_BACKOFF", cfg.MergeRetryBackoff)
+	cfg.MergeURLTimeout = env.duration("MERGE_URL_TIMEOUT", cfg.MergeURLTimeout)
+	cfg.CallbackTimeout = env.duration("CALLBACK_TIMEOUT", cfg.CallbackTimeout)
+	cfg.IdempotencyTTL = env.duration("IDEMPOTENCY_TTL", cfg.IdempotencyTTL)
+	cfg.JobTTL = env.duration("JOB_TTL", cfg.JobTTL)
+	cfg.TrashMaxAge = env.duration("TRASH_MAX_AGE", cfg.TrashMaxAge)
+	cfg.ChunkUploadMaxAge = env.duration("CHUNK_UPLOAD_MAX_AGE", cfg.ChunkUploadMaxAge)
+
+	if err := errors.Join(append(env.errs, cfg.Validate())...); err != nil {
+		return cfg, err
+	}
+	return cfg, nil
+}
+
+// TLSEnabled indica si el servidor sirve HTTPS directamente con TLS_CERT y TLS_KEY
+func (c Config) TLSEnabled() bool {
+	return c.TLSCert != "" && c.TLSKey != ""
+}
+
+// Validate comprueba que los valores tengan sentido antes de arrancar
+func (c Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(c.ListenAddr != "", "LISTEN_ADDR no puede estar vacío")
+	check(c.StorageRoot != "", "STORAGE_ROOT no puede estar vacío")
+	check(c.TempDir != "", "TEMP_DIR no puede estar vacío")
+	check(c.OutputDir == "" || filepath.IsLocal(c.OutputDir), "OUTPUT_DIR debe ser una ruta relativa dentro del almacenamiento del usuario: %q", c.OutputDir)
+	check(!c.Production || c.AuthSecret != "", "AUTH_SECRET es obligatorio con APP_ENV=production")
+	check((c.TLSCert == "") == (c.TLSKey == ""), "TLS_CERT y TLS_KEY deben definirse juntos")
+	if c.TLSEnabled() {
+		_, err := tls.LoadX509KeyPair(c.TLSCert, c.TLSKey)
+		check(err == nil, "TLS_CERT y TLS_KEY no forman un certificado válido: %v", err)
+	}
+	check(c.UploadFieldName != "", "UPLOAD_FIELD_NAME no puede estar vacío")
+	for _, ext := range c.AllowedExtensions {
+		check(validExtension(ext), "ALLOWED_EXTENSIONS contiene una extensión inválida: %q", ext)
+	}
+	cookie := http.Cookie{Name: c.CookieName, Value: "x", Path: c.CookiePath}
+	check(cookie.Valid() == nil && strings.HasPrefix(c.CookiePath, "/"),
+		"COOKIE_NAME y COOKIE_PATH deben formar una cookie válida (%q, %q)", c.CookieName, c.CookiePath)
+
+	check(c.MaxFilesPerFolder >= 0, "MAX_FILES_PER_FOLDER no puede ser negativo")
+	check(c.MaxTotalPages >= 0, "MAX_TOTAL_PAGES no puede ser negativo")
+	check(c.UserQuotaBytes >= 0, "USER_QUOTA_BYTES no puede ser negativo")
+	check(c.MultipartMaxMemory > 0, "MULTIPART_MAX_MEMORY debe ser positivo")
+	check(c.MaxChunkBytes > 0, "MAX_CHUNK_BYTES debe ser positivo")
+	check(c.MaxChunkUploadBytes > 0, "MAX_CHUNK_UPLOAD_BYTES debe ser positivo")
+	check(c.MaxZipExtractBytes > 0, "MAX_ZIP_EXTRACT_BYTES debe ser positivo")
+	check(c.MaxDecompressedBytes > 0, "MAX_DECOMPRESSED_BYTES debe ser positivo")
+	check(c.MaxBase64DownloadBytes > 0, "MAX_BASE64_DOWNLOAD_BYTES debe ser positivo")
+	check(c.MaxMergeURLBytes > 0, "MAX_MERGE_URL_BYTES debe ser positivo")
+	check(c.MaxMergeURLs > 0, "MAX_MERGE_URLS debe ser positivo")
+	check(c.MaxConcurrentMerges > 0, "MAX_CONCURRENT_MERGES debe ser positivo")
+	check(c.IdempotencyMaxKeys > 0, "IDEMPOTENCY_MAX_KEYS debe ser positivo")
+	check(c.MergeAttempts > 0, "MERGE_ATTEMPTS debe ser positivo")
+	check(c.CallbackAttempts > 0, "CALLBACK_ATTEMPTS debe ser positivo")
+	check(c.ThumbnailWidth >= minThumbnailWidth && c.ThumbnailWidth <= maxThumbnailWidth,
+		"THUMBNAIL_WIDTH debe estar entre %d y %d", minThumbnailWidth, maxThumbnailWidth)
+	_, ok := normalizePageSizes[strings.ToLower(c.NormalizePageSize)]
+	check(ok, "NORMALIZE_PAGE_SIZE no soportado: %q", c.NormalizePageSize)
+
+	check(c.ShutdownTimeout > 0, "SHUTDOWN_TIMEOUT debe ser positivo")
+	check(c.ReadHeaderTimeout > 0, "READ_HEADER_TIMEOUT debe ser positivo")
+	check(c.ReadTimeout >= c.ReadHeaderTimeout, "READ_TIMEOUT no puede ser menor que READ_HEADER_TIMEOUT")
+	check(c.WriteTimeout > 0, "WRITE_TIMEOUT debe ser positivo")
+	check(c.IdleTimeout > 0, "IDLE_TIMEOUT debe ser positivo")
+	check(c.MergeQueueTimeout > 0, "MERGE_QUEUE_TIMEOUT debe ser positivo")
+	check(c.MergeRetryBackoff >= 0, "MERGE_RETRY_BACKOFF no puede ser negativo")
+	check(c.MergeURLTimeout > 0, "MERGE_URL_TIMEOUT debe ser positivo")
+	check(c.CallbackTimeout > 0, "CALLBACK_TIMEOUT debe ser positivo")
+	check(c.IdempotencyTTL > 0, "IDEMPOTENCY_TTL debe ser positivo")
+	check(c.JobTTL > 0, "JOB_TTL debe ser positivo")
+	check(c.TrashMaxAge >= 0, "TRASH_MAX_AGE no puede ser negativo")
+	check(c.ChunkUploadMaxAge >= 0, "CHUNK_UPLOAD_MAX_AGE no puede ser negativo")
+
+	return errors.Join(errs...)
+}
+
+// Configure aplica a las variables del paquete los límites de solo lectura que comparte
+// todo el proceso (carpeta temporal, tamaños y reintentos máximos...). Un proceso tiene
+// una sola configuración de límites: si hay varios Server, todos usan la de la última
+// llamada. Lo que guarda estado (el pool de uniones, las claves de idempotencia y los
+// trabajos asíncronos), el almacenamiento, los códigos y el control de acceso son de cada
+// Server (ver NewServer). Debe llamarse al arrancar, antes de atender peticiones.
+func Configure(cfg Config) {
+	tempRoot = cfg.TempDir
+	outputDir = cfg.OutputDir
+	multipartTempDir = cfg.MultipartTempDir
+	mergeURLAllowedHosts = newCodeSet(cfg.MergeURLAllowedHosts)
+	callbackAllowedHosts = newCodeSet(cfg.CallbackAllowedHosts)
+
+	uploadFieldName = cfg.UploadFieldName
+	allowedExtensions = cfg.AllowedExtensions
+	maxFilesPerFolder = cfg.MaxFilesPerFolder
+	maxTotalPages = cfg.MaxTotalPages
+	maxChunkBytes = cfg.MaxChunkBytes
+	maxChunkUploadBytes = cfg.MaxChunkUploadBytes
+	multipartMaxMemory = cfg.MultipartMaxMemory
+	maxZipExtractBytes = cfg.MaxZipExtractBytes
+	maxDecompressedBytes = cfg.MaxDecompressedBytes
+	maxBase64DownloadBytes = cfg.MaxBase64DownloadBytes
+	maxMergeURLBytes = cfg.MaxMergeURLBytes
+	maxMergeURLs = cfg.MaxMergeURLs
+	mergeAttempts = cfg.MergeAttempts
+	callbackAttempts = cfg.CallbackAttempts
+	thumbnailWidth = cfg.ThumbnailWidth
+	defaultNormalizePageSize = cfg.NormalizePageSize
+
+	mergeQueueTimeout = cfg.MergeQueueTimeout
+	mergeRetryBackoff = cfg.MergeRetryBackoff
+	mergeURLTimeout = cfg.MergeURLTimeout
+	callbackTimeout = cfg.CallbackTimeout
+}
+
+// envLoader lee valores numéricos del entorno y acumula los que no se pueden interpretar
+type envLoader struct {
+	errs []error
+}
+
+func (l *envLoader) int(key string, def int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return def
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		l.errs = append(l.errs, fmt.Errorf("%s: se esperaba un entero, se recibió %q", key, value))
+		return def
+	}
+	return n
+}
+
+func (l *envLoader) int64(key string, def int64) int64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return def
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		l.errs = append(l.errs, fmt.Errorf("%s: se esperaba un entero, se recibió %q", key, value))
+		return def
+	}
+	return n
+}
+
+func (l *envLoader) duration(key string, def time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return def
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		l.errs = append(l.errs, fmt.Errorf("%s: se esperaba una duración (ej: 30s), se recibió %q", key, value))
+		return def
+	}
+	return d
+}
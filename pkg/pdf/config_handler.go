@@ -0,0 +1,109 @@
+package pdf
+
+import (
+	"net/http"
+	"time"
+)
+
+// --- Configuración efectiva ---
+// /admin/config muestra los valores con los que arrancó el servidor, con el nombre de su
+// variable de entorno, para diagnosticar por qué se rechaza una subida o una unión sin
+// acceso a la máquina. Los secretos no se muestran: solo si están definidos.
+
+// Valor que reemplaza a un secreto definido
+const redactedValue = "[oculto]"
+
+// AdminConfigHandler: Devuelve la configuración efectiva del servidor sin secretos
+func (s *Server) AdminConfigHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, http.MethodGet)
+		return
+	}
+	writeJSON(w, http.StatusOK, effectiveConfig(s.cfg))
+}
+
+// effectiveConfig devuelve cada valor de c con su variable de entorno como clave. Un
+// campo nuevo de Config debe agregarse aquí (TestEffectiveConfigCoversAllFields lo exige),
+// y si es un secreto, pasar por redactSecret.
+func effectiveConfig(c Config) map[string]any {
+	appEnv := ""
+	if c.Production {
+		appEnv = "production"
+	}
+	return map[string]any{
+		"LISTEN_ADDR":      c.ListenAddr,
+		"SHUTDOWN_TIMEOUT": configDuration(c.ShutdownTimeout),
+		"STORAGE_ROOT":     c.StorageRoot,
+		"TEMP_DIR":         c.TempDir,
+		"APP_ENV":          appEnv,
+		"AUTH_SECRET":      redactSecret(c.AuthSecret),
+		"CODES_FILE":       c.CodesFile,
+		"COOKIE_NAME":      c.CookieName,
+		"COOKIE_PATH":      c.CookiePath,
+		"OUTPUT_DIR":       c.OutputDir,
+		"TLS_CERT":         c.TLSCert,
+		"TLS_KEY":          c.TLSKey, // Es la ruta del archivo, no la clave
+
+		// Los códigos de administrador son credenciales: solo se informa cuántos hay
+		"ADMIN_CODES":             len(c.AdminCodes),
+		"CORS_ALLOWED_ORIGINS":    configList(c.CORSAllowedOrigins),
+		"MERGE_URL_ALLOWED_HOSTS": configList(c.MergeURLAllowedHosts),
+		"CALLBACK_ALLOWED_HOSTS":  configList(c.CallbackAllowedHosts),
+		"ALLOWED_EXTENSIONS":      configList(c.AllowedExtensions),
+
+		"UPLOAD_FIELD_NAME":         c.UploadFieldName,
+		"MAX_FILES_PER_FOLDER":      c.MaxFilesPerFolder,
+		"MAX_TOTAL_PAGES":           c.MaxTotalPages,
+		"USER_QUOTA_BYTES":          c.UserQuotaBytes,
+		"MULTIPART_MAX_MEMORY":      c.MultipartMaxMemory,
+		"MULTIPART_TEMP_DIR":        c.MultipartTempDir,
+		"MAX_CHUNK_BYTES":           c.MaxChunkBytes,
+		"MAX_CHUNK_UPLOAD_BYTES":    c.MaxChunkUploadBytes,
+		"MAX_ZIP_EXTRACT_BYTES":     c.MaxZipExtractBytes,
+		"MAX_DECOMPRESSED_BYTES":    c.MaxDecompressedBytes,
+		"MAX_BASE64_DOWNLOAD_BYTES": c.MaxBase64DownloadBytes,
+		"MAX_MERGE_URL_BYTES":       c.MaxMergeURLBytes,
+		"MAX_MERGE_URLS":            c.MaxMergeURLs,
+		"MAX_CONCURRENT_MERGES":     c.MaxConcurrentMerges,
+		"IDEMPOTENCY_MAX_KEYS":      c.IdempotencyMaxKeys,
+		"MERGE_ATTEMPTS":            c.MergeAttempts,
+		"CALLBACK_ATTEMPTS":         c.CallbackAttempts,
+		"THUMBNAIL_WIDTH":           c.ThumbnailWidth,
+		"NORMALIZE_PAGE_SIZE":       c.NormalizePageSize,
+
+		"MERGE_QUEUE_TIMEOUT":  configDuration(c.MergeQueueTimeout),
+		"MERGE_RETRY_BACKOFF":  configDuration(c.MergeRetryBackoff),
+		"MERGE_URL_TIMEOUT":    configDuration(c.MergeURLTimeout),
+		"CALLBACK_TIMEOUT":     configDuration(c.CallbackTimeout),
+		"IDEMPOTENCY_TTL":      configDuration(c.IdempotencyTTL),
+		"JOB_TTL":              configDuration(c.JobTTL),
+		"TRASH_MAX_AGE":        configDuration(c.TrashMaxAge),
+		"CHUNK_UPLOAD_MAX_AGE": configDuration(c.ChunkUploadMaxAge),
+
+		"READ_HEADER_TIMEOUT": configDuration(c.ReadHeaderTimeout),
+		"READ_TIMEOUT":        configDuration(c.ReadTimeout),
+		"WRITE_TIMEOUT":       configDuration(c.WriteTimeout),
+		"IDLE_TIMEOUT":        configDuration(c.IdleTimeout),
+	}
+}
+
+// redactSecret oculta un secreto definido; vacío indica que no se configuró
+func redactSecret(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	return redactedValue
+}
+
+// configDuration en el mismo formato que aceptan las variables (ej: "1m30s")
+func configDuration(d time.Duration) string {
+	return d.String()
+}
+
+// configList evita que una lista vacía se muestre como null
+func configList(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
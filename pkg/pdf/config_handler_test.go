@@ -0,0 +1,73 @@
+package pdf
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAdminConfigHandler(t *testing.T) {
+	// Arrange
+	cfg := DefaultConfig()
+	cfg.AuthSecret = "s3cr3t-del-servidor"
+	cfg.AdminCodes = []string{"codigo-admin"}
+	cfg.MaxFilesPerFolder = 50
+	cfg.AllowedExtensions = []string{".pdf", ".png"}
+	cfg.WriteTimeout = 90 * time.Second
+	srv := NewServer(cfg, newDefaultCodeStore())
+	req := httptest.NewRequest(http.MethodGet, "/admin/config", nil)
+	rr := httptest.NewRecorder()
+
+	// Act
+	srv.AdminConfigHandler(rr, req)
+
+	// Assert
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	body := rr.Body.String()
+	for _, secret := range []string{"s3cr3t-del-servidor", "codigo-admin"} {
+		if strings.Contains(body, secret) {
+			t.Errorf("expected %q to be redacted, got %s", secret, body)
+		}
+	}
+	var effective map[string]any
+	if err := json.Unmarshal([]byte(body), &effective); err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]any{
+		"AUTH_SECRET":          redactedValue,
+		"ADMIN_CODES":          float64(1),
+		"MAX_FILES_PER_FOLDER": float64(50),
+		"ALLOWED_EXTENSIONS":   []any{".pdf", ".png"},
+		"WRITE_TIMEOUT":        "1m30s",
+		"CORS_ALLOWED_ORIGINS": []any{},
+		"STORAGE_ROOT":         cfg.StorageRoot,
+	}
+	for key, value := range expected {
+		if !reflect.DeepEqual(effective[key], value) {
+			t.Errorf("%s: expected %v, got %v", key, value, effective[key])
+		}
+	}
+}
+
+func TestEffectiveConfigCoversAllFields(t *testing.T) {
+	// Un campo nuevo de Config que no se agrega a effectiveConfig no aparecería en /admin/config
+	fields := reflect.TypeOf(Config{}).NumField()
+	if got := len(effectiveConfig(DefaultConfig())); got != fields {
+		t.Errorf("effectiveConfig has %d values but Config has %d fields", got, fields)
+	}
+}
+
+func TestRedactSecret(t *testing.T) {
+	if got := redactSecret(""); got != "" {
+		t.Errorf("expected an unset secret to stay empty, got %q", got)
+	}
+	if got := redactSecret("x"); got != redactedValue {
+		t.Errorf("expected a set secret to be redacted, got %q", got)
+	}
+}
@@ -0,0 +1,164 @@
+package pdf
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadConfigDefaults(t *testing.T) {
+	// Act
+	cfg, err := LoadConfig()
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.ListenAddr != ":8080" || cfg.MaxConcurrentMerges != 4 || cfg.IdempotencyTTL != 10*time.Minute {
+		t.Errorf("unexpected defaults: %+v", cfg)
+	}
+}
+
+func TestLoadConfigFromEnv(t *testing.T) {
+	// Arrange
+	t.Setenv("LISTEN_ADDR", "127.0.0.1:9000")
+	t.Setenv("STORAGE_ROOT", "/srv/archivos")
+	t.Setenv("MAX_CONCURRENT_MERGES", "8")
+	t.Setenv("MERGE_QUEUE_TIMEOUT", "2s")
+	t.Setenv("ADMIN_CODES", "root, ops")
+
+	// Act
+	cfg, err := LoadConfig()
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.ListenAddr != "127.0.0.1:9000" || cfg.StorageRoot != "/srv/archivos" {
+		t.Errorf("unexpected addresses: %+v", cfg)
+	}
+	if cfg.MaxConcurrentMerges != 8 || cfg.MergeQueueTimeout != 2*time.Second {
+		t.Errorf("unexpected limits: %+v", cfg)
+	}
+	if len(cfg.AdminCodes) != 2 || cfg.AdminCodes[1] != "ops" {
+		t.Errorf("unexpected admin codes: %v", cfg.AdminCodes)
+	}
+}
+
+func TestLoadConfigInvalid(t *testing.T) {
+	tests := []struct {
+		name        string
+		env         map[string]string
+		expectedErr []string
+	}{
+		{
+			name:        "Entero inválido",
+			env:         map[string]string{"MAX_MERGE_URLS": "veinte"},
+			expectedErr: []string{"MAX_MERGE_URLS"},
+		},
+		{
+			name:        "Duración inválida y límite negativo juntos",
+			env:         map[string]string{"MERGE_URL_TIMEOUT": "15", "MAX_FILES_PER_FOLDER": "-1"},
+			expectedErr: []string{"MERGE_URL_TIMEOUT", "MAX_FILES_PER_FOLDER"},
+		},
+		{
+			name:        "Producción sin secreto",
+			env:         map[string]string{"APP_ENV": "production"},
+			expectedErr: []string{"AUTH_SECRET"},
+		},
+		{
+			name:        "Tamaño de página no soportado",
+			env:         map[string]string{"NORMALIZE_PAGE_SIZE": "A3"},
+			expectedErr: []string{"NORMALIZE_PAGE_SIZE"},
+		},
+		{
+			name:        "Extensión permitida inválida",
+			env:         map[string]string{"ALLOWED_EXTENSIONS": "pdf,.tar.gz"},
+			expectedErr: []string{"ALLOWED_EXTENSIONS"},
+		},
+		{
+			name:        "Cookie de sesión inválida",
+			env:         map[string]string{"COOKIE_NAME": "mi cookie", "COOKIE_PATH": "pdf"},
+			expectedErr: []string{"COOKIE_NAME"},
+		},
+		{
+			name:        "Máximo de páginas negativo",
+			env:         map[string]string{"MAX_TOTAL_PAGES": "-5"},
+			expectedErr: []string{"MAX_TOTAL_PAGES"},
+		},
+		{
+			name:        "Timeouts del servidor inválidos",
+			env:         map[string]string{"READ_HEADER_TIMEOUT": "30s", "READ_TIMEOUT": "10s", "WRITE_TIMEOUT": "0s"},
+			expectedErr: []string{"READ_TIMEOUT", "WRITE_TIMEOUT"},
+		},
+		{
+			name:        "Memoria multipart no positiva",
+			env:         map[string]string{"MULTIPART_MAX_MEMORY": "0"},
+			expectedErr: []string{"MULTIPART_MAX_MEMORY"},
+		},
+		{
+			name:        "Antigüedad de la papelera negativa",
+			env:         map[string]string{"TRASH_MAX_AGE": "-1h"},
+			expectedErr: []string{"TRASH_MAX_AGE"},
+		},
+		{
+			name:        "Límite de descompresión no positivo",
+			env:         map[string]string{"MAX_DECOMPRESSED_BYTES": "0"},
+			expectedErr: []string{"MAX_DECOMPRESSED_BYTES"},
+		},
+		{
+			name:        "Certificado TLS sin clave",
+			env:         map[string]string{"TLS_CERT": "cert.pem"},
+			expectedErr: []string{"TLS_CERT y TLS_KEY deben definirse juntos"},
+		},
+		{
+			name:        "Certificado TLS que no existe",
+			env:         map[string]string{"TLS_CERT": "no-existe.pem", "TLS_KEY": "no-existe.key"},
+			expectedErr: []string{"no forman un certificado válido"},
+		},
+		{
+			name:        "Carpeta de salida fuera del usuario",
+			env:         map[string]string{"OUTPUT_DIR": "../compartida"},
+			expectedErr: []string{"OUTPUT_DIR"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			// Act
+			_, err := LoadConfig()
+
+			// Assert
+			if err == nil {
+				t.Fatal("expected a configuration error")
+			}
+			for _, expected := range tt.expectedErr {
+				if !strings.Contains(err.Error(), expected) {
+					t.Errorf("expected error to mention %s, got: %v", expected, err)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadConfigProductionWithSecret(t *testing.T) {
+	// Arrange
+	t.Setenv("APP_ENV", "production")
+	t.Setenv("AUTH_SECRET", "s3cr3t")
+
+	// Act
+	cfg, err := LoadConfig()
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cfg.Production {
+		t.Errorf("expected production mode")
+	}
+}
@@ -0,0 +1,137 @@
+package pdf
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Tamaño máximo del cuerpo JSON de CopyFolderHandler
+const maxCopyBodyBytes = 4 << 10
+
+// CopyFolderHandler: Duplica una carpeta del usuario con {"from","to"} para probar otro
+// orden de unión sin tocar la original. Copia los PDFs fuente, también los de las
+// subcarpetas, pero no el from.pdf ya generado ni los archivos ocultos. Si la carpeta
+// destino ya existe responde 409.
+func (s *Server) CopyFolderHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w, http.MethodPost)
+		return
+	}
+
+	// Obtener la ruta base de almacenamiento del usuario
+	userStoragePath, err := s.storagePath(r)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Error interno de autenticación")
+		return
+	}
+	store, err := s.storageFor(r)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Error interno de autenticación")
+		return
+	}
+
+	if !isJSONRequest(r) {
+		writeJSONError(w, http.StatusBadRequest, "Content-Type debe ser application/json")
+		return
+	}
+	var req CopyFolderRequest
+	r.Body = http.MaxBytesReader(w, r.Body, maxCopyBodyBytes)
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Formato JSON inválido")
+		return
+	}
+	if !validFolderName(req.From) || !validFolderName(req.To) {
+		writeJSONError(w, http.StatusBadRequest, "Nombre de carpeta no válido")
+		return
+	}
+	if req.From == req.To {
+		writeJSONError(w, http.StatusBadRequest, "La carpeta destino es la misma que la de origen")
+		return
+	}
+
+	// Nadie puede cambiar el origen ni crear el destino mientras se copia
+	unlock := lockFolders(userStoragePath, req.From, req.To)
+	defer unlock()
+
+	info, err := os.Stat(filepath.Join(userStoragePath, req.From))
+	if os.IsNotExist(err) || (err == nil && !info.IsDir()) {
+		writeJSONError(w, http.StatusNotFound, "Carpeta no encontrada")
+		return
+	}
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Error al leer la carpeta")
+		return
+	}
+	toPath := filepath.Join(userStoragePath, req.To)
+	if _, err := os.Lstat(toPath); err == nil {
+		writeJSONError(w, http.StatusConflict, "Ya existe una carpeta con ese nombre: "+req.To)
+		return
+	}
+
+	files, err := listStorageFiles(store, req.From, ".pdf", "", true)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Error al listar archivos")
+		return
+	}
+	// La carpeta se crea aunque el origen no tenga PDFs, como una copia vacía
+	if err := os.MkdirAll(toPath, os.ModePerm); err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Error al crear la carpeta")
+		return
+	}
+	for _, name := range files {
+		if err := copyStorageFile(store, req.From, req.To, name); err != nil {
+			// Sin copias a medias: o la carpeta queda completa o no queda
+			os.RemoveAll(toPath)
+			logf(r.Context(), "Error copiando %s/%s: %v", req.From, name, err)
+			writeJSONError(w, http.StatusInternalServerError, "Error al copiar "+name)
+			return
+		}
+	}
+
+	// La copia conserva las fechas de alta del origen para que sort=added la ordene igual
+	if err := recordAddedTimes(store, req.To, copiedAddedTimes(store, req.From, files)); err != nil {
+		logf(r.Context(), "Error registrando la fecha de alta en %s: %v", req.To, err)
+	}
+
+	copied, err := listStorageFiles(store, req.To, ".pdf", "", true)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Error al listar archivos")
+		return
+	}
+	if copied == nil {
+		copied = []string{}
+	}
+	writeJSON(w, http.StatusOK, CopyFolderResponse{Folder: req.To, Files: copied})
+}
+
+// copiedAddedTimes devuelve la fecha por la que sort=added ordena cada archivo de from:
+// la registrada al subirlo o, si no la hay, su fecha de modificación (ver sortFilesByAdded)
+func copiedAddedTimes(store Storage, from string, files []string) map[string]time.Time {
+	added, err := readAddedTimes(store, from)
+	if err != nil {
+		added = map[string]time.Time{}
+	}
+	times := make(map[string]time.Time, len(files))
+	for _, name := range files {
+		if at, ok := added[name]; ok {
+			times[name] = at
+		} else if info, err := store.Stat(from, name); err == nil {
+			times[name] = info.ModTime()
+		}
+	}
+	return times
+}
+
+// copyStorageFile copia from/name a to/name sin cargar el archivo en memoria
+func copyStorageFile(store Storage, from, to, name string) error {
+	src, err := store.Open(from, name)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	_, err = store.Save(to, name, src)
+	return err
+}
@@ -0,0 +1,101 @@
+package pdf
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// newCopyFolderRequest crea una petición JSON para /copy-folder
+func newCopyFolderRequest(body string) (*http.Request, *httptest.ResponseRecorder) {
+	req := httptest.NewRequest(http.MethodPost, "/copy-folder", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req = req.WithContext(context.WithValue(req.Context(), userCodeKey, "testUser"))
+	return req, httptest.NewRecorder()
+}
+
+func TestCopyFolderHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		setup          func(t *testing.T, userPath string)
+		expectedStatus int
+		expectedFiles  []string
+	}{
+		{
+			name:           "Copia los PDFs fuente y sus subcarpetas",
+			body:           `{"from":"facturas","to":"facturas-prueba"}`,
+			expectedStatus: http.StatusOK,
+			expectedFiles:  []string{"1-enero.pdf", "2-febrero.pdf", "anexos/1-anexo.pdf"},
+		},
+		{
+			name: "La carpeta destino ya existe",
+			body: `{"from":"facturas","to":"facturas-prueba"}`,
+			setup: func(t *testing.T, userPath string) {
+				os.MkdirAll(filepath.Join(userPath, "facturas-prueba"), os.ModePerm)
+			},
+			expectedStatus: http.StatusConflict,
+		},
+		{name: "Carpeta inexistente", body: `{"from":"nada","to":"facturas-prueba"}`, expectedStatus: http.StatusNotFound},
+		{name: "Nombre con ruta", body: `{"from":"facturas","to":"../fuera"}`, expectedStatus: http.StatusBadRequest},
+		{name: "Falta el destino", body: `{"from":"facturas"}`, expectedStatus: http.StatusBadRequest},
+		{name: "Mismo nombre", body: `{"from":"facturas","to":"facturas"}`, expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			userPath := t.TempDir()
+			folderPath := filepath.Join(userPath, "facturas")
+			os.MkdirAll(filepath.Join(folderPath, "anexos"), os.ModePerm)
+			os.WriteFile(filepath.Join(folderPath, "1-enero.pdf"), []byte("%PDF-enero"), 0o644)
+			os.WriteFile(filepath.Join(folderPath, "2-febrero.pdf"), []byte("%PDF-febrero"), 0o644)
+			os.WriteFile(filepath.Join(folderPath, "anexos", "1-anexo.pdf"), []byte("%PDF-anexo"), 0o644)
+			os.WriteFile(filepath.Join(folderPath, ".1-enero.pdf.sha256"), []byte("oculto"), 0o644)
+			setupMergedFile(t, userPath, "facturas", "%PDF-unido")
+			if tt.setup != nil {
+				tt.setup(t, userPath)
+			}
+			srv := newTestServer(userPath)
+			req, rr := newCopyFolderRequest(tt.body)
+
+			// Act
+			srv.CopyFolderHandler(rr, req)
+
+			// Assert
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+			var resp CopyFolderResponse
+			if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+				t.Fatal(err)
+			}
+			if resp.Folder != "facturas-prueba" || !reflect.DeepEqual(resp.Files, tt.expectedFiles) {
+				t.Errorf("unexpected response: %+v", resp)
+			}
+			copied, err := os.ReadFile(filepath.Join(userPath, "facturas-prueba", "2-febrero.pdf"))
+			if err != nil || string(copied) != "%PDF-febrero" {
+				t.Errorf("expected copied content, got %q (%v)", copied, err)
+			}
+			// Ni el PDF unido ni los archivos ocultos se copian; el origen queda intacto
+			if _, err := os.Stat(filepath.Join(userPath, "facturas-prueba.pdf")); !os.IsNotExist(err) {
+				t.Errorf("the merged output must not be copied")
+			}
+			if _, err := os.Stat(filepath.Join(userPath, "facturas-prueba", ".1-enero.pdf.sha256")); !os.IsNotExist(err) {
+				t.Errorf("hidden files must not be copied")
+			}
+			if _, err := os.Stat(filepath.Join(folderPath, "1-enero.pdf")); err != nil {
+				t.Errorf("the source folder must be left untouched: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package pdf
+
+import (
+	"net/http"
+	"slices"
+)
+
+// --- CORS ---
+// Orígenes permitidos, separados por comas en CORS_ALLOWED_ORIGINS
+// (ej: "https://app.ejemplo.com,http://localhost:3000"). Sin la variable no se permite ninguno.
+
+// CORSMiddleware agrega las cabeceras CORS para los orígenes permitidos y responde
+// las peticiones preflight OPTIONS con 204. Debe ir por fuera de AuthMiddleware,
+// porque el navegador no envía la cookie en el preflight.
+func (s *Server) CORSMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		w.Header().Add("Vary", "Origin")
+
+		if origin != "" && slices.Contains(s.cfg.CORSAllowedOrigins, origin) {
+			// Con credenciales no se puede usar "*", así que se devuelve el origen exacto
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Set("Access-Control-Allow-Credentials", "true")
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Idempotency-Key, If-Unmodified-Since, X-Request-ID")
+			w.Header().Set("Access-Control-Expose-Headers", "X-Request-ID, X-Regenerated")
+		}
+
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	}
+}
@@ -0,0 +1,75 @@
+package pdf
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCORSMiddleware(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		origin         string
+		expectedStatus int
+		expectedOrigin string
+		expectNext     bool
+	}{
+		{
+			name:           "Preflight de un origen permitido",
+			method:         http.MethodOptions,
+			origin:         "https://app.example.com",
+			expectedStatus: http.StatusNoContent,
+			expectedOrigin: "https://app.example.com",
+		},
+		{
+			name:           "Petición de un origen permitido llega al handler",
+			method:         http.MethodGet,
+			origin:         "https://app.example.com",
+			expectedStatus: http.StatusOK,
+			expectedOrigin: "https://app.example.com",
+			expectNext:     true,
+		},
+		{
+			name:           "Origen no permitido no recibe cabeceras CORS",
+			method:         http.MethodGet,
+			origin:         "https://evil.example.com",
+			expectedStatus: http.StatusOK,
+			expectedOrigin: "",
+			expectNext:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			cfg := DefaultConfig()
+			cfg.CORSAllowedOrigins = []string{"https://app.example.com"}
+			srv := NewServer(cfg, newDefaultCodeStore())
+
+			called := false
+			handler := srv.CORSMiddleware(func(w http.ResponseWriter, r *http.Request) { called = true })
+
+			req := httptest.NewRequest(tt.method, "/list", nil)
+			req.Header.Set("Origin", tt.origin)
+			rr := httptest.NewRecorder()
+
+			// Act
+			handler(rr, req)
+
+			// Assert
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, tt.expectedStatus)
+			}
+			if got := rr.Header().Get("Access-Control-Allow-Origin"); got != tt.expectedOrigin {
+				t.Errorf("expected Access-Control-Allow-Origin %q, got %q", tt.expectedOrigin, got)
+			}
+			if tt.expectedOrigin != "" && rr.Header().Get("Access-Control-Allow-Credentials") != "true" {
+				t.Errorf("expected Access-Control-Allow-Credentials true")
+			}
+			if called != tt.expectNext {
+				t.Errorf("expected next handler called=%v, got %v", tt.expectNext, called)
+			}
+		})
+	}
+}
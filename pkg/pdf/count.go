@@ -0,0 +1,61 @@
+package pdf
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// CountHandler: Devuelve cuántos PDFs tiene una carpeta sin ordenar ni listar los nombres.
+// Una carpeta que todavía no existe cuenta como 0.
+func (s *Server) CountHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, http.MethodGet)
+		return
+	}
+
+	// Obtener la ruta base de almacenamiento del usuario
+	userStoragePath, err := s.storagePath(r)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Error interno de autenticación")
+		return
+	}
+
+	var problems validationErrors
+	folder := r.URL.Query().Get("folder")
+	problems.checkFolder(folder)
+	if problems.respond(w) {
+		return
+	}
+
+	unlock := rLockFolder(userStoragePath, folder)
+	defer unlock()
+
+	count, err := countFilesWithExtension(filepath.Join(userStoragePath, folder), ".pdf")
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Error al leer la carpeta")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, CountResponse{Folder: folder, Count: count})
+}
+
+// countFilesWithExtension cuenta los archivos con la extensión dada; 0 si el directorio no existe
+func countFilesWithExtension(dir, ext string) (int, error) {
+	entries, err := osReadDir(dir)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ext) {
+			count++
+		}
+	}
+	return count, nil
+}
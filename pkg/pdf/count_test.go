@@ -0,0 +1,53 @@
+package pdf
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCountHandler(t *testing.T) {
+	tests := []struct {
+		name          string
+		folder        string
+		expectedCount int
+	}{
+		{name: "Cuenta solo los PDFs", folder: "test-folder", expectedCount: 2},
+		{name: "Carpeta inexistente cuenta 0", folder: "missing", expectedCount: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			userPath := t.TempDir()
+			folderPath := filepath.Join(userPath, "test-folder")
+			os.MkdirAll(filepath.Join(folderPath, "sub.pdf"), os.ModePerm)
+			for _, f := range []string{"1-a.pdf", "2-b.pdf", "notes.txt"} {
+				os.Create(filepath.Join(folderPath, f))
+			}
+
+			srv := newTestServer(userPath)
+
+			req := httptest.NewRequest(http.MethodGet, "/count?folder="+tt.folder, nil)
+			req = req.WithContext(context.WithValue(req.Context(), userCodeKey, "testUser"))
+			rr := httptest.NewRecorder()
+
+			// Act
+			srv.CountHandler(rr, req)
+
+			// Assert
+			if rr.Code != http.StatusOK {
+				t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+			}
+			var resp CountResponse
+			json.NewDecoder(rr.Body).Decode(&resp)
+			if resp.Folder != tt.folder || resp.Count != tt.expectedCount {
+				t.Errorf("expected %s=%d, got %+v", tt.folder, tt.expectedCount, resp)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package pdf
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+// Estilo del texto de la portada y de los separadores
+const (
+	coverTextDesc     = "font:Helvetica, points:28, scale:1 abs, pos:c, rot:0, fillcolor:#000000"
+	separatorTextDesc = "font:Helvetica, points:18, scale:1 abs, pos:c, rot:0, fillcolor:#404040"
+)
+
+// labeledPagePDF crea en path un PDF A4 de una página en blanco con el texto centrado
+func labeledPagePDF(path, text, desc string) error {
+	dim := types.PaperSize["A4"]
+	ctx, err := pdfcpu.CreateContextWithXRefTable(model.NewDefaultConfiguration(), dim)
+	if err != nil {
+		return err
+	}
+
+	rootDict, err := ctx.Catalog()
+	if err != nil {
+		return err
+	}
+	pagesIndRef := rootDict.IndirectRefEntry("Pages")
+	pagesDict, err := ctx.DereferenceDict(*pagesIndRef)
+	if err != nil {
+		return err
+	}
+	pageIndRef, err := ctx.EmptyPage(pagesIndRef, types.RectForDim(dim.Width, dim.Height))
+	if err != nil {
+		return err
+	}
+	pagesDict.Update("Kids", types.Array{*pageIndRef})
+	pagesDict.Update("Count", types.Integer(1))
+	ctx.PageCount = 1
+
+	if err := api.WriteContextFile(ctx, path); err != nil {
+		return err
+	}
+	return api.AddTextWatermarksFile(path, "", nil, true, text, desc, nil)
+}
+
+// withCoverAndSeparators genera en tmpDir la portada y los separadores pedidos y devuelve
+// la lista completa de archivos a unir y las rutas de los generados, para que
+// buildBookmarks no les asigne marcador. Un archivo del usuario no se distingue por su
+// nombre: puede empezar por "." igual que los generados.
+func withCoverAndSeparators(tmpDir string, files []string, opts MergeOptions) ([]string, map[string]bool, error) {
+	var merged []string
+	generated := map[string]bool{}
+	if opts.Cover != "" {
+		coverPath := filepath.Join(tmpDir, ".cover.pdf")
+		if err := labeledPagePDF(coverPath, opts.Cover, coverTextDesc); err != nil {
+			return nil, nil, fmt.Errorf("error al generar la portada: %w", err)
+		}
+		merged = append(merged, coverPath)
+		generated[coverPath] = true
+	}
+
+	for i, file := range files {
+		if opts.Separators {
+			sepPath := filepath.Join(tmpDir, fmt.Sprintf(".separator-%03d.pdf", i+1))
+			if err := labeledPagePDF(sepPath, sourceLabel(opts.LabelTemplate, i+1, filepath.Base(file)), separatorTextDesc); err != nil {
+				return nil, nil, fmt.Errorf("error al generar el separador: %w", err)
+			}
+			merged = append(merged, sepPath)
+			generated[sepPath] = true
+		}
+		merged = append(merged, file)
+	}
+	return merged, generated, nil
+}
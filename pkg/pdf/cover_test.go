@@ -0,0 +1,110 @@
+package pdf
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+)
+
+func TestLabeledPagePDF(t *testing.T) {
+	// Arrange
+	path := filepath.Join(t.TempDir(), "cover.pdf")
+
+	// Act
+	err := labeledPagePDF(path, "Informe anual", coverTextDesc)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := api.ValidateFile(path, nil); err != nil {
+		t.Fatalf("expected a valid PDF: %v", err)
+	}
+	if pages, err := api.PageCountFile(path); err != nil || pages != 1 {
+		t.Errorf("expected a single page, got %d (err: %v)", pages, err)
+	}
+}
+
+func TestJoinPDFsWithCoverAndSeparators(t *testing.T) {
+	// Arrange
+	userPath := t.TempDir()
+	folderPath := filepath.Join(userPath, "test-folder")
+	os.MkdirAll(folderPath, os.ModePerm)
+	writeTestPDF(t, filepath.Join(folderPath, "1-intro.pdf"), 1)
+	writeTestPDF(t, filepath.Join(folderPath, "2-body.pdf"), 2)
+
+	// Act
+	_, err := joinPDFs(context.Background(), userPath, "test-folder", MergeOptions{Cover: "Informe", Separators: true, Bookmarks: true})
+
+	// Assert: portada + (separador + 1) + (separador + 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	outputPath := filepath.Join(userPath, "test-folder.pdf")
+	if pages, err := api.PageCountFile(outputPath); err != nil || pages != 6 {
+		t.Fatalf("expected 6 pages, got %d (err: %v)", pages, err)
+	}
+
+	// Los marcadores apuntan solo a los archivos fuente
+	f, err := os.Open(outputPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	bookmarks, err := api.Bookmarks(f, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bookmarks) != 2 || bookmarks[0].PageFrom != 3 || bookmarks[1].PageFrom != 5 {
+		t.Errorf("unexpected bookmarks %+v", bookmarks)
+	}
+}
+
+func TestGenerateHandlerCoverOptions(t *testing.T) {
+	tests := []struct {
+		name           string
+		form           url.Values
+		expectedStatus int
+		expectedPages  int
+	}{
+		{name: "Sin opciones la salida no cambia", form: url.Values{}, expectedStatus: http.StatusOK, expectedPages: 2},
+		{name: "Solo portada", form: url.Values{"cover": {"Informe"}}, expectedStatus: http.StatusOK, expectedPages: 3},
+		{name: "Solo separadores", form: url.Values{"separators": {"true"}}, expectedStatus: http.StatusOK, expectedPages: 4},
+		{name: "No disponible en modo intercalado", form: url.Values{"mode": {MergeModeInterleave}, "cover": {"Informe"}}, expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			userPath := t.TempDir()
+			folderPath := filepath.Join(userPath, "test-folder")
+			os.MkdirAll(folderPath, os.ModePerm)
+			writeTestPDF(t, filepath.Join(folderPath, "1-a.pdf"), 1)
+			writeTestPDF(t, filepath.Join(folderPath, "2-b.pdf"), 1)
+
+			srv := newTestServer(userPath)
+
+			tt.form.Set("folder", "test-folder")
+			req, rr := newGenerateRequest(tt.form)
+
+			// Act
+			srv.GenerateHandler(rr, req)
+
+			// Assert
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("handler returned wrong status code: got %v want %v (%s)", rr.Code, tt.expectedStatus, rr.Body.String())
+			}
+			if tt.expectedPages == 0 {
+				return
+			}
+			if pages, err := api.PageCountFile(filepath.Join(userPath, "test-folder.pdf")); err != nil || pages != tt.expectedPages {
+				t.Errorf("expected %d pages, got %d (err: %v)", tt.expectedPages, pages, err)
+			}
+		})
+	}
+}
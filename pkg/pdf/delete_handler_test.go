@@ -7,18 +7,24 @@ import (
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"reflect"
+	"sort"
 	"strings"
 	"testing"
+	"time"
 )
 
 // Object Mother para crear objetos de prueba comunes
 type DeleteTestMother struct{}
 
 func (m *DeleteTestMother) CreateValidRequest(method, folder string, files []string) *http.Request {
-	body := DeleteFilesRequest{
+	return m.CreateRequest(method, DeleteFilesRequest{
 		Folder: folder,
 		Files:  files,
-	}
+	})
+}
+
+func (m *DeleteTestMother) CreateRequest(method string, body DeleteFilesRequest) *http.Request {
 	jsonBody, _ := json.Marshal(body)
 	req := httptest.NewRequest(method, "/delete", strings.NewReader(string(jsonBody)))
 	req.Header.Set("Content-Type", "application/json")
@@ -31,9 +37,11 @@ func (m *DeleteTestMother) CreateValidResponse() *httptest.ResponseRecorder {
 
 // Test Data Builder para las solicitudes de eliminación
 type DeleteRequestBuilder struct {
-	folder string
-	files  []string
-	method string
+	folder  string
+	files   []string
+	pattern string
+	purge   bool
+	method  string
 }
 
 func NewDeleteRequestBuilder() *DeleteRequestBuilder {
@@ -54,6 +62,16 @@ func (b *DeleteRequestBuilder) WithFiles(files []string) *DeleteRequestBuilder {
 	return b
 }
 
+func (b *DeleteRequestBuilder) WithPattern(pattern string) *DeleteRequestBuilder {
+	b.pattern = pattern
+	return b
+}
+
+func (b *DeleteRequestBuilder) WithPurge() *DeleteRequestBuilder {
+	b.purge = true
+	return b
+}
+
 func (b *DeleteRequestBuilder) WithMethod(method string) *DeleteRequestBuilder {
 	b.method = method
 	return b
@@ -61,16 +79,18 @@ func (b *DeleteRequestBuilder) WithMethod(method string) *DeleteRequestBuilder {
 
 func (b *DeleteRequestBuilder) Build(t *testing.T) (*http.Request, *httptest.ResponseRecorder) {
 	mother := &DeleteTestMother{}
-	return mother.CreateValidRequest(b.method, b.folder, b.files), mother.CreateValidResponse()
+	body := DeleteFilesRequest{Folder: b.folder, Files: b.files, Pattern: b.pattern, Purge: b.purge}
+	return mother.CreateRequest(b.method, body), mother.CreateValidResponse()
 }
 
 func TestDeleteFilesHandler(t *testing.T) {
 	tests := []struct {
-		name           string
-		setupRequest   func(t *testing.T) (*http.Request, *httptest.ResponseRecorder)
-		setupFiles     func(t *testing.T, userPath string) []string
-		expectedStatus int
-		expectedFiles  []string
+		name            string
+		setupRequest    func(t *testing.T) (*http.Request, *httptest.ResponseRecorder)
+		setupFiles      func(t *testing.T, userPath string) []string
+		expectedStatus  int
+		expectedFiles   []string
+		expectedTrashed []string
 	}{
 		{
 			name: "Eliminar archivos específicos exitosamente",
@@ -89,8 +109,31 @@ func TestDeleteFilesHandler(t *testing.T) {
 				}
 				return files
 			},
-			expectedStatus: http.StatusOK,
-			expectedFiles:  []string{"3-document.pdf"},
+			expectedStatus:  http.StatusOK,
+			expectedFiles:   []string{"3-document.pdf"},
+			expectedTrashed: []string{"1-document.pdf", "2-document.pdf"},
+		},
+		{
+			name: "Con purge se eliminan sin pasar por la papelera",
+			setupRequest: func(t *testing.T) (*http.Request, *httptest.ResponseRecorder) {
+				return NewDeleteRequestBuilder().
+					WithFolder("test-folder").
+					WithFiles([]string{"1-document.pdf"}).
+					WithPurge().
+					Build(t)
+			},
+			setupFiles: func(t *testing.T, userPath string) []string {
+				files := []string{"1-document.pdf", "2-document.pdf"}
+				folderPath := filepath.Join(userPath, "test-folder")
+				os.MkdirAll(folderPath, os.ModePerm)
+				for _, f := range files {
+					os.Create(filepath.Join(folderPath, f))
+				}
+				return files
+			},
+			expectedStatus:  http.StatusOK,
+			expectedFiles:   []string{"2-document.pdf"},
+			expectedTrashed: []string{},
 		},
 		{
 			name: "Eliminar todos los archivos cuando no se especifica lista",
@@ -109,8 +152,9 @@ func TestDeleteFilesHandler(t *testing.T) {
 				}
 				return files
 			},
-			expectedStatus: http.StatusOK,
-			expectedFiles:  []string{},
+			expectedStatus:  http.StatusOK,
+			expectedFiles:   []string{},
+			expectedTrashed: []string{"1-document.pdf", "2-document.pdf"},
 		},
 		{
 			name: "Error al intentar eliminar archivo que no existe",
@@ -154,18 +198,13 @@ func TestDeleteFilesHandler(t *testing.T) {
 			userPath := filepath.Join(tempDir, "testUser")
 			os.MkdirAll(userPath, os.ModePerm)
 
-			originalGetUserStoragePath := getUserStoragePathFn
-			defer func() { getUserStoragePathFn = originalGetUserStoragePath }()
-
-			getUserStoragePathFn = func(r *http.Request) (string, error) {
-				return userPath, nil
-			}
+			srv := newTestServer(userPath)
 
 			tt.setupFiles(t, userPath)
 			req, rr := tt.setupRequest(t)
 
 			// Act
-			DeleteFilesHandler(rr, req)
+			srv.DeleteFilesHandler(rr, req)
 
 			// Assert
 			if status := rr.Code; status != tt.expectedStatus {
@@ -182,6 +221,224 @@ func TestDeleteFilesHandler(t *testing.T) {
 						t.Errorf("expected file %s at position %d, got %s", expectedFile, i, files[i])
 					}
 				}
+				trashed, err := listTrash(NewLocalStorage(userPath), "test-folder")
+				if err != nil {
+					t.Fatal(err)
+				}
+				trashedFiles := []string{}
+				for _, item := range trashed {
+					trashedFiles = append(trashedFiles, item.File)
+				}
+				sort.Strings(trashedFiles)
+				if !reflect.DeepEqual(trashedFiles, tt.expectedTrashed) {
+					t.Errorf("expected %v in the trash, got %v", tt.expectedTrashed, trashedFiles)
+				}
+			}
+		})
+	}
+}
+
+func TestDeleteFilesHandlerBodyValidation(t *testing.T) {
+	tests := []struct {
+		name           string
+		contentType    string
+		body           string
+		expectedStatus int
+	}{
+		{
+			name:           "Content-Type distinto de JSON",
+			contentType:    "text/plain",
+			body:           `{"folder":"test-folder"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Sin Content-Type",
+			contentType:    "",
+			body:           `{"folder":"test-folder"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "JSON mal formado",
+			contentType:    "application/json",
+			body:           `{"folder":`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Cuerpo demasiado grande",
+			contentType:    "application/json; charset=utf-8",
+			body:           `{"folder":"` + strings.Repeat("a", maxDeleteBodyBytes) + `"}`,
+			expectedStatus: http.StatusRequestEntityTooLarge,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			userPath := t.TempDir()
+			srv := newTestServer(userPath)
+
+			req := httptest.NewRequest(http.MethodDelete, "/delete", strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			req = req.WithContext(context.WithValue(req.Context(), userCodeKey, "testUser"))
+			rr := httptest.NewRecorder()
+
+			// Act
+			srv.DeleteFilesHandler(rr, req)
+
+			// Assert
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, tt.expectedStatus)
+			}
+		})
+	}
+}
+
+func TestDeleteFilesHandlerPattern(t *testing.T) {
+	tests := []struct {
+		name            string
+		builder         *DeleteRequestBuilder
+		expectedStatus  int
+		expectedDeleted []string
+		expectedLeft    []string
+	}{
+		{
+			name:            "Elimina solo los que coinciden",
+			builder:         NewDeleteRequestBuilder().WithFiles(nil).WithPattern("*-draft-*.pdf"),
+			expectedStatus:  http.StatusOK,
+			expectedDeleted: []string{"1-draft-a.pdf", "3-draft-b.pdf"},
+			expectedLeft:    []string{"2-final.pdf"},
+		},
+		{
+			name:            "Sin coincidencias no elimina nada",
+			builder:         NewDeleteRequestBuilder().WithFiles(nil).WithPattern("*.tmp.pdf"),
+			expectedStatus:  http.StatusOK,
+			expectedDeleted: []string{},
+			expectedLeft:    []string{"1-draft-a.pdf", "2-final.pdf", "3-draft-b.pdf"},
+		},
+		{
+			name:           "Patrón y lista juntos son ambiguos",
+			builder:        NewDeleteRequestBuilder().WithFiles([]string{"2-final.pdf"}).WithPattern("*.pdf"),
+			expectedStatus: http.StatusBadRequest,
+			expectedLeft:   []string{"1-draft-a.pdf", "2-final.pdf", "3-draft-b.pdf"},
+		},
+		{
+			name:           "Patrón mal formado",
+			builder:        NewDeleteRequestBuilder().WithFiles(nil).WithPattern("[draft"),
+			expectedStatus: http.StatusBadRequest,
+			expectedLeft:   []string{"1-draft-a.pdf", "2-final.pdf", "3-draft-b.pdf"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			userPath := t.TempDir()
+			folderPath := filepath.Join(userPath, "test-folder")
+			os.MkdirAll(folderPath, os.ModePerm)
+			for _, f := range []string{"1-draft-a.pdf", "2-final.pdf", "3-draft-b.pdf"} {
+				os.WriteFile(filepath.Join(folderPath, f), []byte("%PDF"), 0o644)
+			}
+			srv := newTestServer(userPath)
+			req, rr := tt.builder.Build(t)
+
+			// Act
+			srv.DeleteFilesHandler(rr, req)
+
+			// Assert
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("expected %d, got %d: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+			}
+			if tt.expectedStatus == http.StatusOK {
+				var body DeleteFilesResponse
+				if err := json.NewDecoder(rr.Body).Decode(&body); err != nil {
+					t.Fatalf("expected JSON body: %v", err)
+				}
+				if !reflect.DeepEqual(body.Deleted, tt.expectedDeleted) {
+					t.Errorf("expected deleted %v, got %v", tt.expectedDeleted, body.Deleted)
+				}
+			}
+			left, _ := ListFilesWithExtension(folderPath, ".pdf")
+			if !reflect.DeepEqual(left, tt.expectedLeft) {
+				t.Errorf("expected %v left, got %v", tt.expectedLeft, left)
+			}
+		})
+	}
+}
+
+func TestDeleteFilesHandlerIfUnmodifiedSince(t *testing.T) {
+	listedAt := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name           string
+		header         string
+		files          []string
+		expectedStatus int
+		expectedLeft   []string
+	}{
+		{
+			name:           "Sin cambios desde el listado",
+			header:         listedAt.Format(http.TimeFormat),
+			files:          []string{"1-viejo.pdf"},
+			expectedStatus: http.StatusOK,
+			expectedLeft:   []string{"2-nuevo.pdf"},
+		},
+		{
+			name:           "Un archivo cambió después del listado",
+			header:         listedAt.Format(http.TimeFormat),
+			files:          []string{"1-viejo.pdf", "2-nuevo.pdf"},
+			expectedStatus: http.StatusPreconditionFailed,
+			expectedLeft:   []string{"1-viejo.pdf", "2-nuevo.pdf"},
+		},
+		{
+			name:           "Borrar todo incluye lo subido después",
+			header:         listedAt.Format(http.TimeFormat),
+			files:          nil,
+			expectedStatus: http.StatusPreconditionFailed,
+			expectedLeft:   []string{"1-viejo.pdf", "2-nuevo.pdf"},
+		},
+		{
+			name:           "Sin cabecera no se comprueba",
+			files:          []string{"2-nuevo.pdf"},
+			expectedStatus: http.StatusOK,
+			expectedLeft:   []string{"1-viejo.pdf"},
+		},
+		{
+			name:           "Fecha inválida se ignora",
+			header:         "ayer",
+			files:          []string{"2-nuevo.pdf"},
+			expectedStatus: http.StatusOK,
+			expectedLeft:   []string{"1-viejo.pdf"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			userPath := t.TempDir()
+			folderPath := filepath.Join(userPath, "test-folder")
+			os.MkdirAll(folderPath, os.ModePerm)
+			for name, modTime := range map[string]time.Time{"1-viejo.pdf": listedAt, "2-nuevo.pdf": listedAt.Add(time.Minute)} {
+				path := filepath.Join(folderPath, name)
+				os.WriteFile(path, []byte("%PDF"), 0o644)
+				os.Chtimes(path, modTime, modTime)
+			}
+			srv := newTestServer(userPath)
+			req, rr := NewDeleteRequestBuilder().WithFiles(tt.files).WithPurge().Build(t)
+			if tt.header != "" {
+				req.Header.Set("If-Unmodified-Since", tt.header)
+			}
+
+			// Act
+			srv.DeleteFilesHandler(rr, req)
+
+			// Assert
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("expected %d, got %d: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+			}
+			left, _ := ListFilesWithExtension(folderPath, ".pdf")
+			if !reflect.DeepEqual(left, tt.expectedLeft) {
+				t.Errorf("expected %v left, got %v", tt.expectedLeft, left)
 			}
 		})
 	}
@@ -0,0 +1,42 @@
+package pdf
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"os"
+)
+
+// --- Descarga en base64 ---
+// Algunos clientes embebidos no manejan respuestas binarias y necesitan el PDF dentro
+// de un JSON. La codificación mantiene en memoria el archivo y su versión en base64,
+// por eso solo se permite hasta MAX_BASE64_DOWNLOAD_BYTES.
+const downloadFormatBase64 = "base64"
+
+var maxBase64DownloadBytes = defaultConfig.MaxBase64DownloadBytes
+
+// validDownloadFormat indica si download_format es un formato soportado ("" es binario)
+func validDownloadFormat(format string) bool {
+	return format == "" || format == "binary" || format == downloadFormatBase64
+}
+
+// writeBase64Download responde con el PDF de pdfPath codificado en base64 dentro de un JSON
+func writeBase64Download(w http.ResponseWriter, pdfPath, filename string, size int64) {
+	if size > maxBase64DownloadBytes {
+		writeJSONError(w, http.StatusRequestEntityTooLarge,
+			fmt.Sprintf("El PDF supera el máximo de %d bytes para descarga en base64", maxBase64DownloadBytes))
+		return
+	}
+
+	data, err := os.ReadFile(pdfPath)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Error al leer el PDF unido")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, Base64DownloadResponse{
+		Filename: filename,
+		Data:     base64.StdEncoding.EncodeToString(data),
+		Bytes:    len(data),
+	})
+}
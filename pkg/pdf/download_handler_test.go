@@ -0,0 +1,536 @@
+package pdf
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// Test Data Builder para las solicitudes de descarga
+type DownloadRequestBuilder struct {
+	method string
+	query  string
+	header http.Header
+}
+
+func NewDownloadRequestBuilder() *DownloadRequestBuilder {
+	return &DownloadRequestBuilder{
+		method: http.MethodGet,
+		query:  "folder=test-folder",
+		header: http.Header{},
+	}
+}
+
+func (b *DownloadRequestBuilder) WithMethod(method string) *DownloadRequestBuilder {
+	b.method = method
+	return b
+}
+
+func (b *DownloadRequestBuilder) WithQuery(query string) *DownloadRequestBuilder {
+	b.query = query
+	return b
+}
+
+func (b *DownloadRequestBuilder) WithHeader(key, value string) *DownloadRequestBuilder {
+	b.header.Set(key, value)
+	return b
+}
+
+func (b *DownloadRequestBuilder) Build() (*http.Request, *httptest.ResponseRecorder) {
+	req := httptest.NewRequest(b.method, "/download?"+b.query, nil)
+	for key, values := range b.header {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+	req = req.WithContext(context.WithValue(req.Context(), userCodeKey, "testUser"))
+	return req, httptest.NewRecorder()
+}
+
+// setupMergedFile crea un folder.pdf falso en la ruta del usuario
+func setupMergedFile(t *testing.T, userPath, folder, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(userPath, folder+".pdf"), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDownloadHandler(t *testing.T) {
+	tests := []struct {
+		name                string
+		builder             *DownloadRequestBuilder
+		expectedStatus      int
+		expectedDisposition string
+	}{
+		{
+			name:                "Descarga como adjunto por defecto",
+			builder:             NewDownloadRequestBuilder(),
+			expectedStatus:      http.StatusOK,
+			expectedDisposition: `attachment; filename=test-folder.pdf`,
+		},
+		{
+			name:                "Vista en el navegador con inline=true",
+			builder:             NewDownloadRequestBuilder().WithQuery("folder=test-folder&inline=true"),
+			expectedStatus:      http.StatusOK,
+			expectedDisposition: `inline; filename=test-folder.pdf`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			userPath := t.TempDir()
+			setupMergedFile(t, userPath, "test-folder", "%PDF-1.7 contenido")
+
+			srv := newTestServer(userPath)
+
+			req, rr := tt.builder.Build()
+
+			// Act
+			srv.DownloadHandler(rr, req)
+
+			// Assert
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, tt.expectedStatus)
+			}
+			if got := rr.Header().Get("Content-Type"); got != "application/pdf" {
+				t.Errorf("expected Content-Type application/pdf, got %s", got)
+			}
+			if got := rr.Header().Get("Content-Disposition"); got != tt.expectedDisposition {
+				t.Errorf("expected Content-Disposition %s, got %s", tt.expectedDisposition, got)
+			}
+			if got := rr.Header().Get("Content-Length"); got != "18" {
+				t.Errorf("expected Content-Length 18, got %s", got)
+			}
+		})
+	}
+}
+
+func TestDownloadHandlerNotGenerated(t *testing.T) {
+	// Arrange
+	userPath := t.TempDir()
+
+	srv := newTestServer(userPath)
+
+	req, rr := NewDownloadRequestBuilder().Build()
+
+	// Act
+	srv.DownloadHandler(rr, req)
+
+	// Assert
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusNotFound)
+	}
+	if got := rr.Header().Get("Content-Type"); got != "application/json" {
+		t.Errorf("expected Content-Type application/json, got %s", got)
+	}
+	var body ErrorResponse
+	if err := json.NewDecoder(rr.Body).Decode(&body); err != nil {
+		t.Fatalf("expected JSON body: %v", err)
+	}
+	if body.Error != "merged PDF not generated for folder" {
+		t.Errorf("unexpected error message: %s", body.Error)
+	}
+	if body.Status != http.StatusNotFound {
+		t.Errorf("expected status %d in body, got %d", http.StatusNotFound, body.Status)
+	}
+}
+
+func TestDownloadHandlerGzip(t *testing.T) {
+	content := "%PDF-1.7 " + strings.Repeat("contenido repetido ", 200)
+
+	tests := []struct {
+		name             string
+		builder          *DownloadRequestBuilder
+		expectedStatus   int
+		expectedEncoding string
+	}{
+		{
+			name:             "Sin Accept-Encoding no se comprime",
+			builder:          NewDownloadRequestBuilder(),
+			expectedStatus:   http.StatusOK,
+			expectedEncoding: "",
+		},
+		{
+			name:             "Con Accept-Encoding gzip se comprime",
+			builder:          NewDownloadRequestBuilder().WithHeader("Accept-Encoding", "gzip, deflate"),
+			expectedStatus:   http.StatusOK,
+			expectedEncoding: "gzip",
+		},
+		{
+			name:             "gzip con q=0 no se comprime",
+			builder:          NewDownloadRequestBuilder().WithHeader("Accept-Encoding", "gzip;q=0"),
+			expectedStatus:   http.StatusOK,
+			expectedEncoding: "",
+		},
+		{
+			name: "Las peticiones por rangos no se comprimen",
+			builder: NewDownloadRequestBuilder().
+				WithHeader("Accept-Encoding", "gzip").
+				WithHeader("Range", "bytes=0-9"),
+			expectedStatus:   http.StatusPartialContent,
+			expectedEncoding: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			userPath := t.TempDir()
+			setupMergedFile(t, userPath, "test-folder", content)
+
+			srv := newTestServer(userPath)
+
+			req, rr := tt.builder.Build()
+
+			// Act
+			srv.DownloadHandler(rr, req)
+
+			// Assert
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, tt.expectedStatus)
+			}
+			if got := rr.Header().Get("Content-Encoding"); got != tt.expectedEncoding {
+				t.Fatalf("expected Content-Encoding %q, got %q", tt.expectedEncoding, got)
+			}
+			if tt.expectedEncoding != "gzip" {
+				return
+			}
+			gz, err := gzip.NewReader(rr.Body)
+			if err != nil {
+				t.Fatalf("expected a gzip body: %v", err)
+			}
+			body, _ := io.ReadAll(gz)
+			if string(body) != content {
+				t.Errorf("decompressed body does not match the file")
+			}
+		})
+	}
+}
+
+func TestDownloadHandlerOutputVariant(t *testing.T) {
+	tests := []struct {
+		name             string
+		query            string
+		expectedStatus   int
+		expectedBody     string
+		expectedFilename string
+	}{
+		{name: "Salida por defecto", query: "folder=test-folder", expectedStatus: http.StatusOK, expectedBody: "default", expectedFilename: "test-folder.pdf"},
+		{name: "Variante por output", query: "folder=test-folder&output=variante", expectedStatus: http.StatusOK, expectedBody: "variant", expectedFilename: "variante.pdf"},
+		{name: "Variante por file", query: "folder=test-folder&file=variante.pdf", expectedStatus: http.StatusOK, expectedBody: "variant", expectedFilename: "variante.pdf"},
+		{name: "Nombre con ruta", query: "folder=test-folder&output=../secreto", expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			userPath := t.TempDir()
+			setupMergedFile(t, userPath, "test-folder", "default")
+			setupMergedFile(t, userPath, "variante", "variant")
+
+			srv := newTestServer(userPath)
+
+			req, rr := NewDownloadRequestBuilder().WithQuery(tt.query).Build()
+
+			// Act
+			srv.DownloadHandler(rr, req)
+
+			// Assert
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, tt.expectedStatus)
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+			if rr.Body.String() != tt.expectedBody {
+				t.Errorf("expected body %q, got %q", tt.expectedBody, rr.Body.String())
+			}
+			if got := rr.Header().Get("Content-Disposition"); !strings.Contains(got, tt.expectedFilename) {
+				t.Errorf("expected filename %s in Content-Disposition, got %s", tt.expectedFilename, got)
+			}
+		})
+	}
+}
+
+func TestDownloadHandlerBase64(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		maxBytes       int64
+		expectedStatus int
+	}{
+		{name: "PDF en base64", query: "folder=test-folder&download_format=base64", maxBytes: 1 << 20, expectedStatus: http.StatusOK},
+		{name: "Variante en base64", query: "folder=test-folder&output=test-folder&download_format=base64", maxBytes: 1 << 20, expectedStatus: http.StatusOK},
+		{name: "Supera el máximo", query: "folder=test-folder&download_format=base64", maxBytes: 4, expectedStatus: http.StatusRequestEntityTooLarge},
+		{name: "Formato desconocido", query: "folder=test-folder&download_format=hex", maxBytes: 1 << 20, expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			userPath := t.TempDir()
+			setupMergedFile(t, userPath, "test-folder", "%PDF-contenido")
+
+			originalMaxBytes := maxBase64DownloadBytes
+			defer func() { maxBase64DownloadBytes = originalMaxBytes }()
+			srv := newTestServer(userPath)
+			maxBase64DownloadBytes = tt.maxBytes
+
+			req, rr := NewDownloadRequestBuilder().WithQuery(tt.query).Build()
+
+			// Act
+			srv.DownloadHandler(rr, req)
+
+			// Assert
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, tt.expectedStatus)
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+			if ct := rr.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/json") {
+				t.Errorf("expected JSON content type, got %s", ct)
+			}
+			var body Base64DownloadResponse
+			if err := json.NewDecoder(rr.Body).Decode(&body); err != nil {
+				t.Fatalf("could not decode response: %v", err)
+			}
+			data, err := base64.StdEncoding.DecodeString(body.Data)
+			if err != nil {
+				t.Fatalf("invalid base64 data: %v", err)
+			}
+			if string(data) != "%PDF-contenido" || body.Bytes != len(data) {
+				t.Errorf("unexpected payload: %q (%d bytes)", data, body.Bytes)
+			}
+			if body.Filename != "test-folder.pdf" {
+				t.Errorf("expected filename test-folder.pdf, got %s", body.Filename)
+			}
+		})
+	}
+}
+
+func TestDownloadHandlerHead(t *testing.T) {
+	tests := []struct {
+		name           string
+		builder        *DownloadRequestBuilder
+		generated      bool
+		expectedStatus int
+		expectedType   string
+	}{
+		{
+			name:           "Cabeceras del PDF sin cuerpo",
+			builder:        NewDownloadRequestBuilder().WithMethod(http.MethodHead),
+			generated:      true,
+			expectedStatus: http.StatusOK,
+			expectedType:   "application/pdf",
+		},
+		{
+			name:           "HEAD no se comprime aunque el cliente acepte gzip",
+			builder:        NewDownloadRequestBuilder().WithMethod(http.MethodHead).WithHeader("Accept-Encoding", "gzip"),
+			generated:      true,
+			expectedStatus: http.StatusOK,
+			expectedType:   "application/pdf",
+		},
+		{
+			name:           "Sin generar responde 404",
+			builder:        NewDownloadRequestBuilder().WithMethod(http.MethodHead),
+			generated:      false,
+			expectedStatus: http.StatusNotFound,
+			expectedType:   "application/json",
+		},
+		{
+			name:           "Otros métodos no están permitidos",
+			builder:        NewDownloadRequestBuilder().WithMethod(http.MethodPost),
+			generated:      true,
+			expectedStatus: http.StatusMethodNotAllowed,
+			expectedType:   "application/json",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			userPath := t.TempDir()
+			if tt.generated {
+				setupMergedFile(t, userPath, "test-folder", "%PDF-1.7 contenido")
+			}
+			srv := newTestServer(userPath)
+			req, rr := tt.builder.Build()
+
+			// Act
+			srv.DownloadHandler(rr, req)
+
+			// Assert
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, tt.expectedStatus)
+			}
+			if got := rr.Header().Get("Content-Type"); got != tt.expectedType {
+				t.Errorf("expected Content-Type %s, got %s", tt.expectedType, got)
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+			if got := rr.Header().Get("Content-Length"); got != "18" {
+				t.Errorf("expected Content-Length 18, got %s", got)
+			}
+			if rr.Header().Get("Last-Modified") == "" {
+				t.Errorf("expected a Last-Modified header")
+			}
+			if got := rr.Header().Get("Content-Disposition"); got != "attachment; filename=test-folder.pdf" {
+				t.Errorf("unexpected Content-Disposition %s", got)
+			}
+			if rr.Header().Get("Content-Encoding") != "" {
+				t.Errorf("expected no Content-Encoding for HEAD")
+			}
+			if rr.Body.Len() != 0 {
+				t.Errorf("expected no body for HEAD, got %d bytes", rr.Body.Len())
+			}
+		})
+	}
+}
+
+func TestDownloadHandlerPreviewPassword(t *testing.T) {
+	tests := []struct {
+		name                string
+		encrypted           bool
+		query               string
+		expectedStatus      int
+		expectedDisposition string
+		expectedEncrypted   bool
+	}{
+		{name: "Sin contraseña se descarga cifrado", encrypted: true, query: "folder=test-folder", expectedStatus: http.StatusOK, expectedDisposition: "attachment", expectedEncrypted: true},
+		{name: "Vista previa descifrada", encrypted: true, query: "folder=test-folder&preview_password=secreto", expectedStatus: http.StatusOK, expectedDisposition: "inline", expectedEncrypted: false},
+		{name: "Contraseña incorrecta", encrypted: true, query: "folder=test-folder&preview_password=otra", expectedStatus: http.StatusForbidden},
+		{name: "Salida sin cifrar se muestra tal cual", encrypted: false, query: "folder=test-folder&preview_password=secreto", expectedStatus: http.StatusOK, expectedDisposition: "inline", expectedEncrypted: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			originalTempRoot := tempRoot
+			defer func() { tempRoot = originalTempRoot }()
+			tempRoot = t.TempDir()
+
+			userPath := t.TempDir()
+			outputPath := filepath.Join(userPath, "test-folder.pdf")
+			if tt.encrypted {
+				writeEncryptedPDF(t, outputPath, "secreto", "propietario")
+			} else {
+				writeTestPDF(t, outputPath, 1)
+			}
+			srv := newTestServer(userPath)
+			req, rr := NewDownloadRequestBuilder().WithQuery(tt.query).Build()
+
+			// Act
+			srv.DownloadHandler(rr, req)
+
+			// Assert
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("expected %d, got %d: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+			}
+			if leftovers, _ := os.ReadDir(tempRoot); len(leftovers) != 0 {
+				t.Errorf("expected the decrypted copy to be removed, found %d entries", len(leftovers))
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+			if got := rr.Header().Get("Content-Disposition"); !strings.HasPrefix(got, tt.expectedDisposition) {
+				t.Errorf("expected %s disposition, got %s", tt.expectedDisposition, got)
+			}
+			if encrypted := strings.Contains(rr.Body.String(), "/Encrypt"); encrypted != tt.expectedEncrypted {
+				t.Errorf("expected encrypted=%v in the response body", tt.expectedEncrypted)
+			}
+			if raw, _ := os.ReadFile(outputPath); tt.encrypted && !strings.Contains(string(raw), "/Encrypt") {
+				t.Errorf("expected the stored output to stay encrypted")
+			}
+		})
+	}
+}
+
+func TestDownloadHandlerRangeRequests(t *testing.T) {
+	const content = "%PDF-1.7 contenido del PDF unido"
+	tests := []struct {
+		name                 string
+		builder              *DownloadRequestBuilder
+		expectedStatus       int
+		expectedContentRange string
+		expectedBody         string
+	}{
+		{
+			name:                 "Rango inicial",
+			builder:              NewDownloadRequestBuilder().WithHeader("Range", "bytes=0-7"),
+			expectedStatus:       http.StatusPartialContent,
+			expectedContentRange: fmt.Sprintf("bytes 0-7/%d", len(content)),
+			expectedBody:         "%PDF-1.7",
+		},
+		{
+			name:                 "Rango intermedio con inline",
+			builder:              NewDownloadRequestBuilder().WithQuery("folder=test-folder&inline=true").WithHeader("Range", "bytes=9-17"),
+			expectedStatus:       http.StatusPartialContent,
+			expectedContentRange: fmt.Sprintf("bytes 9-17/%d", len(content)),
+			expectedBody:         "contenido",
+		},
+		{
+			name:                 "Últimos bytes",
+			builder:              NewDownloadRequestBuilder().WithHeader("Range", "bytes=-5"),
+			expectedStatus:       http.StatusPartialContent,
+			expectedContentRange: fmt.Sprintf("bytes %d-%d/%d", len(content)-5, len(content)-1, len(content)),
+			expectedBody:         "unido",
+		},
+		{
+			name:                 "Rango fuera del archivo",
+			builder:              NewDownloadRequestBuilder().WithHeader("Range", fmt.Sprintf("bytes=%d-", len(content)+10)),
+			expectedStatus:       http.StatusRequestedRangeNotSatisfiable,
+			expectedContentRange: fmt.Sprintf("bytes */%d", len(content)),
+		},
+		{
+			name:           "HEAD anuncia los rangos",
+			builder:        NewDownloadRequestBuilder().WithMethod(http.MethodHead),
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			userPath := t.TempDir()
+			setupMergedFile(t, userPath, "test-folder", content)
+			srv := newTestServer(userPath)
+			req, rr := tt.builder.Build()
+
+			// Act
+			srv.DownloadHandler(rr, req)
+
+			// Assert
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, tt.expectedStatus)
+			}
+			if got := rr.Header().Get("Accept-Ranges"); got != "bytes" {
+				t.Errorf("expected Accept-Ranges bytes, got %q", got)
+			}
+			if got := rr.Header().Get("Content-Range"); got != tt.expectedContentRange {
+				t.Errorf("expected Content-Range %q, got %q", tt.expectedContentRange, got)
+			}
+			if tt.expectedStatus != http.StatusPartialContent {
+				return
+			}
+			if got := rr.Body.String(); got != tt.expectedBody {
+				t.Errorf("expected body %q, got %q", tt.expectedBody, got)
+			}
+			if got := rr.Header().Get("Content-Length"); got != strconv.Itoa(len(tt.expectedBody)) {
+				t.Errorf("expected Content-Length of the range %d, got %s", len(tt.expectedBody), got)
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package pdf
+
+import (
+	"errors"
+	"path/filepath"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+)
+
+// planMerge calcula lo que haría joinPDFs con opts sin escribir nada: los archivos que
+// elige (los de indices, si se indican) en su orden, el total de páginas y los problemas
+// que harían fallar la unión, incluido superar MAX_TOTAL_PAGES.
+func planMerge(path, folder string, opts MergeOptions) MergePlan {
+	plan := MergePlan{Folder: folder, Files: []string{}, Problems: []FileProblem{}}
+
+	folderPath := filepath.Join(path, folder)
+	files, err := mergeSourceFiles(folderPath, opts)
+	if errors.Is(err, ErrNoPDFs) || errors.Is(err, ErrFileIndexOutOfRange) {
+		plan.Problems = append(plan.Problems, FileProblem{File: folder, Error: err.Error()})
+		return plan
+	}
+	if err != nil {
+		plan.Problems = append(plan.Problems, FileProblem{File: folder, Error: "no se pudo leer la carpeta"})
+		return plan
+	}
+
+	for _, file := range files {
+		plan.Files = append(plan.Files, file)
+		filePath := filepath.Join(folderPath, file)
+
+		if err := api.ValidateFile(filePath, nil); err != nil {
+			plan.Problems = append(plan.Problems, FileProblem{File: file, Error: err.Error()})
+			continue
+		}
+		pages, err := api.PageCountFile(filePath)
+		if err != nil {
+			plan.Problems = append(plan.Problems, FileProblem{File: file, Error: err.Error()})
+			continue
+		}
+		plan.TotalPages += pages
+	}
+	if err := pageLimitError(plan.TotalPages); err != nil {
+		plan.Problems = append(plan.Problems, FileProblem{File: folder, Error: err.Error()})
+	}
+
+	return plan
+}
@@ -0,0 +1,139 @@
+package pdf
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGenerateHandlerDryRun(t *testing.T) {
+	// Arrange
+	userPath := t.TempDir()
+	folderPath := filepath.Join(userPath, "test-folder")
+	os.MkdirAll(folderPath, os.ModePerm)
+	writeTestPDF(t, filepath.Join(folderPath, "2-document.pdf"), 3)
+	writeTestPDF(t, filepath.Join(folderPath, "1-document.pdf"), 2)
+	os.WriteFile(filepath.Join(folderPath, "3-broken.pdf"), []byte("no es un pdf"), 0o644)
+
+	srv := newTestServer(userPath)
+
+	req := httptest.NewRequest(http.MethodPost, "/generate", strings.NewReader("folder=test-folder&dry_run=true"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req = req.WithContext(context.WithValue(req.Context(), userCodeKey, "testUser"))
+	rr := httptest.NewRecorder()
+
+	// Act
+	srv.GenerateHandler(rr, req)
+
+	// Assert
+	if rr.Code != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+	}
+	var plan MergePlan
+	json.NewDecoder(rr.Body).Decode(&plan)
+
+	expectedFiles := []string{"1-document.pdf", "2-document.pdf", "3-broken.pdf"}
+	if !reflect.DeepEqual(plan.Files, expectedFiles) {
+		t.Errorf("expected files %v, got %v", expectedFiles, plan.Files)
+	}
+	if plan.TotalPages != 5 {
+		t.Errorf("expected 5 total pages, got %d", plan.TotalPages)
+	}
+	if len(plan.Problems) != 1 || plan.Problems[0].File != "3-broken.pdf" {
+		t.Errorf("expected a single problem for 3-broken.pdf, got %v", plan.Problems)
+	}
+	if _, err := os.Stat(filepath.Join(userPath, "test-folder.pdf")); !os.IsNotExist(err) {
+		t.Errorf("dry run must not write the merged output")
+	}
+}
+
+func TestGenerateHandlerDryRunUsesMergeOptions(t *testing.T) {
+	tests := []struct {
+		name             string
+		form             url.Values
+		maxTotalPages    int
+		expectedFiles    []string
+		expectedPages    int
+		expectedProblems int
+	}{
+		{
+			name:          "Sin indices entran todos",
+			form:          url.Values{},
+			expectedFiles: []string{"1-document.pdf", "2-document.pdf", "3-document.pdf"},
+			expectedPages: 6,
+		},
+		{
+			name:          "Solo los archivos de indices",
+			form:          url.Values{"indices": {"3,1"}},
+			expectedFiles: []string{"1-document.pdf", "3-document.pdf"},
+			expectedPages: 3,
+		},
+		{
+			name:             "Índice fuera de rango",
+			form:             url.Values{"indices": {"4"}},
+			expectedFiles:    []string{},
+			expectedProblems: 1,
+		},
+		{
+			name:             "Supera MAX_TOTAL_PAGES",
+			form:             url.Values{},
+			maxTotalPages:    5,
+			expectedFiles:    []string{"1-document.pdf", "2-document.pdf", "3-document.pdf"},
+			expectedPages:    6,
+			expectedProblems: 1,
+		},
+		{
+			name:          "Los indices elegidos caben en MAX_TOTAL_PAGES",
+			form:          url.Values{"indices": {"1,3"}},
+			maxTotalPages: 5,
+			expectedFiles: []string{"1-document.pdf", "3-document.pdf"},
+			expectedPages: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			originalMax := maxTotalPages
+			defer func() { maxTotalPages = originalMax }()
+			maxTotalPages = tt.maxTotalPages
+			userPath := t.TempDir()
+			folderPath := filepath.Join(userPath, "test-folder")
+			os.MkdirAll(folderPath, os.ModePerm)
+			writeTestPDF(t, filepath.Join(folderPath, "1-document.pdf"), 1)
+			writeTestPDF(t, filepath.Join(folderPath, "2-document.pdf"), 3)
+			writeTestPDF(t, filepath.Join(folderPath, "3-document.pdf"), 2)
+			srv := newTestServer(userPath)
+			form := tt.form
+			form.Set("folder", "test-folder")
+			form.Set("dry_run", "true")
+			req, rr := newGenerateRequest(form)
+
+			// Act
+			srv.GenerateHandler(rr, req)
+
+			// Assert
+			if rr.Code != http.StatusOK {
+				t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+			}
+			var plan MergePlan
+			json.NewDecoder(rr.Body).Decode(&plan)
+			if !reflect.DeepEqual(plan.Files, tt.expectedFiles) {
+				t.Errorf("expected files %v, got %v", tt.expectedFiles, plan.Files)
+			}
+			if plan.TotalPages != tt.expectedPages {
+				t.Errorf("expected %d total pages, got %d", tt.expectedPages, plan.TotalPages)
+			}
+			if len(plan.Problems) != tt.expectedProblems {
+				t.Errorf("expected %d problems, got %v", tt.expectedProblems, plan.Problems)
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package pdf
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+)
+
+// ErrPasswordRequired indica fuentes protegidas cuya contraseña falta o es incorrecta
+var ErrPasswordRequired = errors.New("hay PDFs protegidos con contraseña")
+
+// errWrongPassword indica que la contraseña no abre el PDF
+var errWrongPassword = errors.New("contraseña incorrecta")
+
+// decryptSources devuelve la lista a unir reemplazando cada PDF que pide contraseña de
+// usuario por una copia descifrada en tmpDir, con el mismo nombre para que los marcadores
+// no cambien. Los que solo tienen contraseña de propietario se abren sin ella y se dejan
+// como están. Si a alguno le falta la contraseña o es incorrecta devuelve
+// ErrPasswordRequired con los nombres de todos ellos.
+func decryptSources(tmpDir string, files []string, opts MergeOptions) ([]string, error) {
+	decryptDir := filepath.Join(tmpDir, "decrypted")
+	result := make([]string, len(files))
+	var missing, wrong []string
+	for i, file := range files {
+		result[i] = file
+		opts.progress(ProgressPreparing, i+1, len(files))
+		protected, err := needsPassword(file)
+		if err != nil {
+			return nil, err
+		}
+		if !protected {
+			continue
+		}
+
+		name := filepath.Base(file)
+		password, ok := opts.Passwords[name]
+		if !ok {
+			password = opts.SourcePassword
+		}
+		if password == "" {
+			missing = append(missing, name)
+			continue
+		}
+
+		if err := os.MkdirAll(decryptDir, os.ModePerm); err != nil {
+			return nil, err
+		}
+		outPath := filepath.Join(decryptDir, name)
+		conf := model.NewDefaultConfiguration()
+		conf.UserPW = password
+		conf.OwnerPW = password
+		err = api.DecryptFile(file, outPath, conf)
+		if errors.Is(err, pdfcpu.ErrWrongPassword) {
+			wrong = append(wrong, name)
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error al descifrar %s: %w", name, err)
+		}
+		result[i] = outPath
+	}
+
+	var problems []string
+	if len(missing) > 0 {
+		problems = append(problems, "falta la contraseña de "+strings.Join(missing, ", "))
+	}
+	if len(wrong) > 0 {
+		problems = append(problems, "contraseña incorrecta para "+strings.Join(wrong, ", "))
+	}
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrPasswordRequired, strings.Join(problems, "; "))
+	}
+	return result, nil
+}
+
+// needsPassword indica si file está cifrado con una contraseña de usuario, sin la cual
+// pdfcpu no puede leerlo
+func needsPassword(file string) (bool, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	_, err = api.ReadContext(f, model.NewDefaultConfiguration())
+	if errors.Is(err, pdfcpu.ErrWrongPassword) {
+		return true, nil
+	}
+	// Otros errores de lectura se informan más adelante al unir, como hasta ahora
+	return false, nil
+}
+
+// decryptForPreview descifra path con password en una carpeta temporal y devuelve la
+// copia; cleanup la elimina. Un PDF sin cifrar se devuelve tal cual. Con una contraseña
+// incorrecta devuelve errWrongPassword.
+func decryptForPreview(path, password string) (previewPath string, cleanup func(), err error) {
+	conf := model.NewDefaultConfiguration()
+	conf.UserPW = password
+	conf.OwnerPW = password
+
+	f, err := os.Open(path)
+	if err != nil {
+		return "", func() {}, err
+	}
+	ctx, err := api.ReadContext(f, conf)
+	f.Close()
+	if errors.Is(err, pdfcpu.ErrWrongPassword) {
+		return "", func() {}, errWrongPassword
+	}
+	if err != nil {
+		return "", func() {}, err
+	}
+	if ctx.Encrypt == nil {
+		return path, func() {}, nil
+	}
+
+	tmpDir, cleanup, err := newTempDir("preview-")
+	if err != nil {
+		return "", func() {}, err
+	}
+	previewPath = filepath.Join(tmpDir, filepath.Base(path))
+	if err := api.DecryptFile(path, previewPath, conf); err != nil {
+		cleanup()
+		return "", func() {}, err
+	}
+	return previewPath, cleanup, nil
+}
+
+// parseSourcePasswords lee el campo passwords, un objeto JSON nombre de archivo → contraseña
+func parseSourcePasswords(value string) (map[string]string, error) {
+	if value == "" {
+		return nil, nil
+	}
+	var passwords map[string]string
+	if err := json.Unmarshal([]byte(value), &passwords); err != nil {
+		return nil, errors.New("passwords debe ser un objeto JSON de nombre de archivo a contraseña")
+	}
+	return passwords, nil
+}
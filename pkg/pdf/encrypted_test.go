@@ -0,0 +1,127 @@
+package pdf
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+)
+
+// writeEncryptedPDF escribe un PDF de una página cifrado con las contraseñas indicadas;
+// con userPW vacío se abre sin contraseña
+func writeEncryptedPDF(t *testing.T, path, userPW, ownerPW string) {
+	t.Helper()
+	plain := filepath.Join(t.TempDir(), "plain.pdf")
+	writeTestPDF(t, plain, 1)
+	if err := api.EncryptFile(plain, path, model.NewAESConfiguration(userPW, ownerPW, 256)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGenerateHandlerEncryptedSources(t *testing.T) {
+	tests := []struct {
+		name           string
+		form           url.Values
+		expectedStatus int
+		expectedInBody []string
+	}{
+		{
+			name:           "Sin contraseña nombra los archivos protegidos",
+			form:           url.Values{},
+			expectedStatus: http.StatusBadRequest,
+			expectedInBody: []string{"2-protegido.pdf", "3-otro.pdf"},
+		},
+		{
+			name:           "Contraseña común para todos",
+			form:           url.Values{"source_password": {"secreto"}},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Contraseña por archivo",
+			form:           url.Values{"passwords": {`{"2-protegido.pdf": "secreto", "3-otro.pdf": "secreto"}`}},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "La contraseña por archivo tiene prioridad sobre la común",
+			form:           url.Values{"passwords": {`{"3-otro.pdf": "secreto"}`}, "source_password": {"secreto"}},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Falta la contraseña de un archivo",
+			form:           url.Values{"passwords": {`{"2-protegido.pdf": "secreto"}`}},
+			expectedStatus: http.StatusBadRequest,
+			expectedInBody: []string{"3-otro.pdf"},
+		},
+		{
+			name:           "Contraseña incorrecta",
+			form:           url.Values{"source_password": {"otra"}},
+			expectedStatus: http.StatusBadRequest,
+			expectedInBody: []string{"incorrecta", "2-protegido.pdf", "3-otro.pdf"},
+		},
+		{
+			name:           "passwords no es JSON",
+			form:           url.Values{"passwords": {"2-protegido.pdf=secreto"}},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			originalTempRoot := tempRoot
+			defer func() { tempRoot = originalTempRoot }()
+			tempRoot = t.TempDir()
+
+			userPath := t.TempDir()
+			folderPath := filepath.Join(userPath, "test-folder")
+			os.MkdirAll(folderPath, os.ModePerm)
+			writeTestPDF(t, filepath.Join(folderPath, "1-normal.pdf"), 2)
+			writeEncryptedPDF(t, filepath.Join(folderPath, "2-protegido.pdf"), "secreto", "propietario")
+			writeEncryptedPDF(t, filepath.Join(folderPath, "3-otro.pdf"), "secreto", "propietario")
+			// Solo contraseña de propietario: se une sin pedirla
+			writeEncryptedPDF(t, filepath.Join(folderPath, "4-solo-propietario.pdf"), "", "propietario")
+
+			srv := newTestServer(userPath)
+			tt.form.Set("folder", "test-folder")
+			req, rr := newGenerateRequest(tt.form)
+
+			// Act
+			srv.GenerateHandler(rr, req)
+
+			// Assert
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("expected %d, got %d: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+			}
+			for _, s := range tt.expectedInBody {
+				if !strings.Contains(rr.Body.String(), s) {
+					t.Errorf("expected %q in the error, got %s", s, rr.Body.String())
+				}
+			}
+			if leftovers, _ := os.ReadDir(tempRoot); len(leftovers) != 0 {
+				t.Errorf("expected decrypted copies to be removed, found %d entries", len(leftovers))
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var resp GenerateResponse
+			json.NewDecoder(rr.Body).Decode(&resp)
+			pages, err := api.PageCountFile(filepath.Join(userPath, resp.Output))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if pages != 5 {
+				t.Errorf("expected 5 pages, got %d", pages)
+			}
+			if raw, _ := os.ReadFile(filepath.Join(folderPath, "2-protegido.pdf")); !strings.Contains(string(raw), "/Encrypt") {
+				t.Errorf("expected the source file to stay encrypted")
+			}
+		})
+	}
+}
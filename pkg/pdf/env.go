@@ -0,0 +1,28 @@
+package pdf
+
+import (
+	"os"
+	"strings"
+)
+
+// --- Helpers para leer configuración desde variables de entorno ---
+// Si la variable no existe se usa el valor por defecto. Los valores numéricos los
+// lee envLoader (config.go), que además informa los que no se pueden interpretar.
+
+func envString(key string, def string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return def
+}
+
+// envList lee una lista separada por comas, ignorando espacios y elementos vacíos
+func envList(key string) []string {
+	var list []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
@@ -0,0 +1,119 @@
+package pdf
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// --- Progreso de uniones largas (Server-Sent Events) ---
+// /events?job_id= mantiene la conexión abierta y envía un evento por cada cambio del
+// trabajo asíncrono, en lugar de que el cliente consulte /job-status en bucle.
+
+// Pasos que joinPDFs informa a MergeOptions.OnProgress
+const (
+	ProgressPreparing   = "preparing" // Revisión de cada fuente (Current/Total)
+	ProgressMerging     = "merging"
+	ProgressNormalizing = "normalizing"
+	ProgressBookmarks   = "bookmarks"
+	ProgressGrayscale   = "grayscale"
+	ProgressLinearizing = "linearizing"
+	ProgressSaving      = "saving"
+)
+
+// Cada cuánto se envía un comentario vacío para que proxies y clientes no den la
+// conexión por muerta durante un paso largo
+var eventsKeepAlive = 15 * time.Second
+
+// progress informa un paso de la unión si hay quien lo escuche
+func (o MergeOptions) progress(step string, current, total int) {
+	if o.OnProgress != nil {
+		o.OnProgress(MergeProgress{Step: step, Current: current, Total: total})
+	}
+}
+
+// EventsHandler: Transmite el progreso de un trabajo asíncrono del usuario autenticado.
+// Envía "progress" en cada cambio y termina con un evento "done" o "error".
+func (s *Server) EventsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, http.MethodGet)
+		return
+	}
+	userCode, ok := r.Context().Value(userCodeKey).(string)
+	if !ok {
+		writeJSONError(w, http.StatusInternalServerError, "Error interno de autenticación")
+		return
+	}
+
+	id := r.URL.Query().Get("job_id")
+	if id == "" {
+		writeJSONError(w, http.StatusBadRequest, "Falta el job_id del trabajo")
+		return
+	}
+	if _, ok := s.jobs.get(userCode, id); !ok {
+		writeJSONError(w, http.StatusNotFound, "Trabajo no encontrado")
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeJSONError(w, http.StatusInternalServerError, "El servidor no admite respuestas en streaming")
+		return
+	}
+
+	// El flujo dura lo que dure el trabajo: WRITE_TIMEOUT lo cortaría a mitad
+	http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no") // Sin buffer en nginx
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(eventsKeepAlive)
+	defer keepAlive.Stop()
+
+	var sent time.Time
+	for {
+		// El canal se pide antes de leer el trabajo para no perder un cambio intermedio
+		changed := s.jobs.changes()
+		job, _ := s.jobs.get(userCode, id)
+		if !job.UpdatedAt.Equal(sent) {
+			sent = job.UpdatedAt
+			event := "progress"
+			switch job.Status {
+			case JobDone:
+				event = "done"
+			case JobError:
+				event = "error"
+			}
+			if err := writeEvent(w, event, job); err != nil {
+				logf(r.Context(), "Error enviando evento del trabajo %s: %v", id, err)
+				return
+			}
+			flusher.Flush()
+			if event != "progress" {
+				return
+			}
+		}
+
+		select {
+		case <-changed:
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// writeEvent escribe un evento SSE con el trabajo en JSON como datos
+func writeEvent(w http.ResponseWriter, event string, job MergeJob) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
+	return err
+}
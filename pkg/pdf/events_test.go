@@ -0,0 +1,136 @@
+package pdf
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func newEventsRequest(userCode, id string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/events?job_id="+id, nil)
+	return req.WithContext(context.WithValue(req.Context(), userCodeKey, userCode))
+}
+
+// progressRecorder avisa cuando el flujo ya envió el primer evento de progreso
+type progressRecorder struct {
+	*httptest.ResponseRecorder
+	once     sync.Once
+	progress chan struct{}
+}
+
+func (p *progressRecorder) Flush() {
+	p.ResponseRecorder.Flush()
+	if strings.Contains(p.Body.String(), "event: progress\n") {
+		p.once.Do(func() { close(p.progress) })
+	}
+}
+
+func TestJoinPDFsReportsProgress(t *testing.T) {
+	// Arrange
+	userPath := t.TempDir()
+	folderPath := filepath.Join(userPath, "test-folder")
+	os.MkdirAll(folderPath, os.ModePerm)
+	writeTestPDF(t, filepath.Join(folderPath, "1-intro.pdf"), 1)
+	writeTestPDF(t, filepath.Join(folderPath, "2-body.pdf"), 1)
+	var steps []MergeProgress
+	opts := MergeOptions{Bookmarks: true, OnProgress: func(p MergeProgress) { steps = append(steps, p) }}
+
+	// Act
+	_, err := joinPDFs(context.Background(), userPath, "test-folder", opts)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []MergeProgress{
+		{Step: ProgressPreparing, Current: 1, Total: 2},
+		{Step: ProgressPreparing, Current: 2, Total: 2},
+		{Step: ProgressMerging},
+		{Step: ProgressBookmarks},
+		{Step: ProgressSaving},
+	}
+	if !reflect.DeepEqual(steps, expected) {
+		t.Errorf("expected steps %+v, got %+v", expected, steps)
+	}
+}
+
+func TestEventsHandlerStreamsUntilDone(t *testing.T) {
+	// Arrange
+	userPath := t.TempDir()
+	folderPath := filepath.Join(userPath, "test-folder")
+	os.MkdirAll(folderPath, os.ModePerm)
+	writeTestPDF(t, filepath.Join(folderPath, "1-intro.pdf"), 1)
+	writeTestPDF(t, filepath.Join(folderPath, "2-body.pdf"), 1)
+	srv := newTestServer(userPath)
+
+	// Con la carpeta bloqueada el trabajo no avanza hasta que el flujo ya está abierto
+	unlock := lockFolder(userPath, "test-folder")
+	job := srv.enqueueMergeJob(context.Background(), "testUser", userPath, "test-folder", MergeOptions{}, "")
+	rr := &progressRecorder{ResponseRecorder: httptest.NewRecorder(), progress: make(chan struct{})}
+	finished := make(chan struct{})
+
+	// Act
+	go func() {
+		defer close(finished)
+		srv.EventsHandler(rr, newEventsRequest("testUser", job.ID))
+	}()
+	<-rr.progress
+	unlock()
+	<-finished
+
+	// Assert
+	if rr.Code != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+	}
+	if ct := rr.Header().Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("expected text/event-stream, got %q", ct)
+	}
+	body := rr.Body.String()
+	events := strings.Split(strings.TrimSpace(body), "\n\n")
+	last := events[len(events)-1]
+	if !strings.HasPrefix(last, "event: done\n") || !strings.Contains(last, `"status":"done"`) {
+		t.Errorf("expected the stream to end with a done event, got %q", last)
+	}
+}
+
+func TestEventsHandlerErrors(t *testing.T) {
+	tests := []struct {
+		name           string
+		userCode       string
+		id             string
+		expectedStatus int
+	}{
+		{name: "Falta job_id", userCode: "testUser", id: "", expectedStatus: http.StatusBadRequest},
+		{name: "Trabajo inexistente", userCode: "testUser", id: "no-existe", expectedStatus: http.StatusNotFound},
+		{name: "Trabajo de otro usuario", userCode: "otherUser", id: "events-job", expectedStatus: http.StatusNotFound},
+	}
+
+	userPath := t.TempDir()
+	os.MkdirAll(filepath.Join(userPath, "empty-folder"), os.ModePerm)
+	originalNewJobID := newJobIDFn
+	defer func() { newJobIDFn = originalNewJobID }()
+	newJobIDFn = func() string { return "events-job" }
+	srv := newTestServer(userPath)
+	srv.enqueueMergeJob(context.Background(), "testUser", userPath, "empty-folder", MergeOptions{}, "")
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			rr := httptest.NewRecorder()
+
+			// Act
+			srv.EventsHandler(rr, newEventsRequest(tt.userCode, tt.id))
+
+			// Assert
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, rr.Code)
+			}
+		})
+	}
+}
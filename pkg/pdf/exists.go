@@ -0,0 +1,47 @@
+package pdf
+
+import (
+	"net/http"
+	"os"
+)
+
+// ExistsHandler: Indica si el PDF unido de una carpeta ya se generó, con su fecha de
+// modificación y tamaño. file elige una variante con nombre propio, igual que en /download.
+func (s *Server) ExistsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, http.MethodGet)
+		return
+	}
+
+	// Obtener la ruta base de almacenamiento del usuario
+	userStoragePath, err := s.storagePath(r)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Error interno de autenticación")
+		return
+	}
+
+	var problems validationErrors
+	folder := r.URL.Query().Get("folder")
+	problems.checkFolder(folder)
+	if problems.respond(w) {
+		return
+	}
+	outputName, err := mergeOutputName(folder, r.URL.Query().Get("file"))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Nombre de salida inválido")
+		return
+	}
+
+	info, err := os.Stat(mergeOutputPath(userStoragePath, outputName))
+	if os.IsNotExist(err) || (err == nil && info.IsDir()) {
+		writeJSON(w, http.StatusOK, ExistsResponse{Exists: false})
+		return
+	}
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Error al leer el PDF unido")
+		return
+	}
+
+	modTime := info.ModTime()
+	writeJSON(w, http.StatusOK, ExistsResponse{Exists: true, ModTime: &modTime, Size: info.Size()})
+}
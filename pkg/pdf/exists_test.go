@@ -0,0 +1,77 @@
+package pdf
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExistsHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		expectedExists bool
+		expectedSize   int64
+	}{
+		{name: "Unión generada", query: "folder=test-folder", expectedStatus: http.StatusOK, expectedExists: true, expectedSize: 7},
+		{name: "Variante con nombre propio", query: "folder=test-folder&file=variante", expectedStatus: http.StatusOK, expectedExists: true, expectedSize: 3},
+		{name: "Unión sin generar", query: "folder=otra", expectedStatus: http.StatusOK, expectedExists: false},
+		{name: "Nombre con ruta", query: "folder=test-folder&file=../x", expectedStatus: http.StatusBadRequest},
+		{name: "Falta la carpeta", query: "", expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			userPath := t.TempDir()
+			setupMergedFile(t, userPath, "test-folder", "content")
+			setupMergedFile(t, userPath, "variante", "var")
+
+			srv := newTestServer(userPath)
+
+			req := httptest.NewRequest(http.MethodGet, "/exists?"+tt.query, nil)
+			req = req.WithContext(context.WithValue(req.Context(), userCodeKey, "testUser"))
+			rr := httptest.NewRecorder()
+
+			// Act
+			srv.ExistsHandler(rr, req)
+
+			// Assert
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, tt.expectedStatus)
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+			var resp ExistsResponse
+			if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+				t.Fatal(err)
+			}
+			if resp.Exists != tt.expectedExists || resp.Size != tt.expectedSize {
+				t.Errorf("unexpected response %+v", resp)
+			}
+			if resp.Exists != (resp.ModTime != nil) {
+				t.Errorf("expected modTime only when the merge exists, got %+v", resp)
+			}
+		})
+	}
+}
+
+func TestExistsHandlerOmitsFieldsWhenMissing(t *testing.T) {
+	userPath := t.TempDir()
+	srv := newTestServer(userPath)
+	os.MkdirAll(filepath.Join(userPath, "test-folder"), os.ModePerm)
+
+	req := httptest.NewRequest(http.MethodGet, "/exists?folder=test-folder", nil)
+	rr := httptest.NewRecorder()
+	srv.ExistsHandler(rr, req)
+
+	if got := rr.Body.String(); got != "{\"exists\":false}\n" {
+		t.Errorf("expected only exists=false, got %s", got)
+	}
+}
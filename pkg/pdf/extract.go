@@ -0,0 +1,86 @@
+package pdf
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+)
+
+// ExtractHandler: Copia un rango de páginas de un PDF de la carpeta a un archivo nuevo
+// en la misma carpeta. Recibe folder, file, pages (ej: "3-7,10") y output.
+func (s *Server) ExtractHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w, http.MethodPost)
+		return
+	}
+
+	// Obtener la ruta base de almacenamiento del usuario
+	userStoragePath, err := s.storagePath(r)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Error interno de autenticación")
+		return
+	}
+
+	var problems validationErrors
+	folder := r.FormValue("folder")
+	problems.checkFolder(folder)
+	if problems.respond(w) {
+		return
+	}
+	filename := r.FormValue("file")
+	if !validFileName(filename) {
+		writeJSONError(w, http.StatusBadRequest, "Nombre de archivo no válido")
+		return
+	}
+	output := r.FormValue("output")
+	if !validFileName(output) {
+		writeJSONError(w, http.StatusBadRequest, "Nombre de salida no válido")
+		return
+	}
+	output = pdfFileName(output)
+
+	folderPath := filepath.Join(userStoragePath, folder)
+	srcPath := filepath.Join(folderPath, filename)
+	outPath := filepath.Join(folderPath, output)
+
+	unlock := lockFolder(userStoragePath, folder)
+	defer unlock()
+
+	pageCount, err := api.PageCountFile(srcPath)
+	if os.IsNotExist(err) {
+		writeJSONError(w, http.StatusNotFound, "Archivo no encontrado: "+filename)
+		return
+	}
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Error al leer el PDF: "+err.Error())
+		return
+	}
+
+	selection, err := parsePageSpec(r.FormValue("pages"), pageCount)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if _, err := os.Stat(outPath); err == nil {
+		writeJSONError(w, http.StatusConflict, "Ya existe un archivo con ese nombre: "+output)
+		return
+	}
+
+	// Collect respeta el orden de la selección, a diferencia de Trim
+	if err := api.CollectFile(srcPath, outPath, selection, nil); err != nil {
+		os.Remove(outPath)
+		writeJSONError(w, http.StatusInternalServerError, "Error al extraer páginas: "+err.Error())
+		return
+	}
+
+	pages, err := api.PageCountFile(outPath)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Error al leer el PDF generado: "+err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, ExtractResponse{Folder: folder, File: output, Pages: pages})
+}
@@ -0,0 +1,55 @@
+package pdf
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrFileIndexOutOfRange indica posiciones de indices que no corresponden a ningún PDF
+var ErrFileIndexOutOfRange = errors.New("índices fuera de rango")
+
+// parseFileIndices lee indices, una lista como "1,3,5" de posiciones (desde 1) de los PDFs
+// en el orden de la unión. Vacío significa todos los archivos. El rango se comprueba al
+// listar la carpeta, en selectFilesByIndex.
+func parseFileIndices(spec string) ([]int, error) {
+	if strings.TrimSpace(spec) == "" {
+		return nil, nil
+	}
+	var indices []int
+	for _, part := range strings.Split(spec, ",") {
+		index, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			return nil, fmt.Errorf("índice no válido: %q", part)
+		}
+		indices = append(indices, index)
+	}
+	return indices, nil
+}
+
+// selectFilesByIndex conserva los archivos de files en las posiciones indicadas, en el
+// orden de files y sin repetirlos. Si alguna posición no existe devuelve
+// ErrFileIndexOutOfRange con todas las que faltan.
+func selectFilesByIndex(files []string, indices []int) ([]string, error) {
+	selected := make([]bool, len(files))
+	var outOfRange []string
+	for _, index := range indices {
+		if index < 1 || index > len(files) {
+			outOfRange = append(outOfRange, strconv.Itoa(index))
+			continue
+		}
+		selected[index-1] = true
+	}
+	if len(outOfRange) > 0 {
+		return nil, fmt.Errorf("%w: %s (la carpeta tiene %d PDFs)", ErrFileIndexOutOfRange, strings.Join(outOfRange, ", "), len(files))
+	}
+
+	var result []string
+	for i, file := range files {
+		if selected[i] {
+			result = append(result, file)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,68 @@
+package pdf
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+)
+
+func TestGenerateHandlerIndices(t *testing.T) {
+	tests := []struct {
+		name           string
+		indices        string
+		expectedStatus int
+		expectedPages  int
+		expectedInBody []string
+	}{
+		{name: "Posiciones salteadas", indices: "1,3", expectedStatus: http.StatusOK, expectedPages: 1 + 3},
+		{name: "La posición sigue el orden numérico", indices: "3", expectedStatus: http.StatusOK, expectedPages: 3},
+		{name: "Se une en el orden de la carpeta sin repetir", indices: "2, 1,2", expectedStatus: http.StatusOK, expectedPages: 1 + 2},
+		{name: "Fuera de rango nombra las posiciones", indices: "0,2,4", expectedStatus: http.StatusBadRequest, expectedInBody: []string{"0, 4"}},
+		{name: "Índice no numérico", indices: "1,dos", expectedStatus: http.StatusBadRequest, expectedInBody: []string{"dos"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange: cada archivo tiene tantas páginas como su posición
+			userPath := t.TempDir()
+			folderPath := filepath.Join(userPath, "test-folder")
+			os.MkdirAll(folderPath, os.ModePerm)
+			writeTestPDF(t, filepath.Join(folderPath, "10-c.pdf"), 3)
+			writeTestPDF(t, filepath.Join(folderPath, "1-a.pdf"), 1)
+			writeTestPDF(t, filepath.Join(folderPath, "2-b.pdf"), 2)
+			srv := newTestServer(userPath)
+			req, rr := newGenerateRequest(url.Values{"folder": {"test-folder"}, "indices": {tt.indices}})
+
+			// Act
+			srv.GenerateHandler(rr, req)
+
+			// Assert
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("expected %d, got %d: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+			}
+			for _, s := range tt.expectedInBody {
+				if !strings.Contains(rr.Body.String(), s) {
+					t.Errorf("expected %q in the error, got %s", s, rr.Body.String())
+				}
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+			var resp GenerateResponse
+			json.NewDecoder(rr.Body).Decode(&resp)
+			pages, err := api.PageCountFile(filepath.Join(userPath, resp.Output))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if pages != tt.expectedPages {
+				t.Errorf("expected %d pages, got %d", tt.expectedPages, pages)
+			}
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package pdf
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// matchesFilter indica si el nombre coincide con el filtro de listado.
+// Sin "*" es una búsqueda de subcadena; con "*" se usa filepath.Match como glob.
+// En ambos casos no se distingue entre mayúsculas y minúsculas.
+func matchesFilter(name, filter string) bool {
+	if filter == "" {
+		return true
+	}
+	name, filter = strings.ToLower(name), strings.ToLower(filter)
+	if strings.Contains(filter, "*") {
+		matched, err := filepath.Match(filter, name)
+		return err == nil && matched
+	}
+	return strings.Contains(name, filter)
+}
+
+// validFilter rechaza patrones glob mal formados (ej: "[a-")
+func validFilter(filter string) bool {
+	if !strings.Contains(filter, "*") {
+		return true
+	}
+	_, err := filepath.Match(filter, "")
+	return err == nil
+}
@@ -0,0 +1,229 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+// Banderas de anotación (campo /F) que indican que no se dibuja en pantalla
+const (
+	annotFlagHidden = 1 << 1
+	annotFlagNoView = 1 << 5
+)
+
+// flattenSources aplana cada PDF de files y devuelve la lista a unir: los que tenían
+// formularios o anotaciones se reemplazan por su copia aplanada en tmpDir, con el
+// mismo nombre para que los marcadores no cambien. flattened indica si alguno cambió.
+func flattenSources(tmpDir string, files []string) (result []string, flattened bool, err error) {
+	flatDir := filepath.Join(tmpDir, "flat")
+	result = make([]string, len(files))
+	for i, file := range files {
+		outPath := filepath.Join(flatDir, filepath.Base(file))
+		changed, err := flattenPDF(file, outPath)
+		if err != nil {
+			return nil, false, fmt.Errorf("error al aplanar %s: %w", filepath.Base(file), err)
+		}
+		result[i] = file
+		if changed {
+			result[i] = outPath
+			flattened = true
+		}
+	}
+	return result, flattened, nil
+}
+
+// flattenPDF dibuja la apariencia de cada anotación (incluidos los campos de formulario)
+// en el contenido de su página y elimina las anotaciones y el formulario, dejando un
+// documento estático. Los enlaces (/Subtype /Link) no tienen nada que dibujar y se
+// conservan para que sigan funcionando. Solo escribe outPath si había algo que aplanar.
+func flattenPDF(inPath, outPath string) (bool, error) {
+	ctx, err := api.ReadContextFile(inPath)
+	if err != nil {
+		return false, err
+	}
+
+	rootDict, err := ctx.Catalog()
+	if err != nil {
+		return false, err
+	}
+	_, changed := rootDict.Find("AcroForm")
+	rootDict.Delete("AcroForm")
+
+	for pageNr := 1; pageNr <= ctx.PageCount; pageNr++ {
+		pageDict, _, inhPAttrs, err := ctx.PageDict(pageNr, false)
+		if err != nil {
+			return false, err
+		}
+		if _, found := pageDict.Find("Annots"); !found {
+			continue
+		}
+		annots, err := ctx.DereferenceArray(pageDict["Annots"])
+		if err != nil {
+			return false, err
+		}
+		links, others := splitLinkAnnots(ctx, annots)
+		if len(others) == 0 {
+			continue
+		}
+		changed = true
+		if len(links) > 0 {
+			pageDict.Update("Annots", links)
+		} else {
+			pageDict.Delete("Annots")
+		}
+		if err := drawAppearances(ctx, pageDict, inhPAttrs, others); err != nil {
+			return false, err
+		}
+	}
+
+	if !changed {
+		return false, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(outPath), os.ModePerm); err != nil {
+		return false, err
+	}
+	return true, api.WriteContextFile(ctx, outPath)
+}
+
+// splitLinkAnnots separa los enlaces, que se conservan, del resto de las anotaciones
+func splitLinkAnnots(ctx *model.Context, annots types.Array) (links, others types.Array) {
+	for _, obj := range annots {
+		annot, err := ctx.DereferenceDict(obj)
+		if err == nil && annot != nil {
+			if subtype := annot.NameEntry("Subtype"); subtype != nil && *subtype == "Link" {
+				links = append(links, obj)
+				continue
+			}
+		}
+		others = append(others, obj)
+	}
+	return links, others
+}
+
+// drawAppearances agrega al final del contenido de la página la apariencia normal de
+// cada anotación visible, como XObject colocado sobre su rectángulo
+func drawAppearances(ctx *model.Context, pageDict types.Dict, inhPAttrs *model.InheritedPageAttrs, annots types.Array) error {
+	xobjects := types.Dict{}
+	var content bytes.Buffer
+	for i, obj := range annots {
+		annot, err := ctx.DereferenceDict(obj)
+		if err != nil || annot == nil {
+			continue // Una anotación dañada simplemente no se dibuja
+		}
+		if flags := annot.IntEntry("F"); flags != nil && *flags&(annotFlagHidden|annotFlagNoView) != 0 {
+			continue
+		}
+		apRef, ok := appearanceRef(ctx, annot)
+		if !ok {
+			continue
+		}
+		cm, ok := appearanceMatrix(ctx, annot, apRef)
+		if !ok {
+			continue
+		}
+		name := fmt.Sprintf("Flat%d", i)
+		xobjects[name] = apRef
+		fmt.Fprintf(&content, "q %s cm /%s Do Q\n", cm, name)
+	}
+	if len(xobjects) == 0 {
+		return nil
+	}
+
+	// Copiar los recursos (propios o heredados) para no modificar los de otras páginas
+	resources := types.Dict{}
+	if own, err := ctx.DereferenceDict(pageDict["Resources"]); err == nil && own != nil {
+		resources = own.Clone().(types.Dict)
+	} else if inhPAttrs != nil && inhPAttrs.Resources != nil {
+		resources = inhPAttrs.Resources.Clone().(types.Dict)
+	}
+	pageXObjects := types.Dict{}
+	if existing, err := ctx.DereferenceDict(resources["XObject"]); err == nil && existing != nil {
+		pageXObjects = existing.Clone().(types.Dict)
+	}
+	for name, ref := range xobjects {
+		pageXObjects[name] = ref
+	}
+	resources["XObject"] = pageXObjects
+	pageDict["Resources"] = resources
+
+	return ctx.AppendContent(pageDict, content.Bytes())
+}
+
+// appearanceRef devuelve el stream de la apariencia normal (/AP /N); en casillas y
+// botones de opción /N tiene un stream por estado y se usa el de /AS
+func appearanceRef(ctx *model.Context, annot types.Dict) (types.IndirectRef, bool) {
+	ap, err := ctx.DereferenceDict(annot["AP"])
+	if err != nil || ap == nil {
+		return types.IndirectRef{}, false
+	}
+	normal := ap["N"]
+	if states, err := ctx.DereferenceDict(normal); err == nil && states != nil {
+		state := annot.NameEntry("AS")
+		if state == nil {
+			return types.IndirectRef{}, false
+		}
+		normal = states[*state]
+	}
+	ref, ok := normal.(types.IndirectRef)
+	return ref, ok
+}
+
+// appearanceMatrix calcula la matriz que lleva el BBox de la apariencia (transformado
+// por su /Matrix) al /Rect de la anotación, como hace un visor al dibujarla
+func appearanceMatrix(ctx *model.Context, annot types.Dict, apRef types.IndirectRef) (string, bool) {
+	rectArr, err := ctx.DereferenceArray(annot["Rect"])
+	if err != nil || len(rectArr) != 4 {
+		return "", false
+	}
+	rect, err := ctx.RectForArray(rectArr)
+	if err != nil {
+		return "", false
+	}
+	sd, _, err := ctx.DereferenceStreamDict(apRef)
+	if err != nil || sd == nil {
+		return "", false
+	}
+	bboxArr, err := ctx.DereferenceArray(sd.Dict["BBox"])
+	if err != nil || len(bboxArr) != 4 {
+		return "", false
+	}
+	bbox, err := ctx.RectForArray(bboxArr)
+	if err != nil {
+		return "", false
+	}
+
+	m := [6]float64{1, 0, 0, 1, 0, 0}
+	if arr, err := ctx.DereferenceArray(sd.Dict["Matrix"]); err == nil && len(arr) == 6 {
+		for i, v := range arr {
+			n, err := ctx.DereferenceNumber(v)
+			if err != nil {
+				return "", false
+			}
+			m[i] = n
+		}
+	}
+
+	// Caja del BBox tras aplicar /Matrix
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, p := range [][2]float64{{bbox.LL.X, bbox.LL.Y}, {bbox.UR.X, bbox.LL.Y}, {bbox.LL.X, bbox.UR.Y}, {bbox.UR.X, bbox.UR.Y}} {
+		x := m[0]*p[0] + m[2]*p[1] + m[4]
+		y := m[1]*p[0] + m[3]*p[1] + m[5]
+		minX, maxX = math.Min(minX, x), math.Max(maxX, x)
+		minY, maxY = math.Min(minY, y), math.Max(maxY, y)
+	}
+	if maxX-minX == 0 || maxY-minY == 0 {
+		return "", false
+	}
+
+	sx := rect.Width() / (maxX - minX)
+	sy := rect.Height() / (maxY - minY)
+	return fmt.Sprintf("%.4f 0 0 %.4f %.4f %.4f", sx, sy, rect.LL.X-minX*sx, rect.LL.Y-minY*sy), true
+}
@@ -0,0 +1,220 @@
+package pdf
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+)
+
+// writeFormPDF escribe un PDF de una página con un campo de texto "nombre" cuya
+// apariencia es un rectángulo relleno
+func writeFormPDF(t *testing.T, path string) {
+	t.Helper()
+
+	appearance := "0 0 1 rg 0 0 100 20 re f"
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R /AcroForm << /Fields [4 0 R] >> >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 200 200] /Annots [4 0 R] >>",
+		"<< /Type /Annot /Subtype /Widget /FT /Tx /T (nombre) /DA (/Helv 12 Tf 0 g) /Rect [50 50 150 70] /P 3 0 R /AP << /N 5 0 R >> >>",
+		fmt.Sprintf("<< /Type /XObject /Subtype /Form /BBox [0 0 100 20] /Length %d >>\nstream\n%s\nendstream", len(appearance), appearance),
+	}
+
+	writeRawPDF(t, path, objects)
+}
+
+func TestFlattenPDF(t *testing.T) {
+	// Arrange
+	dir := t.TempDir()
+	src := filepath.Join(dir, "form.pdf")
+	out := filepath.Join(dir, "flat.pdf")
+	writeFormPDF(t, src)
+
+	// Act
+	changed, err := flattenPDF(src, out)
+
+	// Assert
+	if err != nil || !changed {
+		t.Fatalf("expected the form to be flattened, got %v, %v", changed, err)
+	}
+	if err := api.ValidateFile(out, nil); err != nil {
+		t.Fatalf("flattened output is not a valid PDF: %v", err)
+	}
+	ctx, err := api.ReadContextFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rootDict, _ := ctx.Catalog()
+	if _, found := rootDict.Find("AcroForm"); found {
+		t.Errorf("expected the AcroForm to be removed")
+	}
+	pageDict, _, _, err := ctx.PageDict(1, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, found := pageDict.Find("Annots"); found {
+		t.Errorf("expected the annotations to be removed")
+	}
+	content, err := ctx.PageContent(pageDict)
+	if err != nil || !bytes.Contains(content, []byte("/Flat0 Do")) {
+		t.Errorf("expected the field appearance to be drawn in the page content, got %q (%v)", content, err)
+	}
+}
+
+// writeLinkPDF escribe un PDF de una página con un enlace y, con withField, además el
+// campo de texto de writeFormPDF
+func writeLinkPDF(t *testing.T, path string, withField bool) {
+	t.Helper()
+
+	link := "<< /Type /Annot /Subtype /Link /Rect [10 10 40 30] /Border [0 0 0] /A << /S /URI /URI (https://example.com) >> >>"
+	if !withField {
+		writeRawPDF(t, path, []string{
+			"<< /Type /Catalog /Pages 2 0 R >>",
+			"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+			"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 200 200] /Annots [4 0 R] >>",
+			link,
+		})
+		return
+	}
+	appearance := "0 0 1 rg 0 0 100 20 re f"
+	writeRawPDF(t, path, []string{
+		"<< /Type /Catalog /Pages 2 0 R /AcroForm << /Fields [4 0 R] >> >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 200 200] /Annots [4 0 R 6 0 R] >>",
+		"<< /Type /Annot /Subtype /Widget /FT /Tx /T (nombre) /DA (/Helv 12 Tf 0 g) /Rect [50 50 150 70] /P 3 0 R /AP << /N 5 0 R >> >>",
+		fmt.Sprintf("<< /Type /XObject /Subtype /Form /BBox [0 0 100 20] /Length %d >>\nstream\n%s\nendstream", len(appearance), appearance),
+		link,
+	})
+}
+
+func TestFlattenPDFKeepsLinks(t *testing.T) {
+	tests := []struct {
+		name            string
+		withField       bool
+		expectedChanged bool
+	}{
+		{name: "Se aplana el campo y se conserva el enlace", withField: true, expectedChanged: true},
+		{name: "Una página con solo enlaces no cambia", withField: false, expectedChanged: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			dir := t.TempDir()
+			src := filepath.Join(dir, "links.pdf")
+			out := filepath.Join(dir, "flat.pdf")
+			writeLinkPDF(t, src, tt.withField)
+
+			// Act
+			changed, err := flattenPDF(src, out)
+
+			// Assert
+			if err != nil || changed != tt.expectedChanged {
+				t.Fatalf("expected changed=%v, got %v, %v", tt.expectedChanged, changed, err)
+			}
+			if !changed {
+				return
+			}
+			ctx, err := api.ReadContextFile(out)
+			if err != nil {
+				t.Fatal(err)
+			}
+			pageDict, _, _, err := ctx.PageDict(1, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			annots, err := ctx.DereferenceArray(pageDict["Annots"])
+			if err != nil || len(annots) != 1 {
+				t.Fatalf("expected only the link to remain, got %v (%v)", annots, err)
+			}
+			annot, err := ctx.DereferenceDict(annots[0])
+			if err != nil || annot.NameEntry("Subtype") == nil || *annot.NameEntry("Subtype") != "Link" {
+				t.Errorf("expected the remaining annotation to be the link, got %v (%v)", annot, err)
+			}
+		})
+	}
+}
+
+func TestFlattenPDFWithoutForms(t *testing.T) {
+	// Arrange
+	dir := t.TempDir()
+	src := filepath.Join(dir, "plain.pdf")
+	out := filepath.Join(dir, "flat.pdf")
+	writeTestPDF(t, src, 1)
+
+	// Act
+	changed, err := flattenPDF(src, out)
+
+	// Assert: sin nada que aplanar no se escribe ninguna copia
+	if err != nil || changed {
+		t.Fatalf("expected nothing to flatten, got %v, %v", changed, err)
+	}
+	if _, err := os.Stat(out); !os.IsNotExist(err) {
+		t.Errorf("expected no output file")
+	}
+}
+
+func TestGenerateHandlerFlatten(t *testing.T) {
+	tests := []struct {
+		name              string
+		flatten           string
+		withForm          bool
+		expectedFlattened *bool
+	}{
+		{name: "Por defecto no se aplana", flatten: "", withForm: true, expectedFlattened: nil},
+		{name: "Dos formularios con el mismo campo", flatten: "true", withForm: true, expectedFlattened: boolPtr(true)},
+		{name: "Sin formularios no hay nada que aplanar", flatten: "true", withForm: false, expectedFlattened: boolPtr(false)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			userPath := t.TempDir()
+			folderPath := filepath.Join(userPath, "test-folder")
+			os.MkdirAll(folderPath, os.ModePerm)
+			for _, name := range []string{"1-a.pdf", "2-b.pdf"} {
+				if tt.withForm {
+					writeFormPDF(t, filepath.Join(folderPath, name))
+				} else {
+					writeTestPDF(t, filepath.Join(folderPath, name), 1)
+				}
+			}
+			srv := newTestServer(userPath)
+			req, rr := newGenerateRequest(url.Values{"folder": {"test-folder"}, "flatten": {tt.flatten}})
+
+			// Act
+			srv.GenerateHandler(rr, req)
+
+			// Assert
+			if rr.Code != http.StatusOK {
+				t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+			}
+			var body GenerateResponse
+			json.NewDecoder(rr.Body).Decode(&body)
+			if (body.Flattened == nil) != (tt.expectedFlattened == nil) ||
+				(body.Flattened != nil && *body.Flattened != *tt.expectedFlattened) {
+				t.Errorf("expected flattened %v, got %v", tt.expectedFlattened, body.Flattened)
+			}
+			if tt.flatten != "true" {
+				return
+			}
+			ctx, err := api.ReadContextFile(filepath.Join(userPath, "test-folder.pdf"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			rootDict, _ := ctx.Catalog()
+			if _, found := rootDict.Find("AcroForm"); found {
+				t.Errorf("expected the merged output to have no form")
+			}
+		})
+	}
+}
+
+func boolPtr(b bool) *bool { return &b }
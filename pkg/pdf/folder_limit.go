@@ -0,0 +1,24 @@
+package pdf
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// Máximo de PDFs por carpeta (MAX_FILES_PER_FOLDER); 0 o sin definir significa sin límite
+var maxFilesPerFolder = defaultConfig.MaxFilesPerFolder
+
+// folderLimitExceeded responde 413 si agregar incoming archivos a una carpeta con current
+// supera el máximo. Devuelve true si ya respondió.
+func folderLimitExceeded(w http.ResponseWriter, current, incoming int) bool {
+	if maxFilesPerFolder <= 0 || current+incoming <= maxFilesPerFolder {
+		return false
+	}
+	msg := fmt.Sprintf("La carpeta tiene %d archivos y el máximo es %d; no se pueden agregar %d más", current, maxFilesPerFolder, incoming)
+	writeJSON(w, http.StatusRequestEntityTooLarge, FolderLimitResponse{
+		ErrorResponse: ErrorResponse{Error: msg, Status: http.StatusRequestEntityTooLarge},
+		Count:         current,
+		Limit:         maxFilesPerFolder,
+	})
+	return true
+}
@@ -0,0 +1,94 @@
+package pdf
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// --- Bloqueos por carpeta ---
+// Subir, unir y borrar sobre la misma carpeta a la vez puede producir uniones a medias
+// (p. ej. una unión leyendo archivos que otro handler está borrando). Cada carpeta de
+// cada usuario tiene un RWMutex: los handlers que modifican archivos toman el bloqueo
+// exclusivo mientras trabajan en disco y los de solo lectura el compartido.
+// Las entradas se eliminan del registro cuando nadie las usa.
+type folderLock struct {
+	mu   sync.RWMutex
+	refs int
+}
+
+var (
+	folderLocks      = map[string]*folderLock{}
+	folderLocksMutex sync.Mutex
+)
+
+// folderLockKey identifica la carpeta por usuario y nombre; la ruta del usuario ya incluye su código
+func folderLockKey(userStoragePath, folder string) string {
+	return filepath.Join(userStoragePath, filepath.Clean(folder))
+}
+
+func acquireFolderLock(key string) *folderLock {
+	folderLocksMutex.Lock()
+	defer folderLocksMutex.Unlock()
+	lock, ok := folderLocks[key]
+	if !ok {
+		lock = &folderLock{}
+		folderLocks[key] = lock
+	}
+	lock.refs++
+	return lock
+}
+
+func releaseFolderLock(key string, lock *folderLock) {
+	folderLocksMutex.Lock()
+	defer folderLocksMutex.Unlock()
+	lock.refs--
+	if lock.refs == 0 {
+		delete(folderLocks, key)
+	}
+}
+
+// lockFolder toma el bloqueo exclusivo de la carpeta; la función devuelta lo libera
+func lockFolder(userStoragePath, folder string) (unlock func()) {
+	key := folderLockKey(userStoragePath, folder)
+	lock := acquireFolderLock(key)
+	lock.mu.Lock()
+	return func() {
+		lock.mu.Unlock()
+		releaseFolderLock(key, lock)
+	}
+}
+
+// rLockFolder toma el bloqueo compartido de la carpeta; la función devuelta lo libera
+func rLockFolder(userStoragePath, folder string) (unlock func()) {
+	key := folderLockKey(userStoragePath, folder)
+	lock := acquireFolderLock(key)
+	lock.mu.RLock()
+	return func() {
+		lock.mu.RUnlock()
+		releaseFolderLock(key, lock)
+	}
+}
+
+// lockFolders toma el bloqueo exclusivo de varias carpetas a la vez, siempre en el mismo
+// orden para que dos peticiones que bloquean las mismas carpetas no se esperen mutuamente
+func lockFolders(userStoragePath string, folders ...string) (unlock func()) {
+	sorted := slices.Clone(folders)
+	slices.SortFunc(sorted, func(a, b string) int {
+		return strings.Compare(folderLockKey(userStoragePath, a), folderLockKey(userStoragePath, b))
+	})
+	sorted = slices.CompactFunc(sorted, func(a, b string) bool {
+		return folderLockKey(userStoragePath, a) == folderLockKey(userStoragePath, b)
+	})
+
+	unlocks := make([]func(), len(sorted))
+	for i, folder := range sorted {
+		unlocks[i] = lockFolder(userStoragePath, folder)
+	}
+	return func() {
+		for i := len(unlocks) - 1; i >= 0; i-- {
+			unlocks[i]()
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package pdf
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+)
+
+func TestFolderLockExcludesWriters(t *testing.T) {
+	// Arrange
+	unlock := lockFolder("/storage/user", "docs")
+
+	acquired := make(chan struct{})
+	go func() {
+		unlockOther := rLockFolder("/storage/user", "docs/")
+		close(acquired)
+		unlockOther()
+	}()
+
+	// Assert: el lector espera mientras el escritor tiene el bloqueo
+	select {
+	case <-acquired:
+		t.Fatal("expected the read lock to wait for the exclusive lock")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	unlock()
+	<-acquired
+
+	// Otra carpeta del mismo usuario no se bloquea
+	unlockA := lockFolder("/storage/user", "a")
+	unlockB := lockFolder("/storage/user", "b")
+	unlockA()
+	unlockB()
+
+	folderLocksMutex.Lock()
+	defer folderLocksMutex.Unlock()
+	if len(folderLocks) != 0 {
+		t.Errorf("expected unused locks to be removed, got %d", len(folderLocks))
+	}
+}
+
+func TestLockFoldersInAnyOrder(t *testing.T) {
+	// Arrange: dos peticiones bloquean las mismas carpetas en orden inverso
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			unlock := lockFolders("/storage/user", "a", "b")
+			unlock()
+		}
+	}()
+
+	// Act
+	for i := 0; i < 100; i++ {
+		unlock := lockFolders("/storage/user", "b", "a", "b/")
+		unlock()
+	}
+
+	// Assert: sin interbloqueo y sin bloqueos huérfanos
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected lockFolders not to deadlock")
+	}
+	folderLocksMutex.Lock()
+	defer folderLocksMutex.Unlock()
+	if len(folderLocks) != 0 {
+		t.Errorf("expected unused locks to be removed, got %d", len(folderLocks))
+	}
+}
+
+func TestConcurrentDeleteAndGenerate(t *testing.T) {
+	for i := 0; i < 10; i++ {
+		// Arrange
+		userPath := t.TempDir()
+		folderPath := filepath.Join(userPath, "test-folder")
+		os.MkdirAll(folderPath, os.ModePerm)
+		for n := 1; n <= 3; n++ {
+			writeTestPDF(t, filepath.Join(folderPath, prefixedName("doc.pdf", n)), 2)
+		}
+
+		srv := newTestServer(userPath)
+
+		genReq, genRR := newGenerateRequest(url.Values{"folder": {"test-folder"}})
+		mother := &DeleteTestMother{}
+		delReq, delRR := mother.CreateValidRequest(http.MethodDelete, "test-folder", nil), mother.CreateValidResponse()
+
+		// Act
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() { defer wg.Done(); srv.GenerateHandler(genRR, genReq) }()
+		go func() { defer wg.Done(); srv.DeleteFilesHandler(delRR, delReq) }()
+		wg.Wait()
+
+		// Assert: la unión ve la carpeta completa o vacía, nunca a medias
+		if delRR.Code != http.StatusOK {
+			t.Fatalf("delete failed: %d %s", delRR.Code, delRR.Body.String())
+		}
+		switch genRR.Code {
+		case http.StatusOK:
+			pages, err := api.PageCountFile(filepath.Join(userPath, "test-folder.pdf"))
+			if err != nil || pages != 6 {
+				t.Fatalf("expected a complete 6 page merge, got %d pages (err: %v)", pages, err)
+			}
+		case http.StatusBadRequest:
+			// El borrado terminó antes: la carpeta estaba vacía
+		default:
+			t.Fatalf("unexpected generate status %d: %s", genRR.Code, genRR.Body.String())
+		}
+	}
+}
+
+func TestConcurrentUploadsGetDistinctPrefixes(t *testing.T) {
+	const uploads = 8
+	// Archivos grandes alargan el tiempo entre leer el contador y guardar
+	content := append([]byte("%PDF-1.4\n"), make([]byte, 1<<20)...)
+	for i := 0; i < 10; i++ {
+		// Arrange
+		userPath := t.TempDir()
+		srv := newTestServer(userPath)
+		requests := make([]*http.Request, uploads)
+		recorders := make([]*httptest.ResponseRecorder, uploads)
+		for n := range requests {
+			requests[n], recorders[n] = NewUploadRequestBuilder().WithFolder("test-folder").WithFile("doc.pdf", content).Build(t)
+		}
+
+		// Act
+		var wg sync.WaitGroup
+		wg.Add(uploads)
+		for n := range requests {
+			go func() { defer wg.Done(); srv.UploadHandler(recorders[n], requests[n]) }()
+		}
+		wg.Wait()
+
+		// Assert: cada subida lee el contador después de que la anterior guardó su archivo
+		saved := map[string]bool{}
+		for _, rr := range recorders {
+			if rr.Code != http.StatusOK {
+				t.Fatalf("upload failed: %d %s", rr.Code, rr.Body.String())
+			}
+			var result UploadResult
+			if err := json.NewDecoder(rr.Body).Decode(&result); err != nil {
+				t.Fatal(err)
+			}
+			for _, name := range result.Saved {
+				if saved[name] {
+					t.Fatalf("two uploads were saved as %s", name)
+				}
+				saved[name] = true
+			}
+		}
+		files, err := ListFilesWithExtension(filepath.Join(userPath, "test-folder"), ".pdf")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(files) != uploads || len(saved) != uploads {
+			t.Fatalf("expected %d files, got %v on disk and %d reported", uploads, files, len(saved))
+		}
+		for n := 1; n <= uploads; n++ {
+			if name := prefixedName("doc.pdf", n); !saved[name] {
+				t.Errorf("expected %s to be saved, got %v", name, files)
+			}
+		}
+	}
+}
+
+func TestGenerateLocksCustomOutputFolder(t *testing.T) {
+	// Arrange: otra petición tiene bloqueada la carpeta "otra", dueña de otra.pdf
+	userPath := t.TempDir()
+	os.MkdirAll(filepath.Join(userPath, "test-folder"), os.ModePerm)
+	writeTestPDF(t, filepath.Join(userPath, "test-folder", "1-a.pdf"), 1)
+	srv := newTestServer(userPath)
+	req, rr := newGenerateRequest(url.Values{"folder": {"test-folder"}, "output": {"otra"}})
+	unlock := lockFolder(userPath, "otra")
+
+	// Act
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		srv.GenerateHandler(rr, req)
+	}()
+
+	// Assert: la salida no se escribe hasta que se libera la carpeta dueña
+	select {
+	case <-done:
+		unlock()
+		t.Fatal("expected generate to wait for the output folder lock")
+	case <-time.After(100 * time.Millisecond):
+	}
+	unlock()
+	<-done
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if _, err := os.Stat(filepath.Join(userPath, "otra.pdf")); err != nil {
+		t.Errorf("expected the custom output to be written: %v", err)
+	}
+}
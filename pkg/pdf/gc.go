@@ -0,0 +1,177 @@
+package pdf
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// --- Limpieza de salidas huérfanas ---
+// Borrar una carpeta no borra su PDF unido, que queda ocupando espacio sin que nada lo
+// vuelva a generar. La carpeta de origen de cada salida se conoce por el manifiesto que
+// joinPDFs guarda junto a ella (ver incremental.go).
+//
+// Las salidas anteriores a ese manifiesto no lo tienen: para ellas se aplica la regla de
+// siempre, "<nombre>.pdf" es la unión de la carpeta "<nombre>". Con esa regla no se pueden
+// distinguir de las de /merge-folders y /merge-urls, que tampoco tienen manifiesto, así que
+// se informan aparte como no verificadas y solo se borran con include_unverified=true.
+
+// gcOptions opciones de una pasada de limpieza
+type gcOptions struct {
+	DryRun            bool // Solo informar, no borrar nada
+	IncludeUnverified bool // Borrar también las salidas sin manifiesto
+}
+
+// AdminGCHandler: Borra en todos los usuarios los PDFs unidos cuya carpeta de origen ya no
+// existe, junto con sus manifiestos. Con dry_run=true solo informa lo que borraría y con
+// include_unverified=true borra también las salidas sin manifiesto.
+func (s *Server) AdminGCHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w, http.MethodPost)
+		return
+	}
+	opts := gcOptions{
+		DryRun:            r.FormValue("dry_run") == "true",
+		IncludeUnverified: r.FormValue("include_unverified") == "true",
+	}
+
+	report, err := collectOrphanOutputs(s.cfg.StorageRoot, opts, s.userLabel)
+	if err != nil {
+		logf(r.Context(), "Error en la limpieza de salidas huérfanas: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "Error al limpiar las salidas huérfanas")
+		return
+	}
+	if !opts.DryRun {
+		logf(r.Context(), "Limpieza de salidas huérfanas: %d archivos, %d bytes", len(report.Outputs), report.Bytes)
+	}
+
+	writeJSON(w, http.StatusOK, report)
+}
+
+// collectOrphanOutputs recorre los usuarios de root y borra (o solo informa, con DryRun)
+// sus salidas huérfanas. Si la raíz todavía no existe devuelve un informe vacío.
+// label convierte el nombre de cada carpeta de usuario (su código) en lo que se informa.
+func collectOrphanOutputs(root string, opts gcOptions, label func(code string) string) (GCReport, error) {
+	report := GCReport{DryRun: opts.DryRun, Outputs: []OrphanOutput{}, Unverified: []OrphanOutput{}}
+
+	entries, err := os.ReadDir(root)
+	if os.IsNotExist(err) {
+		return report, nil
+	}
+	if err != nil {
+		return report, err
+	}
+
+	// os.ReadDir ya devuelve las entradas ordenadas por nombre
+	var errs []error
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		orphans, err := userOrphanOutputs(filepath.Join(root, entry.Name()), opts)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", label(entry.Name()), err))
+		}
+		for _, orphan := range orphans {
+			orphan.User = label(entry.Name())
+			if orphan.Unverified && !opts.IncludeUnverified {
+				report.Unverified = append(report.Unverified, orphan)
+				continue
+			}
+			report.Outputs = append(report.Outputs, orphan)
+			report.Bytes += orphan.Bytes
+		}
+	}
+	return report, errors.Join(errs...)
+}
+
+// userOrphanOutputs busca las salidas huérfanas de un usuario en su carpeta de salidas
+// y borra las que opts permite. Sigue con las demás si una no se puede borrar.
+func userOrphanOutputs(userPath string, opts gcOptions) ([]OrphanOutput, error) {
+	entries, err := os.ReadDir(mergeOutputPath(userPath, ""))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var orphans []OrphanOutput
+	var errs []error
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.EqualFold(filepath.Ext(entry.Name()), ".pdf") {
+			continue
+		}
+		orphan, ok, err := removeOrphanOutput(userPath, entry.Name(), opts)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", entry.Name(), err))
+			continue
+		}
+		if ok {
+			orphans = append(orphans, orphan)
+		}
+	}
+	return orphans, errors.Join(errs...)
+}
+
+// removeOrphanOutput borra la salida outputName si su carpeta de origen no existe.
+// Con el lock de la carpeta tomado, una unión que la vuelva a crear no puede
+// escribir la salida mientras se comprueba y se borra.
+func removeOrphanOutput(userPath, outputName string, opts gcOptions) (OrphanOutput, bool, error) {
+	outputPath := mergeOutputPath(userPath, outputName)
+	folder, unverified, ok := mergeSourceFolder(outputPath)
+	if !ok {
+		return OrphanOutput{}, false, nil
+	}
+
+	unlock := lockFolder(userPath, folder)
+	defer unlock()
+
+	if _, err := os.Stat(filepath.Join(userPath, folder)); !os.IsNotExist(err) {
+		return OrphanOutput{}, false, nil
+	}
+	info, err := os.Stat(outputPath)
+	if os.IsNotExist(err) {
+		return OrphanOutput{}, false, nil
+	}
+	if err != nil {
+		return OrphanOutput{}, false, err
+	}
+	orphan := OrphanOutput{Output: outputName, Folder: folder, Bytes: info.Size(), Unverified: unverified}
+	if opts.DryRun || (unverified && !opts.IncludeUnverified) {
+		return orphan, true, nil
+	}
+	if err := os.Remove(outputPath); err != nil && !os.IsNotExist(err) {
+		return OrphanOutput{}, false, err
+	}
+	// Sin la salida los manifiestos ya no describen nada
+	os.Remove(mergeManifestPath(outputPath))
+	discardOutputManifest(outputPath)
+	return orphan, true, nil
+}
+
+// mergeSourceFolder devuelve la carpeta de origen de la salida outputPath. Con manifiesto
+// es la registrada en él; false si no se puede leer o la salida se reescribió después por
+// otra vía (ej: /merge-folders con el mismo nombre). Sin manifiesto es la carpeta con el
+// nombre de la salida, marcada como no verificada.
+func mergeSourceFolder(outputPath string) (folder string, unverified bool, ok bool) {
+	manifest, err := readMergeManifest(outputPath)
+	if os.IsNotExist(err) {
+		folder := outputFolder(filepath.Base(outputPath))
+		return folder, true, validFolderName(folder)
+	}
+	if err != nil {
+		return "", false, false
+	}
+	// Un manifiesto alterado no puede apuntar fuera del espacio del usuario
+	if !validFileName(manifest.Result.Folder) {
+		return "", false, false
+	}
+	if output, err := statFile(outputPath); err != nil || output != manifest.Output {
+		return "", false, false
+	}
+	return manifest.Result.Folder, false, true
+}
@@ -0,0 +1,169 @@
+package pdf
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// setupGCRoot crea en root un usuario "alex" con una salida vigente (vigente.pdf), una
+// huérfana (borrada.pdf) y dos sin manifiesto: urls.pdf sin carpeta y antigua.pdf con ella
+func setupGCRoot(t *testing.T, root string) {
+	t.Helper()
+	userPath := filepath.Join(root, "alex")
+	for _, folder := range []string{"vigente", "borrada"} {
+		folderPath := filepath.Join(userPath, folder)
+		os.MkdirAll(folderPath, os.ModePerm)
+		writeTestPDF(t, filepath.Join(folderPath, "1-a.pdf"), 1)
+		if _, err := joinPDFs(context.Background(), userPath, folder, MergeOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	os.RemoveAll(filepath.Join(userPath, "borrada"))
+	// Como las salidas de /merge-urls: sin carpeta de origen ni manifiesto
+	writeTestPDF(t, filepath.Join(userPath, "urls.pdf"), 1)
+	// Como las uniones anteriores al manifiesto: sin él pero con su carpeta
+	os.MkdirAll(filepath.Join(userPath, "antigua"), os.ModePerm)
+	writeTestPDF(t, filepath.Join(userPath, "antigua.pdf"), 1)
+}
+
+// gcTestUser es como aparece "alex" en los informes: su nombre coincide con el código
+const gcTestUser = "sha256:4135aa9dc1b8"
+
+func TestAdminGCHandler(t *testing.T) {
+	tests := []struct {
+		name               string
+		form               url.Values
+		expectedRemoved    []string
+		expectedOutputs    []string
+		expectedUnverified []string
+	}{
+		{
+			name:               "Borra las salidas huérfanas con manifiesto",
+			form:               url.Values{},
+			expectedRemoved:    []string{"borrada.pdf"},
+			expectedOutputs:    []string{"borrada.pdf"},
+			expectedUnverified: []string{"urls.pdf"},
+		},
+		{
+			name:               "dry_run solo informa",
+			form:               url.Values{"dry_run": {"true"}},
+			expectedOutputs:    []string{"borrada.pdf"},
+			expectedUnverified: []string{"urls.pdf"},
+		},
+		{
+			name:               "include_unverified borra también las salidas sin manifiesto",
+			form:               url.Values{"include_unverified": {"true"}},
+			expectedRemoved:    []string{"borrada.pdf", "urls.pdf"},
+			expectedOutputs:    []string{"borrada.pdf", "urls.pdf"},
+			expectedUnverified: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			root := t.TempDir()
+			setupGCRoot(t, root)
+			userPath := filepath.Join(root, "alex")
+			sizes := map[string]int64{}
+			for _, name := range []string{"borrada.pdf", "urls.pdf"} {
+				sizes[name] = fileSize(t, filepath.Join(userPath, name))
+			}
+			srv := newTestServerWithRoot(root)
+			req := httptest.NewRequest(http.MethodPost, "/admin/gc", strings.NewReader(tt.form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			rr := httptest.NewRecorder()
+
+			// Act
+			srv.AdminGCHandler(rr, req)
+
+			// Assert
+			if rr.Code != http.StatusOK {
+				t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+			}
+			var report GCReport
+			if err := json.NewDecoder(rr.Body).Decode(&report); err != nil {
+				t.Fatal(err)
+			}
+			orphan := func(name string) OrphanOutput {
+				folder := strings.TrimSuffix(name, ".pdf")
+				return OrphanOutput{User: gcTestUser, Output: name, Folder: folder, Bytes: sizes[name], Unverified: name == "urls.pdf"}
+			}
+			expected := GCReport{DryRun: tt.form.Get("dry_run") == "true", Outputs: []OrphanOutput{}, Unverified: []OrphanOutput{}}
+			for _, name := range tt.expectedOutputs {
+				expected.Outputs = append(expected.Outputs, orphan(name))
+				expected.Bytes += sizes[name]
+			}
+			for _, name := range tt.expectedUnverified {
+				expected.Unverified = append(expected.Unverified, orphan(name))
+			}
+			if !reflect.DeepEqual(report, expected) {
+				t.Errorf("expected %+v, got %+v", expected, report)
+			}
+			for _, name := range []string{"vigente.pdf", "borrada.pdf", "urls.pdf", "antigua.pdf"} {
+				_, err := os.Stat(filepath.Join(userPath, name))
+				if removed := os.IsNotExist(err); removed != slices.Contains(tt.expectedRemoved, name) {
+					t.Errorf("expected removed=%v for %s", !removed, name)
+				}
+			}
+			_, err := os.Stat(mergeManifestPath(filepath.Join(userPath, "borrada.pdf")))
+			if removed := os.IsNotExist(err); removed != slices.Contains(tt.expectedRemoved, "borrada.pdf") {
+				t.Errorf("expected removed=%v for the manifest of borrada.pdf", !removed)
+			}
+		})
+	}
+}
+
+func TestAdminGCSkipsRewrittenOutputs(t *testing.T) {
+	// Arrange
+	root := t.TempDir()
+	setupGCRoot(t, root)
+	userPath := filepath.Join(root, "alex")
+	// Otra unión (ej: /merge-folders) reescribió la salida: el manifiesto ya no la describe
+	writeTestPDF(t, filepath.Join(userPath, "borrada.pdf"), 3)
+
+	// Act
+	report, err := collectOrphanOutputs(root, gcOptions{}, func(code string) string { return code })
+
+	// Assert
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Outputs) != 0 {
+		t.Errorf("expected no orphans, got %+v", report.Outputs)
+	}
+	if _, err := os.Stat(filepath.Join(userPath, "borrada.pdf")); err != nil {
+		t.Errorf("expected borrada.pdf to be kept: %v", err)
+	}
+}
+
+func TestAdminGCMissingRoot(t *testing.T) {
+	// Act
+	report, err := collectOrphanOutputs(filepath.Join(t.TempDir(), "archivos"), gcOptions{IncludeUnverified: true}, func(code string) string { return code })
+
+	// Assert
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Outputs) != 0 || report.Bytes != 0 {
+		t.Errorf("expected an empty report, got %+v", report)
+	}
+}
+
+func fileSize(t *testing.T, path string) int64 {
+	t.Helper()
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return info.Size()
+}
@@ -0,0 +1,219 @@
+package pdf
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newGenerateRequest crea una petición de formulario para /generate
+func newGenerateRequest(form url.Values) (*http.Request, *httptest.ResponseRecorder) {
+	req := httptest.NewRequest(http.MethodPost, "/generate", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req = req.WithContext(context.WithValue(req.Context(), userCodeKey, "testUser"))
+	return req, httptest.NewRecorder()
+}
+
+func TestGenerateHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		setupFiles     func(t *testing.T, folderPath string)
+		expectedStatus int
+		expectedError  string
+	}{
+		{
+			name: "Unir PDFs exitosamente",
+			setupFiles: func(t *testing.T, folderPath string) {
+				writeTestPDF(t, filepath.Join(folderPath, "1-a.pdf"), 1)
+				writeTestPDF(t, filepath.Join(folderPath, "2-b.pdf"), 2)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Carpeta sin PDFs es un error del cliente",
+			setupFiles:     func(t *testing.T, folderPath string) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  ErrNoPDFs.Error(),
+		},
+		{
+			name: "PDF corrupto es un error del servidor",
+			setupFiles: func(t *testing.T, folderPath string) {
+				os.WriteFile(filepath.Join(folderPath, "1-broken.pdf"), []byte("no es un pdf"), 0o644)
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			userPath := t.TempDir()
+			folderPath := filepath.Join(userPath, "test-folder")
+			os.MkdirAll(folderPath, os.ModePerm)
+			tt.setupFiles(t, folderPath)
+
+			srv := newTestServer(userPath)
+
+			req, rr := newGenerateRequest(url.Values{"folder": {"test-folder"}})
+
+			// Act
+			srv.GenerateHandler(rr, req)
+
+			// Assert
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("handler returned wrong status code: got %v want %v (%s)", rr.Code, tt.expectedStatus, rr.Body.String())
+			}
+			if tt.expectedError != "" {
+				var body ErrorResponse
+				if err := json.NewDecoder(rr.Body).Decode(&body); err != nil {
+					t.Fatalf("expected JSON body: %v", err)
+				}
+				if body.Error != tt.expectedError {
+					t.Errorf("expected error %q, got %q", tt.expectedError, body.Error)
+				}
+			}
+		})
+	}
+}
+
+func TestGenerateHandlerOutputName(t *testing.T) {
+	tests := []struct {
+		name           string
+		output         string
+		expectedStatus int
+		expectedFile   string
+	}{
+		{name: "Nombre por defecto", output: "", expectedStatus: http.StatusOK, expectedFile: "test-folder.pdf"},
+		{name: "Nombre propio sin extensión", output: "variante", expectedStatus: http.StatusOK, expectedFile: "variante.pdf"},
+		{name: "Nombre propio con extensión", output: "otra.pdf", expectedStatus: http.StatusOK, expectedFile: "otra.pdf"},
+		{name: "Intento de salir del espacio del usuario", output: "../fuera", expectedStatus: http.StatusBadRequest},
+		{name: "Nombre reservado", output: "..", expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			userPath := t.TempDir()
+			folderPath := filepath.Join(userPath, "test-folder")
+			os.MkdirAll(folderPath, os.ModePerm)
+			writeTestPDF(t, filepath.Join(folderPath, "1-a.pdf"), 1)
+
+			srv := newTestServer(userPath)
+
+			req, rr := newGenerateRequest(url.Values{"folder": {"test-folder"}, "output": {tt.output}})
+
+			// Act
+			srv.GenerateHandler(rr, req)
+
+			// Assert
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("handler returned wrong status code: got %v want %v (%s)", rr.Code, tt.expectedStatus, rr.Body.String())
+			}
+			if tt.expectedFile == "" {
+				return
+			}
+			var body GenerateResponse
+			if err := json.NewDecoder(rr.Body).Decode(&body); err != nil {
+				t.Fatalf("expected JSON body: %v", err)
+			}
+			if body.Output != tt.expectedFile {
+				t.Errorf("expected output %q, got %q", tt.expectedFile, body.Output)
+			}
+			if _, err := os.Stat(filepath.Join(userPath, tt.expectedFile)); err != nil {
+				t.Errorf("expected merged file %s: %v", tt.expectedFile, err)
+			}
+		})
+	}
+}
+
+func TestGenerateHandlerJSONErrors(t *testing.T) {
+	srv := NewServer(DefaultConfig(), newDefaultCodeStore())
+
+	tests := []struct {
+		name           string
+		method         string
+		form           url.Values
+		expectedStatus int
+	}{
+		{name: "Método no permitido", method: http.MethodGet, expectedStatus: http.StatusMethodNotAllowed},
+		{name: "Falta la carpeta", method: http.MethodPost, form: url.Values{}, expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			req, rr := newGenerateRequest(tt.form)
+			req.Method = tt.method
+
+			// Act
+			srv.GenerateHandler(rr, req)
+
+			// Assert
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, tt.expectedStatus)
+			}
+			if got := rr.Header().Get("Content-Type"); got != "application/json" {
+				t.Errorf("expected Content-Type application/json, got %s", got)
+			}
+			var body ErrorResponse
+			if err := json.NewDecoder(rr.Body).Decode(&body); err != nil {
+				t.Fatalf("expected JSON body: %v", err)
+			}
+			if body.Status != tt.expectedStatus || body.Error == "" {
+				t.Errorf("unexpected error body: %+v", body)
+			}
+		})
+	}
+}
+
+func TestGenerateHandlerDownloadLink(t *testing.T) {
+	tests := []struct {
+		name         string
+		output       string
+		expectedLink string
+	}{
+		{name: "Nombre por defecto", output: "", expectedLink: "/download?folder=test-folder&output=test-folder.pdf"},
+		{name: "Nombre propio", output: "variante", expectedLink: "/download?folder=test-folder&output=variante.pdf"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			userPath := t.TempDir()
+			folderPath := filepath.Join(userPath, "test-folder")
+			os.MkdirAll(folderPath, os.ModePerm)
+			writeTestPDF(t, filepath.Join(folderPath, "1-a.pdf"), 1)
+			srv := newTestServer(userPath)
+			req, rr := newGenerateRequest(url.Values{"folder": {"test-folder"}, "output": {tt.output}})
+
+			// Act
+			srv.GenerateHandler(rr, req)
+
+			// Assert
+			if rr.Code != http.StatusOK {
+				t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+			}
+			if got := rr.Header().Get("Location"); got != tt.expectedLink {
+				t.Errorf("expected Location %q, got %q", tt.expectedLink, got)
+			}
+			var body GenerateResponse
+			json.NewDecoder(rr.Body).Decode(&body)
+			if body.DownloadURL != tt.expectedLink {
+				t.Errorf("expected download_url %q, got %q", tt.expectedLink, body.DownloadURL)
+			}
+
+			// Seguir el enlace descarga el PDF generado
+			download := httptest.NewRecorder()
+			srv.DownloadHandler(download, httptest.NewRequest(http.MethodGet, body.DownloadURL, nil))
+			if download.Code != http.StatusOK {
+				t.Errorf("expected the link to download the PDF, got %d: %s", download.Code, download.Body.String())
+			}
+		})
+	}
+}
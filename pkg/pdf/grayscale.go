@@ -0,0 +1,106 @@
+package pdf
+
+import (
+	"bytes"
+	"image"
+	"image/draw"
+	_ "image/jpeg" // Decodificadores registrados para image.Decode
+	"image/png"
+	"io"
+	"os"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+)
+
+// convertToGrayscale reemplaza las imágenes embebidas del PDF por su versión en escala de grises.
+// pdfcpu no convierte el espacio de color del contenido vectorial, así que el texto y los
+// gráficos vectoriales conservan su color; para documentos escaneados (el caso habitual)
+// las páginas son imágenes y el resultado queda completamente en grises.
+// El archivo se reescribe en su lugar solo si todo salió bien.
+func convertToGrayscale(pdfPath string) (SizeChange, error) {
+	var change SizeChange
+
+	before, err := os.Stat(pdfPath)
+	if err != nil {
+		return change, err
+	}
+	change.Before = before.Size()
+
+	ctx, err := readImageContext(pdfPath, model.UPDATEIMAGES)
+	if err != nil {
+		return change, err
+	}
+
+	// Una misma imagen puede aparecer en varias páginas; basta con reemplazarla una vez
+	done := map[int]bool{}
+	for pageNr := 1; pageNr <= ctx.PageCount; pageNr++ {
+		images, err := pdfcpu.ExtractPageImages(ctx, pageNr, false)
+		if err != nil {
+			return change, err
+		}
+		for objNr, img := range images {
+			if done[objNr] || img.IsImgMask {
+				continue
+			}
+			done[objNr] = true
+
+			gray, ok := grayPNG(img)
+			if !ok {
+				continue // Formato que image.Decode no soporta: se deja igual
+			}
+			if err := pdfcpu.UpdateImagesByObjNr(ctx, bytes.NewReader(gray), objNr); err != nil {
+				return change, err
+			}
+		}
+	}
+
+	// Escribir a un temporal y renombrar, limpiando el temporal si algo falla
+	tmpPath := pdfPath + ".gray.tmp"
+	defer os.Remove(tmpPath)
+	if err := api.WriteContextFile(ctx, tmpPath); err != nil {
+		return change, err
+	}
+	if err := os.Rename(tmpPath, pdfPath); err != nil {
+		return change, err
+	}
+
+	after, err := os.Stat(pdfPath)
+	if err != nil {
+		return change, err
+	}
+	change.After = after.Size()
+	change.Saved = change.Before - change.After
+	return change, nil
+}
+
+// grayPNG decodifica la imagen y la vuelve a codificar como PNG en escala de grises
+func grayPNG(img io.Reader) ([]byte, bool) {
+	src, _, err := image.Decode(img)
+	if err != nil {
+		return nil, false
+	}
+	gray := image.NewGray(src.Bounds())
+	draw.Draw(gray, gray.Bounds(), src, src.Bounds().Min, draw.Src)
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, gray); err != nil {
+		return nil, false
+	}
+	return buf.Bytes(), true
+}
+
+// readImageContext lee el PDF con la pasada de optimización de pdfcpu, que es la que
+// indexa las imágenes de cada página; sin ella ExtractPageImages no encuentra ninguna.
+func readImageContext(pdfPath string, cmd model.CommandMode) (*model.Context, error) {
+	f, err := os.Open(pdfPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	conf := model.NewDefaultConfiguration()
+	conf.Cmd = cmd
+	return api.ReadValidateAndOptimize(f, conf)
+}
@@ -0,0 +1,81 @@
+package pdf
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+)
+
+// writeColorImagePDF crea un PDF de una página a partir de una imagen roja
+func writeColorImagePDF(t *testing.T, dir string) string {
+	t.Helper()
+
+	img := image.NewRGBA(image.Rect(0, 0, 20, 20))
+	for x := 0; x < 20; x++ {
+		for y := 0; y < 20; y++ {
+			img.Set(x, y, color.RGBA{R: 200, G: 30, B: 30, A: 255})
+		}
+	}
+	imgPath := filepath.Join(dir, "red.png")
+	f, err := os.Create(imgPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	png.Encode(f, img)
+	f.Close()
+
+	pdfPath := filepath.Join(dir, "red.pdf")
+	if err := api.ImportImagesFile([]string{imgPath}, pdfPath, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	return pdfPath
+}
+
+func TestConvertToGrayscale(t *testing.T) {
+	// Arrange
+	dir := t.TempDir()
+	pdfPath := writeColorImagePDF(t, dir)
+
+	// Act
+	change, err := convertToGrayscale(pdfPath)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if change.Before == 0 || change.After == 0 || change.Saved != change.Before-change.After {
+		t.Errorf("unexpected size change: %+v", change)
+	}
+
+	ctx, err := readImageContext(pdfPath, model.EXTRACTIMAGES)
+	if err != nil {
+		t.Fatal(err)
+	}
+	images, err := pdfcpu.ExtractPageImages(ctx, 1, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(images) == 0 {
+		t.Fatalf("expected the page image to be found")
+	}
+	for _, img := range images {
+		decoded, _, err := image.Decode(img)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, g, b, _ := decoded.At(0, 0).RGBA()
+		if r != g || g != b {
+			t.Errorf("expected a gray pixel, got r=%d g=%d b=%d", r, g, b)
+		}
+	}
+	if _, err := os.Stat(pdfPath + ".gray.tmp"); !os.IsNotExist(err) {
+		t.Errorf("temporary file must be removed")
+	}
+}
@@ -0,0 +1,67 @@
+package pdf
+
+import (
+	"compress/gzip"
+	"net/http"
+	"strings"
+)
+
+// acceptsGzip indica si el cliente acepta respuestas comprimidas con gzip
+func acceptsGzip(r *http.Request) bool {
+	for _, encoding := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(encoding), ";")
+		if strings.TrimSpace(name) == "gzip" && strings.ReplaceAll(params, " ", "") != "q=0" {
+			return true
+		}
+	}
+	return false
+}
+
+// shouldGzip decide si comprimir la descarga: el cliente debe aceptarlo, no puede ser
+// una petición por rangos (los rangos se refieren a los bytes sin comprimir) y la
+// respuesta no debe venir ya codificada. HEAD no se comprime para que Content-Length
+// informe el tamaño real del PDF.
+func shouldGzip(w http.ResponseWriter, r *http.Request) bool {
+	return r.Method != http.MethodHead && acceptsGzip(r) && r.Header.Get("Range") == "" && w.Header().Get("Content-Encoding") == ""
+}
+
+// gzipResponseWriter comprime lo que se escribe en el cuerpo. El compresor se crea con
+// la primera escritura para no emitir un cuerpo gzip vacío en respuestas sin cuerpo
+// (HEAD, 304 Not Modified).
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	gz *gzip.Writer
+}
+
+func newGzipResponseWriter(w http.ResponseWriter) *gzipResponseWriter {
+	w.Header().Set("Content-Encoding", "gzip")
+	w.Header().Add("Vary", "Accept-Encoding")
+	w.Header().Del("Content-Length") // El tamaño comprimido no se conoce de antemano
+	return &gzipResponseWriter{ResponseWriter: w}
+}
+
+func (g *gzipResponseWriter) WriteHeader(status int) {
+	if status != http.StatusOK {
+		// Errores y respuestas sin cuerpo se envían sin comprimir
+		g.Header().Del("Content-Encoding")
+	}
+	g.ResponseWriter.WriteHeader(status)
+}
+
+func (g *gzipResponseWriter) Write(b []byte) (int, error) {
+	if g.Header().Get("Content-Encoding") != "gzip" {
+		return g.ResponseWriter.Write(b)
+	}
+	if g.gz == nil {
+		g.gz = gzip.NewWriter(g.ResponseWriter)
+	}
+	return g.gz.Write(b)
+}
+
+// Close termina el flujo gzip si se llegó a escribir algo
+func (g *gzipResponseWriter) Close() error {
+	if g.gz == nil {
+		return nil
+	}
+	return g.gz.Close()
+}
@@ -1,30 +1,24 @@
 package pdf
 
 import (
+	"bytes"
 	"context" // Necesario para pasar el código de usuario en el contexto
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
+	"mime"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
 	"regexp"
 	"sort"
 	"strconv"
 	"strings"
-	"sync" // Necesario para proteger el mapa de códigos válidos
-
-	"github.com/pdfcpu/pdfcpu/pkg/api"
-)
-
-// --- Estado Global (para códigos de acceso válidos) ---
-// Usamos un mapa en memoria para almacenar los códigos válidos.
-// En un sistema de producción, esto debería ser persistente (DB, caché distribuida).
-// Usamos un Mutex para hacer el acceso al mapa seguro en entornos concurrentes.
-var (
-	validCodes = map[string]bool{"alex": true}
-	codesMutex sync.Mutex
+	"time"
 )
 
 // --- Clave de Contexto para pasar el código de usuario ---
@@ -33,119 +27,209 @@ type contextKey string
 
 const userCodeKey contextKey = "userCode"
 
+// writeJSON serializa v como JSON con el status indicado
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeJSONError responde con {"error": msg, "status": status} y el mismo código HTTP
+func writeJSONError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, ErrorResponse{Error: msg, Status: status})
+}
+
+// methodNotAllowed responde 405 con la cabecera Allow que exige HTTP: los métodos que
+// sí acepta la ruta
+func methodNotAllowed(w http.ResponseWriter, allowed ...string) {
+	w.Header().Set("Allow", strings.Join(allowed, ", "))
+	writeJSONError(w, http.StatusMethodNotAllowed, "Método no permitido")
+}
+
+// Formato esperado para la fecha de GenerateCodeHandler
+const codeDateLayout = "2006-01-02"
+
+// encodeAccessCode genera el código de acceso a partir de nombre y fecha.
+// El separador evita colisiones entre entradas distintas ("ab"+"c" vs "a"+"bc"):
+// como la fecha tiene formato fijo y no contiene "|", la división siempre es única.
+func encodeAccessCode(name, date string) string {
+	return base64.StdEncoding.EncodeToString([]byte(name + "|" + date))
+}
+
+// wantsJSON indica si el cliente pidió una respuesta JSON en la cabecera Accept
+func wantsJSON(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), "application/json")
+}
+
+// Tamaño máximo del cuerpo JSON de GenerateCodeHandler
+const maxCodeBodyBytes = 4 << 10
+
+// isJSONRequest indica si el cuerpo de la petición se declara como application/json
+func isJSONRequest(r *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return err == nil && mediaType == "application/json"
+}
+
+var (
+	errCodeFieldsRequired = errors.New("Nombre y fecha son requeridos")
+	errCodeDateFormat     = errors.New("La fecha debe tener el formato AAAA-MM-DD")
+)
+
+// newGeneratedCode valida nombre y fecha y arma el código de acceso. La fecha se
+// normaliza para que el mismo día siempre produzca el mismo código.
+func newGeneratedCode(name, date string) (GeneratedCode, error) {
+	name = strings.TrimSpace(name)
+	date = strings.TrimSpace(date)
+	if name == "" || date == "" {
+		return GeneratedCode{}, errCodeFieldsRequired
+	}
+	parsedDate, err := time.Parse(codeDateLayout, date)
+	if err != nil {
+		return GeneratedCode{}, errCodeDateFormat
+	}
+	date = parsedDate.Format(codeDateLayout)
+
+	// Combinar nombre y fecha y codificarlos a Base64
+	return GeneratedCode{Name: name, Code: encodeAccessCode(name, date), Created: time.Now()}, nil
+}
+
 // GenerateCodeHandler: Genera un nuevo código de acceso basado en nombre y fecha.
 // Este código se almacena en memoria como válido.
-func GenerateCodeHandler(w http.ResponseWriter, r *http.Request) {
+func (s *Server) GenerateCodeHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		methodNotAllowed(w, http.MethodPost)
 		return
 	}
 
-	// Parsear el formulario para obtener nombre y fecha
-	err := r.ParseForm()
+	// Nombre y fecha llegan como formulario o, para integraciones, como cuerpo JSON
+	var name, date string
+	if isJSONRequest(r) {
+		var body GenerateCodeRequest
+		r.Body = http.MaxBytesReader(w, r.Body, maxCodeBodyBytes)
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeJSONError(w, http.StatusBadRequest, "Formato JSON inválido")
+			return
+		}
+		name, date = body.Name, body.Date
+	} else {
+		// Parsear el formulario para obtener nombre y fecha
+		if err := r.ParseForm(); err != nil {
+			writeJSONError(w, http.StatusBadRequest, "Error al parsear el formulario")
+			return
+		}
+		name, date = r.FormValue("name"), r.FormValue("date")
+	}
+	generated, err := newGeneratedCode(name, date)
 	if err != nil {
-		http.Error(w, "Error al parsear el formulario", http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, "Bad Request: "+err.Error())
 		return
 	}
 
-	name := r.FormValue("name")
-	date := r.FormValue("date") // Asumimos que la fecha viene en un formato string
-
-	if name == "" || date == "" {
-		http.Error(w, "Bad Request: Nombre y fecha son requeridos", http.StatusBadRequest)
+	// Agregar el código generado al almacén de códigos válidos
+	if err := s.codes.Add(generated); err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "No se pudo guardar el código de acceso")
 		return
 	}
 
-	// 1. Combinar nombre y fecha para crear los datos a codificar
-	// Puedes usar un separador si quieres, o simplemente concatenar.
-	// Concatenar es suficiente para generar un código único basado en la combinación.
-	dataToEncode := name + date
-
-	// 2. Codificar los datos combinados a Base64
-	// Convertimos el string a []byte antes de codificar
-	code := base64.StdEncoding.EncodeToString([]byte(dataToEncode))
-
-	// 3. Agregar el código generado al mapa de códigos válidos
-	// Es crucial usar el mutex para proteger el acceso al mapa
-	codesMutex.Lock()       // Bloquear el mutex antes de escribir en el mapa
-	validCodes[code] = true // Marcar el código como válido
-	codesMutex.Unlock()     // Desbloquear el mutex después de escribir
-
-	// 4. Responder al cliente con el código generado
+	// Responder al cliente con el código generado
+	// Los clientes que piden JSON reciben también el nombre asociado y la fecha de creación
+	if wantsJSON(r) {
+		writeJSON(w, http.StatusOK, generated)
+		return
+	}
 	w.Header().Set("Content-Type", "text/plain") // Indicar que la respuesta es texto plano
 	w.WriteHeader(http.StatusOK)                 // Opcional: indicar explícitamente el status 200 OK
-	fmt.Fprintln(w, code)                        // Escribir el código en la respuesta
+	fmt.Fprintln(w, generated.Code)              // Escribir el código en la respuesta
 
 }
 
-// Si el código es válido, se establece una cookie de autenticación.
-func LoginHandler(w http.ResponseWriter, r *http.Request) {
+// Si el código es válido, se establece una cookie de autenticación y se redirige a
+// redirect (una ruta local) o a /view/pdf. Con no_redirect=true o Accept: application/json
+// se responde 200 con los datos de la cookie en lugar de redirigir.
+func (s *Server) LoginHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		methodNotAllowed(w, http.MethodPost)
 		return
 	}
 
 	// Parsear el formulario para obtener el código de acceso
 	err := r.ParseForm()
 	if err != nil {
-		http.Error(w, "Error al parsear el formulario", http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, "Error al parsear el formulario")
 		return
 	}
 
 	accessCode := r.FormValue("access_code")
 	if accessCode == "" {
-		http.Error(w, "Falta el código de acceso", http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, "Falta el código de acceso")
+		return
+	}
+	redirect := r.FormValue("redirect")
+	if redirect == "" {
+		redirect = defaultLoginRedirect
+	}
+	if !localRedirect(redirect) {
+		writeJSONError(w, http.StatusBadRequest, "redirect debe ser una ruta local (ej: /view/pdf)")
 		return
 	}
 
 	// Verificar si el código de acceso es válido (thread-safe)
-	codesMutex.Lock()
-	isValid := validCodes[accessCode]
-	codesMutex.Unlock()
+	isValid := s.codes.IsValid(accessCode)
 
 	if !isValid {
-		http.Error(w, "Código de acceso inválido", http.StatusUnauthorized)
+		writeJSONError(w, http.StatusUnauthorized, "Código de acceso inválido")
 		return
 	}
 
 	// Si el código es válido, establecer una cookie de autenticación
 	cookie := http.Cookie{
-		Name:     "auth_code", // Nombre de la cookie
-		Value:    accessCode,  // El valor es el código de acceso
-		Path:     "/",         // La cookie es válida para todas las rutas
-		HttpOnly: true,        // La cookie no es accesible desde JavaScript del cliente
-		// Secure:   true,        // Descomentar en producción con HTTPS
+		Name:     s.cfg.CookieName, // Nombre de la cookie (COOKIE_NAME, por defecto "auth_code")
+		Value:    accessCode,       // El valor es el código de acceso
+		Path:     s.cfg.CookiePath, // Ruta de la cookie (COOKIE_PATH, por defecto todas las rutas)
+		HttpOnly: true,             // La cookie no es accesible desde JavaScript del cliente
+		// Con HTTPS directo (TLS_CERT) la cookie solo viaja cifrada. Detrás de un proxy que
+		// termina TLS el servidor no lo sabe, así que ahí no se marca.
+		Secure:   s.cfg.TLSEnabled(),
 		SameSite: http.SameSiteLaxMode, // Protección básica contra CSRF
 		// Expires: time.Now().Add(24 * time.Hour), // Opcional: establecer expiración
 	}
 	http.SetCookie(w, &cookie)
-	http.Redirect(w, r, "/view/pdf", http.StatusSeeOther)
+
+	// Los clientes que no son navegadores reciben los datos de la sesión en lugar del 303
+	if r.FormValue("no_redirect") == "true" || wantsJSON(r) {
+		writeJSON(w, http.StatusOK, LoginResponse{
+			Cookie:   cookie.Name,
+			Path:     cookie.Path,
+			Secure:   cookie.Secure,
+			Redirect: redirect,
+		})
+		return
+	}
+	http.Redirect(w, r, redirect, http.StatusSeeOther)
 }
 
 // --- Middleware de Autenticación ---
 // Esta función envuelve a los handlers que requieren autenticación.
-// Verifica la cookie "auth_code" y valida el código.
+// Verifica la cookie de sesión (COOKIE_NAME, por defecto "auth_code") y valida el código.
 // Si es válido, agrega el código al contexto de la petición para que los handlers lo usen.
-func AuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
+func (s *Server) AuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// Intentar obtener la cookie de autenticación
-		cookie, err := r.Cookie("auth_code")
+		cookie, err := r.Cookie(s.cfg.CookieName)
 		if err != nil {
 			// Cookie no encontrada o error al leerla
-			http.Error(w, "No autenticado. Por favor, inicie sesión.", http.StatusUnauthorized)
+			writeJSONError(w, http.StatusUnauthorized, "No autenticado. Por favor, inicie sesión.")
 			return
 		}
 
 		accessCode := cookie.Value
 
 		// Verificar si el código de acceso de la cookie es válido (thread-safe)
-		codesMutex.Lock()
-		isValid := validCodes[accessCode]
-		codesMutex.Unlock()
+		isValid := s.codes.IsValid(accessCode)
 
 		if !isValid {
 			// Código de acceso en la cookie no válido
-			http.Error(w, "Código de acceso inválido o expirado. Por favor, inicie sesión de nuevo.", http.StatusUnauthorized)
+			writeJSONError(w, http.StatusUnauthorized, "Código de acceso inválido o expirado. Por favor, inicie sesión de nuevo.")
 			return
 		}
 
@@ -160,44 +244,98 @@ func AuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
 
 // --- Handlers Existentes Modificados para Usar el Código de Usuario ---
 
-// Variable para facilitar el testing
-var getUserStoragePathFn = defaultGetUserStoragePath
-
-// Helper para obtener la ruta base de almacenamiento del usuario
-func defaultGetUserStoragePath(r *http.Request) (string, error) {
-	// Obtener el código de usuario del contexto (establecido por el middleware)
-	userCode, ok := r.Context().Value(userCodeKey).(string)
-	if !ok {
-		// Esto no debería pasar si el middleware se aplica correctamente,
-		// pero es una verificación defensiva.
-		return "", fmt.Errorf("código de usuario no encontrado en el contexto")
+func (s *Server) ListHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, http.MethodGet)
+		return
 	}
 
-	path, _ := os.Getwd() // Obtiene el directorio de trabajo actual
-	// Construye la ruta base de almacenamiento incluyendo el código de usuario
-	return filepath.Join(path, "archivos", userCode), nil
-}
-
-func ListHandler(w http.ResponseWriter, r *http.Request) {
-
 	// Obtener la ruta base de almacenamiento del usuario
-	userStoragePath, err := getUserStoragePathFn(r)
+	userStoragePath, err := s.storagePath(r)
 	if err != nil {
-		http.Error(w, "Error interno de autenticación", http.StatusInternalServerError)
+		writeJSONError(w, http.StatusInternalServerError, "Error interno de autenticación")
 		return
 	}
+	store, err := s.storageFor(r)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Error interno de autenticación")
+		return
+	}
+
+	var problems validationErrors
 	folder := r.URL.Query().Get("folder")
-	if folder == "" {
-		http.Error(w, "Falta el nombre de la carpeta", http.StatusBadRequest)
+	problems.checkFolder(folder)
+	if problems.respond(w) {
 		return
 	}
 
-	folderPath := filepath.Join(userStoragePath, folder)
-	fmt.Println(folderPath)
+	logf(r.Context(), "%s", filepath.Join(userStoragePath, folder))
 
-	files, err := ListFilesWithExtension(folderPath, ".pdf")
+	unlock := rLockFolder(userStoragePath, folder)
+	defer unlock()
+
+	// filter: subcadena sin distinguir mayúsculas, o patrón glob si contiene "*"
+	filter := r.URL.Query().Get("filter")
+	if !validFilter(filter) {
+		writeJSONError(w, http.StatusBadRequest, "Patrón de filtro no válido")
+		return
+	}
+
+	// sort=date ordena por la fecha AAAA-MM-DD del nombre, sort=natural por todos los
+	// números del nombre y sort=added por la fecha de subida; por defecto, por el primer número
+	sortMode := r.URL.Query().Get("sort")
+	if !validSortMode(sortMode) {
+		writeJSONError(w, http.StatusBadRequest, "Orden no soportado: "+sortMode)
+		return
+	}
+
+	// Con recursive=true se incluyen las subcarpetas, con rutas relativas a la carpeta
+	recursive := r.URL.Query().Get("recursive") == "true"
+
+	files, err := listStorageFiles(store, folder, ".pdf", filter, recursive)
 	if err != nil {
-		http.Error(w, "Error al listar archivos", http.StatusInternalServerError)
+		writeJSONError(w, http.StatusInternalServerError, "Error al listar archivos")
+		return
+	}
+	switch sortMode {
+	case sortByDate:
+		sortFilesByDate(files)
+	case sortNatural:
+		sortFilesNatural(files)
+	case sortByAdded:
+		if err := sortFilesByAdded(store, folder, files); err != nil {
+			logf(r.Context(), "Error leyendo las fechas de alta de %s: %v", folder, err)
+		}
+	}
+	if files == nil {
+		files = []string{} // Devolver [] en lugar de null cuando no hay coincidencias
+	}
+
+	// El cliente debe revalidar siempre; si nada cambió recibe 304 sin cuerpo
+	etag, lastModified := listValidators(store, folder, files, r.URL.Query().Encode())
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("ETag", etag)
+	if !lastModified.IsZero() {
+		w.Header().Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+	if notModified(r, etag, lastModified) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	// Con checksums=true cada archivo va con su SHA-256; si falla se omite solo ese hash
+	if r.URL.Query().Get("checksums") == "true" {
+		withSums := make([]FileChecksum, len(files))
+		for i, name := range files {
+			withSums[i].Name = name
+			sum, err := fileChecksum(store, folder, name)
+			if err != nil {
+				logf(r.Context(), "Error calculando el checksum de %s: %v", name, err)
+				continue
+			}
+			withSums[i].SHA256 = sum
+		}
+		writeJSON(w, http.StatusOK, withSums)
 		return
 	}
 
@@ -205,78 +343,250 @@ func ListHandler(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(files)
 }
 
-func UploadHandler(w http.ResponseWriter, r *http.Request) {
+// Campo multipart con los archivos de UploadHandler (UPLOAD_FIELD_NAME, por defecto "pdfs")
+var uploadFieldName = defaultConfig.UploadFieldName
+
+// uploadedFiles devuelve los archivos del campo configurado; si viene vacío usa los de
+// todos los campos (p. ej. "files[]" o "file"), ordenados por nombre de campo
+func uploadedFiles(form *uploadForm) []*uploadedFile {
+	if files := form.File[uploadFieldName]; len(files) > 0 {
+		return files
+	}
+	fields := make([]string, 0, len(form.File))
+	for field := range form.File {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	var files []*uploadedFile
+	for _, field := range fields {
+		files = append(files, form.File[field]...)
+	}
+	return files
+}
+
+func (s *Server) UploadHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		methodNotAllowed(w, http.MethodPost)
 		return
 	}
 
 	// Obtener la ruta base de almacenamiento del usuario
-	userStoragePath, err := getUserStoragePathFn(r)
+	userStoragePath, err := s.storagePath(r)
 	if err != nil {
-		http.Error(w, "Error interno de autenticación", http.StatusInternalServerError)
+		writeJSONError(w, http.StatusInternalServerError, "Error interno de autenticación")
 		return
 	}
-
-	folder := r.FormValue("folder")
-	if folder == "" {
-		http.Error(w, "Falta el nombre de la carpeta", http.StatusBadRequest)
+	store, err := s.storageFor(r)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Error interno de autenticación")
 		return
 	}
 
-	// Construir la ruta completa de la carpeta dentro del espacio del usuario
-	folderPath := filepath.Join(userStoragePath, folder)
-	fmt.Println("Subiendo a:", folderPath) // Log para depuración
-
-	// Crear la carpeta del usuario y la carpeta específica si no existen
-	if err := os.MkdirAll(folderPath, os.ModePerm); err != nil {
-		http.Error(w, "No se pudo crear la carpeta del usuario/carpeta", http.StatusInternalServerError)
-		return
+	// Si es un reintento con la misma Idempotency-Key, repetir la respuesta original
+	// sin volver a guardar los archivos. La clave se asocia al usuario y queda reservada
+	// desde aquí: un reintento que llega mientras esta subida sigue en curso recibe 409.
+	idempotencyKey := r.Header.Get("Idempotency-Key")
+	if idempotencyKey != "" {
+		userCode, _ := r.Context().Value(userCodeKey).(string)
+		idempotencyKey = userCode + "/" + idempotencyKey
+		resp, replay, busy := s.uploadIdempotency.begin(idempotencyKey)
+		if replay {
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Idempotent-Replayed", "true")
+			w.WriteHeader(resp.status)
+			w.Write(resp.body)
+			return
+		}
+		if busy {
+			writeJSONError(w, http.StatusConflict, "Ya hay una subida en curso con esa Idempotency-Key")
+			return
+		}
+		// Sin efecto si la respuesta ya se guardó con put
+		defer s.uploadIdempotency.release(idempotencyKey)
 	}
 
-	destFiles, err := ListFilesWithExtension(folderPath, ".pdf")
+	// Archivos comprimidos con una codificación que no se sabe descomprimir: 415
+	encoding, err := uploadContentEncoding(r)
 	if err != nil {
-		http.Error(w, "Error leyendo el directorio", http.StatusBadRequest)
+		writeJSONError(w, http.StatusUnsupportedMediaType, err.Error())
 		return
 	}
-	counter := len(destFiles)
 
-	// Parsear archivos
-	err = r.ParseMultipartForm(32 << 20) // 32 MB
+	// Parsear el formulario antes de leer cualquier campo: FormValue lo parsearía con el
+	// límite de memoria por defecto de net/http
+	form, cleanupForm, err := parseMultipartForm(r, multipartMaxMemory)
+	defer cleanupForm()
 	if err != nil {
-		http.Error(w, "Error al parsear el formulario", http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, "Error al parsear el formulario")
 		return
 	}
 
-	files := r.MultipartForm.File["pdfs"]
-	for i, fileHeader := range files {
-		file, err := fileHeader.Open()
-		if err != nil {
-			http.Error(w, "Error al abrir archivo", http.StatusInternalServerError)
+	// Validar la carpeta y las extensiones antes de guardar nada (ver checkUploadExtension)
+	// y responder con todos los problemas juntos, con el motivo de cada archivo rechazado
+	var problems validationErrors
+	folder := r.FormValue("folder")
+	problems.checkFolder(folder)
+	convert := r.FormValue("convert") == "true"
+	files := uploadedFiles(form)
+	for _, fileHeader := range files {
+		if err := checkUploadExtension(fileHeader.Filename, convert); err != nil {
+			problems.add(uploadFieldName, fmt.Sprintf("%s: %v", fileHeader.Filename, err))
+		}
+	}
+	if problems.respond(w) {
+		return
+	}
+	for _, fileHeader := range files {
+		// Un ZIP se lee por posiciones y ya va comprimido: no se acepta además con Content-Encoding
+		if encoding != "" && isZipFile(fileHeader.Filename) {
+			writeJSONError(w, http.StatusUnsupportedMediaType, fmt.Sprintf("Los ZIP no se pueden subir con Content-Encoding: %s", fileHeader.Filename))
 			return
 		}
-		defer file.Close()
+	}
+
+	logf(r.Context(), "Subiendo a: %s", filepath.Join(userStoragePath, folder)) // Log para depuración
 
-		filename := fileHeader.Filename
-		numStr := strings.Split(filename, "-")[0]
-		if _, err := strconv.Atoi(numStr); err != nil {
-			filename = fmt.Sprintf("%d-%s", i+1+counter, filename)
+	// El contador de prefijos y el guardado deben ver la carpeta sin cambios de otros handlers
+	unlock := lockFolder(userStoragePath, folder)
+	defer unlock()
+
+	// Una carpeta que todavía no existe tiene 0 archivos; el almacenamiento la crea al guardar
+	counter, err := countStoredFiles(store, folder)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		writeJSONError(w, http.StatusBadRequest, "Error leyendo el directorio")
+		return
+	}
+
+	// Comprobar el máximo de archivos por carpeta con lo que se va a agregar
+	incoming := 0
+	for _, fileHeader := range files {
+		if isZipFile(fileHeader.Filename) {
+			incoming += countZipPDFs(fileHeader)
+		} else {
+			incoming++
 		}
-		dst, err := os.Create(filepath.Join(folderPath, filename))
+	}
+	if folderLimitExceeded(w, counter, incoming) {
+		return
+	}
+
+	// Un archivo que falla no detiene al resto: se informa cuáles se guardaron y cuáles no
+	result := UploadResult{Saved: []string{}, Failed: []UploadFailure{}}
+	next := counter + 1
+	for _, fileHeader := range files {
+		// Un ZIP aporta tantos archivos numerados como PDFs contiene
+		if isZipFile(fileHeader.Filename) {
+			saved, failed := extractZipPDFs(fileHeader, store, folder, next)
+			result.Saved = append(result.Saved, saved...)
+			result.Failed = append(result.Failed, failed...)
+			next += len(saved)
+			continue
+		}
+
+		// Un archivo que falla no consume número: los guardados quedan consecutivos
+		filename, err := saveUploadedFile(fileHeader, store, folder, next, convert, encoding)
 		if err != nil {
-			http.Error(w, "Error al guardar archivo", http.StatusInternalServerError)
-			return
+			result.Failed = append(result.Failed, UploadFailure{Name: fileHeader.Filename, Error: err.Error()})
+			continue
+		}
+		next++
+		result.Saved = append(result.Saved, filename)
+	}
+	// Un nombre que ya llevaba prefijo puede haber reemplazado a un archivo existente
+	for _, filename := range result.Saved {
+		discardChecksumSidecar(store, folder, filename)
+	}
+	if err := recordAdded(store, folder, result.Saved, time.Now()); err != nil {
+		logf(r.Context(), "Error registrando la fecha de alta en %s: %v", folder, err)
+	}
+
+	// 207 indica que la subida fue parcial y 422 que no se guardó ninguno; el detalle por
+	// archivo va en el cuerpo
+	status := http.StatusOK
+	result.Message = "Archivos subidos correctamente"
+	if len(result.Failed) > 0 && len(result.Saved) == 0 {
+		status = http.StatusUnprocessableEntity
+		result.Message = "No se pudo subir ningún archivo"
+	} else if len(result.Failed) > 0 {
+		status = http.StatusMultiStatus
+		result.Message = "Algunos archivos no se pudieron subir"
+	}
+
+	body, _ := json.Marshal(result)
+	if idempotencyKey != "" {
+		s.uploadIdempotency.put(idempotencyKey, status, body)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(body)
+}
+
+// saveUploadedFile guarda un archivo subido en folder con el prefijo index y devuelve
+// el nombre final. Con convert=true las imágenes se guardan como un PDF de una página.
+// Con encoding (ver uploadContentEncoding) el contenido se descomprime antes.
+func saveUploadedFile(fileHeader *uploadedFile, store Storage, folder string, index int, convert bool, encoding string) (string, error) {
+	file, err := fileHeader.Open()
+	if err != nil {
+		return "", fmt.Errorf("error al abrir archivo: %w", err)
+	}
+	defer file.Close()
+
+	var src io.Reader = file
+	if encoding != "" {
+		decoded, err := decodingReader(file, encoding)
+		if err != nil {
+			return "", fmt.Errorf("error al descomprimir archivo: %w", err)
 		}
-		defer dst.Close()
+		defer decoded.Close()
+		// Un archivo comprimido puede ocupar mucho más al descomprimirse: se corta al pasar el límite
+		src = io.LimitReader(decoded, maxDecompressedBytes+1)
+	}
 
-		if _, err := io.Copy(dst, file); err != nil {
-			http.Error(w, "Error al copiar archivo", http.StatusInternalServerError)
-			return
+	if convert && isImageFile(fileHeader.Filename) {
+		filename := prefixedName(imagePDFName(fileHeader.Filename), index)
+		var converted bytes.Buffer
+		if err := convertImageToPDF(src, &converted); err != nil {
+			return "", fmt.Errorf("error al convertir la imagen: %w", err)
 		}
+		if _, err := store.Save(folder, filename, &converted); err != nil {
+			return "", fmt.Errorf("error al guardar archivo: %w", err)
+		}
+		return filename, nil
 	}
 
-	w.WriteHeader(http.StatusOK)
-	w.Write([]byte("Archivos subidos correctamente"))
+	filename := prefixedName(fileHeader.Filename, index)
+	written, err := store.Save(folder, filename, src)
+	if err != nil {
+		return "", fmt.Errorf("error al guardar archivo: %w", err)
+	}
+	if encoding != "" && written > maxDecompressedBytes {
+		store.Delete(folder, filename) // No dejar un archivo truncado
+		return "", errDecompressedTooLarge
+	}
+	return filename, nil
+}
+
+// prefixedName antepone el número de orden al nombre si no empieza ya por "N-"
+func prefixedName(filename string, index int) string {
+	numStr := strings.Split(filename, "-")[0]
+	if _, err := strconv.Atoi(numStr); err != nil {
+		return fmt.Sprintf("%d-%s", index, filename)
+	}
+	return filename
+}
+
+// Errores de validación de nombres de archivo compartidos por varios handlers
+var (
+	errMissingFile     = errors.New("falta el nombre del archivo")
+	errInvalidFileName = errors.New("nombre de archivo no válido")
+	errFileNotFound    = errors.New("archivo no encontrado")
+)
+
+// validFileName rechaza nombres vacíos o que intenten salir de la carpeta del usuario
+func validFileName(name string) bool {
+	return name != "" && name != "." && name != ".." && !strings.ContainsAny(name, `/\`)
 }
 
 var osReadDir = os.ReadDir // Alias para facilitar mocking en tests si fuera necesario
@@ -284,178 +594,615 @@ var osReadDir = os.ReadDir // Alias para facilitar mocking en tests si fuera nec
 // ListFilesWithExtension: Función auxiliar que lista y ordena archivos PDF en un directorio dado.
 // No necesita saber del código de usuario, solo opera sobre la ruta que recibe.
 func ListFilesWithExtension(dir string, ext string) ([]string, error) {
+	return ListFilesWithFilter(dir, ext, "")
+}
+
+// ListFilesWithFilter: Igual que ListFilesWithExtension pero solo incluye los nombres que
+// coinciden con filter (ver matchesFilter). El filtro se aplica antes de ordenar.
+func ListFilesWithFilter(dir string, ext string, filter string) ([]string, error) {
 	fmt.Println("Leyendo directorio:", dir) // Log para depuración
 	files, err := osReadDir(dir)
 	if err != nil {
 		return nil, err // Devuelve el error si el directorio no existe o hay problemas de permisos
 	}
 
-	var matchedFiles []string
+	var names []string
 	for _, file := range files {
-		// Ignorar directorios y solo incluir archivos con la extensión especificada
-		if !file.IsDir() && strings.HasSuffix(file.Name(), ext) {
-			matchedFiles = append(matchedFiles, file.Name())
+		// Ignorar directorios
+		if !file.IsDir() {
+			names = append(names, file.Name())
 		}
 	}
 
-	// Ordenar los archivos basándose en el primer número encontrado en el nombre
-	sort.Slice(matchedFiles, func(i, j int) bool {
-		// Expresión regular para encontrar números
-		re := regexp.MustCompile(`\d+`)
+	// Solo los archivos con la extensión especificada, ordenados por número
+	matchedFiles := filterFileNames(names, ext, filter)
+	sortByNumber(matchedFiles)
 
-		// Encontrar todos los números en ambos nombres de archivo
-		foundN1 := re.FindString(matchedFiles[i])
-		foundN2 := re.FindString(matchedFiles[j])
+	return matchedFiles, nil
+}
 
-		// Si alguno no tiene números o hay algún problema, usar orden alfabético (simplificado)
-		if foundN1 == "" || foundN2 == "" {
-			return matchedFiles[i] < matchedFiles[j] // Fallback alfabético
-		}
+// sortByNumber ordena los nombres basándose en el primer número encontrado (ver lessByNumber)
+func sortByNumber(names []string) {
+	sort.SliceStable(names, func(i, j int) bool {
+		return lessByNumber(names[i], names[j])
+	})
+}
 
-		// Convertir el primer número encontrado a entero
-		n1, err1 := strconv.Atoi(foundN1)
-		n2, err2 := strconv.Atoi(foundN2)
+// Expresión regular para encontrar números
+var firstNumberRe = regexp.MustCompile(`\d+`)
 
-		// Si la conversión falla, usar orden alfabético (simplificado)
-		if err1 != nil || err2 != nil {
-			return matchedFiles[i] < matchedFiles[j] // Fallback alfabético
-		}
+// lessByNumber compara dos nombres por el primer número que contienen
+func lessByNumber(a, b string) bool {
+	// Encontrar el primer número en ambos nombres de archivo
+	foundN1 := firstNumberRe.FindString(a)
+	foundN2 := firstNumberRe.FindString(b)
 
-		// Comparar los números
-		return n1 < n2
-	})
+	// Si alguno no tiene números o hay algún problema, usar orden alfabético (simplificado)
+	if foundN1 == "" || foundN2 == "" {
+		return a < b // Fallback alfabético
+	}
 
-	return matchedFiles, nil
+	// Convertir el primer número encontrado a entero
+	n1, err1 := strconv.Atoi(foundN1)
+	n2, err2 := strconv.Atoi(foundN2)
+
+	// Si la conversión falla, usar orden alfabético (simplificado)
+	if err1 != nil || err2 != nil {
+		return a < b // Fallback alfabético
+	}
+
+	// Comparar los números; si empatan ("1-b.pdf" y "01-a.pdf") desempatar por el
+	// nombre completo para que el orden sea siempre el mismo
+	if n1 != n2 {
+		return n1 < n2
+	}
+	return a < b
 }
 
-func GenerateHandler(w http.ResponseWriter, r *http.Request) {
+func (s *Server) GenerateHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		methodNotAllowed(w, http.MethodPost)
 		return
 	}
 
 	// Obtener la ruta base de almacenamiento del usuario
-	userStoragePath, err := getUserStoragePathFn(r)
+	userStoragePath, err := s.storagePath(r)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Error interno de autenticación")
+		return
+	}
+
+	// Opciones por defecto guardadas por el usuario; la petición tiene prioridad
+	prefs, err := loadPreferences(userStoragePath)
 	if err != nil {
-		http.Error(w, "Error interno de autenticación", http.StatusInternalServerError)
+		logf(r.Context(), "Error leyendo preferencias: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "Error al leer las preferencias")
 		return
 	}
 
+	// Validar toda la petición y responder con todos los problemas juntos
+	var problems validationErrors
 	folder := r.FormValue("folder")
-	if folder == "" {
-		http.Error(w, "Falta el nombre de la carpeta", http.StatusBadRequest)
+	problems.checkFolder(folder)
+	opts := parseMergeOptions(r, prefs)
+	outputName, err := mergeOutputName(folder, opts.Output)
+	if err != nil {
+		problems.add("output", "Nombre de salida inválido")
+	}
+	if !validMergeMode(opts.Mode) {
+		problems.add("mode", "Modo de unión no soportado: "+opts.Mode)
+	}
+	if opts.Mode == MergeModeInterleave && opts.Bookmarks {
+		// Los marcadores por archivo fuente suponen páginas consecutivas
+		problems.add("bookmarks", "Los marcadores no están disponibles en el modo intercalado")
+	}
+	if opts.Mode == MergeModeInterleave && (opts.Cover != "" || opts.Separators) {
+		problems.add("mode", "La portada y los separadores no están disponibles en el modo intercalado")
+	}
+	if opts.Normalize, err = resolvePageSize(opts.Normalize); err != nil {
+		problems.add("normalize", err.Error())
+	}
+	if opts.Indices, err = parseFileIndices(r.FormValue("indices")); err != nil {
+		problems.add("indices", err.Error())
+	}
+	if err := validateLabelTemplate(opts.LabelTemplate); err != nil {
+		problems.add("label_template", err.Error())
+	}
+	if opts.Passwords, err = parseSourcePasswords(r.FormValue("passwords")); err != nil {
+		problems.add("passwords", err.Error())
+	}
+	// Aviso al terminar: solo tiene sentido para un trabajo asíncrono
+	callbackURL, err := parseCallbackURL(r.FormValue("callback_url"))
+	if err != nil {
+		problems.add("callback_url", err.Error())
+	} else if callbackURL != "" && r.FormValue("async") != "true" {
+		problems.add("callback_url", "callback_url requiere async=true")
+	}
+	if problems.respond(w) {
+		return
+	}
+
+	// Modo de prueba: devolver el plan de la unión sin escribir ninguna salida
+	if r.FormValue("dry_run") == "true" {
+		writeJSON(w, http.StatusOK, planMerge(userStoragePath, folder, opts))
 		return
 	}
 
+	// Modo asíncrono: encolar el trabajo y devolver su id inmediatamente
+	if r.FormValue("async") == "true" {
+		userCode, _ := r.Context().Value(userCodeKey).(string)
+		job := s.enqueueMergeJob(r.Context(), userCode, userStoragePath, folder, opts, callbackURL)
+		writeJSON(w, http.StatusAccepted, job)
+		return
+	}
+
+	// Ocupar un espacio del pool de uniones; si está lleno tras la espera, responder 429
+	if err := s.merges.acquire(r.Context()); err != nil {
+		w.Header().Set("Retry-After", strconv.Itoa(int(mergeQueueTimeout.Seconds())+1))
+		writeJSONError(w, http.StatusTooManyRequests, "Servidor ocupado: "+err.Error())
+		return
+	}
+	defer s.merges.release()
+
+	// Ningún otro handler puede modificar la carpeta ni su salida mientras se une
+	unlock := lockFolders(userStoragePath, folder, outputFolder(outputName))
+	defer unlock()
+
 	// Llamar a la función auxiliar para unir PDFs, pasándole la ruta base del usuario y la carpeta
-	err = joinPDFs(userStoragePath, folder) // joinPDFs ahora recibe la ruta base del usuario
+	result, err := joinPDFs(r.Context(), userStoragePath, folder, opts) // joinPDFs ahora recibe la ruta base del usuario
+	if errors.Is(err, ErrNoPDFs) || errors.Is(err, ErrInterleaveFileCount) || errors.Is(err, ErrInterleavePageCount) ||
+		errors.Is(err, ErrPasswordRequired) || errors.Is(err, ErrFileIndexOutOfRange) {
+		// Una carpeta vacía, incompatible con el modo pedido, con PDFs protegidos sin su
+		// contraseña o sin los índices pedidos es un error del cliente, no del servidor
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if errors.Is(err, ErrTooManyPages) {
+		writeJSONError(w, http.StatusRequestEntityTooLarge, err.Error())
+		return
+	}
 	if err != nil {
-		http.Error(w, "Error al unir PDFs: "+err.Error(), http.StatusInternalServerError)
+		writeJSONError(w, http.StatusInternalServerError, "Error al unir PDFs: "+err.Error())
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
-	w.Write([]byte("PDF generado correctamente"))
+	message := "PDF generado correctamente"
+	if result.Unchanged {
+		message = "El PDF ya estaba actualizado"
+	} else {
+		mergesTotal.Add(1)
+	}
+	link := downloadURL(folder, result.Output)
+	w.Header().Set("Location", link)
+	writeJSON(w, http.StatusOK, GenerateResponse{Message: message, MergeResult: result, DownloadURL: link})
 }
 
-func joinPDFs(path, folder string) error {
-	folderPath := filepath.Join(path, folder)
+// parseMergeOptions lee las opciones de unión del formulario; los campos que no vienen
+// toman el valor de prefs y, sin preferencias, la salida no cambia
+func parseMergeOptions(r *http.Request, prefs MergePreferences) MergeOptions {
+	opts := MergeOptions{
+		Grayscale:  formBool(r, "grayscale", prefs.Grayscale),
+		Bookmarks:  formBool(r, "bookmarks", prefs.Bookmarks),
+		Output:     strings.TrimSpace(r.FormValue("output")),
+		Mode:       r.FormValue("mode"),
+		Reverse:    r.FormValue("reverse") == "true",
+		Cover:      strings.TrimSpace(r.FormValue("cover")),
+		Separators: formBool(r, "separators", prefs.Separators),
+		Normalize:  formString(r, "normalize", prefs.Normalize),
+		Flatten:    formBool(r, "flatten", prefs.Flatten),
+		Linearize:  formBool(r, "linearize", prefs.Linearize),
+		Force:      r.FormValue("force") == "true",
+
+		LabelTemplate: formString(r, "label_template", prefs.LabelTemplate),
+
+		SourcePassword: r.FormValue("source_password"),
+	}
+	// Lo que el modo intercalado no admite solo se aplica si se pide explícitamente,
+	// para que las preferencias no conviertan la petición en un error
+	if opts.Mode == MergeModeInterleave {
+		opts.Bookmarks = formBool(r, "bookmarks", false)
+		opts.Separators = formBool(r, "separators", false)
+	}
+	return opts
+}
+
+// mergeOutputName devuelve el nombre del PDF unido: "<folder>.pdf" por defecto o el
+// indicado en output, que se valida como nombre de archivo para impedir salir del
+// espacio del usuario y recibe la extensión .pdf si no la tiene
+func mergeOutputName(folder, output string) (string, error) {
+	if output == "" {
+		return folder + ".pdf", nil
+	}
+	if !validFileName(output) {
+		return "", errInvalidFileName
+	}
+	return pdfFileName(output), nil
+}
+
+// downloadURL ruta de DownloadHandler que descarga la salida outputName de folder
+func downloadURL(folder, outputName string) string {
+	return "/download?" + url.Values{"folder": {folder}, "output": {outputName}}.Encode()
+}
+
+// ErrNoPDFs indica que la carpeta a unir no contiene ningún PDF
+var ErrNoPDFs = errors.New("no se encontraron archivos PDF en la ruta proporcionada")
+
+// mergeSourceFiles devuelve los PDFs de folderPath que joinPDFs une con opts, en orden
+func mergeSourceFiles(folderPath string, opts MergeOptions) ([]string, error) {
 	files, err := ListFilesWithExtension(folderPath, ".pdf")
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if len(files) == 0 {
-		return fmt.Errorf("no se encontraron archivos PDF en la ruta proporcionada")
+		return nil, ErrNoPDFs
+	}
+	if len(opts.Indices) > 0 {
+		return selectFilesByIndex(files, opts.Indices)
+	}
+	return files, nil
+}
+
+func joinPDFs(ctx context.Context, path, folder string, opts MergeOptions) (MergeResult, error) {
+	outputName, err := mergeOutputName(folder, opts.Output)
+	if err != nil {
+		return MergeResult{Folder: folder}, err
+	}
+	result := MergeResult{Folder: folder, Output: outputName}
+	folderPath := filepath.Join(path, folder)
+	files, err := mergeSourceFiles(folderPath, opts)
+	if err != nil {
+		return result, err
 	}
-	outputFilePath := filepath.Join(folderPath, "../", folder+".pdf")
+	outputFilePath := mergeOutputPath(path, outputName)
 	filesToJoin := make([]string, len(files))
 	for i := 0; i < len(files); i++ {
 		filesToJoin[i] = filepath.Join(folderPath, files[i])
 	}
-	err = api.MergeCreateFile(filesToJoin, outputFilePath, false, nil)
+	sources := filesToJoin
+
+	// Si nada cambió desde la última unión se devuelve su resultado sin volver a unir
+	if !opts.Force {
+		// Sin manifiesto de auditoría se vuelve a unir para generarlo
+		_, err := os.Stat(outputManifestPath(outputFilePath))
+		if previous, ok := unchangedMerge(outputFilePath, sources, opts); ok && err == nil {
+			return previous, nil
+		}
+	}
+
+	// La unión y los post-procesos trabajan en una carpeta temporal; la salida solo
+	// se mueve junto a la carpeta cuando está completa
+	tmpDir, cleanup, err := newTempDir("merge-")
 	if err != nil {
-		return err
+		return result, err
+	}
+	defer cleanup()
+	workPath := filepath.Join(tmpDir, outputName)
+
+	// Las fuentes cifradas se descifran primero: sin ello no se pueden ni contar sus páginas
+	if filesToJoin, err = decryptSources(tmpDir, filesToJoin, opts); err != nil {
+		return result, err
+	}
+	if result.TotalPages, err = checkPageLimit(filesToJoin); err != nil {
+		return result, err
+	}
+
+	// Aplanar cada fuente antes de unir evita conflictos entre campos con el mismo nombre
+	if opts.Flatten {
+		var flattened bool
+		if filesToJoin, flattened, err = flattenSources(tmpDir, filesToJoin); err != nil {
+			return result, err
+		}
+		result.Flattened = &flattened
+	}
+
+	// Portada y separadores opcionales, generados en la misma carpeta temporal
+	mergeFiles := filesToJoin
+	var generated map[string]bool
+	if opts.Cover != "" || opts.Separators {
+		if mergeFiles, generated, err = withCoverAndSeparators(tmpDir, filesToJoin, opts); err != nil {
+			return result, err
+		}
+	}
+
+	opts.progress(ProgressMerging, 0, 0)
+	if opts.Mode == MergeModeInterleave {
+		err = interleavePDFs(filesToJoin, workPath, opts.Reverse)
+	} else {
+		err = mergeWithRetry(ctx, mergeFiles, workPath)
+	}
+	if err != nil {
+		return result, err
+	}
+
+	// Post-procesos opcionales sobre la salida ya unida
+	if opts.Normalize != "" {
+		opts.progress(ProgressNormalizing, 0, 0)
+		if err := normalizePages(workPath, opts.Normalize); err != nil {
+			return result, err
+		}
+		result.PageSize = opts.Normalize
 	}
-	return nil
+	if opts.Bookmarks {
+		opts.progress(ProgressBookmarks, 0, 0)
+		if err := addSourceBookmarks(workPath, mergeFiles, generated, opts.LabelTemplate); err != nil {
+			return result, err
+		}
+	}
+	if opts.Grayscale {
+		opts.progress(ProgressGrayscale, 0, 0)
+		change, err := convertToGrayscale(workPath)
+		if err != nil {
+			return result, err
+		}
+		result.Grayscale = &change
+	}
+	// La linealización va al final: cualquier reescritura posterior la desharía
+	if opts.Linearize {
+		opts.progress(ProgressLinearizing, 0, 0)
+		linearized, err := linearizePDF(workPath)
+		if err != nil {
+			return result, err
+		}
+		result.Linearized = &linearized
+	}
+	opts.progress(ProgressSaving, 0, 0)
+	if err := os.MkdirAll(filepath.Dir(outputFilePath), os.ModePerm); err != nil {
+		return result, err
+	}
+	if err := moveFile(workPath, outputFilePath); err != nil {
+		return result, err
+	}
+	// Si el manifiesto de auditoría no se puede escribir no queda el de una unión anterior
+	if err := writeOutputManifest(path, folder, outputFilePath, files, filesToJoin, opts, time.Now()); err != nil {
+		discardOutputManifest(outputFilePath)
+	}
+	// Sin manifiesto la próxima unión simplemente vuelve a hacerse completa
+	writeMergeManifest(outputFilePath, sources, opts, result)
+	return result, nil
 }
 
-func DownloadHandler(w http.ResponseWriter, r *http.Request) {
+// DownloadHandler descarga el PDF unido. HEAD devuelve las mismas cabeceras que GET
+// (tamaño, tipo, fecha) sin el cuerpo, para comprobar la salida sin transferirla.
+// Con regenerate_if_stale=true antes la vuelve a unir si sus fuentes cambiaron.
+func (s *Server) DownloadHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		methodNotAllowed(w, http.MethodGet, http.MethodHead)
+		return
+	}
+
 	// Obtener la ruta base de almacenamiento del usuario
-	userStoragePath, err := getUserStoragePathFn(r)
+	userStoragePath, err := s.storagePath(r)
 	if err != nil {
-		http.Error(w, "Error interno de autenticación", http.StatusInternalServerError)
+		writeJSONError(w, http.StatusInternalServerError, "Error interno de autenticación")
 		return
 	}
+	var problems validationErrors
 	folder := r.URL.Query().Get("folder")
-	if folder == "" {
-		http.Error(w, "Falta el nombre de la carpeta", http.StatusBadRequest)
+	problems.checkFolder(folder)
+	if problems.respond(w) {
+		return
+	}
+	// output (o file) elige una variante generada con un nombre de salida propio
+	output := r.URL.Query().Get("output")
+	if output == "" {
+		output = r.URL.Query().Get("file")
+	}
+	outputName, err := mergeOutputName(folder, output)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Nombre de salida inválido")
+		return
+	}
+	format := r.URL.Query().Get("download_format")
+	if !validDownloadFormat(format) {
+		writeJSONError(w, http.StatusBadRequest, "download_format inválido: use binary o base64")
+		return
+	}
+	pdfPath := mergeOutputPath(userStoragePath, outputName)
+
+	// Con regenerate_if_stale=true una salida que ya no corresponde a sus fuentes se
+	// vuelve a unir antes de servirla (ver stale_output.go)
+	if r.URL.Query().Get("regenerate_if_stale") == "true" {
+		regenerated, err := s.regenerateIfStale(r.Context(), userStoragePath, folder, outputName)
+		if errors.Is(err, ErrMergePoolFull) {
+			w.Header().Set("Retry-After", strconv.Itoa(int(mergeQueueTimeout.Seconds())+1))
+			writeJSONError(w, http.StatusTooManyRequests, "Servidor ocupado: "+err.Error())
+			return
+		}
+		if errors.Is(err, errStaleOutput) {
+			writeJSONError(w, http.StatusConflict, err.Error())
+			return
+		}
+		if err != nil {
+			writeJSONError(w, http.StatusInternalServerError, "Error al regenerar el PDF unido: "+err.Error())
+			return
+		}
+		if regenerated {
+			w.Header().Set("X-Regenerated", "true")
+		}
+	}
+
+	// Verificar que la unión ya se generó para distinguir este caso de otros errores
+	info, err := os.Stat(pdfPath)
+	if os.IsNotExist(err) {
+		writeJSONError(w, http.StatusNotFound, "merged PDF not generated for folder")
+		return
+	}
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Error al leer el PDF unido")
+		return
+	}
+
+	// Vista previa de una salida cifrada: se sirve una copia descifrada que se borra al
+	// terminar la respuesta; sin preview_password el archivo sale cifrado como está
+	preview := r.URL.Query().Has("preview_password")
+	if preview {
+		previewPath, cleanup, err := decryptForPreview(pdfPath, r.URL.Query().Get("preview_password"))
+		if errors.Is(err, errWrongPassword) {
+			writeJSONError(w, http.StatusForbidden, "Contraseña incorrecta")
+			return
+		}
+		if err != nil {
+			writeJSONError(w, http.StatusInternalServerError, "Error al descifrar el PDF unido: "+err.Error())
+			return
+		}
+		defer cleanup()
+		if info, err = os.Stat(previewPath); err != nil {
+			writeJSONError(w, http.StatusInternalServerError, "Error al leer el PDF unido")
+			return
+		}
+		pdfPath = previewPath
+		w.Header().Set("Cache-Control", "no-store")
+	}
+
+	if format == downloadFormatBase64 {
+		writeBase64Download(w, pdfPath, outputName, info.Size())
 		return
 	}
-	pdfPath := filepath.Join(userStoragePath, folder+".pdf")
+
+	// Por defecto forzar la descarga con el nombre de la carpeta; inline=true (o una vista
+	// previa) permite verlo en el navegador
+	disposition := "attachment"
+	if preview || r.URL.Query().Get("inline") == "true" {
+		disposition = "inline"
+	}
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": outputName}))
+	w.Header().Set("Content-Length", strconv.FormatInt(info.Size(), 10))
+
+	// Comprimir con gzip si el cliente lo acepta y no es una petición por rangos
+	if shouldGzip(w, r) {
+		gw := newGzipResponseWriter(w)
+		defer gw.Close()
+		http.ServeFile(gw, r, pdfPath)
+		return
+	}
+
+	// Los visores como PDF.js piden el archivo por rangos (Range: bytes=a-b) a medida que
+	// muestran páginas. ServeFile responde 206 con Content-Range y su propio Content-Length
+	// (o 416 si el rango no existe) y se encarga de la caché; Accept-Ranges se anuncia
+	// también en HEAD para que el visor sepa que puede pedirlos.
+	w.Header().Set("Accept-Ranges", "bytes")
 	http.ServeFile(w, r, pdfPath)
 }
 
+// Tamaño máximo del cuerpo JSON de DeleteFilesHandler
+const maxDeleteBodyBytes = 1 << 20 // 1 MB
+
 // DeleteFilesHandler maneja la eliminación de archivos PDF
-func DeleteFilesHandler(w http.ResponseWriter, r *http.Request) {
+func (s *Server) DeleteFilesHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodDelete {
-		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		methodNotAllowed(w, http.MethodDelete)
 		return
 	}
 
+	// Exigir JSON y limitar el tamaño del cuerpo antes de decodificar
+	if !isJSONRequest(r) {
+		writeJSONError(w, http.StatusBadRequest, "Content-Type debe ser application/json")
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxDeleteBodyBytes)
+
 	// Decodificar el cuerpo de la solicitud
 	var req DeleteFilesRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Error al decodificar la solicitud", http.StatusBadRequest)
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			writeJSONError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("El cuerpo de la solicitud supera el máximo de %d bytes", maxBytesErr.Limit))
+			return
+		}
+		writeJSONError(w, http.StatusBadRequest, "Error al decodificar la solicitud: JSON mal formado")
 		return
 	}
 
-	// Validar que se proporcionó una carpeta
-	if req.Folder == "" {
-		http.Error(w, "Falta el nombre de la carpeta", http.StatusBadRequest)
+	// Validar que se proporcionó una carpeta válida
+	var problems validationErrors
+	problems.checkFolder(req.Folder)
+	if problems.respond(w) {
+		return
+	}
+
+	// Una lista explícita y un patrón juntos son ambiguos: no se adivina cuál prevalece
+	if req.Pattern != "" && len(req.Files) > 0 {
+		writeJSONError(w, http.StatusBadRequest, "Indique files o pattern, no ambos")
+		return
+	}
+	if _, err := filepath.Match(req.Pattern, ""); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Patrón no válido: "+req.Pattern)
 		return
 	}
 
 	// Obtener la ruta base de almacenamiento del usuario
-	userStoragePath, err := getUserStoragePathFn(r)
+	userStoragePath, err := s.storagePath(r)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Error interno de autenticación")
+		return
+	}
+
+	store, err := s.storageFor(r)
 	if err != nil {
-		http.Error(w, "Error interno de autenticación", http.StatusInternalServerError)
+		writeJSONError(w, http.StatusInternalServerError, "Error interno de autenticación")
 		return
 	}
 
-	folderPath := filepath.Join(userStoragePath, req.Folder)
+	unlock := lockFolder(userStoragePath, req.Folder)
+	defer unlock()
 
-	// Si no se especifican archivos, eliminar todos
-	if len(req.Files) == 0 {
-		files, err := ListFilesWithExtension(folderPath, ".pdf")
+	// Con pattern se eliminan los PDFs que coinciden; sin archivos ni patrón, todos
+	if req.Pattern != "" || len(req.Files) == 0 {
+		files, err := listStorageFiles(store, req.Folder, ".pdf", "", false)
 		if err != nil {
-			http.Error(w, "Error al listar archivos", http.StatusInternalServerError)
+			writeJSONError(w, http.StatusInternalServerError, "Error al listar archivos")
 			return
 		}
 		req.Files = files
+		if req.Pattern != "" {
+			req.Files = []string{}
+			for _, filename := range files {
+				if matched, _ := filepath.Match(req.Pattern, filename); matched {
+					req.Files = append(req.Files, filename)
+				}
+			}
+		}
 	}
 
 	// Verificar que todos los archivos existen antes de eliminar
 	for _, filename := range req.Files {
 		if !strings.HasSuffix(filename, ".pdf") {
-			http.Error(w, "Tipo de archivo no permitido", http.StatusBadRequest)
+			writeJSONError(w, http.StatusBadRequest, "Tipo de archivo no permitido")
 			return
 		}
-		filePath := filepath.Join(folderPath, filename)
-		if _, err := os.Stat(filePath); os.IsNotExist(err) {
-			http.Error(w, fmt.Sprintf("Archivo no encontrado: %s", filename), http.StatusBadRequest)
+		if exists, err := store.Exists(req.Folder, filename); err != nil || !exists {
+			writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("Archivo no encontrado: %s", filename))
 			return
 		}
 	}
+	// Con If-Unmodified-Since no se borra nada si algún archivo cambió después de esa fecha
+	if filename, changed := modifiedSince(r, store, req.Folder, req.Files); changed {
+		writeJSONError(w, http.StatusPreconditionFailed, fmt.Sprintf("El archivo cambió después de If-Unmodified-Since: %s", filename))
+		return
+	}
 
-	// Eliminar los archivos
-	for _, filename := range req.Files {
-		filePath := filepath.Join(folderPath, filename)
-		if err := os.Remove(filePath); err != nil {
-			http.Error(w, fmt.Sprintf("Error al eliminar archivo %s: %v", filename, err), http.StatusInternalServerError)
+	// Mover los archivos a la papelera, o eliminarlos con purge. Si uno falla al moverlo,
+	// los que ya se movieron vuelven a la carpeta: o se borran todos o ninguno. Con purge
+	// no hay vuelta atrás (ya se comprobó que existen todos), así que si uno falla la
+	// respuesta indica cuáles se borraron antes.
+	if purged, err := removeFiles(r.Context(), store, req.Folder, req.Files, req.Purge, time.Now()); err != nil {
+		if req.Purge {
+			writePartialDelete(w, req.Folder, purged, err)
 			return
 		}
+		writeJSONError(w, http.StatusInternalServerError, "Error al eliminar archivo "+err.Error())
+		return
 	}
 
+	if req.Pattern != "" {
+		writeJSON(w, http.StatusOK, DeleteFilesResponse{Folder: req.Folder, Deleted: req.Files})
+		return
+	}
 	w.WriteHeader(http.StatusOK)
-	w.Write([]byte("Archivos eliminados correctamente"))
+	if req.Purge {
+		w.Write([]byte("Archivos eliminados correctamente"))
+		return
+	}
+	w.Write([]byte("Archivos movidos a la papelera"))
 }
@@ -150,6 +150,26 @@ func TestListFilesWithExtension(t *testing.T) {
 			},
 			expectedError: nil,
 		},
+		{
+			name:      "Desempate alfabético con el mismo número",
+			directory: "/test/dir",
+			extension: ".pdf",
+			mockFiles: []MockFile{
+				NewMockFileBuilder().WithName("2-document.pdf").Build(),
+				NewMockFileBuilder().WithName("1-b.pdf").Build(),
+				NewMockFileBuilder().WithName("01-c.pdf").Build(),
+				NewMockFileBuilder().WithName("1-a.pdf").Build(),
+				NewMockFileBuilder().WithName("001-a.pdf").Build(),
+			},
+			expectedFiles: []string{
+				"001-a.pdf",
+				"01-c.pdf",
+				"1-a.pdf",
+				"1-b.pdf",
+				"2-document.pdf",
+			},
+			expectedError: nil,
+		},
 	}
 
 	for _, tt := range tests {
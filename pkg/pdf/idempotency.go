@@ -0,0 +1,110 @@
+package pdf
+
+import (
+	"sync"
+	"time"
+)
+
+// --- Claves de idempotencia para UploadHandler ---
+// Un cliente que reintenta una subida con la misma cabecera Idempotency-Key recibe la
+// respuesta original en lugar de guardar los archivos otra vez. Las claves se guardan
+// en memoria de cada Server con un TTL y un máximo de entradas configurables. Mientras la
+// primera subida sigue en curso la clave queda reservada y un reintento recibe 409.
+
+// idempotentResponse respuesta guardada para repetirla ante un reintento
+type idempotentResponse struct {
+	status  int
+	body    []byte
+	expires time.Time
+}
+
+type idempotencyStore struct {
+	mu      sync.Mutex
+	entries map[string]idempotentResponse
+	pending map[string]bool // Claves reservadas por una subida que todavía no terminó
+	maxKeys int
+	ttl     time.Duration
+	now     func() time.Time // Variable para facilitar el testing
+}
+
+func newIdempotencyStore(maxKeys int, ttl time.Duration) *idempotencyStore {
+	return &idempotencyStore{
+		entries: map[string]idempotentResponse{},
+		pending: map[string]bool{},
+		maxKeys: max(maxKeys, 1),
+		ttl:     ttl,
+		now:     time.Now,
+	}
+}
+
+// get devuelve la respuesta guardada para la clave si todavía no venció
+func (s *idempotencyStore) get(key string) (idempotentResponse, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.getLocked(key)
+}
+
+// begin reserva la clave para una subida nueva. Si ya terminó una con la misma clave
+// devuelve su respuesta con replay=true; si otra sigue en curso devuelve busy=true. Si
+// no, la clave queda reservada hasta put o release.
+func (s *idempotencyStore) begin(key string) (resp idempotentResponse, replay, busy bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if resp, ok := s.getLocked(key); ok {
+		return resp, true, false
+	}
+	if s.pending[key] {
+		return idempotentResponse{}, false, true
+	}
+	s.pending[key] = true
+	return idempotentResponse{}, false, false
+}
+
+// release libera la reserva de begin sin guardar respuesta (ej: la petición era
+// inválida), así un reintento vuelve a procesarse
+func (s *idempotencyStore) release(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.pending, key)
+}
+
+func (s *idempotencyStore) getLocked(key string) (idempotentResponse, bool) {
+	resp, ok := s.entries[key]
+	if !ok {
+		return idempotentResponse{}, false
+	}
+	if s.now().After(resp.expires) {
+		delete(s.entries, key)
+		return idempotentResponse{}, false
+	}
+	return resp, true
+}
+
+// put guarda la respuesta; si el almacén está lleno descarta primero las vencidas
+// y, si no alcanza, la que vence antes
+func (s *idempotencyStore) put(key string, status int, body []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.pending, key)
+	now := s.now()
+	if _, exists := s.entries[key]; !exists && len(s.entries) >= s.maxKeys {
+		var oldestKey string
+		var oldest time.Time
+		for k, resp := range s.entries {
+			if now.After(resp.expires) {
+				delete(s.entries, k)
+				continue
+			}
+			if oldestKey == "" || resp.expires.Before(oldest) {
+				oldestKey, oldest = k, resp.expires
+			}
+		}
+		if len(s.entries) >= s.maxKeys {
+			delete(s.entries, oldestKey)
+		}
+	}
+
+	s.entries[key] = idempotentResponse{status: status, body: body, expires: now.Add(s.ttl)}
+}
@@ -0,0 +1,113 @@
+package pdf
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestIdempotencyStore(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	store := newIdempotencyStore(2, time.Minute)
+	store.now = func() time.Time { return now }
+
+	store.put("a", http.StatusOK, []byte("a"))
+	if _, ok := store.get("a"); !ok {
+		t.Fatalf("expected key a to be stored")
+	}
+
+	// Al llenarse se descarta la clave que vence antes
+	now = now.Add(time.Second)
+	store.put("b", http.StatusOK, []byte("b"))
+	store.put("c", http.StatusOK, []byte("c"))
+	if _, ok := store.get("a"); ok {
+		t.Errorf("expected oldest key a to be evicted")
+	}
+	if _, ok := store.get("c"); !ok {
+		t.Errorf("expected key c to be stored")
+	}
+
+	// Las claves vencidas no se devuelven
+	now = now.Add(2 * time.Minute)
+	if _, ok := store.get("b"); ok {
+		t.Errorf("expected key b to expire")
+	}
+}
+
+func TestUploadHandlerIdempotencyKey(t *testing.T) {
+	// Arrange
+	userPath := t.TempDir()
+
+	srv := newTestServer(userPath)
+
+	upload := func(key string) int {
+		req, rr := NewUploadRequestBuilder().WithFile("a.pdf", []byte("%PDF-1.4")).Build(t)
+		req.Header.Set("Idempotency-Key", key)
+		srv.UploadHandler(rr, req)
+		return rr.Code
+	}
+
+	// Act
+	first := upload("retry-1")
+	second := upload("retry-1")
+	third := upload("retry-2")
+
+	// Assert
+	if first != http.StatusOK || second != http.StatusOK || third != http.StatusOK {
+		t.Fatalf("expected all uploads to return 200, got %d %d %d", first, second, third)
+	}
+	// Solo cuentan los PDFs: la carpeta también guarda el manifiesto oculto de fechas de alta
+	entries, _ := ListFilesWithExtension(filepath.Join(userPath, "test-folder"), ".pdf")
+	if len(entries) != 2 {
+		t.Errorf("expected 2 stored files (retry not saved again), got %d", len(entries))
+	}
+}
+
+func TestUploadHandlerIdempotencyKeyInFlight(t *testing.T) {
+	// Arrange
+	userPath := t.TempDir()
+	srv := newTestServer(userPath)
+	upload := func() *httptest.ResponseRecorder {
+		req, rr := NewUploadRequestBuilder().WithFile("a.pdf", []byte("%PDF-1.4")).Build(t)
+		req.Header.Set("Idempotency-Key", "retry-1")
+		srv.UploadHandler(rr, req)
+		return rr
+	}
+	// La primera subida queda esperando el lock de la carpeta con la clave ya reservada
+	unlock := lockFolder(userPath, "test-folder")
+	firstDone := make(chan *httptest.ResponseRecorder)
+	go func() { firstDone <- upload() }()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		srv.uploadIdempotency.mu.Lock()
+		reserved := srv.uploadIdempotency.pending["testUser/retry-1"]
+		srv.uploadIdempotency.mu.Unlock()
+		if reserved || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// Act
+	retry := upload()
+	unlock()
+	first := <-firstDone
+	replay := upload()
+
+	// Assert
+	if retry.Code != http.StatusConflict {
+		t.Errorf("expected 409 for the concurrent retry, got %d: %s", retry.Code, retry.Body.String())
+	}
+	if first.Code != http.StatusOK {
+		t.Errorf("expected 200 for the first upload, got %d: %s", first.Code, first.Body.String())
+	}
+	if replay.Code != http.StatusOK || replay.Header().Get("Idempotent-Replayed") != "true" {
+		t.Errorf("expected the finished upload to be replayed, got %d", replay.Code)
+	}
+	entries, _ := ListFilesWithExtension(filepath.Join(userPath, "test-folder"), ".pdf")
+	if len(entries) != 1 {
+		t.Errorf("expected the files to be saved once, got %v", entries)
+	}
+}
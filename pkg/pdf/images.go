@@ -0,0 +1,25 @@
+package pdf
+
+import (
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+)
+
+// isImageFile indica si el nombre tiene una extensión de imagen que pdfcpu puede importar
+func isImageFile(name string) bool {
+	return model.ImageFileName(name)
+}
+
+// imagePDFName cambia la extensión de la imagen por .pdf ("scan.jpg" -> "scan.pdf")
+func imagePDFName(name string) string {
+	return strings.TrimSuffix(name, filepath.Ext(name)) + ".pdf"
+}
+
+// convertImageToPDF escribe en dst un PDF de una sola página con la imagen recibida
+func convertImageToPDF(src io.Reader, dst io.Writer) error {
+	return api.ImportImages(nil, dst, []io.Reader{src}, nil, nil)
+}
@@ -0,0 +1,118 @@
+package pdf
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// --- Uniones incrementales ---
+// Tras cada unión joinPDFs guarda junto a la salida un manifiesto oculto con las fuentes
+// usadas (tamaño y fecha de modificación), las opciones y la propia salida. Si en la
+// siguiente unión nada de eso cambió se devuelve el resultado anterior sin leer las
+// fuentes; force=true vuelve a unir siempre.
+
+// Tamaño máximo que se lee de un manifiesto
+const maxMergeManifestBytes = 1 << 20
+
+type mergeManifest struct {
+	Options MergeOptions `json:"options"`
+	Sources []fileStamp  `json:"sources"`
+	Output  fileStamp    `json:"output"`
+	Result  MergeResult  `json:"result"`
+}
+
+// fileStamp identifica una versión de un archivo sin leer su contenido
+type fileStamp struct {
+	Name    string `json:"name"`
+	Size    int64  `json:"size"`
+	ModTime int64  `json:"mod_time"` // En nanosegundos
+}
+
+// mergeManifestPath ruta del manifiesto de la salida outputPath:
+// "u/facturas.pdf" -> "u/.facturas.pdf.manifest". No termina en .pdf, así que los
+// listados y contadores no lo ven.
+func mergeManifestPath(outputPath string) string {
+	dir, base := filepath.Split(outputPath)
+	return filepath.Join(dir, "."+base+".manifest")
+}
+
+func statFile(path string) (fileStamp, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileStamp{}, err
+	}
+	return fileStamp{Name: filepath.Base(path), Size: info.Size(), ModTime: info.ModTime().UnixNano()}, nil
+}
+
+func statFiles(paths []string) ([]fileStamp, error) {
+	stamps := make([]fileStamp, len(paths))
+	for i, path := range paths {
+		stamp, err := statFile(path)
+		if err != nil {
+			return nil, err
+		}
+		stamps[i] = stamp
+	}
+	return stamps, nil
+}
+
+// unchangedMerge devuelve el resultado de la última unión si outputPath sigue siendo la
+// salida que produjo y sources y opts son los mismos que entonces
+func unchangedMerge(outputPath string, sources []string, opts MergeOptions) (MergeResult, bool) {
+	manifest, err := readMergeManifest(outputPath)
+	if err != nil {
+		return MergeResult{}, false
+	}
+
+	// Las opciones se comparan serializadas: así quedan fuera las contraseñas
+	previousOpts, err1 := json.Marshal(manifest.Options)
+	currentOpts, err2 := json.Marshal(opts)
+	if err1 != nil || err2 != nil || !bytes.Equal(previousOpts, currentOpts) {
+		return MergeResult{}, false
+	}
+	output, err := statFile(outputPath)
+	if err != nil || output != manifest.Output {
+		return MergeResult{}, false
+	}
+	stamps, err := statFiles(sources)
+	if err != nil || !slices.Equal(stamps, manifest.Sources) {
+		return MergeResult{}, false
+	}
+
+	result := manifest.Result
+	result.Unchanged = true
+	return result, true
+}
+
+// readMergeManifest lee el manifiesto de la salida outputPath
+func readMergeManifest(outputPath string) (mergeManifest, error) {
+	var manifest mergeManifest
+	file, err := os.Open(mergeManifestPath(outputPath))
+	if err != nil {
+		return manifest, err
+	}
+	defer file.Close()
+	err = json.NewDecoder(io.LimitReader(file, maxMergeManifestBytes)).Decode(&manifest)
+	return manifest, err
+}
+
+// writeMergeManifest guarda el manifiesto de la salida outputPath recién escrita
+func writeMergeManifest(outputPath string, sources []string, opts MergeOptions, result MergeResult) error {
+	manifest := mergeManifest{Options: opts, Result: result}
+	var err error
+	if manifest.Sources, err = statFiles(sources); err != nil {
+		return err
+	}
+	if manifest.Output, err = statFile(outputPath); err != nil {
+		return err
+	}
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(mergeManifestPath(outputPath), data, 0o644)
+}
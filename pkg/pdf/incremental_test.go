@@ -0,0 +1,89 @@
+package pdf
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestGenerateHandlerUnchanged(t *testing.T) {
+	tests := []struct {
+		name              string
+		form              url.Values
+		change            func(t *testing.T, userPath string)
+		expectedUnchanged bool
+	}{
+		{name: "Sin cambios no se vuelve a unir", form: url.Values{}, expectedUnchanged: true},
+		{name: "Con force=true se vuelve a unir", form: url.Values{"force": {"true"}}, expectedUnchanged: false},
+		{
+			name: "Un archivo nuevo en la carpeta",
+			form: url.Values{},
+			change: func(t *testing.T, userPath string) {
+				writeTestPDF(t, filepath.Join(userPath, "test-folder", "2-b.pdf"), 1)
+			},
+			expectedUnchanged: false,
+		},
+		{
+			name: "Una fuente modificada",
+			form: url.Values{},
+			change: func(t *testing.T, userPath string) {
+				later := time.Now().Add(time.Hour)
+				os.Chtimes(filepath.Join(userPath, "test-folder", "1-a.pdf"), later, later)
+			},
+			expectedUnchanged: false,
+		},
+		{
+			name: "La salida modificada fuera de la unión",
+			form: url.Values{},
+			change: func(t *testing.T, userPath string) {
+				writeTestPDF(t, filepath.Join(userPath, "test-folder.pdf"), 3)
+			},
+			expectedUnchanged: false,
+		},
+		{name: "Otras opciones", form: url.Values{"bookmarks": {"true"}}, expectedUnchanged: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			userPath := t.TempDir()
+			folderPath := filepath.Join(userPath, "test-folder")
+			os.MkdirAll(folderPath, os.ModePerm)
+			writeTestPDF(t, filepath.Join(folderPath, "1-a.pdf"), 1)
+			if _, err := joinPDFs(context.Background(), userPath, "test-folder", MergeOptions{}); err != nil {
+				t.Fatal(err)
+			}
+			if tt.change != nil {
+				tt.change(t, userPath)
+			}
+			srv := newTestServer(userPath)
+			tt.form.Set("folder", "test-folder")
+			req, rr := newGenerateRequest(tt.form)
+			mergesBefore := mergesTotal.Load()
+
+			// Act
+			srv.GenerateHandler(rr, req)
+
+			// Assert
+			if rr.Code != http.StatusOK {
+				t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+			}
+			var resp GenerateResponse
+			json.NewDecoder(rr.Body).Decode(&resp)
+			if resp.Unchanged != tt.expectedUnchanged {
+				t.Errorf("expected unchanged %v, got %v", tt.expectedUnchanged, resp.Unchanged)
+			}
+			if resp.Output != "test-folder.pdf" {
+				t.Errorf("expected output test-folder.pdf, got %q", resp.Output)
+			}
+			if merged := mergesTotal.Load() - mergesBefore; merged != 0 && tt.expectedUnchanged {
+				t.Errorf("expected no merge to be counted, got %d", merged)
+			}
+		})
+	}
+}
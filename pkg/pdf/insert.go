@@ -0,0 +1,130 @@
+package pdf
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+)
+
+// InsertHandler: Inserta todas las páginas de source dentro de target, después de la
+// página after_page (0 las pone al principio). Los dos archivos son de la misma carpeta
+// y target se reemplaza por el resultado; source no cambia.
+func (s *Server) InsertHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w, http.MethodPost)
+		return
+	}
+
+	// Obtener la ruta base de almacenamiento del usuario
+	userStoragePath, err := s.storagePath(r)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Error interno de autenticación")
+		return
+	}
+
+	var problems validationErrors
+	folder := r.FormValue("folder")
+	problems.checkFolder(folder)
+	if problems.respond(w) {
+		return
+	}
+	target := r.FormValue("target")
+	if !validFileName(target) {
+		writeJSONError(w, http.StatusBadRequest, "Nombre de archivo destino no válido")
+		return
+	}
+	source := r.FormValue("source")
+	if !validFileName(source) {
+		writeJSONError(w, http.StatusBadRequest, "Nombre de archivo origen no válido")
+		return
+	}
+	afterPage, err := strconv.Atoi(r.FormValue("after_page"))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "after_page debe ser un número de página (0 para insertar al principio)")
+		return
+	}
+
+	folderPath := filepath.Join(userStoragePath, folder)
+	targetPath := filepath.Join(folderPath, target)
+	sourcePath := filepath.Join(folderPath, source)
+
+	unlock := lockFolder(userStoragePath, folder)
+	defer unlock()
+
+	targetPages, err := api.PageCountFile(targetPath)
+	if os.IsNotExist(err) {
+		writeJSONError(w, http.StatusNotFound, "Archivo no encontrado: "+target)
+		return
+	}
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Error al leer el PDF: "+err.Error())
+		return
+	}
+	if _, err := api.PageCountFile(sourcePath); os.IsNotExist(err) {
+		writeJSONError(w, http.StatusNotFound, "Archivo no encontrado: "+source)
+		return
+	} else if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Error al leer el PDF: "+err.Error())
+		return
+	}
+	if afterPage < 0 || afterPage > targetPages {
+		writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("after_page fuera de rango: %s tiene %d páginas", target, targetPages))
+		return
+	}
+
+	tmpDir, cleanup, err := newTempDir("insert-")
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Error al preparar la inserción")
+		return
+	}
+	defer cleanup()
+
+	pages, err := insertPages(r.Context(), tmpDir, targetPath, sourcePath, afterPage, targetPages)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Error al insertar páginas: "+err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, ExtractResponse{Folder: folder, File: target, Pages: pages})
+}
+
+// insertPages arma en tmpDir las páginas 1..afterPage de targetPath, source completo y
+// el resto de target, y reemplaza targetPath por el resultado solo cuando está completo.
+// Devuelve el nuevo total de páginas.
+func insertPages(ctx context.Context, tmpDir, targetPath, sourcePath string, afterPage, targetPages int) (int, error) {
+	var parts []string
+	if afterPage > 0 {
+		head := filepath.Join(tmpDir, "inicio.pdf")
+		if err := api.CollectFile(targetPath, head, []string{fmt.Sprintf("1-%d", afterPage)}, nil); err != nil {
+			return 0, err
+		}
+		parts = append(parts, head)
+	}
+	parts = append(parts, sourcePath)
+	if afterPage < targetPages {
+		tail := filepath.Join(tmpDir, "final.pdf")
+		if err := api.CollectFile(targetPath, tail, []string{fmt.Sprintf("%d-%d", afterPage+1, targetPages)}, nil); err != nil {
+			return 0, err
+		}
+		parts = append(parts, tail)
+	}
+
+	combined := filepath.Join(tmpDir, filepath.Base(targetPath))
+	if err := mergeWithRetry(ctx, parts, combined); err != nil {
+		return 0, err
+	}
+	pages, err := api.PageCountFile(combined)
+	if err != nil {
+		return 0, err
+	}
+	if err := moveFile(combined, targetPath); err != nil {
+		return 0, err
+	}
+	discardChecksumSidecarFile(targetPath)
+	return pages, nil
+}
@@ -0,0 +1,165 @@
+package pdf
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+)
+
+// writeWidePDF escribe un PDF de pages páginas de 300x100, para distinguirlas de las
+// de 200x200 de writeTestPDF
+func writeWidePDF(t *testing.T, path string, pages int) {
+	t.Helper()
+	objects := []string{"<< /Type /Catalog /Pages 2 0 R >>"}
+	var kids []string
+	for i := 0; i < pages; i++ {
+		kids = append(kids, fmt.Sprintf("%d 0 R", 3+i))
+	}
+	objects = append(objects, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), pages))
+	for i := 0; i < pages; i++ {
+		objects = append(objects, "<< /Type /Page /Parent 2 0 R /MediaBox [0 0 300 100] >>")
+	}
+	writeRawPDF(t, path, objects)
+}
+
+func TestInsertHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		afterPage      string
+		expectedStatus int
+		expectedLayout string // Una letra por página: a de target, b de source
+	}{
+		{name: "Después de una página intermedia", afterPage: "2", expectedStatus: http.StatusOK, expectedLayout: "aabba"},
+		{name: "0 inserta al principio", afterPage: "0", expectedStatus: http.StatusOK, expectedLayout: "bbaaa"},
+		{name: "Después de la última página", afterPage: "3", expectedStatus: http.StatusOK, expectedLayout: "aaabb"},
+		{name: "Fuera de rango", afterPage: "4", expectedStatus: http.StatusBadRequest, expectedLayout: "aaa"},
+		{name: "Negativo", afterPage: "-1", expectedStatus: http.StatusBadRequest, expectedLayout: "aaa"},
+		{name: "No numérico", afterPage: "dos", expectedStatus: http.StatusBadRequest, expectedLayout: "aaa"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			userPath := t.TempDir()
+			folderPath := filepath.Join(userPath, "test-folder")
+			os.MkdirAll(folderPath, os.ModePerm)
+			writeTestPDF(t, filepath.Join(folderPath, "1-a.pdf"), 3)
+			writeWidePDF(t, filepath.Join(folderPath, "2-b.pdf"), 2)
+			srv := newTestServer(userPath)
+			req, rr := newGenerateRequest(url.Values{"folder": {"test-folder"}, "target": {"1-a.pdf"}, "source": {"2-b.pdf"}, "after_page": {tt.afterPage}})
+
+			// Act
+			srv.InsertHandler(rr, req)
+
+			// Assert
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("expected %d, got %d: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+			}
+			dims, err := api.PageDimsFile(filepath.Join(folderPath, "1-a.pdf"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			layout := ""
+			for _, dim := range dims {
+				if dim.Width == 300 {
+					layout += "b"
+				} else {
+					layout += "a"
+				}
+			}
+			if layout != tt.expectedLayout {
+				t.Errorf("expected pages %q, got %q", tt.expectedLayout, layout)
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+			var resp ExtractResponse
+			if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+				t.Fatal(err)
+			}
+			expected := ExtractResponse{Folder: "test-folder", File: "1-a.pdf", Pages: len(tt.expectedLayout)}
+			if !reflect.DeepEqual(resp, expected) {
+				t.Errorf("expected %+v, got %+v", expected, resp)
+			}
+			if pages, err := api.PageCountFile(filepath.Join(folderPath, "2-b.pdf")); err != nil || pages != 2 {
+				t.Errorf("expected source to keep its 2 pages, got %d (err: %v)", pages, err)
+			}
+		})
+	}
+}
+
+func TestInsertHandlerMissingFiles(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		source string
+	}{
+		{name: "Destino inexistente", target: "9-x.pdf", source: "2-b.pdf"},
+		{name: "Origen inexistente", target: "1-a.pdf", source: "9-x.pdf"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			userPath := t.TempDir()
+			folderPath := filepath.Join(userPath, "test-folder")
+			os.MkdirAll(folderPath, os.ModePerm)
+			writeTestPDF(t, filepath.Join(folderPath, "1-a.pdf"), 1)
+			writeTestPDF(t, filepath.Join(folderPath, "2-b.pdf"), 1)
+			srv := newTestServer(userPath)
+			req, rr := newGenerateRequest(url.Values{"folder": {"test-folder"}, "target": {tt.target}, "source": {tt.source}, "after_page": {"0"}})
+
+			// Act
+			srv.InsertHandler(rr, req)
+
+			// Assert
+			if rr.Code != http.StatusNotFound {
+				t.Errorf("expected 404, got %d: %s", rr.Code, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestInsertHandlerRejectsFolderOutsideUser(t *testing.T) {
+	tests := []struct {
+		name   string
+		folder string
+	}{
+		{name: "Carpeta de otro usuario", folder: "../otro"},
+		{name: "Carpeta vacía", folder: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange: otro usuario con dos PDFs en la raíz de su espacio
+			root := t.TempDir()
+			userPath := filepath.Join(root, "alex")
+			otherPath := filepath.Join(root, "otro")
+			os.MkdirAll(userPath, os.ModePerm)
+			os.MkdirAll(otherPath, os.ModePerm)
+			writeTestPDF(t, filepath.Join(otherPath, "1-a.pdf"), 1)
+			writeTestPDF(t, filepath.Join(otherPath, "2-b.pdf"), 1)
+			srv := newTestServer(userPath)
+			req, rr := newGenerateRequest(url.Values{"folder": {tt.folder}, "target": {"1-a.pdf"}, "source": {"2-b.pdf"}, "after_page": {"0"}})
+
+			// Act
+			srv.InsertHandler(rr, req)
+
+			// Assert
+			if rr.Code != http.StatusBadRequest {
+				t.Fatalf("expected 400, got %d: %s", rr.Code, rr.Body.String())
+			}
+			if pages, err := api.PageCountFile(filepath.Join(otherPath, "1-a.pdf")); err != nil || pages != 1 {
+				t.Errorf("expected the other user's file to keep its page, got %d (err: %v)", pages, err)
+			}
+		})
+	}
+}
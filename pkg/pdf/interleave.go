@@ -0,0 +1,77 @@
+package pdf
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+)
+
+// MergeModeInterleave alterna las páginas de dos archivos (caras impares y pares
+// de un escaneo a doble cara) en lugar de concatenarlos
+const MergeModeInterleave = "interleave"
+
+var (
+	// ErrInterleaveFileCount indica que el modo intercalado no recibió exactamente dos PDFs
+	ErrInterleaveFileCount = errors.New("el modo intercalado requiere exactamente dos archivos PDF")
+	// ErrInterleavePageCount indica que las páginas de los dos PDFs no se pueden alternar
+	ErrInterleavePageCount = errors.New("el segundo PDF debe tener las mismas páginas que el primero o una menos")
+)
+
+// validMergeMode indica si mode es un modo de unión soportado ("" concatena)
+func validMergeMode(mode string) bool {
+	return mode == "" || mode == MergeModeInterleave
+}
+
+// interleavePageOrder devuelve el orden de páginas del documento intercalado, numeradas
+// sobre la concatenación de ambos archivos (first: 1..n1, second: n1+1..n1+n2).
+// Con reverse el segundo archivo se lee del final al principio, como sale del escáner.
+func interleavePageOrder(firstPages, secondPages int, reverse bool) ([]string, error) {
+	if secondPages != firstPages && secondPages != firstPages-1 {
+		return nil, fmt.Errorf("%w (%d y %d páginas)", ErrInterleavePageCount, firstPages, secondPages)
+	}
+
+	order := make([]string, 0, firstPages+secondPages)
+	for i := 1; i <= firstPages; i++ {
+		order = append(order, strconv.Itoa(i))
+		if i > secondPages {
+			break
+		}
+		back := i
+		if reverse {
+			back = secondPages - i + 1
+		}
+		order = append(order, strconv.Itoa(firstPages+back))
+	}
+	return order, nil
+}
+
+// interleavePDFs une los dos archivos en un temporal y después recoge sus páginas
+// en orden alterno sobre outputFilePath
+func interleavePDFs(files []string, outputFilePath string, reverse bool) error {
+	if len(files) != 2 {
+		return ErrInterleaveFileCount
+	}
+
+	firstPages, err := api.PageCountFile(files[0])
+	if err != nil {
+		return err
+	}
+	secondPages, err := api.PageCountFile(files[1])
+	if err != nil {
+		return err
+	}
+	order, err := interleavePageOrder(firstPages, secondPages, reverse)
+	if err != nil {
+		return err
+	}
+
+	tmpPath := outputFilePath + ".interleave.tmp"
+	defer os.Remove(tmpPath)
+	if err := api.MergeCreateFile(files, tmpPath, false, nil); err != nil {
+		return err
+	}
+	return api.CollectFile(tmpPath, outputFilePath, order, nil)
+}
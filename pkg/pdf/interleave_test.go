@@ -0,0 +1,98 @@
+package pdf
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+)
+
+func TestInterleavePageOrder(t *testing.T) {
+	tests := []struct {
+		name          string
+		first, second int
+		reverse       bool
+		expected      []string
+		expectedErr   error
+	}{
+		{name: "Mismo número de páginas", first: 3, second: 3, expected: []string{"1", "4", "2", "5", "3", "6"}},
+		{name: "Segundo archivo al revés", first: 3, second: 3, reverse: true, expected: []string{"1", "6", "2", "5", "3", "4"}},
+		{name: "Última cara impar sin reverso", first: 3, second: 2, reverse: true, expected: []string{"1", "5", "2", "4", "3"}},
+		{name: "Segundo archivo más largo", first: 2, second: 3, expectedErr: ErrInterleavePageCount},
+		{name: "Diferencia de más de una página", first: 4, second: 2, expectedErr: ErrInterleavePageCount},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := interleavePageOrder(tt.first, tt.second, tt.reverse)
+			if !errors.Is(err, tt.expectedErr) {
+				t.Fatalf("expected error %v, got %v", tt.expectedErr, err)
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("expected order %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestGenerateHandlerInterleave(t *testing.T) {
+	tests := []struct {
+		name           string
+		pages          []int
+		form           url.Values
+		expectedStatus int
+		expectedPages  int
+	}{
+		{name: "Intercala dos archivos", pages: []int{3, 3}, form: url.Values{"reverse": {"true"}}, expectedStatus: http.StatusOK, expectedPages: 6},
+		{name: "Más de dos archivos", pages: []int{1, 1, 1}, expectedStatus: http.StatusBadRequest},
+		{name: "Páginas incompatibles", pages: []int{1, 3}, expectedStatus: http.StatusBadRequest},
+		{name: "Marcadores no soportados", pages: []int{1, 1}, form: url.Values{"bookmarks": {"true"}}, expectedStatus: http.StatusBadRequest},
+		{name: "Modo desconocido", pages: []int{1, 1}, form: url.Values{"mode": {"zigzag"}}, expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			userPath := t.TempDir()
+			folderPath := filepath.Join(userPath, "test-folder")
+			os.MkdirAll(folderPath, os.ModePerm)
+			for i, pages := range tt.pages {
+				writeTestPDF(t, filepath.Join(folderPath, prefixedName("scan.pdf", i+1)), pages)
+			}
+
+			srv := newTestServer(userPath)
+
+			form := url.Values{"folder": {"test-folder"}, "mode": {MergeModeInterleave}}
+			for key, values := range tt.form {
+				form[key] = values
+			}
+			req, rr := newGenerateRequest(form)
+
+			// Act
+			srv.GenerateHandler(rr, req)
+
+			// Assert
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("handler returned wrong status code: got %v want %v (%s)", rr.Code, tt.expectedStatus, rr.Body.String())
+			}
+			if tt.expectedPages == 0 {
+				return
+			}
+			pages, err := api.PageCountFile(filepath.Join(userPath, "test-folder.pdf"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if pages != tt.expectedPages {
+				t.Errorf("expected %d pages, got %d", tt.expectedPages, pages)
+			}
+			if _, err := os.Stat(filepath.Join(userPath, "test-folder.pdf.interleave.tmp")); !os.IsNotExist(err) {
+				t.Errorf("expected temporary merge to be removed")
+			}
+		})
+	}
+}
@@ -0,0 +1,207 @@
+package pdf
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// --- Trabajos de unión asíncronos ---
+// Igual que con los códigos de acceso, cada Server guarda sus trabajos en memoria,
+// indexados por usuario+id para que un usuario no pueda consultar los de otro.
+// Un trabajo terminado se conserva JOB_TTL para consultarlo y después se descarta.
+
+// Variable para facilitar el testing
+var newJobIDFn = defaultNewJobID
+
+type jobStore struct {
+	mu   sync.Mutex
+	jobs map[string]*MergeJob
+	ttl  time.Duration
+	wg   sync.WaitGroup // Trabajos en curso, para esperarlos al apagar el servidor
+	// Se cierra (y se reemplaza) en cada cambio de un trabajo para despertar a /events
+	changed chan struct{}
+}
+
+func newJobStore(ttl time.Duration) *jobStore {
+	return &jobStore{
+		jobs:    map[string]*MergeJob{},
+		ttl:     ttl,
+		changed: make(chan struct{}),
+	}
+}
+
+func jobKey(userCode, id string) string {
+	return userCode + "/" + id
+}
+
+func defaultNewJobID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// enqueueMergeJob registra un trabajo pendiente y lanza la unión en segundo plano.
+// La goroutine espera un espacio del pool sin límite de tiempo: mientras tanto
+// el trabajo permanece en estado "pending". Con callbackURL, al terminar envía el
+// resultado a esa URL y registra en el trabajo si se pudo entregar. De ctx solo se usan sus
+// valores (el id de la petición de los logs): el trabajo sigue aunque la petición termine.
+func (s *Server) enqueueMergeJob(ctx context.Context, userCode, userStoragePath, folder string, opts MergeOptions, callbackURL string) *MergeJob {
+	now := time.Now()
+	job := &MergeJob{
+		ID:        newJobIDFn(),
+		Folder:    folder,
+		Status:    JobPending,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if callbackURL != "" {
+		job.CallbackStatus = CallbackPending
+	}
+
+	s.jobs.mu.Lock()
+	s.jobs.sweepLocked(now)
+	s.jobs.jobs[jobKey(userCode, job.ID)] = job
+	snapshot := *job
+	s.jobs.mu.Unlock()
+
+	ctx = context.WithoutCancel(ctx)
+
+	s.jobs.wg.Add(1)
+	go func() {
+		defer s.jobs.wg.Done()
+		s.merges <- struct{}{}
+		defer s.merges.release()
+
+		s.jobs.update(userCode, job.ID, func(j *MergeJob) { j.Status = JobRunning })
+		opts.OnProgress = func(p MergeProgress) {
+			s.jobs.update(userCode, job.ID, func(j *MergeJob) { j.Progress = &p })
+		}
+		outputName, _ := mergeOutputName(folder, opts.Output) // GenerateHandler ya lo validó
+		unlock := lockFolders(userStoragePath, folder, outputFolder(outputName))
+		result, err := joinPDFs(ctx, userStoragePath, folder, opts)
+		unlock()
+		if err == nil && !result.Unchanged {
+			mergesTotal.Add(1)
+		}
+		s.jobs.update(userCode, job.ID, func(j *MergeJob) {
+			j.Progress = nil
+			if err != nil {
+				j.Status = JobError
+				j.Error = err.Error()
+				return
+			}
+			j.Status = JobDone
+			j.Output = result.Output
+		})
+
+		if callbackURL == "" {
+			return
+		}
+		finished, _ := s.jobs.get(userCode, job.ID)
+		deliveryErr := deliverJobCallback(callbackURL, jobCallbackPayload(finished))
+		s.jobs.update(userCode, job.ID, func(j *MergeJob) {
+			if deliveryErr != nil {
+				j.CallbackStatus = CallbackFailed
+				j.CallbackError = deliveryErr.Error()
+				return
+			}
+			j.CallbackStatus = CallbackDelivered
+		})
+	}()
+
+	return &snapshot
+}
+
+// WaitMergeJobs espera a que terminen los trabajos asíncronos en curso o a que venza ctx.
+// Se usa durante el apagado para no cortar una unión a mitad de escritura.
+func (s *Server) WaitMergeJobs(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		s.jobs.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// update aplica un cambio al trabajo protegido por el mutex
+func (s *jobStore) update(userCode, id string, apply func(j *MergeJob)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if job, ok := s.jobs[jobKey(userCode, id)]; ok {
+		apply(job)
+		job.UpdatedAt = time.Now()
+		close(s.changed)
+		s.changed = make(chan struct{})
+	}
+}
+
+// changes devuelve un canal que se cierra en el próximo cambio de cualquier trabajo.
+// Hay que pedirlo antes de leer el estado para no perder un cambio intermedio.
+func (s *jobStore) changes() <-chan struct{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.changed
+}
+
+// get devuelve una copia del trabajo para no exponer el puntero compartido.
+// Un trabajo vencido ya no se encuentra aunque todavía no se haya barrido.
+func (s *jobStore) get(userCode, id string) (MergeJob, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[jobKey(userCode, id)]
+	if !ok || s.expired(job, time.Now()) {
+		return MergeJob{}, false
+	}
+	return *job, true
+}
+
+// expired indica si el trabajo terminó (y su aviso, si lo había, ya se intentó
+// entregar) hace más de JOB_TTL
+func (s *jobStore) expired(job *MergeJob, now time.Time) bool {
+	finished := job.Status == JobDone || job.Status == JobError
+	return finished && job.CallbackStatus != CallbackPending && now.Sub(job.UpdatedAt) > s.ttl
+}
+
+// sweepLocked descarta los trabajos vencidos. Se llama con el mutex tomado al registrar
+// uno nuevo, como las claves de idempotencia, para que el mapa no crezca durante toda
+// la vida del proceso.
+func (s *jobStore) sweepLocked(now time.Time) {
+	for key, job := range s.jobs {
+		if s.expired(job, now) {
+			delete(s.jobs, key)
+		}
+	}
+}
+
+// JobStatusHandler: Informa el estado de un trabajo asíncrono del usuario autenticado.
+func (s *Server) JobStatusHandler(w http.ResponseWriter, r *http.Request) {
+	userCode, ok := r.Context().Value(userCodeKey).(string)
+	if !ok {
+		writeJSONError(w, http.StatusInternalServerError, "Error interno de autenticación")
+		return
+	}
+
+	id := r.URL.Query().Get("id")
+	if id == "" {
+		writeJSONError(w, http.StatusBadRequest, "Falta el id del trabajo")
+		return
+	}
+
+	job, ok := s.jobs.get(userCode, id)
+	if !ok {
+		writeJSONError(w, http.StatusNotFound, "Trabajo no encontrado")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, job)
+}
@@ -0,0 +1,120 @@
+package pdf
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newJobStatusRequest(userCode, id string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/job-status?id="+id, nil)
+	return req.WithContext(context.WithValue(req.Context(), userCodeKey, userCode))
+}
+
+func TestAsyncGenerateReportsJobStatus(t *testing.T) {
+	// Arrange
+	userPath := t.TempDir()
+	os.MkdirAll(filepath.Join(userPath, "empty-folder"), os.ModePerm)
+
+	originalNewJobID := newJobIDFn
+	defer func() { newJobIDFn = originalNewJobID }()
+	srv := newTestServer(userPath)
+	newJobIDFn = func() string { return "job-1" }
+
+	req := httptest.NewRequest(http.MethodPost, "/generate", strings.NewReader("folder=empty-folder&async=true"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req = req.WithContext(context.WithValue(req.Context(), userCodeKey, "testUser"))
+	rr := httptest.NewRecorder()
+
+	// Act
+	srv.GenerateHandler(rr, req)
+
+	// Assert
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusAccepted)
+	}
+
+	// La carpeta está vacía, así que el trabajo debe terminar en error
+	var job MergeJob
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		status := httptest.NewRecorder()
+		srv.JobStatusHandler(status, newJobStatusRequest("testUser", "job-1"))
+		json.NewDecoder(status.Body).Decode(&job)
+		if job.Status == JobError || job.Status == JobDone {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if job.Status != JobError {
+		t.Errorf("expected job status %q, got %q", JobError, job.Status)
+	}
+
+	// Otro usuario no puede ver el trabajo
+	other := httptest.NewRecorder()
+	srv.JobStatusHandler(other, newJobStatusRequest("otherUser", "job-1"))
+	if other.Code != http.StatusNotFound {
+		t.Errorf("expected %v for another user, got %v", http.StatusNotFound, other.Code)
+	}
+}
+
+func TestMergeJobsExpireAfterTTL(t *testing.T) {
+	tests := []struct {
+		name          string
+		job           MergeJob
+		expectedFound bool
+	}{
+		{
+			name:          "Un trabajo terminado hace más del TTL se descarta",
+			job:           MergeJob{Status: JobDone},
+			expectedFound: false,
+		},
+		{
+			name:          "Un trabajo con error hace más del TTL se descarta",
+			job:           MergeJob{Status: JobError, CallbackStatus: CallbackFailed},
+			expectedFound: false,
+		},
+		{
+			name:          "Un trabajo en curso se conserva",
+			job:           MergeJob{Status: JobRunning},
+			expectedFound: true,
+		},
+		{
+			name:          "Un trabajo con el aviso pendiente se conserva",
+			job:           MergeJob{Status: JobDone, CallbackStatus: CallbackPending},
+			expectedFound: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			store := newJobStore(time.Minute)
+			job := tt.job
+			job.ID = "old-job"
+			job.UpdatedAt = time.Now().Add(-time.Hour)
+			store.jobs[jobKey("testUser", job.ID)] = &job
+
+			// Act
+			_, found := store.get("testUser", job.ID)
+			store.mu.Lock()
+			store.sweepLocked(time.Now())
+			_, kept := store.jobs[jobKey("testUser", job.ID)]
+			store.mu.Unlock()
+
+			// Assert
+			if found != tt.expectedFound {
+				t.Errorf("expected found=%v, got %v", tt.expectedFound, found)
+			}
+			if kept != tt.expectedFound {
+				t.Errorf("expected kept=%v after the sweep, got %v", tt.expectedFound, kept)
+			}
+		})
+	}
+}
@@ -0,0 +1,9 @@
+package pdf
+
+// linearizePDF prepara path para vista web rápida (linealización), de modo que un visor
+// pueda mostrar la primera página antes de terminar la descarga. pdfcpu (v0.9) lee PDFs
+// linealizados pero todavía no sabe escribirlos, así que por ahora el archivo queda igual
+// y devuelve false; joinPDFs lo informa en MergeResult.Linearized en lugar de fallar.
+func linearizePDF(path string) (bool, error) {
+	return false, nil
+}
@@ -0,0 +1,52 @@
+package pdf
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGenerateHandlerLinearize(t *testing.T) {
+	tests := []struct {
+		name               string
+		form               url.Values
+		expectedLinearized *bool
+	}{
+		{name: "Por defecto no se informa", form: url.Values{}, expectedLinearized: nil},
+		// pdfcpu no escribe PDFs linealizados: la unión sigue adelante y se informa false
+		{name: "Con linearize=true se informa que no se linealizó", form: url.Values{"linearize": {"true"}}, expectedLinearized: boolPtr(false)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			userPath := t.TempDir()
+			folderPath := filepath.Join(userPath, "test-folder")
+			os.MkdirAll(folderPath, os.ModePerm)
+			writeTestPDF(t, filepath.Join(folderPath, "1-a.pdf"), 1)
+			srv := newTestServer(userPath)
+			tt.form.Set("folder", "test-folder")
+			req, rr := newGenerateRequest(tt.form)
+
+			// Act
+			srv.GenerateHandler(rr, req)
+
+			// Assert
+			if rr.Code != http.StatusOK {
+				t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+			}
+			var resp GenerateResponse
+			json.NewDecoder(rr.Body).Decode(&resp)
+			if (resp.Linearized == nil) != (tt.expectedLinearized == nil) ||
+				(resp.Linearized != nil && *resp.Linearized != *tt.expectedLinearized) {
+				t.Errorf("expected linearized %v, got %v", tt.expectedLinearized, resp.Linearized)
+			}
+			if _, err := os.Stat(filepath.Join(userPath, "test-folder.pdf")); err != nil {
+				t.Errorf("expected the output to be written: %v", err)
+			}
+		})
+	}
+}
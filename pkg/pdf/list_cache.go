@@ -0,0 +1,58 @@
+package pdf
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// --- Caché condicional de ListHandler ---
+// Los paneles que sondean /list reciben 304 sin cuerpo mientras la carpeta no cambie.
+// El ETag resume los nombres, fechas y tamaños de los archivos listados junto con la
+// consulta (filtro, orden...), porque la misma carpeta da respuestas distintas según ella.
+
+// listValidators calcula el ETag y el Last-Modified del listado files de folder. La fecha
+// es la más reciente entre la carpeta (cambia al crear o borrar) y sus archivos.
+func listValidators(store Storage, folder string, files []string, query string) (string, time.Time) {
+	var lastModified time.Time
+	if info, err := store.Stat(folder, ""); err == nil {
+		lastModified = info.ModTime()
+	}
+
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s\n", query)
+	for _, name := range files {
+		info, err := store.Stat(folder, name)
+		if err != nil {
+			fmt.Fprintf(hash, "%s\n", name)
+			continue
+		}
+		fmt.Fprintf(hash, "%s %d %d\n", name, info.ModTime().UnixNano(), info.Size())
+		if info.ModTime().After(lastModified) {
+			lastModified = info.ModTime()
+		}
+	}
+	return `"` + hex.EncodeToString(hash.Sum(nil)[:16]) + `"`, lastModified
+}
+
+// notModified aplica If-None-Match y, si no viene, If-Modified-Since (RFC 9110)
+func notModified(r *http.Request, etag string, lastModified time.Time) bool {
+	if match := r.Header.Get("If-None-Match"); match != "" {
+		for _, candidate := range strings.Split(match, ",") {
+			candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+			if candidate == etag || candidate == "*" {
+				return true
+			}
+		}
+		return false
+	}
+	since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil || lastModified.IsZero() {
+		return false
+	}
+	// Last-Modified solo tiene precisión de segundos
+	return !lastModified.Truncate(time.Second).After(since)
+}
@@ -0,0 +1,221 @@
+package pdf
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestListHandlerFilter(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		expectedFiles  []string
+	}{
+		{
+			name:           "Sin filtro devuelve todo",
+			query:          "folder=test-folder",
+			expectedStatus: http.StatusOK,
+			expectedFiles:  []string{"1-Draft-intro.pdf", "2-final.pdf", "3-draft-notes.pdf"},
+		},
+		{
+			name:           "Subcadena sin distinguir mayúsculas",
+			query:          "folder=test-folder&filter=DRAFT",
+			expectedStatus: http.StatusOK,
+			expectedFiles:  []string{"1-Draft-intro.pdf", "3-draft-notes.pdf"},
+		},
+		{
+			name:           "Patrón glob",
+			query:          "folder=test-folder&filter=*-final.pdf",
+			expectedStatus: http.StatusOK,
+			expectedFiles:  []string{"2-final.pdf"},
+		},
+		{
+			name:           "Sin coincidencias devuelve lista vacía",
+			query:          "folder=test-folder&filter=nada",
+			expectedStatus: http.StatusOK,
+			expectedFiles:  []string{},
+		},
+		{
+			name:           "Patrón glob mal formado",
+			query:          "folder=test-folder&filter=[a-*",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			userPath := t.TempDir()
+			folderPath := filepath.Join(userPath, "test-folder")
+			os.MkdirAll(folderPath, os.ModePerm)
+			for _, f := range []string{"3-draft-notes.pdf", "1-Draft-intro.pdf", "2-final.pdf"} {
+				os.Create(filepath.Join(folderPath, f))
+			}
+
+			srv := newTestServer(userPath)
+
+			req := httptest.NewRequest(http.MethodGet, "/list?"+tt.query, nil)
+			req = req.WithContext(context.WithValue(req.Context(), userCodeKey, "testUser"))
+			rr := httptest.NewRecorder()
+
+			// Act
+			srv.ListHandler(rr, req)
+
+			// Assert
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, tt.expectedStatus)
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+			var files []string
+			json.NewDecoder(rr.Body).Decode(&files)
+			if !reflect.DeepEqual(files, tt.expectedFiles) {
+				t.Errorf("expected %v, got %v", tt.expectedFiles, files)
+			}
+		})
+	}
+}
+
+func TestListHandlerSortByDate(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		expectedFiles  []string
+	}{
+		{
+			name:           "Orden por defecto sin cambios",
+			query:          "folder=test-folder",
+			expectedStatus: http.StatusOK,
+			expectedFiles:  []string{"1-anexo.pdf", "2-portada.pdf", "report-2023-12-31.pdf", "report-2024-02-30.pdf", "report-2024-03-01.pdf"},
+		},
+		{
+			name:           "Fechas en orden cronológico y el resto después",
+			query:          "folder=test-folder&sort=date",
+			expectedStatus: http.StatusOK,
+			expectedFiles:  []string{"report-2023-12-31.pdf", "report-2024-03-01.pdf", "1-anexo.pdf", "2-portada.pdf", "report-2024-02-30.pdf"},
+		},
+		{
+			name:           "Orden desconocido",
+			query:          "folder=test-folder&sort=size",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			userPath := t.TempDir()
+			folderPath := filepath.Join(userPath, "test-folder")
+			os.MkdirAll(folderPath, os.ModePerm)
+			// report-2024-02-30 no es una fecha válida y se trata como un nombre sin fecha
+			for _, f := range []string{"report-2024-03-01.pdf", "2-portada.pdf", "report-2023-12-31.pdf", "1-anexo.pdf", "report-2024-02-30.pdf"} {
+				os.Create(filepath.Join(folderPath, f))
+			}
+
+			srv := newTestServer(userPath)
+
+			req := httptest.NewRequest(http.MethodGet, "/list?"+tt.query, nil)
+			req = req.WithContext(context.WithValue(req.Context(), userCodeKey, "testUser"))
+			rr := httptest.NewRecorder()
+
+			// Act
+			srv.ListHandler(rr, req)
+
+			// Assert
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, tt.expectedStatus)
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+			var files []string
+			if err := json.NewDecoder(rr.Body).Decode(&files); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(files, tt.expectedFiles) {
+				t.Errorf("expected %v, got %v", tt.expectedFiles, files)
+			}
+		})
+	}
+}
+
+func TestListHandlerConditionalRequests(t *testing.T) {
+	// Arrange
+	userPath := t.TempDir()
+	folderPath := filepath.Join(userPath, "test-folder")
+	os.MkdirAll(folderPath, os.ModePerm)
+	os.WriteFile(filepath.Join(folderPath, "1-a.pdf"), []byte("%PDF"), 0o644)
+	os.WriteFile(filepath.Join(folderPath, "2-b.pdf"), []byte("%PDF"), 0o644)
+	srv := newTestServer(userPath)
+
+	list := func(query string, header http.Header) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/list?"+query, nil)
+		req.Header = header
+		rr := httptest.NewRecorder()
+		srv.ListHandler(rr, req)
+		return rr
+	}
+
+	first := list("folder=test-folder", http.Header{})
+	etag := first.Header().Get("ETag")
+	if first.Code != http.StatusOK || etag == "" || first.Header().Get("Cache-Control") != "no-cache" {
+		t.Fatalf("expected 200 with ETag and Cache-Control, got %d %v", first.Code, first.Header())
+	}
+	lastModified := first.Header().Get("Last-Modified")
+
+	tests := []struct {
+		name           string
+		setup          func()
+		query          string
+		header         http.Header
+		expectedStatus int
+	}{
+		{name: "Mismo ETag sin cambios", query: "folder=test-folder", header: http.Header{"If-None-Match": {etag}}, expectedStatus: http.StatusNotModified},
+		{name: "ETag débil entre varios", query: "folder=test-folder", header: http.Header{"If-None-Match": {`"otro", W/` + etag}}, expectedStatus: http.StatusNotModified},
+		{name: "Otra consulta no comparte ETag", query: "folder=test-folder&filter=a", header: http.Header{"If-None-Match": {etag}}, expectedStatus: http.StatusOK},
+		{name: "If-Modified-Since sin cambios", query: "folder=test-folder", header: http.Header{"If-Modified-Since": {lastModified}}, expectedStatus: http.StatusNotModified},
+		{
+			name:           "Un archivo nuevo cambia el ETag",
+			setup:          func() { os.WriteFile(filepath.Join(folderPath, "3-c.pdf"), []byte("%PDF"), 0o644) },
+			query:          "folder=test-folder",
+			header:         http.Header{"If-None-Match": {etag}},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "Borrar un archivo cambia el ETag",
+			setup: func() {
+				os.Remove(filepath.Join(folderPath, "3-c.pdf"))
+				os.Remove(filepath.Join(folderPath, "2-b.pdf"))
+			},
+			query:          "folder=test-folder",
+			header:         http.Header{"If-None-Match": {etag}},
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.setup != nil {
+				tt.setup()
+			}
+
+			rr := list(tt.query, tt.header)
+
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("expected %d, got %d", tt.expectedStatus, rr.Code)
+			}
+			if tt.expectedStatus == http.StatusNotModified && rr.Body.Len() != 0 {
+				t.Errorf("expected no body with 304, got %q", rr.Body.String())
+			}
+		})
+	}
+}
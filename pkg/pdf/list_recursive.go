@@ -0,0 +1,65 @@
+package pdf
+
+import (
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ListFilesWithExtensionRecursive: Como ListFilesWithExtension pero recorriendo las subcarpetas.
+// Devuelve rutas relativas a dir (con "/" como separador) y nunca incluye directorios.
+// El orden numérico se aplica por nivel: en cada carpeta van primero sus archivos
+// y después el contenido de sus subcarpetas, ambos ordenados por número.
+func ListFilesWithExtensionRecursive(dir string, ext string) ([]string, error) {
+	return listFilesRecursive(dir, ext, "")
+}
+
+// listFilesRecursive aplica además el filtro de ListHandler sobre el nombre de cada archivo
+func listFilesRecursive(dir string, ext string, filter string) ([]string, error) {
+	var matchedFiles []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(d.Name(), ext) || !matchesFilter(d.Name(), filter) {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		matchedFiles = append(matchedFiles, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sortRelPaths(matchedFiles)
+	return matchedFiles, nil
+}
+
+// sortRelPaths ordena rutas relativas nivel por nivel (ver lessRelPath)
+func sortRelPaths(paths []string) {
+	sort.SliceStable(paths, func(i, j int) bool {
+		return lessRelPath(paths[i], paths[j])
+	})
+}
+
+// lessRelPath compara dos rutas relativas nivel por nivel
+func lessRelPath(a, b string) bool {
+	partsA, partsB := strings.Split(a, "/"), strings.Split(b, "/")
+	for k := 0; k < len(partsA) && k < len(partsB); k++ {
+		if partsA[k] == partsB[k] {
+			continue
+		}
+		// Dentro de una misma carpeta los archivos van antes que las subcarpetas
+		aIsFile, bIsFile := k == len(partsA)-1, k == len(partsB)-1
+		if aIsFile != bIsFile {
+			return aIsFile
+		}
+		return lessByNumber(partsA[k], partsB[k])
+	}
+	return len(partsA) < len(partsB)
+}
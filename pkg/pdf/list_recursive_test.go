@@ -0,0 +1,54 @@
+package pdf
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestListFilesWithExtensionRecursive(t *testing.T) {
+	// Arrange
+	dir := t.TempDir()
+	for _, f := range []string{
+		"10-final.pdf",
+		"2-intro.pdf",
+		"notes.txt",
+		"2-anexos/1-a.pdf",
+		"2-anexos/10-c.pdf",
+		"2-anexos/3-b.pdf",
+		"10-extra/1-x.pdf",
+		"1-capitulos/2-dos.pdf",
+		"1-capitulos/sub/1-uno.pdf",
+		"vacia/",
+	} {
+		path := filepath.Join(dir, f)
+		if f[len(f)-1] == '/' {
+			os.MkdirAll(path, os.ModePerm)
+			continue
+		}
+		os.MkdirAll(filepath.Dir(path), os.ModePerm)
+		os.Create(path)
+	}
+
+	// Act
+	files, err := ListFilesWithExtensionRecursive(dir, ".pdf")
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []string{
+		"2-intro.pdf",
+		"10-final.pdf",
+		"1-capitulos/2-dos.pdf",
+		"1-capitulos/sub/1-uno.pdf",
+		"2-anexos/1-a.pdf",
+		"2-anexos/3-b.pdf",
+		"2-anexos/10-c.pdf",
+		"10-extra/1-x.pdf",
+	}
+	if !reflect.DeepEqual(files, expected) {
+		t.Errorf("expected %v, got %v", expected, files)
+	}
+}
@@ -0,0 +1,29 @@
+package pdf
+
+import (
+	"net/url"
+	"strings"
+)
+
+// --- Destino después del login ---
+// LoginHandler redirige a defaultLoginRedirect o a la ruta del campo redirect. Solo se
+// aceptan rutas locales: redirigir a otro sitio con la sesión recién creada permitiría
+// enlaces de login que terminan en una página ajena (open redirect).
+
+// Página a la que LoginHandler redirige si no se indica otra
+const defaultLoginRedirect = "/view/pdf"
+
+// localRedirect indica si target es una ruta absoluta de este mismo sitio. "//otro.com"
+// y "/\otro.com" se rechazan porque los navegadores los interpretan como otro host.
+func localRedirect(target string) bool {
+	if !strings.HasPrefix(target, "/") || strings.HasPrefix(target, "//") || strings.ContainsAny(target, "\\") {
+		return false
+	}
+	for _, r := range target {
+		if r < 0x20 || r == 0x7f {
+			return false
+		}
+	}
+	u, err := url.Parse(target)
+	return err == nil && u.Scheme == "" && u.Host == ""
+}
@@ -0,0 +1,127 @@
+package pdf
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestLocalRedirect(t *testing.T) {
+	tests := []struct {
+		target   string
+		expected bool
+	}{
+		{target: "/view/pdf", expected: true},
+		{target: "/app?tab=2#inicio", expected: true},
+		{target: "", expected: false},
+		{target: "view/pdf", expected: false},
+		{target: "https://otro.com/", expected: false},
+		{target: "//otro.com", expected: false},
+		{target: "/\\otro.com", expected: false},
+		{target: "/\totro.com", expected: false},
+		{target: "javascript:alert(1)", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			// Act
+			got := localRedirect(tt.target)
+
+			// Assert
+			if got != tt.expected {
+				t.Errorf("localRedirect(%q) = %v, want %v", tt.target, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLoginHandlerRedirect(t *testing.T) {
+	tests := []struct {
+		name             string
+		form             url.Values
+		accept           string
+		expectedStatus   int
+		expectedLocation string
+		expectedRedirect string
+	}{
+		{
+			name:             "Por defecto redirige a /view/pdf",
+			form:             url.Values{},
+			expectedStatus:   http.StatusSeeOther,
+			expectedLocation: "/view/pdf",
+		},
+		{
+			name:             "Ruta local indicada",
+			form:             url.Values{"redirect": {"/app/inicio?x=1"}},
+			expectedStatus:   http.StatusSeeOther,
+			expectedLocation: "/app/inicio?x=1",
+		},
+		{
+			name:           "Rechaza otro sitio",
+			form:           url.Values{"redirect": {"https://otro.com/robar"}},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Rechaza URL relativa al protocolo",
+			form:           url.Values{"redirect": {"//otro.com"}},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:             "no_redirect responde JSON",
+			form:             url.Values{"no_redirect": {"true"}, "redirect": {"/app"}},
+			expectedStatus:   http.StatusOK,
+			expectedRedirect: "/app",
+		},
+		{
+			name:             "Accept JSON responde JSON",
+			form:             url.Values{},
+			accept:           "application/json",
+			expectedStatus:   http.StatusOK,
+			expectedRedirect: "/view/pdf",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			srv := newTestServer(t.TempDir())
+			tt.form.Set("access_code", "alex")
+			req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(tt.form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			rr := httptest.NewRecorder()
+
+			// Act
+			srv.LoginHandler(rr, req)
+
+			// Assert
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("expected %d, got %d: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+			}
+			cookies := rr.Result().Cookies()
+			switch tt.expectedStatus {
+			case http.StatusBadRequest:
+				if len(cookies) != 0 {
+					t.Errorf("expected no session cookie on a rejected redirect, got %+v", cookies)
+				}
+			case http.StatusSeeOther:
+				if location := rr.Header().Get("Location"); location != tt.expectedLocation {
+					t.Errorf("expected Location %q, got %q", tt.expectedLocation, location)
+				}
+			case http.StatusOK:
+				var resp LoginResponse
+				if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+					t.Fatal(err)
+				}
+				if resp.Redirect != tt.expectedRedirect || resp.Cookie != "auth_code" || len(cookies) != 1 {
+					t.Errorf("unexpected response %+v with cookies %+v", resp, cookies)
+				}
+			}
+		})
+	}
+}
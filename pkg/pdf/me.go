@@ -0,0 +1,27 @@
+package pdf
+
+import "net/http"
+
+// WhoAmIHandler devuelve el nombre y la fecha de creación asociados al código
+// con el que está autenticada la petición. Se registra detrás de AuthMiddleware.
+func (s *Server) WhoAmIHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, http.MethodGet)
+		return
+	}
+
+	userCode, ok := r.Context().Value(userCodeKey).(string)
+	if !ok || userCode == "" {
+		writeJSONError(w, http.StatusUnauthorized, "Usuario no autenticado")
+		return
+	}
+
+	// El código pudo dejar de ser válido entre el middleware y este punto
+	info, ok := s.codes.Get(userCode)
+	if !ok {
+		writeJSONError(w, http.StatusUnauthorized, "Código de acceso inválido")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, info)
+}
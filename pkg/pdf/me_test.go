@@ -0,0 +1,56 @@
+package pdf
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWhoAmIHandler(t *testing.T) {
+	// Arrange: generar un código para que quede registrado con su nombre
+	srv := NewServer(DefaultConfig(), newDefaultCodeStore())
+	rr := httptest.NewRecorder()
+	srv.GenerateCodeHandler(rr, newGenerateCodeRequest("maria", "2024-03-01", ""))
+	code := strings.TrimSpace(rr.Body.String())
+
+	tests := []struct {
+		name           string
+		cookie         string
+		expectedStatus int
+		expectedName   string
+	}{
+		{name: "Sin cookie", cookie: "", expectedStatus: http.StatusUnauthorized},
+		{name: "Código inválido", cookie: "nope", expectedStatus: http.StatusUnauthorized},
+		{name: "Código generado", cookie: code, expectedStatus: http.StatusOK, expectedName: "maria"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/me", nil)
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: "auth_code", Value: tt.cookie})
+			}
+			rr := httptest.NewRecorder()
+
+			// Act
+			srv.AuthMiddleware(srv.WhoAmIHandler)(rr, req)
+
+			// Assert
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+			var got GeneratedCode
+			if err := json.NewDecoder(rr.Body).Decode(&got); err != nil {
+				t.Fatalf("invalid JSON: %v", err)
+			}
+			if got.Name != tt.expectedName || got.Code != code || got.Created.IsZero() {
+				t.Errorf("unexpected profile: %+v", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,162 @@
+package pdf
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+)
+
+// Máximo de carpetas de una unión y tamaño máximo del cuerpo JSON de MergeFoldersHandler
+const (
+	maxMergeFolders          = 20
+	maxMergeFoldersBodyBytes = 16 << 10
+)
+
+// MergeFoldersHandler: Une en un solo PDF los archivos de varias carpetas con
+// {"folders":["a","b"],"output":"combinado.pdf"}. Cada carpeta se ordena igual que en
+// GenerateHandler y las carpetas se concatenan en el orden pedido. La salida se guarda
+// donde las demás uniones (ver mergeOutputPath) y se informa el total de páginas.
+func (s *Server) MergeFoldersHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w, http.MethodPost)
+		return
+	}
+
+	// Obtener la ruta base de almacenamiento del usuario
+	userStoragePath, err := s.storagePath(r)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Error interno de autenticación")
+		return
+	}
+
+	if !isJSONRequest(r) {
+		writeJSONError(w, http.StatusBadRequest, "Content-Type debe ser application/json")
+		return
+	}
+	var body MergeFoldersRequest
+	r.Body = http.MaxBytesReader(w, r.Body, maxMergeFoldersBodyBytes)
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Formato JSON inválido")
+		return
+	}
+
+	var problems validationErrors
+	switch {
+	case len(body.Folders) == 0:
+		problems.add("folders", "Falta la lista de carpetas")
+	case len(body.Folders) > maxMergeFolders:
+		problems.add("folders", fmt.Sprintf("Se permiten como máximo %d carpetas", maxMergeFolders))
+	}
+	seen := make(map[string]bool, len(body.Folders))
+	for _, folder := range body.Folders {
+		if !validFolderName(folder) {
+			problems.add("folders", "Nombre de carpeta no válido: "+folder)
+		} else if seen[folder] {
+			problems.add("folders", "Carpeta repetida: "+folder)
+		}
+		seen[folder] = true
+	}
+	if body.Output == "" {
+		problems.add("output", "Falta el nombre de la salida")
+	} else if !validFileName(body.Output) {
+		problems.add("output", "Nombre de salida inválido")
+	}
+	if problems.respond(w) {
+		return
+	}
+	outputName := pdfFileName(body.Output)
+
+	// Ninguna subida o borrado puede cambiar las carpetas mientras se unen, ni otra
+	// petición escribir la salida
+	unlock := lockFolders(userStoragePath, append(slices.Clone(body.Folders), outputFolder(outputName))...)
+	defer unlock()
+
+	// Listar cada carpeta en orden; las que no existen o no tienen PDFs se informan juntas
+	var files []string
+	for _, folder := range body.Folders {
+		folderPath := filepath.Join(userStoragePath, folder)
+		names, err := ListFilesWithExtension(folderPath, ".pdf")
+		if errors.Is(err, os.ErrNotExist) {
+			problems.add("folders", "Carpeta no encontrada: "+folder)
+			continue
+		}
+		if err != nil {
+			writeJSONError(w, http.StatusInternalServerError, "Error al leer la carpeta "+folder)
+			return
+		}
+		if len(names) == 0 {
+			problems.add("folders", "La carpeta no tiene PDFs: "+folder)
+			continue
+		}
+		for _, name := range names {
+			files = append(files, filepath.Join(folderPath, name))
+		}
+	}
+	if problems.respond(w) {
+		return
+	}
+
+	// Ocupar un espacio del pool de uniones igual que GenerateHandler
+	if err := s.merges.acquire(r.Context()); err != nil {
+		w.Header().Set("Retry-After", strconv.Itoa(int(mergeQueueTimeout.Seconds())+1))
+		writeJSONError(w, http.StatusTooManyRequests, "Servidor ocupado: "+err.Error())
+		return
+	}
+	defer s.merges.release()
+
+	if _, err := checkPageLimit(files); err != nil {
+		if errors.Is(err, ErrTooManyPages) {
+			writeJSONError(w, http.StatusRequestEntityTooLarge, err.Error())
+			return
+		}
+		writeJSONError(w, http.StatusUnprocessableEntity, "Error al leer los PDFs: "+err.Error())
+		return
+	}
+
+	tmpDir, cleanup, err := newTempDir("merge-folders-")
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "No se pudo crear la carpeta temporal")
+		return
+	}
+	defer cleanup()
+
+	mergedPath := filepath.Join(tmpDir, outputName)
+	if err := mergeWithRetry(r.Context(), files, mergedPath); err != nil {
+		writeJSONError(w, http.StatusUnprocessableEntity, "Error al unir PDFs: "+err.Error())
+		return
+	}
+	totalPages, err := api.PageCountFile(mergedPath)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Error al contar las páginas del PDF unido")
+		return
+	}
+
+	outputPath := mergeOutputPath(userStoragePath, outputName)
+	if err := os.MkdirAll(filepath.Dir(outputPath), os.ModePerm); err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "No se pudo crear la carpeta del usuario")
+		return
+	}
+	if err := moveFile(mergedPath, outputPath); err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Error al guardar el PDF unido")
+		return
+	}
+	discardOutputManifest(outputPath) // Describía una unión anterior con el mismo nombre
+
+	mergesTotal.Add(1)
+	// "<nombre>.pdf" se descarga como la unión de la carpeta "<nombre>"
+	folder := outputFolder(outputName)
+	writeJSON(w, http.StatusOK, MergeFoldersResponse{
+		Output:      outputName,
+		Folders:     body.Folders,
+		Files:       len(files),
+		TotalPages:  totalPages,
+		DownloadURL: downloadURL(folder, outputName),
+	})
+}
@@ -0,0 +1,130 @@
+package pdf
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+)
+
+// newMergeFoldersRequest crea una petición JSON para /merge-folders
+func newMergeFoldersRequest(body string) (*http.Request, *httptest.ResponseRecorder) {
+	req := httptest.NewRequest(http.MethodPost, "/merge-folders", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req = req.WithContext(context.WithValue(req.Context(), userCodeKey, "testUser"))
+	return req, httptest.NewRecorder()
+}
+
+func TestMergeFoldersHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+		expectedPages  int
+		expectedFields []string
+	}{
+		{
+			name:           "Une las carpetas en el orden pedido",
+			body:           `{"folders":["b","a"],"output":"combinado"}`,
+			expectedStatus: http.StatusOK,
+			expectedPages:  6,
+		},
+		{
+			name:           "Carpeta inexistente y carpeta vacía juntas",
+			body:           `{"folders":["a","nada","vacia"],"output":"combinado.pdf"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedFields: []string{"folders", "folders"},
+		},
+		{
+			name:           "Carpeta repetida, con ruta y sin salida",
+			body:           `{"folders":["a","a","../b"]}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedFields: []string{"folders", "folders", "output"},
+		},
+		{
+			name:           "Sin carpetas",
+			body:           `{"folders":[],"output":"combinado.pdf"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedFields: []string{"folders"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			userPath := t.TempDir()
+			for folder, pages := range map[string][]int{"a": {1, 2}, "b": {3}} {
+				os.MkdirAll(filepath.Join(userPath, folder), os.ModePerm)
+				for i, n := range pages {
+					writeTestPDF(t, filepath.Join(userPath, folder, prefixedName("doc.pdf", i+1)), n)
+				}
+			}
+			os.MkdirAll(filepath.Join(userPath, "vacia"), os.ModePerm)
+			srv := newTestServer(userPath)
+			req, rr := newMergeFoldersRequest(tt.body)
+
+			// Act
+			srv.MergeFoldersHandler(rr, req)
+
+			// Assert
+			if tt.expectedStatus != http.StatusOK {
+				assertValidationErrors(t, rr.Code, rr.Body.Bytes(), tt.expectedFields)
+				return
+			}
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("expected %d, got %d: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+			}
+			var resp MergeFoldersResponse
+			json.NewDecoder(rr.Body).Decode(&resp)
+			if resp.Output != "combinado.pdf" || resp.Files != 3 || resp.TotalPages != tt.expectedPages {
+				t.Errorf("unexpected response: %+v", resp)
+			}
+			outputPath := filepath.Join(userPath, "combinado.pdf")
+			if pages, err := api.PageCountFile(outputPath); err != nil || pages != tt.expectedPages {
+				t.Fatalf("expected %d pages in the output, got %d (%v)", tt.expectedPages, pages, err)
+			}
+		})
+	}
+}
+
+func TestMergeFoldersHandlerLocksOutputFolder(t *testing.T) {
+	// Arrange: otra petición tiene bloqueada la carpeta "combinado", dueña de combinado.pdf
+	userPath := t.TempDir()
+	for _, folder := range []string{"a", "b"} {
+		os.MkdirAll(filepath.Join(userPath, folder), os.ModePerm)
+		writeTestPDF(t, filepath.Join(userPath, folder, "1-doc.pdf"), 1)
+	}
+	srv := newTestServer(userPath)
+	req, rr := newMergeFoldersRequest(`{"folders":["a","b"],"output":"combinado"}`)
+	unlock := lockFolder(userPath, "combinado")
+
+	// Act
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		srv.MergeFoldersHandler(rr, req)
+	}()
+
+	// Assert: la salida no se escribe hasta que se libera la carpeta dueña
+	select {
+	case <-done:
+		unlock()
+		t.Fatal("expected the merge to wait for the output folder lock")
+	case <-time.After(100 * time.Millisecond):
+	}
+	if _, err := os.Stat(filepath.Join(userPath, "combinado.pdf")); !os.IsNotExist(err) {
+		t.Errorf("expected no output while the folder is locked")
+	}
+	unlock()
+	<-done
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
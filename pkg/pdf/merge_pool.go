@@ -0,0 +1,42 @@
+package pdf
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// --- Pool de uniones ---
+// Cada unión de PDFs consume bastante memoria y CPU, así que cada Server limita cuántas
+// pueden correr a la vez (MAX_CONCURRENT_MERGES). El canal actúa como semáforo:
+// cada unión ocupa un espacio mientras se ejecuta en la goroutine de la petición.
+var mergeQueueTimeout = defaultConfig.MergeQueueTimeout
+
+type mergePool chan struct{}
+
+func newMergePool(size int) mergePool {
+	return make(mergePool, max(size, 1))
+}
+
+// ErrMergePoolFull se devuelve cuando no se liberó ningún espacio en el tiempo de espera.
+var ErrMergePoolFull = errors.New("demasiadas uniones en curso, intente más tarde")
+
+// acquire espera un espacio libre durante mergeQueueTimeout como máximo.
+// Quien obtiene el espacio debe llamar a release al terminar.
+func (p mergePool) acquire(ctx context.Context) error {
+	timer := time.NewTimer(mergeQueueTimeout)
+	defer timer.Stop()
+
+	select {
+	case p <- struct{}{}:
+		return nil
+	case <-timer.C:
+		return ErrMergePoolFull
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (p mergePool) release() {
+	<-p
+}
@@ -0,0 +1,92 @@
+package pdf
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAcquireMergeSlotFull(t *testing.T) {
+	// Arrange
+	originalTimeout := mergeQueueTimeout
+	defer func() { mergeQueueTimeout = originalTimeout }()
+
+	pool := newMergePool(1)
+	mergeQueueTimeout = 10 * time.Millisecond
+
+	// Act
+	first := pool.acquire(context.Background())
+	second := pool.acquire(context.Background())
+
+	// Assert
+	if first != nil {
+		t.Fatalf("expected first acquire to succeed, got %v", first)
+	}
+	if second != ErrMergePoolFull {
+		t.Errorf("expected ErrMergePoolFull, got %v", second)
+	}
+
+	pool.release()
+	if err := pool.acquire(context.Background()); err != nil {
+		t.Errorf("expected acquire after release to succeed, got %v", err)
+	}
+}
+
+func TestGenerateHandlerPoolFull(t *testing.T) {
+	// Arrange
+	originalTimeout := mergeQueueTimeout
+	defer func() { mergeQueueTimeout = originalTimeout }()
+
+	mergeQueueTimeout = 10 * time.Millisecond
+	srv := newTestServer(t.TempDir())
+	srv.merges = newMergePool(1)
+	srv.merges <- struct{}{} // Pool lleno
+
+	req := httptest.NewRequest(http.MethodPost, "/generate", strings.NewReader("folder=test-folder"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rr := httptest.NewRecorder()
+
+	// Act
+	srv.GenerateHandler(rr, req)
+
+	// Assert
+	if rr.Code != http.StatusTooManyRequests {
+		t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusTooManyRequests)
+	}
+	if rr.Header().Get("Retry-After") == "" {
+		t.Errorf("expected Retry-After header")
+	}
+}
+
+func TestAppendHandlerPoolFull(t *testing.T) {
+	// Arrange
+	originalTimeout := mergeQueueTimeout
+	defer func() { mergeQueueTimeout = originalTimeout }()
+
+	mergeQueueTimeout = 10 * time.Millisecond
+	userPath := t.TempDir()
+	os.MkdirAll(filepath.Join(userPath, "test-folder"), os.ModePerm)
+	writeTestPDF(t, filepath.Join(userPath, "test-folder", "1-document.pdf"), 1)
+	srv := newTestServer(userPath)
+	srv.merges = newMergePool(1)
+	srv.merges <- struct{}{} // Pool lleno
+
+	req, rr := newGenerateRequest(url.Values{"folder": {"test-folder"}, "file": {"1-document.pdf"}})
+
+	// Act
+	srv.AppendHandler(rr, req)
+
+	// Assert
+	if rr.Code != http.StatusTooManyRequests {
+		t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusTooManyRequests)
+	}
+	if rr.Header().Get("Retry-After") == "" {
+		t.Errorf("expected Retry-After header")
+	}
+}
@@ -0,0 +1,58 @@
+package pdf
+
+import (
+	"context"
+	"errors"
+	"os"
+	"syscall"
+	"time"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+)
+
+// --- Reintentos de la unión ---
+// En almacenamiento de red MergeCreateFile falla a veces por un bloqueo o un error de E/S
+// pasajero. Esos errores se reintentan con espera exponencial; los de validación de un
+// PDF roto no, porque fallarían igual.
+var (
+	mergeAttempts     = defaultConfig.MergeAttempts
+	mergeRetryBackoff = defaultConfig.MergeRetryBackoff
+)
+
+// Variable para facilitar el testing
+var mergeCreateFileFn = api.MergeCreateFile
+
+// isTransientMergeError indica si el error es de E/S y puede desaparecer al reintentar
+func isTransientMergeError(err error) bool {
+	if errors.Is(err, os.ErrDeadlineExceeded) {
+		return true
+	}
+	var errno syscall.Errno
+	if !errors.As(err, &errno) {
+		return false
+	}
+	switch errno {
+	case syscall.EAGAIN, syscall.EBUSY, syscall.EINTR, syscall.EIO, syscall.ETIMEDOUT, syscall.ESTALE:
+		return true
+	}
+	return false
+}
+
+// mergeWithRetry une files en outputPath reintentando los errores transitorios hasta
+// mergeAttempts veces; la espera se duplica en cada intento. Los reintentos se registran
+// con el id de la petición de ctx.
+func mergeWithRetry(ctx context.Context, files []string, outputPath string) error {
+	backoff := mergeRetryBackoff
+	var err error
+	for attempt := 1; attempt <= max(mergeAttempts, 1); attempt++ {
+		if err = mergeCreateFileFn(files, outputPath, false, nil); err == nil || !isTransientMergeError(err) {
+			return err
+		}
+		if attempt < mergeAttempts {
+			logf(ctx, "Error transitorio al unir %s (intento %d de %d), reintentando en %s: %v", outputPath, attempt, mergeAttempts, backoff, err)
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+	}
+	return err
+}
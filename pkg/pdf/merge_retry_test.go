@@ -0,0 +1,105 @@
+package pdf
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+)
+
+func TestMergeWithRetry(t *testing.T) {
+	transient := &os.PathError{Op: "write", Path: "salida.pdf", Err: syscall.EIO}
+	permanent := errors.New("validación: xref corrupta")
+
+	tests := []struct {
+		name             string
+		errs             []error // Resultado de cada intento; los que sobran devuelven nil
+		expectedAttempts int
+		expectedErr      error
+		minElapsed       time.Duration // Esperas de 5ms y 10ms entre los tres intentos
+	}{
+		{name: "Éxito al primer intento", errs: nil, expectedAttempts: 1},
+		{name: "Transitorio y luego éxito", errs: []error{transient}, expectedAttempts: 2, minElapsed: 5 * time.Millisecond},
+		{name: "Transitorio en todos los intentos", errs: []error{transient, transient, transient, transient}, expectedAttempts: 3, expectedErr: transient, minElapsed: 15 * time.Millisecond},
+		{name: "Plazo vencido es transitorio", errs: []error{os.ErrDeadlineExceeded}, expectedAttempts: 2},
+		{name: "Permanente no se reintenta", errs: []error{permanent}, expectedAttempts: 1, expectedErr: permanent},
+		{name: "Permanente tras un transitorio", errs: []error{transient, permanent}, expectedAttempts: 2, expectedErr: permanent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			originalFn, originalAttempts, originalBackoff := mergeCreateFileFn, mergeAttempts, mergeRetryBackoff
+			defer func() {
+				mergeCreateFileFn, mergeAttempts, mergeRetryBackoff = originalFn, originalAttempts, originalBackoff
+			}()
+			mergeAttempts, mergeRetryBackoff = 3, 5*time.Millisecond
+			attempts := 0
+			mergeCreateFileFn = func(inFiles []string, outFile string, dividerPage bool, conf *model.Configuration) error {
+				attempts++
+				if attempts <= len(tt.errs) {
+					return tt.errs[attempts-1]
+				}
+				return nil
+			}
+
+			// Act
+			start := time.Now()
+			err := mergeWithRetry(context.Background(), []string{"1-a.pdf", "2-b.pdf"}, "salida.pdf")
+			elapsed := time.Since(start)
+
+			// Assert
+			if attempts != tt.expectedAttempts {
+				t.Errorf("expected %d attempts, got %d", tt.expectedAttempts, attempts)
+			}
+			if !errors.Is(err, tt.expectedErr) {
+				t.Errorf("expected error %v, got %v", tt.expectedErr, err)
+			}
+			if elapsed < tt.minElapsed {
+				t.Errorf("expected the retries to back off at least %s, took %s", tt.minElapsed, elapsed)
+			}
+		})
+	}
+}
+
+func TestMergeWithRetryLogsRequestID(t *testing.T) {
+	// Arrange
+	var buf bytes.Buffer
+	originalOutput, originalFlags := log.Writer(), log.Flags()
+	originalFn, originalAttempts, originalBackoff := mergeCreateFileFn, mergeAttempts, mergeRetryBackoff
+	defer func() {
+		log.SetOutput(originalOutput)
+		log.SetFlags(originalFlags)
+		mergeCreateFileFn, mergeAttempts, mergeRetryBackoff = originalFn, originalAttempts, originalBackoff
+	}()
+	log.SetOutput(&buf)
+	log.SetFlags(0)
+	mergeAttempts, mergeRetryBackoff = 2, time.Millisecond
+	attempts := 0
+	mergeCreateFileFn = func(inFiles []string, outFile string, dividerPage bool, conf *model.Configuration) error {
+		attempts++
+		if attempts == 1 {
+			return &os.PathError{Op: "write", Path: outFile, Err: syscall.EIO}
+		}
+		return nil
+	}
+	ctx := context.WithValue(context.Background(), requestIDKey, "abc-123")
+
+	// Act
+	err := mergeWithRetry(ctx, []string{"1-a.pdf"}, "salida.pdf")
+
+	// Assert
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(buf.String(), "[abc-123] Error transitorio al unir salida.pdf") {
+		t.Errorf("expected the retry to be logged with the request id, got %q", buf.String())
+	}
+}
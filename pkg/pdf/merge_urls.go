@@ -0,0 +1,213 @@
+package pdf
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+)
+
+// --- Unión de PDFs descargados desde URLs ---
+// Para evitar SSRF solo se descargan URLs http(s) de los hosts de MERGE_URL_ALLOWED_HOSTS
+// (también tras redirecciones); sin esa variable la función queda deshabilitada.
+var (
+	mergeURLAllowedHosts = newCodeSet(defaultConfig.MergeURLAllowedHosts)
+	mergeURLTimeout      = defaultConfig.MergeURLTimeout
+	maxMergeURLBytes     = defaultConfig.MaxMergeURLBytes
+	maxMergeURLs         = defaultConfig.MaxMergeURLs
+)
+
+// Tamaño máximo del cuerpo JSON de MergeURLsHandler
+const maxMergeURLsBodyBytes = 64 << 10
+
+var errURLNotAllowed = errors.New("URL no permitida")
+
+// allowedMergeURL indica si la URL es http(s) y su host (con o sin puerto) está permitido
+func allowedMergeURL(u *url.URL) bool {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return false
+	}
+	return mergeURLAllowedHosts[u.Host] || mergeURLAllowedHosts[u.Hostname()]
+}
+
+// newMergeURLClient crea un cliente con timeout que no sigue redirecciones fuera del allowlist
+func newMergeURLClient() *http.Client {
+	return &http.Client{
+		Timeout: mergeURLTimeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 5 {
+				return errors.New("demasiadas redirecciones")
+			}
+			if !allowedMergeURL(req.URL) {
+				return errURLNotAllowed
+			}
+			return nil
+		},
+	}
+}
+
+// fetchPDF descarga rawURL en dstPath comprobando el tipo de contenido y el tamaño máximo
+func fetchPDF(client *http.Client, r *http.Request, rawURL, dstPath string) error {
+	req, err := http.NewRequestWithContext(r.Context(), http.MethodGet, rawURL, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("respuesta %d", resp.StatusCode)
+	}
+	if mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type")); err != nil || mediaType != "application/pdf" {
+		return fmt.Errorf("tipo de contenido no permitido: %q", resp.Header.Get("Content-Type"))
+	}
+	if resp.ContentLength > maxMergeURLBytes {
+		return fmt.Errorf("el archivo supera el máximo de %d bytes", maxMergeURLBytes)
+	}
+
+	dst, err := os.Create(dstPath)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	// Leer un byte de más para detectar archivos que superan el máximo sin Content-Length
+	n, err := io.Copy(dst, io.LimitReader(resp.Body, maxMergeURLBytes+1))
+	if err != nil {
+		return err
+	}
+	if n > maxMergeURLBytes {
+		return fmt.Errorf("el archivo supera el máximo de %d bytes", maxMergeURLBytes)
+	}
+	return nil
+}
+
+// MergeURLsHandler descarga los PDFs de {"urls":[...]} en orden y los une. Sin "output"
+// devuelve el PDF unido en la respuesta; con "output" lo guarda en el espacio del
+// usuario y devuelve el enlace de descarga.
+func (s *Server) MergeURLsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w, http.MethodPost)
+		return
+	}
+	if len(mergeURLAllowedHosts) == 0 {
+		writeJSONError(w, http.StatusForbidden, "La unión desde URLs no está habilitada")
+		return
+	}
+
+	userStoragePath, err := s.storagePath(r)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Error interno de autenticación")
+		return
+	}
+
+	if !isJSONRequest(r) {
+		writeJSONError(w, http.StatusBadRequest, "Content-Type debe ser application/json")
+		return
+	}
+	var body MergeURLsRequest
+	r.Body = http.MaxBytesReader(w, r.Body, maxMergeURLsBodyBytes)
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Formato JSON inválido")
+		return
+	}
+	if len(body.URLs) == 0 {
+		writeJSONError(w, http.StatusBadRequest, "Falta la lista de URLs")
+		return
+	}
+	if len(body.URLs) > maxMergeURLs {
+		writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("Se permiten como máximo %d URLs", maxMergeURLs))
+		return
+	}
+
+	// Validar todas las URLs antes de descargar ninguna
+	for _, rawURL := range body.URLs {
+		u, err := url.Parse(rawURL)
+		if err != nil || !allowedMergeURL(u) {
+			writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("%s: %s", errURLNotAllowed, rawURL))
+			return
+		}
+	}
+
+	outputName := ""
+	if body.Output != "" {
+		if !validFileName(body.Output) {
+			writeJSONError(w, http.StatusBadRequest, "Nombre de salida inválido")
+			return
+		}
+		outputName = pdfFileName(body.Output)
+	}
+
+	tmpDir, cleanup, err := newTempDir("merge-urls-")
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "No se pudo crear la carpeta temporal")
+		return
+	}
+	defer cleanup()
+
+	// Descargar en orden; el nombre numerado conserva el orden de la lista
+	client := newMergeURLClient()
+	files := make([]string, len(body.URLs))
+	for i, rawURL := range body.URLs {
+		files[i] = filepath.Join(tmpDir, fmt.Sprintf("%03d.pdf", i+1))
+		if err := fetchPDF(client, r, rawURL, files[i]); err != nil {
+			writeJSONError(w, http.StatusBadGateway, fmt.Sprintf("Error al descargar %s: %v", rawURL, err))
+			return
+		}
+	}
+
+	if err := s.merges.acquire(r.Context()); err != nil {
+		w.Header().Set("Retry-After", strconv.Itoa(int(mergeQueueTimeout.Seconds())+1))
+		writeJSONError(w, http.StatusTooManyRequests, "Servidor ocupado: "+err.Error())
+		return
+	}
+	defer s.merges.release()
+
+	mergedPath := filepath.Join(tmpDir, "merged.pdf")
+	if err := api.MergeCreateFile(files, mergedPath, false, nil); err != nil {
+		writeJSONError(w, http.StatusUnprocessableEntity, "Error al unir PDFs: "+err.Error())
+		return
+	}
+
+	if outputName == "" {
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": "merged.pdf"}))
+		http.ServeFile(w, r, mergedPath)
+		return
+	}
+
+	// La salida pertenece a una carpeta: no se reemplaza mientras otra petición la usa
+	unlock := lockFolder(userStoragePath, outputFolder(outputName))
+	defer unlock()
+
+	outputPath := mergeOutputPath(userStoragePath, outputName)
+	if err := os.MkdirAll(filepath.Dir(outputPath), os.ModePerm); err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "No se pudo crear la carpeta del usuario")
+		return
+	}
+	if err := moveFile(mergedPath, outputPath); err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Error al guardar el PDF unido")
+		return
+	}
+	discardOutputManifest(outputPath) // Describía una unión anterior con el mismo nombre
+
+	// "<nombre>.pdf" se descarga como la unión de la carpeta "<nombre>"
+	folder := strings.TrimSuffix(outputName, filepath.Ext(outputName))
+	writeJSON(w, http.StatusOK, MergeURLsResponse{
+		Output:      outputName,
+		Files:       len(files),
+		DownloadURL: downloadURL(folder, outputName),
+	})
+}
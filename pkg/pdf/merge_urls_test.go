@@ -0,0 +1,131 @@
+package pdf
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+)
+
+// newMergeURLsServer sirve PDFs de prueba y respuestas que el handler debe rechazar
+func newMergeURLsServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	pdfPath := filepath.Join(t.TempDir(), "src.pdf")
+	writeTestPDF(t, pdfPath, 2)
+	pdfBytes, err := os.ReadFile(pdfPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/doc.pdf", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Write(pdfBytes)
+	})
+	mux.HandleFunc("/page.html", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte("<html></html>"))
+	})
+	mux.HandleFunc("/redirect", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "http://169.254.169.254/latest/meta-data", http.StatusFound)
+	})
+	return httptest.NewServer(mux)
+}
+
+func newMergeURLsRequest(body string) (*http.Request, *httptest.ResponseRecorder) {
+	req := httptest.NewRequest(http.MethodPost, "/merge-urls", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req = req.WithContext(context.WithValue(req.Context(), userCodeKey, "testUser"))
+	return req, httptest.NewRecorder()
+}
+
+func TestMergeURLsHandler(t *testing.T) {
+	server := newMergeURLsServer(t)
+	defer server.Close()
+	serverURL, _ := url.Parse(server.URL)
+
+	tests := []struct {
+		name           string
+		body           string
+		allowedHosts   []string
+		expectedStatus int
+	}{
+		{name: "Deshabilitado sin allowlist", body: `{"urls":["` + server.URL + `/doc.pdf"]}`, expectedStatus: http.StatusForbidden},
+		{name: "Host fuera del allowlist", body: `{"urls":["http://example.com/doc.pdf"]}`, allowedHosts: []string{serverURL.Host}, expectedStatus: http.StatusBadRequest},
+		{name: "Esquema no permitido", body: `{"urls":["file:///etc/passwd"]}`, allowedHosts: []string{serverURL.Host}, expectedStatus: http.StatusBadRequest},
+		{name: "Tipo de contenido no permitido", body: `{"urls":["` + server.URL + `/page.html"]}`, allowedHosts: []string{serverURL.Host}, expectedStatus: http.StatusBadGateway},
+		{name: "Redirección fuera del allowlist", body: `{"urls":["` + server.URL + `/redirect"]}`, allowedHosts: []string{serverURL.Hostname()}, expectedStatus: http.StatusBadGateway},
+		{name: "Devuelve el PDF unido", body: `{"urls":["` + server.URL + `/doc.pdf","` + server.URL + `/doc.pdf"]}`, allowedHosts: []string{serverURL.Host}, expectedStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			userPath := t.TempDir()
+			originalHosts := mergeURLAllowedHosts
+			defer func() { mergeURLAllowedHosts = originalHosts }()
+			srv := newTestServer(userPath)
+			mergeURLAllowedHosts = newCodeSet(tt.allowedHosts)
+
+			req, rr := newMergeURLsRequest(tt.body)
+
+			// Act
+			srv.MergeURLsHandler(rr, req)
+
+			// Assert
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("handler returned wrong status code: got %v want %v (%s)", rr.Code, tt.expectedStatus, rr.Body.String())
+			}
+			if tt.expectedStatus == http.StatusOK {
+				if got := rr.Header().Get("Content-Type"); got != "application/pdf" {
+					t.Errorf("expected application/pdf, got %s", got)
+				}
+				merged := filepath.Join(t.TempDir(), "merged.pdf")
+				os.WriteFile(merged, rr.Body.Bytes(), 0o644)
+				if pages, err := api.PageCountFile(merged); err != nil || pages != 4 {
+					t.Errorf("expected 4 merged pages, got %d (err: %v)", pages, err)
+				}
+			}
+		})
+	}
+}
+
+func TestMergeURLsHandlerSavesOutput(t *testing.T) {
+	// Arrange
+	server := newMergeURLsServer(t)
+	defer server.Close()
+	serverURL, _ := url.Parse(server.URL)
+
+	userPath := t.TempDir()
+	originalHosts := mergeURLAllowedHosts
+	defer func() { mergeURLAllowedHosts = originalHosts }()
+	srv := newTestServer(userPath)
+	mergeURLAllowedHosts = newCodeSet([]string{serverURL.Host})
+
+	req, rr := newMergeURLsRequest(`{"urls":["` + server.URL + `/doc.pdf"],"output":"externo"}`)
+
+	// Act
+	srv.MergeURLsHandler(rr, req)
+
+	// Assert
+	if rr.Code != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v (%s)", rr.Code, http.StatusOK, rr.Body.String())
+	}
+	var resp MergeURLsResponse
+	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Output != "externo.pdf" || resp.Files != 1 || resp.DownloadURL != "/download?folder=externo&output=externo.pdf" {
+		t.Errorf("unexpected response %+v", resp)
+	}
+	if _, err := os.Stat(filepath.Join(userPath, "externo.pdf")); err != nil {
+		t.Errorf("expected merged output in user storage: %v", err)
+	}
+}
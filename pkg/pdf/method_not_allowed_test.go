@@ -0,0 +1,50 @@
+package pdf
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMethodNotAllowedSetsAllow(t *testing.T) {
+	srv := newTestServer(t.TempDir())
+	tests := []struct {
+		name          string
+		handler       http.HandlerFunc
+		method        string
+		expectedAllow string
+	}{
+		{name: "Upload", handler: srv.UploadHandler, method: http.MethodGet, expectedAllow: "POST"},
+		{name: "Generate", handler: srv.GenerateHandler, method: http.MethodGet, expectedAllow: "POST"},
+		{name: "Login", handler: srv.LoginHandler, method: http.MethodGet, expectedAllow: "POST"},
+		{name: "Delete", handler: srv.DeleteFilesHandler, method: http.MethodPost, expectedAllow: "DELETE"},
+		{name: "Clear", handler: srv.ClearFolderHandler, method: http.MethodGet, expectedAllow: "DELETE"},
+		{name: "Count", handler: srv.CountHandler, method: http.MethodPost, expectedAllow: "GET"},
+		{name: "List", handler: srv.ListHandler, method: http.MethodPost, expectedAllow: "GET"},
+		{name: "List rechaza DELETE", handler: srv.ListHandler, method: http.MethodDelete, expectedAllow: "GET"},
+		{name: "Download", handler: srv.DownloadHandler, method: http.MethodPost, expectedAllow: "GET, HEAD"},
+		{name: "Download rechaza PUT", handler: srv.DownloadHandler, method: http.MethodPut, expectedAllow: "GET, HEAD"},
+		{name: "Preferences", handler: srv.PreferencesHandler, method: http.MethodPost, expectedAllow: "GET, PUT"},
+		{name: "AdminGC", handler: srv.AdminGCHandler, method: http.MethodGet, expectedAllow: "POST"},
+		{name: "AdminConfig", handler: srv.AdminConfigHandler, method: http.MethodPost, expectedAllow: "GET"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			req := httptest.NewRequest(tt.method, "/", nil)
+			rr := httptest.NewRecorder()
+
+			// Act
+			tt.handler(rr, req)
+
+			// Assert
+			if rr.Code != http.StatusMethodNotAllowed {
+				t.Fatalf("expected 405, got %d", rr.Code)
+			}
+			if allow := rr.Header().Get("Allow"); allow != tt.expectedAllow {
+				t.Errorf("expected Allow %q, got %q", tt.expectedAllow, allow)
+			}
+		})
+	}
+}
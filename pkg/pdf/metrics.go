@@ -0,0 +1,39 @@
+package pdf
+
+import (
+	"net/http"
+	"sync/atomic"
+)
+
+// mergesTotal cuenta las uniones completadas desde el arranque (síncronas y asíncronas)
+var mergesTotal atomic.Int64
+
+// MetricsHandler: Expone métricas básicas de operación en JSON. Se registra detrás
+// de AdminMiddleware porque recorre todo el almacenamiento.
+func (s *Server) MetricsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, http.MethodGet)
+		return
+	}
+
+	usage, err := collectUsage(s.cfg.StorageRoot, s.userLabel)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Error al calcular el uso de almacenamiento")
+		return
+	}
+
+	codes, err := s.codes.List()
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Error al listar los códigos de acceso")
+		return
+	}
+	metrics := Metrics{ValidCodes: len(codes)}
+
+	metrics.UsersWithStorage = len(usage)
+	for _, u := range usage {
+		metrics.BytesStored += u.Bytes
+	}
+	metrics.MergesTotal = mergesTotal.Load()
+
+	writeJSON(w, http.StatusOK, metrics)
+}
@@ -0,0 +1,58 @@
+package pdf
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMetricsHandler(t *testing.T) {
+	// Arrange
+	root := filepath.Join(t.TempDir(), "archivos")
+	userPath := filepath.Join(root, "testUser")
+	folderPath := filepath.Join(userPath, "test-folder")
+	os.MkdirAll(folderPath, os.ModePerm)
+	writeTestPDF(t, filepath.Join(folderPath, "1-a.pdf"), 1)
+	os.MkdirAll(filepath.Join(root, "otro"), os.ModePerm)
+
+	srv := newTestServerWithRoot(root)
+
+	mergesBefore := mergesTotal.Load()
+	req, rr := newGenerateRequest(url.Values{"folder": {"test-folder"}})
+	srv.GenerateHandler(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("generate failed: %d %s", rr.Code, rr.Body.String())
+	}
+
+	srv.codes.Add(GeneratedCode{Name: "bea", Code: "bea"})
+	expectedCodes := 2 // "alex" y "bea"
+
+	// Act
+	rr = httptest.NewRecorder()
+	srv.MetricsHandler(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	// Assert
+	if rr.Code != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+	}
+	var metrics Metrics
+	if err := json.NewDecoder(rr.Body).Decode(&metrics); err != nil {
+		t.Fatal(err)
+	}
+	if metrics.ValidCodes != expectedCodes {
+		t.Errorf("expected %d valid codes, got %d", expectedCodes, metrics.ValidCodes)
+	}
+	if metrics.UsersWithStorage != 2 {
+		t.Errorf("expected 2 users with storage, got %d", metrics.UsersWithStorage)
+	}
+	if metrics.BytesStored == 0 {
+		t.Errorf("expected stored bytes to be counted")
+	}
+	if metrics.MergesTotal != mergesBefore+1 {
+		t.Errorf("expected merges counter to increase by one, got %d (before %d)", metrics.MergesTotal, mergesBefore)
+	}
+}
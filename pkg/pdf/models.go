@@ -1,7 +1,464 @@
 package pdf
 
-// DeleteFilesRequest estructura para la solicitud de eliminación de archivos
+import "time"
+
+// LoginResponse respuesta de LoginHandler para clientes que no siguen la redirección.
+// El valor de la cookie no se repite: llega en Set-Cookie.
+type LoginResponse struct {
+	Cookie   string `json:"cookie"`
+	Path     string `json:"path"`
+	Secure   bool   `json:"secure"`
+	Redirect string `json:"redirect"` // Adonde habría redirigido a un navegador
+}
+
+// DeleteFilesRequest estructura para la solicitud de eliminación de archivos.
+// Files y Pattern son excluyentes; sin ninguno de los dos se eliminan todos los PDFs.
 type DeleteFilesRequest struct {
+	Folder  string   `json:"folder"`
+	Files   []string `json:"files"`
+	Pattern string   `json:"pattern,omitempty"` // Patrón de filepath.Match, p. ej. "draft-*.pdf"
+	Purge   bool     `json:"purge,omitempty"`   // Borrar definitivamente en lugar de mover a la papelera
+}
+
+// DeleteFilesResponse resultado de eliminar por patrón
+type DeleteFilesResponse struct {
+	Folder  string   `json:"folder"`
+	Deleted []string `json:"deleted"`
+}
+
+// TrashItem archivo en la papelera del usuario
+type TrashItem struct {
+	ID        string    `json:"id"` // Para RestoreHandler
+	Folder    string    `json:"folder"`
+	File      string    `json:"file"`
+	Size      int64     `json:"size"`
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+// TrashListResponse respuesta de ListTrashHandler
+type TrashListResponse struct {
+	Folder string      `json:"folder,omitempty"`
+	Files  []TrashItem `json:"files"`
+}
+
+// RestoreRequest cuerpo JSON de RestoreHandler
+type RestoreRequest struct {
+	ID string `json:"id"`
+}
+
+// RestoreResponse archivo devuelto a su carpeta
+type RestoreResponse struct {
+	Folder string `json:"folder"`
+	File   string `json:"file"`
+}
+
+// RenameFolderRequest cuerpo JSON de RenameFolderHandler
+type RenameFolderRequest struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// RenameFolderResponse resultado de renombrar una carpeta; Output solo si se movió su PDF unido
+type RenameFolderResponse struct {
+	Folder string `json:"folder"`
+	Output string `json:"output,omitempty"`
+}
+
+// CopyFolderRequest cuerpo JSON de CopyFolderHandler
+type CopyFolderRequest struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// CopyFolderResponse carpeta creada por CopyFolderHandler y los PDFs que contiene
+type CopyFolderResponse struct {
 	Folder string   `json:"folder"`
 	Files  []string `json:"files"`
 }
+
+// JobStatus estado de un trabajo de unión asíncrono
+type JobStatus string
+
+const (
+	JobPending JobStatus = "pending"
+	JobRunning JobStatus = "running"
+	JobDone    JobStatus = "done"
+	JobError   JobStatus = "error"
+)
+
+// MergeJob estado de una unión lanzada con async=true
+type MergeJob struct {
+	ID        string    `json:"job_id"`
+	Folder    string    `json:"folder"`
+	Status    JobStatus `json:"status"`
+	Output    string    `json:"output,omitempty"`
+	Error     string    `json:"error,omitempty"`
+	CreatedAt time.Time `json:"created"`
+	UpdatedAt time.Time `json:"updated"`
+	// Entrega del aviso a callback_url, si se pidió: pending, delivered o failed
+	CallbackStatus string `json:"callback_status,omitempty"`
+	CallbackError  string `json:"callback_error,omitempty"`
+	// Paso en curso de la unión mientras el trabajo está en "running" (ver /events)
+	Progress *MergeProgress `json:"progress,omitempty"`
+}
+
+// MergeProgress paso de joinPDFs en curso. Current y Total solo se informan en los
+// pasos que recorren las fuentes una a una.
+type MergeProgress struct {
+	Step    string `json:"step"`
+	Current int    `json:"current,omitempty"`
+	Total   int    `json:"total,omitempty"`
+}
+
+// MergeJobCallback cuerpo JSON que se envía a callback_url al terminar un trabajo
+type MergeJobCallback struct {
+	ID          string    `json:"job_id"`
+	Status      JobStatus `json:"status"`
+	Folder      string    `json:"folder"`
+	Output      string    `json:"output,omitempty"`
+	DownloadURL string    `json:"download_url,omitempty"`
+	Error       string    `json:"error,omitempty"`
+}
+
+// AppendResponse resultado de agregar un archivo al PDF unido existente
+type AppendResponse struct {
+	Folder      string `json:"folder"`
+	File        string `json:"file"`
+	Mode        string `json:"mode"` // "append" o "full" si no existía salida previa
+	PagesBefore int    `json:"pages_before"`
+	PagesAfter  int    `json:"pages_after"`
+	PagesAdded  int    `json:"pages_added"`
+}
+
+// ExtractResponse resultado de extraer un rango de páginas a un archivo nuevo; también
+// de InsertHandler y RemovePagesHandler, con el archivo modificado y su nuevo total de páginas
+type ExtractResponse struct {
+	Folder string `json:"folder"`
+	File   string `json:"file"`
+	Pages  int    `json:"pages"`
+}
+
+// SplitSection archivo producido por SplitByBookmarksHandler para un marcador
+type SplitSection struct {
+	File     string `json:"file"`
+	Title    string `json:"title"`
+	PageFrom int    `json:"page_from"`
+	PageThru int    `json:"page_thru"`
+}
+
+// SplitResponse resultado de dividir un PDF unido por sus marcadores
+type SplitResponse struct {
+	Folder   string         `json:"folder"`
+	Sections []SplitSection `json:"sections"`
+}
+
+// FileChecksum archivo de ListHandler con checksums=true; SHA256 se omite si no se pudo calcular
+type FileChecksum struct {
+	Name   string `json:"name"`
+	SHA256 string `json:"sha256,omitempty"`
+}
+
+// PreviewPage miniatura de la primera página de un archivo, en el orden de la unión.
+// Image es un PNG en base64; si no se pudo generar se informa Error en su lugar.
+type PreviewPage struct {
+	File  string `json:"file"`
+	Image string `json:"image,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// PreviewResponse resultado de PreviewHandler
+type PreviewResponse struct {
+	Folder string        `json:"folder"`
+	Width  int           `json:"width"`
+	Pages  []PreviewPage `json:"pages"`
+}
+
+// RepairResponse resultado de RepairHandler. Valid indica si el archivo original pasó la
+// validación; si no, Repaired indica si se reemplazó por una copia reparada válida.
+type RepairResponse struct {
+	Folder          string `json:"folder"`
+	File            string `json:"file"`
+	Valid           bool   `json:"valid"`
+	ValidationError string `json:"validation_error,omitempty"`
+	Repaired        bool   `json:"repaired"`
+	RepairError     string `json:"repair_error,omitempty"`
+}
+
+// FileProblem describe un problema detectado con un archivo concreto
+type FileProblem struct {
+	File  string `json:"file"`
+	Error string `json:"error"`
+}
+
+// MergePlan resultado de GenerateHandler con dry_run=true
+type MergePlan struct {
+	Folder     string        `json:"folder"`
+	Files      []string      `json:"files"`
+	TotalPages int           `json:"total_pages"`
+	Problems   []FileProblem `json:"problems"`
+}
+
+// QuotaResponse espacio usado por el usuario autenticado frente a su cuota
+type QuotaResponse struct {
+	UsedBytes  int64 `json:"usedBytes"`
+	LimitBytes int64 `json:"limitBytes"` // -1 sin límite
+	Folders    int   `json:"folders"`
+}
+
+// UserUsage espacio ocupado por un usuario en el almacenamiento
+type UserUsage struct {
+	User    string `json:"user"`
+	Bytes   int64  `json:"bytes"`
+	Folders int    `json:"folders"`
+}
+
+// OrphanOutput salida unida cuya carpeta de origen ya no existe. Unverified indica que
+// la salida no tiene manifiesto y su carpeta se dedujo de su nombre.
+type OrphanOutput struct {
+	User       string `json:"user"`
+	Output     string `json:"output"`
+	Folder     string `json:"folder"`
+	Bytes      int64  `json:"bytes"`
+	Unverified bool   `json:"unverified,omitempty"`
+}
+
+// GCReport resultado de AdminGCHandler; con DryRun nada se borró. Outputs son las salidas
+// borradas (o que se borrarían) y Bytes su tamaño; Unverified, las salidas sin manifiesto
+// que se dejaron porque no se pidió include_unverified.
+type GCReport struct {
+	DryRun     bool           `json:"dry_run"`
+	Outputs    []OrphanOutput `json:"outputs"`
+	Bytes      int64          `json:"bytes"`
+	Unverified []OrphanOutput `json:"unverified"`
+}
+
+// MergeOptions opciones de GenerateHandler; el valor cero produce la salida por defecto
+type MergeOptions struct {
+	Grayscale  bool   `json:"grayscale,omitempty"`
+	Bookmarks  bool   `json:"bookmarks,omitempty"`
+	Output     string `json:"output,omitempty"`     // Nombre del PDF unido; por defecto "<folder>.pdf"
+	Mode       string `json:"mode,omitempty"`       // "" concatena; "interleave" alterna dos archivos
+	Reverse    bool   `json:"reverse,omitempty"`    // En modo intercalado, leer el segundo archivo al revés
+	Cover      string `json:"cover,omitempty"`      // Título de una portada generada al inicio
+	Separators bool   `json:"separators,omitempty"` // Página con el nombre de cada archivo antes de él
+	Normalize  string `json:"normalize,omitempty"`  // Tamaño al que se escalan todas las páginas (A4, Letter)
+	Flatten    bool   `json:"flatten,omitempty"`    // Aplanar formularios y anotaciones de cada fuente
+	Indices    []int  `json:"indices,omitempty"`    // Posiciones (desde 1) de los PDFs a unir; vacío todos
+	Linearize  bool   `json:"linearize,omitempty"`  // Salida para vista web rápida (ver linearizePDF)
+	Force      bool   `json:"-"`                    // Volver a unir aunque nada haya cambiado (ver unchangedMerge)
+
+	// Recibe cada paso de la unión; lo usan los trabajos asíncronos para /events
+	OnProgress func(MergeProgress) `json:"-"`
+
+	// Título de cada marcador y separador, p. ej. "{index}. {name}" (ver sourceLabel)
+	LabelTemplate string `json:"label_template,omitempty"`
+
+	// Contraseñas de las fuentes cifradas: por nombre de archivo o, si no está, la común.
+	// Nunca se serializan.
+	Passwords      map[string]string `json:"-"`
+	SourcePassword string            `json:"-"`
+}
+
+// MergePreferences opciones de unión por defecto de un usuario (ver /preferences).
+// GenerateHandler las usa para los campos que la petición no trae.
+type MergePreferences struct {
+	Grayscale     bool   `json:"grayscale,omitempty"`
+	Bookmarks     bool   `json:"bookmarks,omitempty"`
+	Separators    bool   `json:"separators,omitempty"`
+	Normalize     string `json:"normalize,omitempty"`
+	Flatten       bool   `json:"flatten,omitempty"`
+	Linearize     bool   `json:"linearize,omitempty"`
+	LabelTemplate string `json:"label_template,omitempty"`
+}
+
+// SizeChange diferencia de tamaño producida por un post-proceso
+type SizeChange struct {
+	Before int64 `json:"size_before"`
+	After  int64 `json:"size_after"`
+	Saved  int64 `json:"size_saved"`
+}
+
+// MergeResult describe la salida producida por joinPDFs
+type MergeResult struct {
+	Folder    string      `json:"folder"`
+	Output    string      `json:"output"`
+	Grayscale *SizeChange `json:"grayscale,omitempty"`
+	PageSize  string      `json:"page_size,omitempty"` // Tamaño de página si se normalizó
+	Flattened *bool       `json:"flattened,omitempty"` // Con flatten=true, si alguna fuente tenía algo que aplanar
+	// Con linearize=true, si la salida quedó linealizada; hoy siempre false (ver linearizePDF)
+	Linearized *bool `json:"linearized,omitempty"`
+	// Páginas de los PDFs unidos; solo se calcula con MAX_TOTAL_PAGES configurado
+	TotalPages int `json:"total_pages,omitempty"`
+	// La salida ya correspondía a las fuentes y opciones actuales y no se volvió a unir
+	Unchanged bool `json:"unchanged,omitempty"`
+}
+
+// ManifestFile fuente de una unión en el manifiesto de auditoría
+type ManifestFile struct {
+	Name   string `json:"name"`
+	SHA256 string `json:"sha256"`
+	Pages  int    `json:"pages"`
+}
+
+// OutputManifest manifiesto de auditoría de una salida: qué archivos, en qué orden y con
+// qué opciones la produjeron
+type OutputManifest struct {
+	Folder       string         `json:"folder"`
+	Output       string         `json:"output"`
+	OutputSHA256 string         `json:"output_sha256"`
+	CreatedAt    time.Time      `json:"created_at"`
+	Options      MergeOptions   `json:"options"`
+	Files        []ManifestFile `json:"files"`
+}
+
+// GenerateResponse respuesta de GenerateHandler cuando la unión termina
+type GenerateResponse struct {
+	Message string `json:"message"`
+	MergeResult
+	DownloadURL string `json:"download_url"` // También en la cabecera Location
+}
+
+// GenerateCodeRequest cuerpo JSON opcional de GenerateCodeHandler
+type GenerateCodeRequest struct {
+	Name string `json:"name"`
+	Date string `json:"date"`
+}
+
+// GeneratedCode respuesta JSON de GenerateCodeHandler
+type GeneratedCode struct {
+	Name    string    `json:"name"`
+	Code    string    `json:"code"`
+	Created time.Time `json:"created"`
+}
+
+// CodeBatchResult resultado de una entrada de GenerateCodesBatchHandler, en la misma
+// posición que en la petición: el código generado o el motivo por el que no se generó
+type CodeBatchResult struct {
+	Index int `json:"index"`
+	*GeneratedCode
+	Error string `json:"error,omitempty"`
+}
+
+// ClearFolderResponse resultado de vaciar una carpeta
+type ClearFolderResponse struct {
+	Folder  string `json:"folder"`
+	Deleted int    `json:"deleted"`
+}
+
+// CountResponse número de PDFs de una carpeta
+type CountResponse struct {
+	Folder string `json:"folder"`
+	Count  int    `json:"count"`
+}
+
+// NextIndexResponse prefijo que recibiría el próximo archivo subido a una carpeta
+type NextIndexResponse struct {
+	Folder    string `json:"folder"`
+	Count     int    `json:"count"`
+	NextIndex int    `json:"next_index"`
+	Name      string `json:"name,omitempty"` // Nombre que recibiría el archivo pedido con file
+}
+
+// ErrorResponse cuerpo JSON de las respuestas de error de los handlers
+type ErrorResponse struct {
+	Error  string `json:"error"`
+	Status int    `json:"status"`
+}
+
+// PartialDeleteResponse cuerpo del 500 de un borrado con purge que falló a mitad
+type PartialDeleteResponse struct {
+	ErrorResponse
+	Folder  string   `json:"folder"`
+	Deleted []string `json:"deleted"` // Borrados antes del fallo: ya no se pueden recuperar
+}
+
+// FieldError un problema de validación de un campo de la petición
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationErrorResponse cuerpo de un 400 con todos los problemas de validación
+type ValidationErrorResponse struct {
+	ErrorResponse
+	Errors []FieldError `json:"errors"`
+}
+
+// ChunkUploadStatus estado de una subida por fragmentos
+type ChunkUploadStatus struct {
+	UploadID string `json:"upload_id"`
+	Total    int    `json:"total"`
+	Received int    `json:"received"`
+	Missing  []int  `json:"missing,omitempty"`
+	File     string `json:"file,omitempty"` // Nombre final, solo al completar la subida
+}
+
+// UploadFailure archivo que no se pudo guardar en UploadHandler
+type UploadFailure struct {
+	Name  string `json:"name"`
+	Error string `json:"error"`
+}
+
+// UploadResult resumen por archivo de UploadHandler
+type UploadResult struct {
+	Message string          `json:"message"`
+	Saved   []string        `json:"saved"`
+	Failed  []UploadFailure `json:"failed"`
+}
+
+// MergeURLsRequest cuerpo JSON de MergeURLsHandler
+type MergeURLsRequest struct {
+	URLs   []string `json:"urls"`
+	Output string   `json:"output"`
+}
+
+// MergeURLsResponse resultado de MergeURLsHandler cuando se guarda la salida
+type MergeURLsResponse struct {
+	Output      string `json:"output"`
+	Files       int    `json:"files"`
+	DownloadURL string `json:"download_url"`
+}
+
+// MergeFoldersRequest cuerpo JSON de MergeFoldersHandler
+type MergeFoldersRequest struct {
+	Folders []string `json:"folders"`
+	Output  string   `json:"output"`
+}
+
+// MergeFoldersResponse resultado de MergeFoldersHandler
+type MergeFoldersResponse struct {
+	Output      string   `json:"output"`
+	Folders     []string `json:"folders"`
+	Files       int      `json:"files"`
+	TotalPages  int      `json:"total_pages"`
+	DownloadURL string   `json:"download_url"`
+}
+
+// ExistsResponse resultado de ExistsHandler; sin la salida solo se informa exists=false
+type ExistsResponse struct {
+	Exists  bool       `json:"exists"`
+	ModTime *time.Time `json:"modTime,omitempty"`
+	Size    int64      `json:"size,omitempty"`
+}
+
+// Metrics respuesta de MetricsHandler
+type Metrics struct {
+	ValidCodes       int   `json:"valid_codes"`
+	UsersWithStorage int   `json:"users_with_storage"`
+	BytesStored      int64 `json:"bytes_stored"`
+	MergesTotal      int64 `json:"merges_total"`
+}
+
+// FolderLimitResponse error 413 cuando una subida supera MAX_FILES_PER_FOLDER
+type FolderLimitResponse struct {
+	ErrorResponse
+	Count int `json:"count"`
+	Limit int `json:"limit"`
+}
+
+// Base64DownloadResponse PDF unido codificado en base64 (download_format=base64)
+type Base64DownloadResponse struct {
+	Filename string `json:"filename"`
+	Data     string `json:"data"`
+	Bytes    int    `json:"bytes"`
+}
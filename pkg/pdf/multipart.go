@@ -0,0 +1,164 @@
+package pdf
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+)
+
+// --- Formularios multipart ---
+// Los archivos de un formulario se guardan en memoria hasta MULTIPART_MAX_MEMORY bytes y el
+// resto en temporales de MULTIPART_TEMP_DIR. mime/multipart siempre crea los suyos en
+// os.TempDir(), así que el formulario se lee parte a parte en vez de con ParseMultipartForm.
+var (
+	multipartMaxMemory = defaultConfig.MultipartMaxMemory
+	multipartTempDir   = defaultConfig.MultipartTempDir
+)
+
+// Margen para los campos de texto, el mismo que da mime/multipart además de maxMemory
+const multipartValueBytes = 10 << 20
+
+// uploadedFile es un archivo de un formulario multipart, en memoria o en un temporal
+type uploadedFile struct {
+	Filename string
+	Size     int64
+	content  []byte
+	tmpfile  string
+}
+
+// memoryFile permite abrir como multipart.File un archivo guardado en memoria
+type memoryFile struct {
+	*bytes.Reader
+}
+
+func (memoryFile) Close() error { return nil }
+
+// Open abre el contenido del archivo, esté en memoria o en disco
+func (f *uploadedFile) Open() (multipart.File, error) {
+	if f.tmpfile != "" {
+		return os.Open(f.tmpfile)
+	}
+	return memoryFile{bytes.NewReader(f.content)}, nil
+}
+
+// uploadForm son los archivos de un formulario multipart, por nombre de campo. Los campos
+// de texto quedan en r.Form y r.PostForm, así que se leen con r.FormValue.
+type uploadForm struct {
+	File map[string][]*uploadedFile
+}
+
+// RemoveAll borra los temporales en disco del formulario
+func (f *uploadForm) RemoveAll() {
+	for _, files := range f.File {
+		for _, file := range files {
+			if file.tmpfile != "" {
+				os.Remove(file.tmpfile)
+			}
+		}
+	}
+}
+
+// parseMultipartForm parsea el formulario de r guardando en memoria hasta maxMemory bytes.
+// Quien lo llama debe diferir cleanup aunque falle: borra los temporales en disco sin
+// esperar a que el servidor termine la respuesta.
+func parseMultipartForm(r *http.Request, maxMemory int64) (form *uploadForm, cleanup func(), err error) {
+	form = &uploadForm{File: map[string][]*uploadedFile{}}
+	cleanup = form.RemoveAll
+
+	// Igual que ParseMultipartForm, un cuerpo que no es multipart se parsea con ParseForm
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType != "multipart/form-data" {
+		if err := r.ParseForm(); err != nil {
+			return form, cleanup, err
+		}
+		return form, cleanup, http.ErrNotMultipart
+	}
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return form, cleanup, err
+	}
+	if multipartTempDir != "" {
+		if err := os.MkdirAll(multipartTempDir, os.ModePerm); err != nil {
+			return form, cleanup, err
+		}
+	}
+
+	values := url.Values{}
+	valueBytes := maxMemory + multipartValueBytes
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return form, cleanup, err
+		}
+		name := part.FormName()
+		if name == "" {
+			continue
+		}
+
+		if part.FileName() == "" {
+			var value bytes.Buffer
+			n, err := io.CopyN(&value, part, valueBytes+1)
+			if err != nil && err != io.EOF {
+				return form, cleanup, err
+			}
+			valueBytes -= n
+			if valueBytes < 0 {
+				return form, cleanup, multipart.ErrMessageTooLarge
+			}
+			values.Add(name, value.String())
+			continue
+		}
+
+		file, err := readUploadedFile(part, &maxMemory)
+		if file != nil {
+			form.File[name] = append(form.File[name], file)
+		}
+		if err != nil {
+			return form, cleanup, err
+		}
+	}
+
+	// Igual que ParseMultipartForm: r.Form junta la query y el cuerpo
+	r.PostForm = values
+	r.Form = url.Values{}
+	for key, vs := range r.URL.Query() {
+		r.Form[key] = append(r.Form[key], vs...)
+	}
+	for key, vs := range values {
+		r.Form[key] = append(r.Form[key], vs...)
+	}
+	return form, cleanup, nil
+}
+
+// readUploadedFile lee una parte con archivo: en memoria si cabe en lo que queda de
+// *memory, y si no en un temporal de MULTIPART_TEMP_DIR. Si el temporal se llegó a crear
+// devuelve el archivo aunque falle, para que RemoveAll lo borre.
+func readUploadedFile(part *multipart.Part, memory *int64) (*uploadedFile, error) {
+	file := &uploadedFile{Filename: part.FileName()}
+	var buf bytes.Buffer
+	n, err := io.CopyN(&buf, part, *memory+1)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	if n <= *memory {
+		*memory -= n
+		file.content, file.Size = buf.Bytes(), n
+		return file, nil
+	}
+
+	// Con "" os.CreateTemp usa la temporal del sistema
+	tmp, err := os.CreateTemp(multipartTempDir, "multipart-")
+	if err != nil {
+		return nil, err
+	}
+	defer tmp.Close()
+	file.tmpfile = tmp.Name()
+	file.Size, err = io.Copy(tmp, io.MultiReader(&buf, part))
+	return file, err
+}
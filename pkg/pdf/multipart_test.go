@@ -0,0 +1,129 @@
+package pdf
+
+import (
+	"bytes"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestUploadHandlerMultipartSpill(t *testing.T) {
+	tests := []struct {
+		name           string
+		builder        *UploadRequestBuilder
+		expectedStatus int
+	}{
+		{
+			name:           "Subida que no cabe en memoria",
+			builder:        NewUploadRequestBuilder().WithFile("a.pdf", []byte("%PDF-1.4 contenido")),
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Subida rechazada también limpia los temporales",
+			builder:        NewUploadRequestBuilder().WithFile("notas.txt", []byte("no es un PDF")),
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			spillDir := filepath.Join(t.TempDir(), "spill")
+			originalMemory, originalDir := multipartMaxMemory, multipartTempDir
+			defer func() { multipartMaxMemory, multipartTempDir = originalMemory, originalDir }()
+			multipartMaxMemory = 1
+			multipartTempDir = spillDir
+			userPath := t.TempDir()
+			srv := newTestServer(userPath)
+			req, rr := tt.builder.Build(t)
+
+			// Act
+			srv.UploadHandler(rr, req)
+
+			// Assert
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("expected %d, got %d: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+			}
+			entries, err := os.ReadDir(spillDir)
+			if err != nil {
+				t.Fatalf("expected the spill directory to be created: %v", err)
+			}
+			if len(entries) != 0 {
+				t.Errorf("expected the multipart temp files to be removed, found %d", len(entries))
+			}
+		})
+	}
+}
+
+func TestParseMultipartFormSpillDir(t *testing.T) {
+	tests := []struct {
+		name      string
+		maxMemory int64
+		spilled   bool
+	}{
+		{name: "Cabe en memoria", maxMemory: 1 << 20, spilled: false},
+		{name: "Se escribe en MULTIPART_TEMP_DIR", maxMemory: 1, spilled: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			spillDir := filepath.Join(t.TempDir(), "spill")
+			originalDir := multipartTempDir
+			defer func() { multipartTempDir = originalDir }()
+			multipartTempDir = spillDir
+			content := []byte("%PDF-1.4 contenido")
+			req, _ := NewUploadRequestBuilder().WithFolder("test-folder").WithFile("a.pdf", content).Build(t)
+
+			// Act
+			form, cleanup, err := parseMultipartForm(req, tt.maxMemory)
+			defer cleanup()
+
+			// Assert
+			if err != nil {
+				t.Fatal(err)
+			}
+			if req.FormValue("folder") != "test-folder" {
+				t.Errorf("expected the folder field in r.Form, got %q", req.FormValue("folder"))
+			}
+			files := uploadedFiles(form)
+			if len(files) != 1 {
+				t.Fatalf("expected one file, got %d", len(files))
+			}
+			if spilled := filepath.Dir(files[0].tmpfile) == spillDir; spilled != tt.spilled {
+				t.Errorf("expected spilled=%v, got temp file %q", tt.spilled, files[0].tmpfile)
+			}
+			file, err := files[0].Open()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer file.Close()
+			got, _ := io.ReadAll(file)
+			if !bytes.Equal(got, content) || files[0].Size != int64(len(content)) {
+				t.Errorf("unexpected content %q (size %d)", got, files[0].Size)
+			}
+		})
+	}
+}
+
+func TestParseMultipartFormValueLimit(t *testing.T) {
+	// Arrange: un campo de texto mayor que maxMemory más el margen de los campos
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	writer.WriteField("folder", string(bytes.Repeat([]byte("a"), multipartValueBytes+2)))
+	writer.Close()
+	req, _ := http.NewRequest(http.MethodPost, "/upload", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	// Act
+	_, cleanup, err := parseMultipartForm(req, 1)
+	defer cleanup()
+
+	// Assert
+	if err != multipart.ErrMessageTooLarge {
+		t.Errorf("expected ErrMessageTooLarge, got %v", err)
+	}
+}
@@ -0,0 +1,60 @@
+package pdf
+
+import (
+	"errors"
+	"io/fs"
+	"net/http"
+)
+
+// NextIndexHandler: Devuelve el prefijo numérico que UploadHandler daría al próximo archivo
+// de una carpeta, para que el cliente pueda nombrar sus archivos de antemano. Igual que en
+// la subida es el número de archivos de la carpeta (ver countStoredFiles) más uno, no el mayor prefijo existente: si
+// se borraron archivos puede coincidir con uno que ya está. Con ?file= devuelve además el
+// nombre con el que se guardaría ese archivo (ver prefixedName), que conserva el prefijo
+// si ya empieza por "N-". Los archivos de una misma subida reciben números consecutivos.
+func (s *Server) NextIndexHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, http.MethodGet)
+		return
+	}
+
+	// Obtener la ruta base de almacenamiento del usuario
+	userStoragePath, err := s.storagePath(r)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Error interno de autenticación")
+		return
+	}
+	store, err := s.storageFor(r)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Error interno de autenticación")
+		return
+	}
+
+	var problems validationErrors
+	folder := r.URL.Query().Get("folder")
+	problems.checkFolder(folder)
+	if problems.respond(w) {
+		return
+	}
+	file := r.URL.Query().Get("file")
+	if file != "" && !validFileName(file) {
+		writeJSONError(w, http.StatusBadRequest, "Nombre de archivo no válido")
+		return
+	}
+
+	unlock := rLockFolder(userStoragePath, folder)
+	defer unlock()
+
+	// El mismo listado que usa UploadHandler; una carpeta que no existe tiene 0 archivos
+	count, err := countStoredFiles(store, folder)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		writeJSONError(w, http.StatusInternalServerError, "Error al leer la carpeta")
+		return
+	}
+
+	resp := NextIndexResponse{Folder: folder, Count: count, NextIndex: count + 1}
+	if file != "" {
+		resp.Name = prefixedName(file, resp.NextIndex)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
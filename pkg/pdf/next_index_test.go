@@ -0,0 +1,111 @@
+package pdf
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNextIndexHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		expectedResp   NextIndexResponse
+	}{
+		{
+			// La imagen guardada sin convertir cuenta; el registro de altas no
+			name:           "Número de archivos más uno",
+			query:          "folder=test-folder",
+			expectedStatus: http.StatusOK,
+			expectedResp:   NextIndexResponse{Folder: "test-folder", Count: 3, NextIndex: 4},
+		},
+		{
+			// Igual que la subida: se cuentan los archivos, no se busca el mayor prefijo
+			name:           "Con huecos en la numeración",
+			query:          "folder=huecos",
+			expectedStatus: http.StatusOK,
+			expectedResp:   NextIndexResponse{Folder: "huecos", Count: 1, NextIndex: 2},
+		},
+		{
+			name:           "Carpeta inexistente",
+			query:          "folder=missing",
+			expectedStatus: http.StatusOK,
+			expectedResp:   NextIndexResponse{Folder: "missing", Count: 0, NextIndex: 1},
+		},
+		{
+			name:           "Nombre que recibiría un archivo",
+			query:          "folder=test-folder&file=c.pdf",
+			expectedStatus: http.StatusOK,
+			expectedResp:   NextIndexResponse{Folder: "test-folder", Count: 3, NextIndex: 4, Name: "4-c.pdf"},
+		},
+		{
+			name:           "Un archivo con prefijo lo conserva",
+			query:          "folder=test-folder&file=7-c.pdf",
+			expectedStatus: http.StatusOK,
+			expectedResp:   NextIndexResponse{Folder: "test-folder", Count: 3, NextIndex: 4, Name: "7-c.pdf"},
+		},
+		{name: "Falta la carpeta", query: "", expectedStatus: http.StatusBadRequest},
+		{name: "Nombre de archivo con ruta", query: "folder=test-folder&file=../c.pdf", expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			userPath := t.TempDir()
+			for _, f := range []string{"test-folder/1-a.pdf", "test-folder/2-b.pdf", "test-folder/3-scan.png", "test-folder/" + addedManifestName, "huecos/5-e.pdf"} {
+				os.MkdirAll(filepath.Join(userPath, filepath.Dir(f)), os.ModePerm)
+				os.WriteFile(filepath.Join(userPath, f), []byte("%PDF"), 0o644)
+			}
+			srv := newTestServer(userPath)
+			req := httptest.NewRequest(http.MethodGet, "/next-index?"+tt.query, nil)
+			req = req.WithContext(context.WithValue(req.Context(), userCodeKey, "testUser"))
+			rr := httptest.NewRecorder()
+
+			// Act
+			srv.NextIndexHandler(rr, req)
+
+			// Assert
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("expected %d, got %d: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+			var resp NextIndexResponse
+			json.NewDecoder(rr.Body).Decode(&resp)
+			if resp != tt.expectedResp {
+				t.Errorf("expected %+v, got %+v", tt.expectedResp, resp)
+			}
+		})
+	}
+}
+
+func TestNextIndexMatchesUpload(t *testing.T) {
+	// Arrange
+	userPath := t.TempDir()
+	os.MkdirAll(filepath.Join(userPath, "test-folder"), os.ModePerm)
+	os.WriteFile(filepath.Join(userPath, "test-folder", "1-a.pdf"), []byte("%PDF"), 0o644)
+	srv := newTestServer(userPath)
+	req := httptest.NewRequest(http.MethodGet, "/next-index?folder=test-folder&file=b.pdf", nil)
+	req = req.WithContext(context.WithValue(req.Context(), userCodeKey, "testUser"))
+	rr := httptest.NewRecorder()
+	srv.NextIndexHandler(rr, req)
+	var next NextIndexResponse
+	json.NewDecoder(rr.Body).Decode(&next)
+	uploadReq, uploadRR := NewUploadRequestBuilder().WithFile("b.pdf", []byte("%PDF-1.4")).Build(t)
+
+	// Act
+	srv.UploadHandler(uploadRR, uploadReq)
+
+	// Assert
+	var result UploadResult
+	json.NewDecoder(uploadRR.Body).Decode(&result)
+	if len(result.Saved) != 1 || result.Saved[0] != next.Name {
+		t.Errorf("expected the upload to save %q, got %v", next.Name, result.Saved)
+	}
+}
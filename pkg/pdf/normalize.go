@@ -0,0 +1,48 @@
+package pdf
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+// Tamaños de página a los que se puede normalizar una unión, por nombre en minúsculas
+var normalizePageSizes = map[string]string{
+	"a4":     "A4",
+	"letter": "Letter",
+}
+
+// Tamaño usado con normalize=true; se configura con NORMALIZE_PAGE_SIZE
+var defaultNormalizePageSize = defaultConfig.NormalizePageSize
+
+var errInvalidPageSize = errors.New("normalize debe ser true, false, A4 o Letter")
+
+// resolvePageSize interpreta el valor de normalize y devuelve el tamaño de página
+// canónico ("" si no se normaliza). true usa el tamaño configurado por defecto.
+func resolvePageSize(value string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "", "false":
+		return "", nil
+	case "true":
+		value = defaultNormalizePageSize
+	}
+	size, ok := normalizePageSizes[strings.ToLower(value)]
+	if !ok {
+		return "", errInvalidPageSize
+	}
+	return size, nil
+}
+
+// normalizePages escala cada página del PDF al tamaño indicado, centrando el contenido
+// y rellenando el margen que sobre. Las páginas apaisadas se mantienen apaisadas.
+// El archivo se reescribe en su lugar.
+func normalizePages(pdfPath, pageSize string) error {
+	resize, err := pdfcpu.ParseResizeConfig("formsize:"+pageSize, types.POINTS)
+	if err != nil {
+		return err
+	}
+	return api.ResizeFile(pdfPath, "", nil, resize, nil)
+}
@@ -0,0 +1,102 @@
+package pdf
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+)
+
+func TestResolvePageSize(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected string
+		wantErr  bool
+	}{
+		{value: "", expected: ""},
+		{value: "false", expected: ""},
+		{value: "true", expected: defaultNormalizePageSize},
+		{value: "a4", expected: "A4"},
+		{value: "Letter", expected: "Letter"},
+		{value: "A3", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			// Act
+			size, err := resolvePageSize(tt.value)
+
+			// Assert
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if size != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, size)
+			}
+		})
+	}
+}
+
+func TestGenerateHandlerNormalize(t *testing.T) {
+	tests := []struct {
+		name             string
+		normalize        string
+		expectedStatus   int
+		expectedPageSize string
+		expectedWidth    float64
+		expectedHeight   float64
+	}{
+		{name: "Sin normalizar", normalize: "", expectedStatus: http.StatusOK, expectedWidth: 200, expectedHeight: 200},
+		{name: "Normalizar a Letter", normalize: "letter", expectedStatus: http.StatusOK, expectedPageSize: "Letter", expectedWidth: 612, expectedHeight: 792},
+		{name: "Tamaño no soportado", normalize: "A3", expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			userPath := t.TempDir()
+			folderPath := filepath.Join(userPath, "test-folder")
+			os.MkdirAll(folderPath, os.ModePerm)
+			writeTestPDF(t, filepath.Join(folderPath, "1-a.pdf"), 1)
+			writeTestPDF(t, filepath.Join(folderPath, "2-b.pdf"), 2)
+
+			srv := newTestServer(userPath)
+
+			req, rr := newGenerateRequest(url.Values{"folder": {"test-folder"}, "normalize": {tt.normalize}})
+
+			// Act
+			srv.GenerateHandler(rr, req)
+
+			// Assert
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("handler returned wrong status code: got %v want %v (%s)", rr.Code, tt.expectedStatus, rr.Body.String())
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+			var body GenerateResponse
+			if err := json.NewDecoder(rr.Body).Decode(&body); err != nil {
+				t.Fatalf("expected JSON body: %v", err)
+			}
+			if body.PageSize != tt.expectedPageSize {
+				t.Errorf("expected page_size %q, got %q", tt.expectedPageSize, body.PageSize)
+			}
+			dims, err := api.PageDimsFile(filepath.Join(userPath, "test-folder.pdf"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(dims) != 3 {
+				t.Fatalf("expected 3 pages, got %d", len(dims))
+			}
+			for i, dim := range dims {
+				if dim.Width != tt.expectedWidth || dim.Height != tt.expectedHeight {
+					t.Errorf("page %d: expected %vx%v, got %vx%v", i+1, tt.expectedWidth, tt.expectedHeight, dim.Width, dim.Height)
+				}
+			}
+		})
+	}
+}
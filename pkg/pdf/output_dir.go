@@ -0,0 +1,34 @@
+package pdf
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// Carpeta de las salidas unidas relativa al almacenamiento de cada usuario (OUTPUT_DIR).
+// Vacía deja cada salida en la raíz del usuario, junto a la carpeta de origen.
+var outputDir = defaultConfig.OutputDir
+
+// mergeOutputPath ruta de la salida outputName: donde la escriben joinPDFs y
+// MergeURLsHandler y donde la buscan DownloadHandler, ExistsHandler y AppendHandler
+func mergeOutputPath(userStoragePath, outputName string) string {
+	return filepath.Join(userStoragePath, outputDir, outputName)
+}
+
+// outputDirRoot primer elemento de OUTPUT_DIR ("salidas" en "salidas/pdf"), o vacío si las
+// salidas van a la raíz del usuario. Es una carpeta reservada: si se pudiera subir, unir,
+// listar o borrar como las demás, esas operaciones alcanzarían a todas las salidas.
+func outputDirRoot() string {
+	if outputDir == "" {
+		return ""
+	}
+	root, _, _ := strings.Cut(filepath.ToSlash(filepath.Clean(outputDir)), "/")
+	return root
+}
+
+// outputFolder carpeta dueña de la salida outputName: "<nombre>.pdf" es la salida por
+// defecto de la carpeta "<nombre>". Quien escriba outputName bloquea también esa carpeta,
+// así no se cruza con un /generate, /append o una regeneración de la carpeta dueña.
+func outputFolder(outputName string) string {
+	return strings.TrimSuffix(outputName, filepath.Ext(outputName))
+}
@@ -0,0 +1,119 @@
+package pdf
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOutputDir(t *testing.T) {
+	tests := []struct {
+		name         string
+		outputDir    string
+		expectedPath string // Relativa a la carpeta del usuario
+	}{
+		{name: "Por defecto junto a la carpeta", outputDir: "", expectedPath: "test-folder.pdf"},
+		{name: "Carpeta de salidas", outputDir: "salidas", expectedPath: "salidas/test-folder.pdf"},
+		{name: "Carpeta anidada", outputDir: "salidas/unidas", expectedPath: "salidas/unidas/test-folder.pdf"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			originalOutputDir := outputDir
+			defer func() { outputDir = originalOutputDir }()
+			outputDir = tt.outputDir
+
+			userPath := t.TempDir()
+			folderPath := filepath.Join(userPath, "test-folder")
+			os.MkdirAll(folderPath, os.ModePerm)
+			writeTestPDF(t, filepath.Join(folderPath, "1-a.pdf"), 1)
+			writeTestPDF(t, filepath.Join(folderPath, "2-b.pdf"), 1)
+			srv := newTestServer(userPath)
+
+			// Act
+			req, rr := newGenerateRequest(url.Values{"folder": {"test-folder"}})
+			srv.GenerateHandler(rr, req)
+
+			// Assert
+			if rr.Code != http.StatusOK {
+				t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+			}
+			merged, err := os.ReadFile(filepath.Join(userPath, filepath.FromSlash(tt.expectedPath)))
+			if err != nil {
+				t.Fatalf("expected the output at %s: %v", tt.expectedPath, err)
+			}
+			if tt.outputDir != "" {
+				if _, err := os.Stat(filepath.Join(userPath, "test-folder.pdf")); !os.IsNotExist(err) {
+					t.Errorf("expected no output in the user's root")
+				}
+			}
+
+			req, rr = NewDownloadRequestBuilder().Build()
+			srv.DownloadHandler(rr, req)
+			if rr.Code != http.StatusOK || !bytes.Equal(rr.Body.Bytes(), merged) {
+				t.Errorf("expected DownloadHandler to serve the output, got %d", rr.Code)
+			}
+
+			req = httptest.NewRequest(http.MethodGet, "/exists?folder=test-folder", nil)
+			rr = httptest.NewRecorder()
+			srv.ExistsHandler(rr, req)
+			var exists ExistsResponse
+			json.NewDecoder(rr.Body).Decode(&exists)
+			if !exists.Exists {
+				t.Errorf("expected ExistsHandler to find the output")
+			}
+		})
+	}
+}
+
+func TestOutputDirIsReservedFolder(t *testing.T) {
+	tests := []struct {
+		name      string
+		outputDir string
+		folder    string
+		expected  bool
+	}{
+		{name: "Sin OUTPUT_DIR cualquier nombre vale", outputDir: "", folder: "salidas", expected: true},
+		{name: "La carpeta de salidas", outputDir: "salidas", folder: "salidas", expected: false},
+		{name: "La raíz de una carpeta anidada", outputDir: "salidas/unidas", folder: "salidas", expected: false},
+		{name: "Otra carpeta", outputDir: "salidas", folder: "facturas", expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			originalOutputDir := outputDir
+			defer func() { outputDir = originalOutputDir }()
+			outputDir = tt.outputDir
+
+			// Act
+			valid := validFolderName(tt.folder)
+
+			// Assert
+			if valid != tt.expected {
+				t.Errorf("validFolderName(%q) with OUTPUT_DIR=%q = %v, want %v", tt.folder, tt.outputDir, valid, tt.expected)
+			}
+		})
+	}
+}
+
+func TestGenerateRejectsOutputDirFolder(t *testing.T) {
+	// Arrange
+	originalOutputDir := outputDir
+	defer func() { outputDir = originalOutputDir }()
+	outputDir = "salidas"
+	srv := newTestServer(t.TempDir())
+	req, rr := newGenerateRequest(url.Values{"folder": {"salidas"}})
+
+	// Act
+	srv.GenerateHandler(rr, req)
+
+	// Assert
+	assertValidationErrors(t, rr.Code, rr.Body.Bytes(), []string{"folder"})
+}
@@ -0,0 +1,125 @@
+package pdf
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+)
+
+// --- Manifiesto de auditoría de cada unión ---
+// Junto a cada salida de joinPDFs se guarda "<salida>.manifest.json" con los archivos
+// unidos en orden (nombre, SHA-256 y páginas), las opciones, el SHA-256 de la salida y
+// la fecha, para poder demostrar cómo se armó el documento. A diferencia del manifiesto
+// oculto de las uniones incrementales es para el usuario: ManifestHandler lo descarga.
+// Quien reescribe la salida por otra vía (/append, /merge-folders, /merge-urls) lo
+// descarta, porque ya no la describe.
+
+// outputManifestPath ruta del manifiesto de auditoría de la salida outputPath:
+// "u/facturas.pdf" -> "u/facturas.manifest.json"
+func outputManifestPath(outputPath string) string {
+	return strings.TrimSuffix(outputPath, filepath.Ext(outputPath)) + ".manifest.json"
+}
+
+// writeOutputManifest guarda el manifiesto de auditoría de la salida outputPath recién
+// escrita. sources son las fuentes tal como están en folder y pageSources las copias
+// que se unieron (descifradas o aplanadas), en el mismo orden, de las que se cuentan
+// las páginas.
+func writeOutputManifest(path, folder, outputPath string, files, pageSources []string, opts MergeOptions, createdAt time.Time) error {
+	manifest := OutputManifest{
+		Folder:    folder,
+		Output:    filepath.Base(outputPath),
+		CreatedAt: createdAt.UTC(),
+		Options:   opts,
+		Files:     make([]ManifestFile, len(files)),
+	}
+	store := NewLocalStorage(path)
+	for i, name := range files {
+		sum, err := fileChecksum(store, folder, name)
+		if err != nil {
+			return err
+		}
+		pages, err := api.PageCountFile(pageSources[i])
+		if err != nil {
+			return err
+		}
+		manifest.Files[i] = ManifestFile{Name: name, SHA256: sum, Pages: pages}
+	}
+	var err error
+	if manifest.OutputSHA256, err = sha256File(outputPath); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(outputManifestPath(outputPath), data, 0o644)
+}
+
+// discardOutputManifest borra el manifiesto de auditoría de outputPath, si lo hay
+func discardOutputManifest(outputPath string) {
+	os.Remove(outputManifestPath(outputPath))
+}
+
+// sha256File devuelve el SHA-256 en hexadecimal del archivo path
+func sha256File(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// ManifestHandler: Descarga el manifiesto de auditoría de la salida de una carpeta
+// (folder y output como en /download). 404 si la salida no tiene manifiesto: no se
+// generó todavía o se reescribió por otra vía.
+func (s *Server) ManifestHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, http.MethodGet)
+		return
+	}
+
+	// Obtener la ruta base de almacenamiento del usuario
+	userStoragePath, err := s.storagePath(r)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Error interno de autenticación")
+		return
+	}
+	var problems validationErrors
+	folder := r.URL.Query().Get("folder")
+	problems.checkFolder(folder)
+	if problems.respond(w) {
+		return
+	}
+	outputName, err := mergeOutputName(folder, r.URL.Query().Get("output"))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Nombre de salida inválido")
+		return
+	}
+
+	manifestPath := outputManifestPath(mergeOutputPath(userStoragePath, outputName))
+	if _, err := os.Stat(manifestPath); os.IsNotExist(err) {
+		writeJSONError(w, http.StatusNotFound, "La salida no tiene manifiesto")
+		return
+	} else if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Error al leer el manifiesto")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filepath.Base(manifestPath)}))
+	http.ServeFile(w, r, manifestPath)
+}
@@ -0,0 +1,132 @@
+package pdf
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// setupManifestFolder crea test-folder con dos PDFs y la une
+func setupManifestFolder(t *testing.T, userPath string, opts MergeOptions) {
+	t.Helper()
+	folderPath := filepath.Join(userPath, "test-folder")
+	os.MkdirAll(folderPath, os.ModePerm)
+	writeTestPDF(t, filepath.Join(folderPath, "1-a.pdf"), 2)
+	writeTestPDF(t, filepath.Join(folderPath, "2-b.pdf"), 1)
+	if _, err := joinPDFs(context.Background(), userPath, "test-folder", opts); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestJoinPDFsWritesOutputManifest(t *testing.T) {
+	// Arrange
+	userPath := t.TempDir()
+
+	// Act
+	setupManifestFolder(t, userPath, MergeOptions{Bookmarks: true})
+
+	// Assert
+	data, err := os.ReadFile(filepath.Join(userPath, "test-folder.manifest.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var manifest OutputManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		t.Fatal(err)
+	}
+	var files []ManifestFile
+	for _, f := range []struct {
+		name  string
+		pages int
+	}{{"1-a.pdf", 2}, {"2-b.pdf", 1}} {
+		sum, err := sha256File(filepath.Join(userPath, "test-folder", f.name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, ManifestFile{Name: f.name, SHA256: sum, Pages: f.pages})
+	}
+	if !reflect.DeepEqual(manifest.Files, files) {
+		t.Errorf("expected files %+v, got %+v", files, manifest.Files)
+	}
+	outputSum, err := sha256File(filepath.Join(userPath, "test-folder.pdf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if manifest.Folder != "test-folder" || manifest.Output != "test-folder.pdf" || manifest.OutputSHA256 != outputSum {
+		t.Errorf("unexpected output in manifest: %+v", manifest)
+	}
+	if !manifest.Options.Bookmarks || manifest.CreatedAt.IsZero() {
+		t.Errorf("expected options and creation time in manifest, got %+v", manifest)
+	}
+}
+
+func TestJoinPDFsRestoresMissingOutputManifest(t *testing.T) {
+	// Arrange
+	userPath := t.TempDir()
+	setupManifestFolder(t, userPath, MergeOptions{})
+	manifestPath := filepath.Join(userPath, "test-folder.manifest.json")
+	os.Remove(manifestPath)
+
+	// Act
+	result, err := joinPDFs(context.Background(), userPath, "test-folder", MergeOptions{})
+
+	// Assert
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Unchanged {
+		t.Errorf("expected a full merge when the audit manifest is missing")
+	}
+	if _, err := os.Stat(manifestPath); err != nil {
+		t.Errorf("expected the audit manifest to be written again: %v", err)
+	}
+}
+
+func TestManifestHandler(t *testing.T) {
+	tests := []struct {
+		name                string
+		merge               bool
+		expectedStatus      int
+		expectedDisposition string
+	}{
+		{name: "Descarga el manifiesto", merge: true, expectedStatus: http.StatusOK, expectedDisposition: "attachment; filename=test-folder.manifest.json"},
+		{name: "Sin unión no hay manifiesto", merge: false, expectedStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			userPath := t.TempDir()
+			if tt.merge {
+				setupManifestFolder(t, userPath, MergeOptions{})
+			}
+			srv := newTestServer(userPath)
+			req := httptest.NewRequest(http.MethodGet, "/manifest?folder=test-folder", nil)
+			req = req.WithContext(context.WithValue(req.Context(), userCodeKey, "testUser"))
+			rr := httptest.NewRecorder()
+
+			// Act
+			srv.ManifestHandler(rr, req)
+
+			// Assert
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("expected %d, got %d: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+			if disposition := rr.Header().Get("Content-Disposition"); disposition != tt.expectedDisposition {
+				t.Errorf("expected Content-Disposition %q, got %q", tt.expectedDisposition, disposition)
+			}
+			var manifest OutputManifest
+			if err := json.NewDecoder(rr.Body).Decode(&manifest); err != nil || len(manifest.Files) != 2 {
+				t.Errorf("expected a manifest with 2 files, got %+v (err: %v)", manifest, err)
+			}
+		})
+	}
+}
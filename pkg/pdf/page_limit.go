@@ -0,0 +1,39 @@
+package pdf
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+)
+
+// Máximo de páginas de una unión (MAX_TOTAL_PAGES); 0 o sin definir significa sin límite
+var maxTotalPages = defaultConfig.MaxTotalPages
+
+// ErrTooManyPages indica que la suma de páginas de los PDFs supera MAX_TOTAL_PAGES
+var ErrTooManyPages = errors.New("la unión supera el máximo de páginas")
+
+// checkPageLimit suma las páginas de files y falla con ErrTooManyPages si superan el
+// máximo. Sin límite configurado no abre los archivos y devuelve 0.
+func checkPageLimit(files []string) (int, error) {
+	if maxTotalPages <= 0 {
+		return 0, nil
+	}
+	total := 0
+	for _, file := range files {
+		pages, err := api.PageCountFile(file)
+		if err != nil {
+			return 0, err
+		}
+		total += pages
+	}
+	return total, pageLimitError(total)
+}
+
+// pageLimitError devuelve ErrTooManyPages si total supera MAX_TOTAL_PAGES
+func pageLimitError(total int) error {
+	if maxTotalPages > 0 && total > maxTotalPages {
+		return fmt.Errorf("%w: %d páginas y el máximo es %d", ErrTooManyPages, total, maxTotalPages)
+	}
+	return nil
+}
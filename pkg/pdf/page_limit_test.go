@@ -0,0 +1,66 @@
+package pdf
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenerateHandlerMaxTotalPages(t *testing.T) {
+	tests := []struct {
+		name           string
+		limit          int
+		expectedStatus int
+		expectedTotal  int
+	}{
+		{name: "Sin límite configurado", limit: 0, expectedStatus: http.StatusOK, expectedTotal: 0},
+		{name: "Justo en el límite", limit: 3, expectedStatus: http.StatusOK, expectedTotal: 3},
+		{name: "Supera el límite", limit: 2, expectedStatus: http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange: 1 + 2 páginas
+			userPath := t.TempDir()
+			folderPath := filepath.Join(userPath, "test-folder")
+			os.MkdirAll(folderPath, os.ModePerm)
+			writeTestPDF(t, filepath.Join(folderPath, "1-a.pdf"), 1)
+			writeTestPDF(t, filepath.Join(folderPath, "2-b.pdf"), 2)
+
+			originalLimit := maxTotalPages
+			defer func() { maxTotalPages = originalLimit }()
+			srv := newTestServer(userPath)
+			maxTotalPages = tt.limit
+
+			req, rr := newGenerateRequest(url.Values{"folder": {"test-folder"}})
+
+			// Act
+			srv.GenerateHandler(rr, req)
+
+			// Assert
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("expected %d, got %d: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+			}
+			if tt.expectedStatus != http.StatusOK {
+				var body ErrorResponse
+				json.NewDecoder(rr.Body).Decode(&body)
+				if !strings.Contains(body.Error, "3 páginas") {
+					t.Errorf("expected the error to name the total, got %q", body.Error)
+				}
+				if _, err := os.Stat(filepath.Join(userPath, "test-folder.pdf")); !os.IsNotExist(err) {
+					t.Errorf("expected no merged output when the limit is exceeded")
+				}
+				return
+			}
+			var body GenerateResponse
+			json.NewDecoder(rr.Body).Decode(&body)
+			if body.TotalPages != tt.expectedTotal {
+				t.Errorf("expected total_pages %d, got %d", tt.expectedTotal, body.TotalPages)
+			}
+		})
+	}
+}
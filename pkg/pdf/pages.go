@@ -0,0 +1,47 @@
+package pdf
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// parsePageSpec valida una especificación de páginas como "3-7,10" contra el total
+// de páginas del documento y la devuelve en el formato de selección de pdfcpu.
+func parsePageSpec(spec string, pageCount int) ([]string, error) {
+	if strings.TrimSpace(spec) == "" {
+		return nil, fmt.Errorf("falta la especificación de páginas")
+	}
+
+	var selection []string
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		from, thru, isRange := strings.Cut(part, "-")
+
+		start, err := strconv.Atoi(strings.TrimSpace(from))
+		if err != nil {
+			return nil, fmt.Errorf("página no válida: %q", part)
+		}
+		end := start
+		if isRange {
+			end, err = strconv.Atoi(strings.TrimSpace(thru))
+			if err != nil {
+				return nil, fmt.Errorf("página no válida: %q", part)
+			}
+		}
+
+		if start < 1 || end < start || end > pageCount {
+			return nil, fmt.Errorf("rango %q fuera del documento (%d páginas)", part, pageCount)
+		}
+		selection = append(selection, fmt.Sprintf("%d-%d", start, end))
+	}
+	return selection, nil
+}
+
+// pdfFileName agrega la extensión .pdf al nombre si no la tiene
+func pdfFileName(name string) string {
+	if strings.HasSuffix(strings.ToLower(name), ".pdf") {
+		return name
+	}
+	return name + ".pdf"
+}
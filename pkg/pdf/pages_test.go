@@ -0,0 +1,43 @@
+package pdf
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParsePageSpec(t *testing.T) {
+	tests := []struct {
+		name      string
+		spec      string
+		pageCount int
+		expected  []string
+		expectErr bool
+	}{
+		{name: "Rango y página suelta", spec: "3-7,10", pageCount: 10, expected: []string{"3-7", "10-10"}},
+		{name: "Espacios alrededor", spec: " 1 , 2-3 ", pageCount: 3, expected: []string{"1-1", "2-3"}},
+		{name: "Página fuera del documento", spec: "11", pageCount: 10, expectErr: true},
+		{name: "Rango invertido", spec: "5-2", pageCount: 10, expectErr: true},
+		{name: "Página cero", spec: "0-2", pageCount: 10, expectErr: true},
+		{name: "Texto no numérico", spec: "a-b", pageCount: 10, expectErr: true},
+		{name: "Especificación vacía", spec: "", pageCount: 10, expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := parsePageSpec(tt.spec, tt.pageCount)
+
+			if tt.expectErr {
+				if err == nil {
+					t.Errorf("expected error for spec %q", tt.spec)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+}
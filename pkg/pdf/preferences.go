@@ -0,0 +1,145 @@
+package pdf
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// --- Preferencias de unión por usuario ---
+// Cada usuario puede guardar sus opciones de unión habituales en un archivo oculto de
+// su raíz. GenerateHandler parte de ellas y los campos de la petición las reemplazan,
+// también cuando piden explícitamente "false".
+
+// Nombre del archivo en la raíz del usuario; no es carpeta ni PDF, así que los listados no lo ven
+const preferencesFileName = ".preferences.json"
+
+// Tamaño máximo del cuerpo de PUT /preferences y de lo que se lee del archivo
+const maxPreferencesBytes = 64 << 10
+
+// Serializa las escrituras: dos PUT a la vez no deben pisarse el temporal
+var preferencesMutex sync.Mutex
+
+func preferencesPath(userStoragePath string) string {
+	return filepath.Join(userStoragePath, preferencesFileName)
+}
+
+// loadPreferences lee las preferencias del usuario; sin archivo devuelve las vacías
+func loadPreferences(userStoragePath string) (MergePreferences, error) {
+	var prefs MergePreferences
+	file, err := os.Open(preferencesPath(userStoragePath))
+	if errors.Is(err, os.ErrNotExist) {
+		return prefs, nil
+	}
+	if err != nil {
+		return prefs, err
+	}
+	defer file.Close()
+	err = json.NewDecoder(io.LimitReader(file, maxPreferencesBytes)).Decode(&prefs)
+	return prefs, err
+}
+
+// savePreferences escribe las preferencias en un temporal y lo renombra para no dejarlas a medias
+func savePreferences(userStoragePath string, prefs MergePreferences) error {
+	data, err := json.MarshalIndent(prefs, "", "  ")
+	if err != nil {
+		return err
+	}
+	preferencesMutex.Lock()
+	defer preferencesMutex.Unlock()
+	if err := os.MkdirAll(userStoragePath, os.ModePerm); err != nil {
+		return err
+	}
+	path := preferencesPath(userStoragePath)
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// validate comprueba las preferencias con las mismas reglas que GenerateHandler y
+// deja normalize en su forma canónica (ej: "a4" -> "A4")
+func (p *MergePreferences) validate(problems *validationErrors) {
+	var err error
+	if p.Normalize, err = resolvePageSize(p.Normalize); err != nil {
+		problems.add("normalize", err.Error())
+	}
+	if err := validateLabelTemplate(p.LabelTemplate); err != nil {
+		problems.add("label_template", err.Error())
+	}
+}
+
+// PreferencesHandler: GET devuelve las opciones de unión por defecto del usuario y
+// PUT las reemplaza por completo.
+func (s *Server) PreferencesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPut {
+		methodNotAllowed(w, http.MethodGet, http.MethodPut)
+		return
+	}
+
+	// Obtener la ruta base de almacenamiento del usuario
+	userStoragePath, err := s.storagePath(r)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Error interno de autenticación")
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		prefs, err := loadPreferences(userStoragePath)
+		if err != nil {
+			writeJSONError(w, http.StatusInternalServerError, "Error al leer las preferencias")
+			return
+		}
+		writeJSON(w, http.StatusOK, prefs)
+		return
+	}
+
+	if !isJSONRequest(r) {
+		writeJSONError(w, http.StatusBadRequest, "Content-Type debe ser application/json")
+		return
+	}
+	var prefs MergePreferences
+	r.Body = http.MaxBytesReader(w, r.Body, maxPreferencesBytes)
+	decoder := json.NewDecoder(r.Body)
+	// Un campo desconocido suele ser un error de escritura que, si no, se ignoraría sin aviso
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&prefs); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Formato JSON inválido: "+err.Error())
+		return
+	}
+	var problems validationErrors
+	prefs.validate(&problems)
+	if problems.respond(w) {
+		return
+	}
+
+	if err := savePreferences(userStoragePath, prefs); err != nil {
+		logf(r.Context(), "Error guardando preferencias: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "Error al guardar las preferencias")
+		return
+	}
+	writeJSON(w, http.StatusOK, prefs)
+}
+
+// formBool lee un booleano del formulario; si la petición no trae el campo devuelve def
+func formBool(r *http.Request, key string, def bool) bool {
+	value := r.FormValue(key) // Analiza el formulario si aún no se hizo
+	if !r.Form.Has(key) {
+		return def
+	}
+	return value == "true"
+}
+
+// formString lee un texto del formulario; si la petición no trae el campo devuelve def
+func formString(r *http.Request, key, def string) string {
+	value := r.FormValue(key)
+	if !r.Form.Has(key) {
+		return def
+	}
+	return value
+}
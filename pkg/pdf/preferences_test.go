@@ -0,0 +1,125 @@
+package pdf
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newPreferencesRequest(method, body string) *http.Request {
+	req := httptest.NewRequest(method, "/preferences", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	return req
+}
+
+func TestPreferencesHandlerSaveAndLoad(t *testing.T) {
+	// Arrange
+	userPath := t.TempDir()
+	srv := newTestServer(userPath)
+	put := httptest.NewRecorder()
+
+	// Act
+	srv.PreferencesHandler(put, newPreferencesRequest(http.MethodPut, `{"bookmarks":true,"normalize":"a4","label_template":"{index}. {name}"}`))
+	get := httptest.NewRecorder()
+	srv.PreferencesHandler(get, newPreferencesRequest(http.MethodGet, ""))
+
+	// Assert
+	if put.Code != http.StatusOK {
+		t.Fatalf("expected 200 on PUT, got %d: %s", put.Code, put.Body.String())
+	}
+	var prefs MergePreferences
+	if err := json.NewDecoder(get.Body).Decode(&prefs); err != nil {
+		t.Fatal(err)
+	}
+	expected := MergePreferences{Bookmarks: true, Normalize: "A4", LabelTemplate: "{index}. {name}"}
+	if prefs != expected {
+		t.Errorf("expected %+v, got %+v", expected, prefs)
+	}
+	if _, err := os.Stat(filepath.Join(userPath, preferencesFileName)); err != nil {
+		t.Errorf("expected preferences file: %v", err)
+	}
+}
+
+func TestPreferencesHandlerRejectsInvalidValues(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		expectedFields []string
+	}{
+		{name: "Tamaño y plantilla inválidos", body: `{"normalize":"A9","label_template":"{fecha}"}`, expectedFields: []string{"normalize", "label_template"}},
+		{name: "Campo desconocido", body: `{"optimise":true}`},
+		{name: "Tipo incorrecto", body: `{"bookmarks":"si"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			userPath := t.TempDir()
+			srv := newTestServer(userPath)
+			rr := httptest.NewRecorder()
+
+			// Act
+			srv.PreferencesHandler(rr, newPreferencesRequest(http.MethodPut, tt.body))
+
+			// Assert
+			if tt.expectedFields != nil {
+				assertValidationErrors(t, rr.Code, rr.Body.Bytes(), tt.expectedFields)
+			} else if rr.Code != http.StatusBadRequest {
+				t.Errorf("expected 400, got %d", rr.Code)
+			}
+			if _, err := os.Stat(filepath.Join(userPath, preferencesFileName)); !os.IsNotExist(err) {
+				t.Errorf("invalid preferences must not be saved")
+			}
+		})
+	}
+}
+
+func TestGenerateHandlerUsesPreferences(t *testing.T) {
+	tests := []struct {
+		name              string
+		form              url.Values
+		expectedGrayscale bool
+	}{
+		{name: "Sin el campo se usa la preferencia", form: url.Values{}, expectedGrayscale: true},
+		{name: "La petición tiene prioridad", form: url.Values{"grayscale": {"false"}}, expectedGrayscale: false},
+		// Los marcadores preferidos no convierten la petición intercalada en un error
+		{name: "El modo intercalado ignora los marcadores", form: url.Values{"mode": {MergeModeInterleave}}, expectedGrayscale: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			userPath := t.TempDir()
+			folderPath := filepath.Join(userPath, "test-folder")
+			os.MkdirAll(folderPath, os.ModePerm)
+			writeTestPDF(t, filepath.Join(folderPath, "1-intro.pdf"), 1)
+			writeTestPDF(t, filepath.Join(folderPath, "2-body.pdf"), 1)
+			if err := savePreferences(userPath, MergePreferences{Grayscale: true, Bookmarks: true}); err != nil {
+				t.Fatal(err)
+			}
+			srv := newTestServer(userPath)
+			tt.form.Set("folder", "test-folder")
+			req, rr := newGenerateRequest(tt.form)
+
+			// Act
+			srv.GenerateHandler(rr, req)
+
+			// Assert
+			if rr.Code != http.StatusOK {
+				t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+			}
+			var resp GenerateResponse
+			if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+				t.Fatal(err)
+			}
+			if (resp.Grayscale != nil) != tt.expectedGrayscale {
+				t.Errorf("expected grayscale %v, got %+v", tt.expectedGrayscale, resp.Grayscale)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package pdf
+
+import (
+	"encoding/base64"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// PreviewHandler: Devuelve la miniatura de la primera página de cada PDF de la carpeta,
+// en el mismo orden en que GenerateHandler los uniría, para revisar el orden antes de
+// generar. Reutiliza la caché de miniaturas de ThumbnailHandler.
+func (s *Server) PreviewHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, http.MethodGet)
+		return
+	}
+
+	// Obtener la ruta base de almacenamiento del usuario
+	userStoragePath, err := s.storagePath(r)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Error interno de autenticación")
+		return
+	}
+
+	var problems validationErrors
+	folder := r.URL.Query().Get("folder")
+	problems.checkFolder(folder)
+	if problems.respond(w) {
+		return
+	}
+	width, err := thumbnailWidthParam(r)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	folderPath := filepath.Join(userStoragePath, folder)
+
+	unlock := rLockFolder(userStoragePath, folder)
+	defer unlock()
+
+	files, err := ListFilesWithExtension(folderPath, ".pdf")
+	if os.IsNotExist(err) {
+		writeJSONError(w, http.StatusNotFound, "Carpeta no encontrada")
+		return
+	}
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Error al listar archivos")
+		return
+	}
+
+	// Un archivo sin miniatura (p. ej. solo contenido vectorial) no impide ver el resto
+	pages := make([]PreviewPage, len(files))
+	for i, file := range files {
+		pages[i].File = file
+		thumb, err := cachedThumbnail(folderPath, file, width)
+		if err != nil {
+			pages[i].Error = err.Error()
+			continue
+		}
+		pages[i].Image = base64.StdEncoding.EncodeToString(thumb)
+	}
+
+	writeJSON(w, http.StatusOK, PreviewResponse{Folder: folder, Width: width, Pages: pages})
+}
@@ -0,0 +1,86 @@
+package pdf
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPreviewHandler(t *testing.T) {
+	// Arrange: el orden de la unión es por número, no alfabético
+	userPath := t.TempDir()
+	folderPath := filepath.Join(userPath, "test-folder")
+	os.MkdirAll(folderPath, os.ModePerm)
+	os.Rename(writeColorImagePDF(t, t.TempDir()), filepath.Join(folderPath, "10-scan.pdf"))
+	writeTestPDF(t, filepath.Join(folderPath, "2-vector.pdf"), 1)
+	srv := newTestServer(userPath)
+
+	req := httptest.NewRequest(http.MethodGet, "/preview?folder=test-folder&width=40", nil)
+	rr := httptest.NewRecorder()
+
+	// Act
+	srv.PreviewHandler(rr, req)
+
+	// Assert
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var body PreviewResponse
+	if err := json.NewDecoder(rr.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if len(body.Pages) != 2 || body.Pages[0].File != "2-vector.pdf" || body.Pages[1].File != "10-scan.pdf" {
+		t.Fatalf("expected the merge order, got %+v", body.Pages)
+	}
+	if body.Pages[0].Image != "" || body.Pages[0].Error == "" {
+		t.Errorf("expected an error instead of an image for the vector PDF: %+v", body.Pages[0])
+	}
+	data, err := base64.StdEncoding.DecodeString(body.Pages[1].Image)
+	if err != nil {
+		t.Fatalf("expected base64 image: %v", err)
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("expected a PNG: %v", err)
+	}
+	if img.Bounds().Dx() != 40 || body.Width != 40 {
+		t.Errorf("expected width 40, got %d", img.Bounds().Dx())
+	}
+}
+
+func TestPreviewHandlerErrors(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		query          string
+		expectedStatus int
+	}{
+		{name: "Método no permitido", method: http.MethodPost, query: "folder=test-folder", expectedStatus: http.StatusMethodNotAllowed},
+		{name: "Falta la carpeta", method: http.MethodGet, query: "", expectedStatus: http.StatusBadRequest},
+		{name: "Ancho fuera de rango", method: http.MethodGet, query: "folder=test-folder&width=1", expectedStatus: http.StatusBadRequest},
+		{name: "Carpeta inexistente", method: http.MethodGet, query: "folder=otra", expectedStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			srv := newTestServer(t.TempDir())
+			req := httptest.NewRequest(tt.method, "/preview?"+tt.query, nil)
+			rr := httptest.NewRecorder()
+
+			// Act
+			srv.PreviewHandler(rr, req)
+
+			// Assert
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("expected %d, got %d: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+			}
+		})
+	}
+}
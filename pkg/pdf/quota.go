@@ -0,0 +1,35 @@
+package pdf
+
+import (
+	"net/http"
+	"os"
+)
+
+// QuotaHandler: Informa el espacio que ocupa el usuario autenticado y su cuota
+// (USER_QUOTA_BYTES, -1 si no hay límite), para mostrar una barra de uso.
+func (s *Server) QuotaHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, http.MethodGet)
+		return
+	}
+
+	// Obtener la ruta base de almacenamiento del usuario
+	userStoragePath, err := s.storagePath(r)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Error interno de autenticación")
+		return
+	}
+
+	// Un usuario que todavía no subió nada no tiene carpeta y usa 0 bytes
+	usage, err := userDirUsage(userStoragePath)
+	if err != nil && !os.IsNotExist(err) {
+		writeJSONError(w, http.StatusInternalServerError, "Error al calcular el uso de almacenamiento")
+		return
+	}
+
+	limit := s.cfg.UserQuotaBytes
+	if limit <= 0 {
+		limit = -1
+	}
+	writeJSON(w, http.StatusOK, QuotaResponse{UsedBytes: usage.Bytes, LimitBytes: limit, Folders: usage.Folders})
+}
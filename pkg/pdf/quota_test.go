@@ -0,0 +1,65 @@
+package pdf
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestQuotaHandler(t *testing.T) {
+	tests := []struct {
+		name     string
+		quota    int64
+		setup    func(t *testing.T, userPath string)
+		expected QuotaResponse
+	}{
+		{
+			name:     "Usuario sin carpeta y sin límite",
+			quota:    0,
+			setup:    func(t *testing.T, userPath string) { os.Remove(userPath) },
+			expected: QuotaResponse{UsedBytes: 0, LimitBytes: -1, Folders: 0},
+		},
+		{
+			name:  "Suma todas las carpetas frente a la cuota",
+			quota: 1000,
+			setup: func(t *testing.T, userPath string) {
+				os.MkdirAll(filepath.Join(userPath, "a", "sub"), os.ModePerm)
+				os.MkdirAll(filepath.Join(userPath, "b"), os.ModePerm)
+				os.WriteFile(filepath.Join(userPath, "a", "1-x.pdf"), make([]byte, 100), 0o644)
+				os.WriteFile(filepath.Join(userPath, "a", "sub", "1-y.pdf"), make([]byte, 50), 0o644)
+				os.WriteFile(filepath.Join(userPath, "a.pdf"), make([]byte, 25), 0o644)
+			},
+			expected: QuotaResponse{UsedBytes: 175, LimitBytes: 1000, Folders: 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			userPath := filepath.Join(t.TempDir(), "testUser")
+			os.MkdirAll(userPath, os.ModePerm)
+			tt.setup(t, userPath)
+			srv := newTestServer(userPath)
+			srv.cfg.UserQuotaBytes = tt.quota
+			rr := httptest.NewRecorder()
+
+			// Act
+			srv.QuotaHandler(rr, httptest.NewRequest(http.MethodGet, "/quota", nil))
+
+			// Assert
+			if rr.Code != http.StatusOK {
+				t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+			}
+			var got QuotaResponse
+			if err := json.NewDecoder(rr.Body).Decode(&got); err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.expected {
+				t.Errorf("expected %+v, got %+v", tt.expected, got)
+			}
+		})
+	}
+}
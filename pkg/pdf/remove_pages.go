@@ -0,0 +1,102 @@
+package pdf
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+)
+
+// RemovePagesHandler: Quita páginas de un PDF de la carpeta (ej: una hoja en blanco de un
+// escaneo) y lo reescribe en su lugar. Recibe folder, file y pages (ej: "2,5-7"). No
+// permite quitar todas las páginas: para eso está /delete.
+func (s *Server) RemovePagesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w, http.MethodPost)
+		return
+	}
+
+	// Obtener la ruta base de almacenamiento del usuario
+	userStoragePath, err := s.storagePath(r)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Error interno de autenticación")
+		return
+	}
+
+	var problems validationErrors
+	folder := r.FormValue("folder")
+	problems.checkFolder(folder)
+	if problems.respond(w) {
+		return
+	}
+	filename := r.FormValue("file")
+	if !validFileName(filename) {
+		writeJSONError(w, http.StatusBadRequest, "Nombre de archivo no válido")
+		return
+	}
+	filePath := filepath.Join(userStoragePath, folder, filename)
+
+	unlock := lockFolder(userStoragePath, folder)
+	defer unlock()
+
+	pageCount, err := api.PageCountFile(filePath)
+	if os.IsNotExist(err) {
+		writeJSONError(w, http.StatusNotFound, "Archivo no encontrado: "+filename)
+		return
+	}
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Error al leer el PDF: "+err.Error())
+		return
+	}
+
+	selection, err := parsePageSpec(r.FormValue("pages"), pageCount)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if countSelectedPages(selection) >= pageCount {
+		writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("No se pueden quitar todas las páginas (%d): para eso elimine el archivo", pageCount))
+		return
+	}
+
+	// El original solo se reemplaza cuando el resultado está completo
+	tmpDir, cleanup, err := newTempDir("remove-pages-")
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Error al preparar el archivo")
+		return
+	}
+	defer cleanup()
+	workPath := filepath.Join(tmpDir, filename)
+	if err := api.RemovePagesFile(filePath, workPath, selection, nil); err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Error al quitar páginas: "+err.Error())
+		return
+	}
+	pages, err := api.PageCountFile(workPath)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Error al leer el PDF generado: "+err.Error())
+		return
+	}
+	if err := moveFile(workPath, filePath); err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Error al guardar el PDF")
+		return
+	}
+	discardChecksumSidecarFile(filePath)
+
+	writeJSON(w, http.StatusOK, ExtractResponse{Folder: folder, File: filename, Pages: pages})
+}
+
+// countSelectedPages cuenta las páginas distintas de una selección de parsePageSpec;
+// "1-3,2" son 3 páginas
+func countSelectedPages(selection []string) int {
+	pages := map[int]bool{}
+	for _, part := range selection {
+		var from, thru int
+		fmt.Sscanf(part, "%d-%d", &from, &thru)
+		for page := from; page <= thru; page++ {
+			pages[page] = true
+		}
+	}
+	return len(pages)
+}
@@ -0,0 +1,128 @@
+package pdf
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+)
+
+func TestRemovePagesHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		pages          string
+		expectedStatus int
+		expectedWidths []float64 // El ancho identifica cada página original
+	}{
+		{name: "Quita una página", pages: "2", expectedStatus: http.StatusOK, expectedWidths: []float64{100, 300, 400}},
+		{name: "Quita una lista y un rango", pages: "1,3-4", expectedStatus: http.StatusOK, expectedWidths: []float64{200}},
+		{name: "Páginas repetidas cuentan una vez", pages: "1-3,2", expectedStatus: http.StatusOK, expectedWidths: []float64{400}},
+		{name: "No quita todas las páginas", pages: "1-2,3-4", expectedStatus: http.StatusBadRequest, expectedWidths: []float64{100, 200, 300, 400}},
+		{name: "Fuera de rango", pages: "5", expectedStatus: http.StatusBadRequest, expectedWidths: []float64{100, 200, 300, 400}},
+		{name: "Sin páginas", pages: "", expectedStatus: http.StatusBadRequest, expectedWidths: []float64{100, 200, 300, 400}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			userPath := t.TempDir()
+			folderPath := filepath.Join(userPath, "test-folder")
+			os.MkdirAll(folderPath, os.ModePerm)
+			writeRawPDF(t, filepath.Join(folderPath, "1-scan.pdf"), []string{
+				"<< /Type /Catalog /Pages 2 0 R >>",
+				"<< /Type /Pages /Kids [3 0 R 4 0 R 5 0 R 6 0 R] /Count 4 >>",
+				"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 100 200] >>",
+				"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 200 200] >>",
+				"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 300 200] >>",
+				"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 400 200] >>",
+			})
+			srv := newTestServer(userPath)
+			req, rr := newGenerateRequest(url.Values{"folder": {"test-folder"}, "file": {"1-scan.pdf"}, "pages": {tt.pages}})
+
+			// Act
+			srv.RemovePagesHandler(rr, req)
+
+			// Assert
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("expected %d, got %d: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+			}
+			dims, err := api.PageDimsFile(filepath.Join(folderPath, "1-scan.pdf"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var widths []float64
+			for _, dim := range dims {
+				widths = append(widths, dim.Width)
+			}
+			if !reflect.DeepEqual(widths, tt.expectedWidths) {
+				t.Errorf("expected pages %v, got %v", tt.expectedWidths, widths)
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+			var resp ExtractResponse
+			if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+				t.Fatal(err)
+			}
+			if resp.Pages != len(tt.expectedWidths) {
+				t.Errorf("expected %d pages in the response, got %d", len(tt.expectedWidths), resp.Pages)
+			}
+		})
+	}
+}
+
+func TestRemovePagesHandlerMissingFile(t *testing.T) {
+	// Arrange
+	userPath := t.TempDir()
+	os.MkdirAll(filepath.Join(userPath, "test-folder"), os.ModePerm)
+	srv := newTestServer(userPath)
+	req, rr := newGenerateRequest(url.Values{"folder": {"test-folder"}, "file": {"1-x.pdf"}, "pages": {"1"}})
+
+	// Act
+	srv.RemovePagesHandler(rr, req)
+
+	// Assert
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestRemovePagesHandlerRejectsFolderOutsideUser(t *testing.T) {
+	tests := []struct {
+		name   string
+		folder string
+	}{
+		{name: "Carpeta de otro usuario", folder: "../otro"},
+		{name: "Carpeta vacía", folder: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange: otro usuario con un PDF de tres páginas en la raíz de su espacio
+			root := t.TempDir()
+			userPath := filepath.Join(root, "alex")
+			otherPath := filepath.Join(root, "otro")
+			os.MkdirAll(userPath, os.ModePerm)
+			os.MkdirAll(otherPath, os.ModePerm)
+			writeTestPDF(t, filepath.Join(otherPath, "1-a.pdf"), 3)
+			srv := newTestServer(userPath)
+			req, rr := newGenerateRequest(url.Values{"folder": {tt.folder}, "file": {"1-a.pdf"}, "pages": {"1"}})
+
+			// Act
+			srv.RemovePagesHandler(rr, req)
+
+			// Assert
+			if rr.Code != http.StatusBadRequest {
+				t.Fatalf("expected 400, got %d: %s", rr.Code, rr.Body.String())
+			}
+			if pages, err := api.PageCountFile(filepath.Join(otherPath, "1-a.pdf")); err != nil || pages != 3 {
+				t.Errorf("expected the other user's file to keep its 3 pages, got %d (err: %v)", pages, err)
+			}
+		})
+	}
+}
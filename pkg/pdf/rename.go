@@ -0,0 +1,127 @@
+package pdf
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// Tamaño máximo del cuerpo JSON de RenameFolderHandler
+const maxRenameBodyBytes = 4 << 10
+
+// RenameFolderHandler: Renombra una carpeta del usuario con {"from","to"}, moviendo sus
+// archivos y, si existe, el from.pdf ya generado a to.pdf. Si la carpeta destino (o su
+// to.pdf) ya existe responde 409 en lugar de mezclar o sobrescribir archivos.
+func (s *Server) RenameFolderHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w, http.MethodPost)
+		return
+	}
+
+	// Obtener la ruta base de almacenamiento del usuario
+	userStoragePath, err := s.storagePath(r)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Error interno de autenticación")
+		return
+	}
+
+	if !isJSONRequest(r) {
+		writeJSONError(w, http.StatusBadRequest, "Content-Type debe ser application/json")
+		return
+	}
+	var req RenameFolderRequest
+	r.Body = http.MaxBytesReader(w, r.Body, maxRenameBodyBytes)
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Formato JSON inválido")
+		return
+	}
+	if !validFolderName(req.From) || !validFolderName(req.To) {
+		writeJSONError(w, http.StatusBadRequest, "Nombre de carpeta no válido")
+		return
+	}
+	if req.From == req.To {
+		writeJSONError(w, http.StatusBadRequest, "La carpeta destino es la misma que la de origen")
+		return
+	}
+
+	fromPath := filepath.Join(userStoragePath, req.From)
+	toPath := filepath.Join(userStoragePath, req.To)
+	fromOutput := mergeOutputPath(userStoragePath, req.From+".pdf")
+	toOutput := mergeOutputPath(userStoragePath, req.To+".pdf")
+
+	// Ninguna subida o unión puede usar ninguna de las dos carpetas durante el cambio
+	unlock := lockFolders(userStoragePath, req.From, req.To)
+	defer unlock()
+
+	info, err := os.Stat(fromPath)
+	if os.IsNotExist(err) || (err == nil && !info.IsDir()) {
+		writeJSONError(w, http.StatusNotFound, "Carpeta no encontrada")
+		return
+	}
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Error al leer la carpeta")
+		return
+	}
+	if _, err := os.Lstat(toPath); err == nil {
+		writeJSONError(w, http.StatusConflict, "Ya existe una carpeta con ese nombre: "+req.To)
+		return
+	}
+	_, err = os.Stat(fromOutput)
+	hasOutput := err == nil
+	if _, err := os.Lstat(toOutput); hasOutput && err == nil {
+		writeJSONError(w, http.StatusConflict, "Ya existe un PDF unido con ese nombre: "+req.To+".pdf")
+		return
+	}
+
+	if err := os.Rename(fromPath, toPath); err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Error al renombrar la carpeta")
+		return
+	}
+	resp := RenameFolderResponse{Folder: req.To}
+	if hasOutput {
+		if err := os.Rename(fromOutput, toOutput); err != nil {
+			// Deshacer para no dejar la carpeta separada de su PDF unido
+			os.Rename(toPath, fromPath)
+			writeJSONError(w, http.StatusInternalServerError, "Error al renombrar el PDF unido")
+			return
+		}
+		resp.Output = req.To + ".pdf"
+	}
+	moveOutputManifests(fromOutput, toOutput, req.To, hasOutput)
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// moveOutputManifests lleva los dos manifiestos de fromOutput (el de la unión incremental y
+// el de auditoría) a toOutput con la carpeta y la salida nuevas. Sin salida, o si alguno no
+// se puede reescribir, se descarta: la próxima unión de folder lo vuelve a crear.
+func moveOutputManifests(fromOutput, toOutput, folder string, hasOutput bool) {
+	outputName := filepath.Base(toOutput)
+	if manifest, err := readMergeManifest(fromOutput); err == nil && hasOutput {
+		manifest.Output.Name = outputName
+		manifest.Result.Folder = folder
+		manifest.Result.Output = outputName
+		writeManifestJSON(mergeManifestPath(toOutput), manifest)
+	}
+	os.Remove(mergeManifestPath(fromOutput))
+
+	var audit OutputManifest
+	if data, err := os.ReadFile(outputManifestPath(fromOutput)); err == nil && hasOutput && json.Unmarshal(data, &audit) == nil {
+		audit.Folder = folder
+		audit.Output = outputName
+		writeManifestJSON(outputManifestPath(toOutput), audit)
+	}
+	discardOutputManifest(fromOutput)
+}
+
+// writeManifestJSON escribe manifest en path; si falla borra path para no dejar uno a medias
+func writeManifestJSON(path string, manifest any) {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err == nil {
+		err = os.WriteFile(path, data, 0o644)
+	}
+	if err != nil {
+		os.Remove(path)
+	}
+}
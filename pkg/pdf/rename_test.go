@@ -0,0 +1,153 @@
+package pdf
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newRenameFolderRequest crea una petición JSON para /rename-folder
+func newRenameFolderRequest(body string) (*http.Request, *httptest.ResponseRecorder) {
+	req := httptest.NewRequest(http.MethodPost, "/rename-folder", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req = req.WithContext(context.WithValue(req.Context(), userCodeKey, "testUser"))
+	return req, httptest.NewRecorder()
+}
+
+func TestRenameFolderHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		setup          func(t *testing.T, userPath string)
+		expectedStatus int
+		expectedResp   RenameFolderResponse
+	}{
+		{
+			name:           "Renombra la carpeta y su PDF unido",
+			body:           `{"from":"factruas","to":"facturas"}`,
+			expectedStatus: http.StatusOK,
+			expectedResp:   RenameFolderResponse{Folder: "facturas", Output: "facturas.pdf"},
+		},
+		{
+			name: "Sin PDF unido solo mueve la carpeta",
+			body: `{"from":"factruas","to":"facturas"}`,
+			setup: func(t *testing.T, userPath string) {
+				os.Remove(filepath.Join(userPath, "factruas.pdf"))
+			},
+			expectedStatus: http.StatusOK,
+			expectedResp:   RenameFolderResponse{Folder: "facturas"},
+		},
+		{
+			name: "La carpeta destino ya existe",
+			body: `{"from":"factruas","to":"facturas"}`,
+			setup: func(t *testing.T, userPath string) {
+				os.MkdirAll(filepath.Join(userPath, "facturas"), os.ModePerm)
+			},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name: "El PDF unido destino ya existe",
+			body: `{"from":"factruas","to":"facturas"}`,
+			setup: func(t *testing.T, userPath string) {
+				os.WriteFile(filepath.Join(userPath, "facturas.pdf"), []byte("%PDF-otro"), 0o644)
+			},
+			expectedStatus: http.StatusConflict,
+		},
+		{name: "Carpeta inexistente", body: `{"from":"nada","to":"facturas"}`, expectedStatus: http.StatusNotFound},
+		{name: "Nombre con ruta", body: `{"from":"factruas","to":"../facturas"}`, expectedStatus: http.StatusBadRequest},
+		{name: "Falta el destino", body: `{"from":"factruas"}`, expectedStatus: http.StatusBadRequest},
+		{name: "Mismo nombre", body: `{"from":"factruas","to":"factruas"}`, expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			userPath := t.TempDir()
+			os.MkdirAll(filepath.Join(userPath, "factruas"), os.ModePerm)
+			os.WriteFile(filepath.Join(userPath, "factruas", "1-enero.pdf"), []byte("%PDF-enero"), 0o644)
+			setupMergedFile(t, userPath, "factruas", "%PDF-unido")
+			if tt.setup != nil {
+				tt.setup(t, userPath)
+			}
+			srv := newTestServer(userPath)
+			req, rr := newRenameFolderRequest(tt.body)
+
+			// Act
+			srv.RenameFolderHandler(rr, req)
+
+			// Assert
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("expected %d, got %d: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+			}
+			if tt.expectedStatus != http.StatusOK {
+				if _, err := os.Stat(filepath.Join(userPath, "factruas", "1-enero.pdf")); err != nil {
+					t.Errorf("expected the source folder to be left untouched")
+				}
+				return
+			}
+			var resp RenameFolderResponse
+			json.NewDecoder(rr.Body).Decode(&resp)
+			if resp != tt.expectedResp {
+				t.Errorf("expected %+v, got %+v", tt.expectedResp, resp)
+			}
+			if _, err := os.Stat(filepath.Join(userPath, "facturas", "1-enero.pdf")); err != nil {
+				t.Errorf("expected the files to move with the folder: %v", err)
+			}
+			if _, err := os.Stat(filepath.Join(userPath, "factruas")); !os.IsNotExist(err) {
+				t.Errorf("expected the old folder to be gone")
+			}
+			if resp.Output != "" {
+				if got, _ := os.ReadFile(filepath.Join(userPath, "facturas.pdf")); string(got) != "%PDF-unido" {
+					t.Errorf("expected the merged PDF to be renamed, got %q", got)
+				}
+			}
+		})
+	}
+}
+
+func TestRenameFolderMovesManifests(t *testing.T) {
+	// Arrange: una carpeta unida con sus dos manifiestos
+	userPath := t.TempDir()
+	os.MkdirAll(filepath.Join(userPath, "factruas"), os.ModePerm)
+	writeTestPDF(t, filepath.Join(userPath, "factruas", "1-a.pdf"), 1)
+	if _, err := joinPDFs(context.Background(), userPath, "factruas", MergeOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	srv := newTestServer(userPath)
+	req, rr := newRenameFolderRequest(`{"from":"factruas","to":"facturas"}`)
+
+	// Act
+	srv.RenameFolderHandler(rr, req)
+
+	// Assert
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	fromOutput, toOutput := filepath.Join(userPath, "factruas.pdf"), filepath.Join(userPath, "facturas.pdf")
+	for _, old := range []string{mergeManifestPath(fromOutput), outputManifestPath(fromOutput)} {
+		if _, err := os.Stat(old); !os.IsNotExist(err) {
+			t.Errorf("expected %s to be moved", filepath.Base(old))
+		}
+	}
+	if folder, unverified, ok := mergeSourceFolder(toOutput); !ok || unverified || folder != "facturas" {
+		t.Errorf("expected the merge manifest to point at facturas, got %q (unverified=%v, ok=%v)", folder, unverified, ok)
+	}
+	manifestReq := httptest.NewRequest(http.MethodGet, "/manifest?folder=facturas", nil)
+	manifestRR := httptest.NewRecorder()
+	srv.ManifestHandler(manifestRR, manifestReq)
+	var audit OutputManifest
+	json.NewDecoder(manifestRR.Body).Decode(&audit)
+	if manifestRR.Code != http.StatusOK || audit.Folder != "facturas" || audit.Output != "facturas.pdf" {
+		t.Errorf("expected the audit manifest of facturas, got %d %+v", manifestRR.Code, audit)
+	}
+	// La salida sigue al día: la próxima unión no se repite
+	result, err := joinPDFs(context.Background(), userPath, "facturas", MergeOptions{})
+	if err != nil || !result.Unchanged {
+		t.Errorf("expected an unchanged merge after the rename, got %+v (%v)", result, err)
+	}
+}
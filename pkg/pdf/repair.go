@@ -0,0 +1,122 @@
+package pdf
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+)
+
+// RepairHandler: Valida un PDF ya subido y, si no pasa la validación, intenta reescribirlo
+// con pdfcpu. Si la copia reescrita sí es válida reemplaza al original, así el usuario
+// corrige el archivo sin volver a escanearlo. Responde con el estado en ambos casos.
+func (s *Server) RepairHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w, http.MethodPost)
+		return
+	}
+
+	// Obtener la ruta base de almacenamiento del usuario
+	userStoragePath, err := s.storagePath(r)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Error interno de autenticación")
+		return
+	}
+
+	var problems validationErrors
+	folder := r.FormValue("folder")
+	problems.checkFolder(folder)
+	if problems.respond(w) {
+		return
+	}
+	filename := r.FormValue("file")
+	if !validFileName(filename) {
+		writeJSONError(w, http.StatusBadRequest, "Nombre de archivo no válido")
+		return
+	}
+	srcPath := filepath.Join(userStoragePath, folder, filename)
+
+	unlock := lockFolder(userStoragePath, folder)
+	defer unlock()
+
+	if _, err := os.Stat(srcPath); err != nil {
+		if os.IsNotExist(err) {
+			writeJSONError(w, http.StatusNotFound, "Archivo no encontrado: "+filename)
+			return
+		}
+		writeJSONError(w, http.StatusInternalServerError, "Error al leer el archivo")
+		return
+	}
+
+	resp := RepairResponse{Folder: folder, File: filename, Valid: true}
+	validationErr := api.ValidateFile(srcPath, nil)
+	if validationErr == nil {
+		writeJSON(w, http.StatusOK, resp)
+		return
+	}
+	resp.Valid = false
+	resp.ValidationError = validationErr.Error()
+
+	if err := repairPDF(srcPath); err != nil {
+		resp.RepairError = err.Error()
+		writeJSON(w, http.StatusOK, resp)
+		return
+	}
+	resp.Repaired = true
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// repairPDF reescribe srcPath leyéndolo sin validar, lo que reconstruye la tabla xref y
+// recalcula el árbol de páginas. Solo reemplaza el original si la copia pasa la validación.
+func repairPDF(srcPath string) error {
+	tmpDir, cleanup, err := newTempDir("repair-")
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	outPath := filepath.Join(tmpDir, filepath.Base(srcPath))
+
+	if err := rewritePDF(srcPath, outPath); err != nil {
+		return err
+	}
+	if err := api.ValidateFile(outPath, nil); err != nil {
+		return errors.New("la copia reparada sigue sin ser válida: " + err.Error())
+	}
+	if err := moveFile(outPath, srcPath); err != nil {
+		return err
+	}
+	discardChecksumSidecarFile(srcPath)
+	return nil
+}
+
+// rewritePDF lee inPath sin validarlo, elimina objetos duplicados y lo vuelve a escribir en outPath
+func rewritePDF(inPath, outPath string) error {
+	in, err := os.Open(inPath)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	conf := model.NewDefaultConfiguration()
+	conf.ValidationMode = model.ValidationRelaxed
+	ctx, err := api.ReadContext(in, conf)
+	if err != nil {
+		return err
+	}
+	if err := api.OptimizeContext(ctx); err != nil {
+		return err
+	}
+
+	out, err := os.Create(outPath)
+	if err != nil {
+		return err
+	}
+	err = api.WriteContext(ctx, out)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
@@ -0,0 +1,146 @@
+package pdf
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+)
+
+// newRepairRequest crea una petición de formulario para /repair
+func newRepairRequest(body string) (*http.Request, *httptest.ResponseRecorder) {
+	req := httptest.NewRequest(http.MethodPost, "/repair", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req = req.WithContext(context.WithValue(req.Context(), userCodeKey, "testUser"))
+	return req, httptest.NewRecorder()
+}
+
+func TestRepairHandler(t *testing.T) {
+	tests := []struct {
+		name             string
+		write            func(t *testing.T, path string)
+		expectedValid    bool
+		expectedRepaired bool
+	}{
+		{
+			name:          "PDF válido no se modifica",
+			write:         func(t *testing.T, path string) { writeTestPDF(t, path, 2) },
+			expectedValid: true,
+		},
+		{
+			// /Count no coincide con las páginas: al reescribirlo se recalcula
+			name: "Árbol de páginas con /Count incorrecto se repara",
+			write: func(t *testing.T, path string) {
+				writeRawPDF(t, path, []string{
+					"<< /Type /Catalog /Pages 2 0 R >>",
+					"<< /Type /Pages /Kids [3 0 R] /Count 5 >>",
+					"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 200 200] >>",
+				})
+			},
+			expectedRepaired: true,
+		},
+		{
+			name: "Página sin MediaBox no se puede reparar",
+			write: func(t *testing.T, path string) {
+				writeRawPDF(t, path, []string{
+					"<< /Type /Catalog /Pages 2 0 R >>",
+					"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+					"<< /Type /Page /Parent 2 0 R >>",
+				})
+			},
+		},
+		{
+			name: "Archivo que no es un PDF",
+			write: func(t *testing.T, path string) {
+				os.WriteFile(path, []byte("no es un pdf"), 0o644)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			userPath := t.TempDir()
+			folderPath := filepath.Join(userPath, "test-folder")
+			os.MkdirAll(folderPath, os.ModePerm)
+			filePath := filepath.Join(folderPath, "1-scan.pdf")
+			tt.write(t, filePath)
+			original, _ := os.ReadFile(filePath)
+
+			srv := newTestServer(userPath)
+			req, rr := newRepairRequest("folder=test-folder&file=1-scan.pdf")
+
+			// Act
+			srv.RepairHandler(rr, req)
+
+			// Assert
+			if rr.Code != http.StatusOK {
+				t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+			}
+			var resp RepairResponse
+			if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+				t.Fatal(err)
+			}
+			if resp.Valid != tt.expectedValid || resp.Repaired != tt.expectedRepaired {
+				t.Fatalf("expected valid=%v repaired=%v, got %+v", tt.expectedValid, tt.expectedRepaired, resp)
+			}
+			if !resp.Valid && resp.ValidationError == "" {
+				t.Errorf("expected the validation error to be reported")
+			}
+
+			current, _ := os.ReadFile(filePath)
+			if tt.expectedRepaired {
+				if resp.RepairError != "" {
+					t.Errorf("unexpected repair error %q", resp.RepairError)
+				}
+				if err := api.ValidateFile(filePath, nil); err != nil {
+					t.Errorf("expected the repaired file to validate, got %v", err)
+				}
+				return
+			}
+			if !resp.Valid && resp.RepairError == "" {
+				t.Errorf("expected the repair error to be reported")
+			}
+			if string(current) != string(original) {
+				t.Errorf("expected the original file to be left untouched")
+			}
+		})
+	}
+}
+
+func TestRepairHandlerInvalidRequest(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		body           string
+		expectedStatus int
+	}{
+		{name: "Método no permitido", method: http.MethodGet, body: "", expectedStatus: http.StatusMethodNotAllowed},
+		{name: "Falta la carpeta", method: http.MethodPost, body: "file=1-scan.pdf", expectedStatus: http.StatusBadRequest},
+		{name: "Nombre con ruta", method: http.MethodPost, body: "folder=test-folder&file=../1-scan.pdf", expectedStatus: http.StatusBadRequest},
+		{name: "Archivo inexistente", method: http.MethodPost, body: "folder=test-folder&file=9-nada.pdf", expectedStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			srv := newTestServer(t.TempDir())
+			req, rr := newRepairRequest(tt.body)
+			req.Method = tt.method
+
+			// Act
+			srv.RepairHandler(rr, req)
+
+			// Assert
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("expected %d, got %d: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+			}
+		})
+	}
+}
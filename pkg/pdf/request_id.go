@@ -0,0 +1,71 @@
+package pdf
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"log"
+	"net/http"
+)
+
+// --- Id de petición ---
+// RequestIDMiddleware asocia a cada petición un id que viaja en la cabecera X-Request-ID:
+// se respeta el que envía el cliente o el proxy y, si no hay ninguno, se genera un UUID.
+// El id se devuelve en la respuesta y encabeza los logs de la petición (ver logf), así se
+// pueden relacionar la subida, la unión y la descarga de una misma acción del usuario.
+const requestIDHeader = "X-Request-ID"
+
+const requestIDKey contextKey = "requestID"
+
+// Longitud máxima de un X-Request-ID recibido; uno más largo se reemplaza
+const maxRequestIDLength = 128
+
+func (s *Server) RequestIDMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		w.Header().Set(requestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIDKey, id)))
+	}
+}
+
+// requestID devuelve el id de la petición de ctx o "" si no pasó por RequestIDMiddleware
+func requestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+// validRequestID acepta solo ASCII visible sin espacios: el id se escribe tal cual en los
+// logs y no debe poder partir ni falsear una línea
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID genera un UUID versión 4
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	b[6] = b[6]&0x0f | 0x40 // Versión 4
+	b[8] = b[8]&0x3f | 0x80 // Variante RFC 4122
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+// logf escribe una línea de log precedida por el id de la petición de ctx, si lo tiene.
+// El id lo elige el cliente, así que va como argumento y nunca dentro de format.
+func logf(ctx context.Context, format string, args ...any) {
+	if id := requestID(ctx); id != "" {
+		log.Printf("[%s] "+format, append([]any{id}, args...)...)
+		return
+	}
+	log.Printf(format, args...)
+}
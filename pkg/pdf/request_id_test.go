@@ -0,0 +1,90 @@
+package pdf
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var uuidRe = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+func TestRequestIDMiddleware(t *testing.T) {
+	tests := []struct {
+		name       string
+		incoming   string
+		expectKept bool
+	}{
+		{name: "Respeta el id recibido", incoming: "proxy-1234", expectKept: true},
+		{name: "Genera un UUID si no hay", incoming: "", expectKept: false},
+		{name: "Reemplaza un id con espacios", incoming: "a b", expectKept: false},
+		{name: "Reemplaza un id demasiado largo", incoming: strings.Repeat("a", maxRequestIDLength+1), expectKept: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			srv := newTestServer(t.TempDir())
+			var seen string
+			handler := srv.RequestIDMiddleware(func(w http.ResponseWriter, r *http.Request) {
+				seen = requestID(r.Context())
+			})
+			req := httptest.NewRequest(http.MethodGet, "/count", nil)
+			if tt.incoming != "" {
+				req.Header.Set(requestIDHeader, tt.incoming)
+			}
+			rr := httptest.NewRecorder()
+
+			// Act
+			handler(rr, req)
+
+			// Assert
+			got := rr.Header().Get(requestIDHeader)
+			if got != seen {
+				t.Errorf("expected the response header %q to match the context id %q", got, seen)
+			}
+			if tt.expectKept && got != tt.incoming {
+				t.Errorf("expected %q to be kept, got %q", tt.incoming, got)
+			}
+			if !tt.expectKept && !uuidRe.MatchString(got) {
+				t.Errorf("expected a generated UUID, got %q", got)
+			}
+		})
+	}
+}
+
+func TestLogfIncludesRequestID(t *testing.T) {
+	tests := []struct {
+		name      string
+		requestID string
+		expected  string
+	}{
+		{name: "Id generado", requestID: "abc-123", expected: "[abc-123] Subiendo a: facturas\nSin petición\n"},
+		{name: "Id con verbos de formato", requestID: "a%s%d", expected: "[a%s%d] Subiendo a: facturas\nSin petición\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			var buf bytes.Buffer
+			originalOutput, originalFlags := log.Writer(), log.Flags()
+			defer func() { log.SetOutput(originalOutput); log.SetFlags(originalFlags) }()
+			log.SetOutput(&buf)
+			log.SetFlags(0)
+			ctx := context.WithValue(context.Background(), requestIDKey, tt.requestID)
+
+			// Act
+			logf(ctx, "Subiendo a: %s", "facturas")
+			logf(context.Background(), "Sin petición")
+
+			// Assert
+			if buf.String() != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, buf.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package pdf
+
+import (
+	"fmt"
+	"net/http"
+	"path/filepath"
+)
+
+// --- Servidor ---
+// Server agrupa las dependencias de los handlers: la configuración, el almacén de
+// códigos de acceso, la carpeta de almacenamiento de cada usuario y el estado en memoria
+// (pool de uniones, claves de idempotencia y trabajos). Los handlers son métodos de
+// Server, así dos instancias en el mismo proceso (por ejemplo en los tests) no comparten
+// códigos, archivos ni estado. Los límites de solo lectura son del proceso (ver Configure).
+type Server struct {
+	cfg        Config
+	adminCodes map[string]bool
+
+	// Códigos válidos con el nombre y la fecha de creación asociados (ver WhoAmIHandler)
+	codes CodeStore
+
+	// storagePath devuelve la carpeta del usuario autenticado; los tests la reemplazan
+	storagePath func(r *http.Request) (string, error)
+
+	// storageFor devuelve el almacenamiento de archivos del usuario autenticado.
+	// Por defecto es un LocalStorage sobre storagePath.
+	storageFor func(r *http.Request) (Storage, error)
+
+	// Estado en memoria de este Server: no se comparte con otros del mismo proceso
+	merges            mergePool         // Uniones en curso (MAX_CONCURRENT_MERGES)
+	uploadIdempotency *idempotencyStore // Respuestas de /upload por Idempotency-Key
+	jobs              *jobStore         // Trabajos de unión asíncronos
+}
+
+// NewServer crea un Server con la configuración y el almacén de códigos dados (ver NewCodeStore).
+// De cfg toma el almacenamiento, la sesión, los códigos de administración y el tamaño del
+// estado en memoria; los límites que lee Configure se aplican a todo el proceso.
+func NewServer(cfg Config, codes CodeStore) *Server {
+	s := &Server{
+		cfg:        cfg,
+		adminCodes: newCodeSet(cfg.AdminCodes),
+		codes:      codes,
+
+		merges:            newMergePool(cfg.MaxConcurrentMerges),
+		uploadIdempotency: newIdempotencyStore(cfg.IdempotencyMaxKeys, cfg.IdempotencyTTL),
+		jobs:              newJobStore(cfg.JobTTL),
+	}
+	s.storagePath = s.userStoragePath
+	s.storageFor = s.userStorage
+	return s
+}
+
+// userStoragePath devuelve la ruta base de almacenamiento del usuario autenticado
+func (s *Server) userStoragePath(r *http.Request) (string, error) {
+	// Obtener el código de usuario del contexto (establecido por el middleware)
+	userCode, ok := r.Context().Value(userCodeKey).(string)
+	if !ok {
+		// Esto no debería pasar si el middleware se aplica correctamente,
+		// pero es una verificación defensiva.
+		return "", fmt.Errorf("código de usuario no encontrado en el contexto")
+	}
+
+	// Construye la ruta base de almacenamiento incluyendo el código de usuario
+	return filepath.Join(s.cfg.StorageRoot, userCode), nil
+}
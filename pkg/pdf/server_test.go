@@ -0,0 +1,168 @@
+package pdf
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newTestServerWithRoot crea un Server con la configuración por defecto y la raíz de almacenamiento indicada
+func newTestServerWithRoot(root string) *Server {
+	cfg := DefaultConfig()
+	cfg.StorageRoot = root
+	return NewServer(cfg, newDefaultCodeStore())
+}
+
+// newTestServer crea un Server con la configuración por defecto cuya carpeta de usuario es userPath
+func newTestServer(userPath string) *Server {
+	s := NewServer(DefaultConfig(), newDefaultCodeStore())
+	s.storagePath = func(r *http.Request) (string, error) { return userPath, nil }
+	return s
+}
+
+func TestServersAreIsolated(t *testing.T) {
+	// Arrange: dos servidores con raíces de almacenamiento distintas
+	cfgA, cfgB := DefaultConfig(), DefaultConfig()
+	cfgA.StorageRoot, cfgB.StorageRoot = t.TempDir(), t.TempDir()
+	srvA, srvB := NewServer(cfgA, newDefaultCodeStore()), NewServer(cfgB, newDefaultCodeStore())
+	os.MkdirAll(filepath.Join(cfgA.StorageRoot, "alex", "test-folder"), os.ModePerm)
+	writeTestPDF(t, filepath.Join(cfgA.StorageRoot, "alex", "test-folder", "1-a.pdf"), 1)
+
+	// Act: un código generado en A
+	req := httptest.NewRequest(http.MethodPost, "/generate-code", strings.NewReader("name=bea&date=2024-01-02"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rr := httptest.NewRecorder()
+	srvA.GenerateCodeHandler(rr, req)
+	code := strings.TrimSpace(rr.Body.String())
+
+	// Assert: el código solo es válido en A
+	if !srvA.codes.IsValid(code) {
+		t.Fatalf("expected code %q to be valid in server A", code)
+	}
+	if srvB.codes.IsValid(code) {
+		t.Errorf("code generated in server A must not be valid in server B")
+	}
+
+	// Assert: cada servidor lista su propio almacenamiento
+	for _, tt := range []struct {
+		srv      *Server
+		expected int
+	}{{srvA, 1}, {srvB, 0}} {
+		req := httptest.NewRequest(http.MethodGet, "/count?folder=test-folder", nil)
+		req = req.WithContext(context.WithValue(req.Context(), userCodeKey, "alex"))
+		rr := httptest.NewRecorder()
+		tt.srv.CountHandler(rr, req)
+		var body CountResponse
+		if err := json.NewDecoder(rr.Body).Decode(&body); err != nil {
+			t.Fatalf("expected JSON body: %v", err)
+		}
+		if body.Count != tt.expected {
+			t.Errorf("expected count %d, got %d", tt.expected, body.Count)
+		}
+	}
+}
+
+func TestServersDoNotShareState(t *testing.T) {
+	// Arrange: A tiene el pool lleno, una respuesta guardada y un trabajo
+	cfgA := DefaultConfig()
+	cfgA.MaxConcurrentMerges = 1
+	srvA, srvB := NewServer(cfgA, newDefaultCodeStore()), NewServer(DefaultConfig(), newDefaultCodeStore())
+	srvA.merges <- struct{}{}
+	srvA.uploadIdempotency.put("alex/key-1", http.StatusOK, []byte("{}"))
+	srvA.jobs.jobs[jobKey("alex", "job-1")] = &MergeJob{ID: "job-1", Status: JobRunning}
+
+	// Act
+	_, replay, busy := srvB.uploadIdempotency.begin("alex/key-1")
+	_, found := srvB.jobs.get("alex", "job-1")
+	acquireErr := srvB.merges.acquire(context.Background())
+
+	// Assert
+	if replay || busy {
+		t.Errorf("idempotency key stored in server A must not be seen by server B")
+	}
+	if found {
+		t.Errorf("job of server A must not be visible in server B")
+	}
+	if acquireErr != nil {
+		t.Errorf("expected server B to have its own merge pool, got %v", acquireErr)
+	}
+}
+
+func TestSessionCookieUsesConfiguredNameAndPath(t *testing.T) {
+	// Arrange
+	cfg := DefaultConfig()
+	cfg.CookieName, cfg.CookiePath = "pdf_session", "/pdf"
+	srv := NewServer(cfg, newDefaultCodeStore())
+
+	login := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader("access_code=alex"))
+	login.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rr := httptest.NewRecorder()
+
+	// Act
+	srv.LoginHandler(rr, login)
+
+	// Assert: la cookie se emite con el nombre y la ruta configurados
+	cookies := rr.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != "pdf_session" || cookies[0].Path != "/pdf" {
+		t.Fatalf("unexpected cookies: %+v", cookies)
+	}
+
+	// AuthMiddleware lee la misma cookie e ignora la de nombre por defecto
+	tests := []struct {
+		name           string
+		cookie         *http.Cookie
+		expectedStatus int
+	}{
+		{name: "Cookie configurada", cookie: cookies[0], expectedStatus: http.StatusOK},
+		{name: "Cookie con el nombre por defecto", cookie: &http.Cookie{Name: "auth_code", Value: "alex"}, expectedStatus: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/me", nil)
+			req.AddCookie(tt.cookie)
+			rr := httptest.NewRecorder()
+			srv.AuthMiddleware(srv.WhoAmIHandler)(rr, req)
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("expected %d, got %d", tt.expectedStatus, rr.Code)
+			}
+		})
+	}
+}
+
+func TestSessionCookieSecureWithTLS(t *testing.T) {
+	tests := []struct {
+		name           string
+		tlsCert        string
+		tlsKey         string
+		expectedSecure bool
+	}{
+		{name: "HTTP sin certificado", expectedSecure: false},
+		{name: "HTTPS directo con TLS_CERT y TLS_KEY", tlsCert: "cert.pem", tlsKey: "key.pem", expectedSecure: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			cfg := DefaultConfig()
+			cfg.TLSCert, cfg.TLSKey = tt.tlsCert, tt.tlsKey
+			srv := NewServer(cfg, newDefaultCodeStore())
+			login := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader("access_code=alex"))
+			login.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			rr := httptest.NewRecorder()
+
+			// Act
+			srv.LoginHandler(rr, login)
+
+			// Assert
+			cookies := rr.Result().Cookies()
+			if len(cookies) != 1 || cookies[0].Secure != tt.expectedSecure {
+				t.Errorf("expected one cookie with Secure=%v, got %+v", tt.expectedSecure, cookies)
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package pdf
+
+import (
+	"path"
+	"regexp"
+	"sort"
+	"time"
+)
+
+// Orden alternativo de ListHandler (sort=date)
+const sortByDate = "date"
+
+// embeddedDateRe encuentra una fecha AAAA-MM-DD dentro del nombre ("report-2024-03-01.pdf")
+var embeddedDateRe = regexp.MustCompile(`\d{4}-\d{2}-\d{2}`)
+
+// validSortMode indica si mode es un orden soportado ("" es el orden numérico por defecto)
+func validSortMode(mode string) bool {
+	return mode == "" || mode == sortByDate || mode == sortNatural || mode == sortByAdded
+}
+
+// embeddedDate devuelve la primera fecha válida contenida en el nombre del archivo
+func embeddedDate(name string) (time.Time, bool) {
+	for _, match := range embeddedDateRe.FindAllString(path.Base(name), -1) {
+		if date, err := time.Parse(codeDateLayout, match); err == nil {
+			return date, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// sortFilesByDate ordena cronológicamente los archivos con fecha en el nombre; los que
+// no la tienen van después, con el orden numérico/alfabético de siempre
+func sortFilesByDate(files []string) {
+	sort.SliceStable(files, func(i, j int) bool {
+		dateI, okI := embeddedDate(files[i])
+		dateJ, okJ := embeddedDate(files[j])
+		if okI != okJ {
+			return okI
+		}
+		if okI && !dateI.Equal(dateJ) {
+			return dateI.Before(dateJ)
+		}
+		return lessByNumber(files[i], files[j])
+	})
+}
@@ -0,0 +1,70 @@
+package pdf
+
+import (
+	"cmp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Orden natural de ListHandler (sort=natural), como el Explorador de Windows
+const sortNatural = "natural"
+
+// sortFilesNatural ordena los archivos comparando cada tramo numérico por su valor
+func sortFilesNatural(files []string) {
+	sort.SliceStable(files, func(i, j int) bool {
+		return naturalLess(files[i], files[j])
+	})
+}
+
+// naturalLess compara dos nombres por tramos: los números por su valor ("sec2" < "sec10")
+// y el texto sin distinguir mayúsculas. Si son equivalentes ("a01" y "A1") decide el
+// orden alfabético estricto para que el resultado sea estable.
+func naturalLess(a, b string) bool {
+	if c := naturalCompare(a, b); c != 0 {
+		return c < 0
+	}
+	return a < b
+}
+
+// naturalCompare devuelve -1, 0 o 1 según el orden natural de a y b
+func naturalCompare(a, b string) int {
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		if isASCIIDigit(a[i]) && isASCIIDigit(b[j]) {
+			startA, startB := i, j
+			for i < len(a) && isASCIIDigit(a[i]) {
+				i++
+			}
+			for j < len(b) && isASCIIDigit(b[j]) {
+				j++
+			}
+			// Comparar sin convertir a entero: sin ceros a la izquierda, el número más
+			// largo es el mayor, y a igual longitud decide la comparación de texto
+			numA := strings.TrimLeft(a[startA:i], "0")
+			numB := strings.TrimLeft(b[startB:j], "0")
+			if c := cmp.Compare(len(numA), len(numB)); c != 0 {
+				return c
+			}
+			if c := strings.Compare(numA, numB); c != 0 {
+				return c
+			}
+			continue
+		}
+
+		runeA, sizeA := utf8.DecodeRuneInString(a[i:])
+		runeB, sizeB := utf8.DecodeRuneInString(b[j:])
+		if c := cmp.Compare(unicode.ToLower(runeA), unicode.ToLower(runeB)); c != 0 {
+			return c
+		}
+		i += sizeA
+		j += sizeB
+	}
+	// El que se acabó antes es un prefijo del otro
+	return cmp.Compare(len(a)-i, len(b)-j)
+}
+
+func isASCIIDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
@@ -0,0 +1,67 @@
+package pdf
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestNaturalLess(t *testing.T) {
+	tests := []struct {
+		name     string
+		a, b     string
+		expected bool
+	}{
+		{name: "Segundo número decide", a: "ch1-sec2.pdf", b: "ch1-sec10.pdf", expected: true},
+		{name: "Primer número decide antes que el segundo", a: "ch2-sec1.pdf", b: "ch10-sec1.pdf", expected: true},
+		{name: "Texto entre números", a: "ch1-appendix.pdf", b: "ch1-sec1.pdf", expected: true},
+		{name: "Sin distinguir mayúsculas", a: "Beta.pdf", b: "alpha.pdf", expected: false},
+		{name: "Ceros a la izquierda equivalen", a: "doc007.pdf", b: "doc7b.pdf", expected: true},
+		{name: "Números mayores que un int64", a: "v99999999999999999999.pdf", b: "v100000000000000000000.pdf", expected: true},
+		{name: "Prefijo va primero", a: "doc", b: "doc1", expected: true},
+		{name: "Equivalentes desempatan alfabéticamente", a: "A01.pdf", b: "a1.pdf", expected: true},
+		{name: "Un nombre no es menor que sí mismo", a: "x1.pdf", b: "x1.pdf", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := naturalLess(tt.a, tt.b); got != tt.expected {
+				t.Errorf("naturalLess(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestListHandlerSortNatural(t *testing.T) {
+	// Arrange
+	userPath := t.TempDir()
+	folderPath := filepath.Join(userPath, "test-folder")
+	os.MkdirAll(folderPath, os.ModePerm)
+	for _, f := range []string{"ch1-sec10.pdf", "ch10-sec1.pdf", "ch1-sec2.pdf", "ch2-sec1.pdf"} {
+		os.Create(filepath.Join(folderPath, f))
+	}
+	srv := newTestServer(userPath)
+
+	req := httptest.NewRequest(http.MethodGet, "/list?folder=test-folder&sort=natural", nil)
+	req = req.WithContext(context.WithValue(req.Context(), userCodeKey, "testUser"))
+	rr := httptest.NewRecorder()
+
+	// Act
+	srv.ListHandler(rr, req)
+
+	// Assert
+	if rr.Code != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+	}
+	var files []string
+	json.NewDecoder(rr.Body).Decode(&files)
+	expected := []string{"ch1-sec2.pdf", "ch1-sec10.pdf", "ch2-sec1.pdf", "ch10-sec1.pdf"}
+	if !reflect.DeepEqual(files, expected) {
+		t.Errorf("expected %v, got %v", expected, files)
+	}
+}
@@ -0,0 +1,161 @@
+package pdf
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
+)
+
+// ErrNoBookmarks indica que el PDF unido no tiene marcadores por los que dividirlo
+var ErrNoBookmarks = errors.New("el PDF unido no tiene marcadores: genérelo con bookmarks=true")
+
+// SplitByBookmarksHandler: Divide un PDF unido en un archivo por cada marcador de primer
+// nivel, con las páginas desde ese marcador hasta el siguiente. Recibe folder, output
+// (opcional, como en /download) y to, la carpeta nueva donde se guardan las secciones;
+// por defecto "<salida>-secciones". Las páginas anteriores al primer marcador (una
+// portada) no forman parte de ninguna sección.
+func (s *Server) SplitByBookmarksHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w, http.MethodPost)
+		return
+	}
+
+	// Obtener la ruta base de almacenamiento del usuario
+	userStoragePath, err := s.storagePath(r)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Error interno de autenticación")
+		return
+	}
+
+	var problems validationErrors
+	folder := r.FormValue("folder")
+	problems.checkFolder(folder)
+	outputName, err := mergeOutputName(folder, strings.TrimSpace(r.FormValue("output")))
+	if err != nil {
+		problems.add("output", "Nombre de salida inválido")
+	}
+	to := strings.TrimSpace(r.FormValue("to"))
+	if to == "" {
+		to = strings.TrimSuffix(outputName, filepath.Ext(outputName)) + "-secciones"
+	}
+	if !validFolderName(to) {
+		problems.add("to", "Nombre de carpeta no válido")
+	}
+	if problems.respond(w) {
+		return
+	}
+
+	// La salida pertenece a folder: nadie puede regenerarla mientras se divide
+	unlock := lockFolders(userStoragePath, folder, to, outputFolder(outputName))
+	defer unlock()
+
+	pdfPath := mergeOutputPath(userStoragePath, outputName)
+	sections, err := bookmarkSections(pdfPath)
+	if os.IsNotExist(err) {
+		writeJSONError(w, http.StatusNotFound, "merged PDF not generated for folder")
+		return
+	}
+	if errors.Is(err, ErrNoBookmarks) {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Error al leer los marcadores: "+err.Error())
+		return
+	}
+
+	toPath := filepath.Join(userStoragePath, to)
+	if _, err := os.Lstat(toPath); err == nil {
+		writeJSONError(w, http.StatusConflict, "Ya existe una carpeta con ese nombre: "+to)
+		return
+	}
+	if err := os.MkdirAll(toPath, os.ModePerm); err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Error al crear la carpeta")
+		return
+	}
+	for i := range sections {
+		// El prefijo numérico conserva el orden: unir la carpeta nueva reproduce la salida
+		sections[i].File = fmt.Sprintf("%d-%s.pdf", i+1, sectionFileName(sections[i].Title))
+		selection := []string{fmt.Sprintf("%d-%d", sections[i].PageFrom, sections[i].PageThru)}
+		if err := api.CollectFile(pdfPath, filepath.Join(toPath, sections[i].File), selection, nil); err != nil {
+			// Sin divisiones a medias: o la carpeta queda completa o no queda
+			os.RemoveAll(toPath)
+			writeJSONError(w, http.StatusInternalServerError, "Error al extraer la sección "+sections[i].Title+": "+err.Error())
+			return
+		}
+	}
+
+	writeJSON(w, http.StatusOK, SplitResponse{Folder: to, Sections: sections})
+}
+
+// bookmarkSections devuelve el rango de páginas de cada marcador de primer nivel de
+// pdfPath, en el orden de sus páginas. Cada sección llega hasta la página anterior al
+// siguiente marcador y la última hasta el final. Sin marcadores devuelve ErrNoBookmarks.
+func bookmarkSections(pdfPath string) ([]SplitSection, error) {
+	file, err := os.Open(pdfPath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	bookmarks, err := api.Bookmarks(file, nil)
+	if errors.Is(err, api.ErrNoOutlines) || (err == nil && len(bookmarks) == 0) {
+		return nil, ErrNoBookmarks
+	}
+	if err != nil {
+		return nil, err
+	}
+	pageCount, err := api.PageCountFile(pdfPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// Los marcadores que no apuntan a ninguna página no delimitan nada
+	var tops []pdfcpu.Bookmark
+	for _, bookmark := range bookmarks {
+		if bookmark.PageFrom >= 1 && bookmark.PageFrom <= pageCount {
+			tops = append(tops, bookmark)
+		}
+	}
+	sort.SliceStable(tops, func(i, j int) bool { return tops[i].PageFrom < tops[j].PageFrom })
+
+	var sections []SplitSection
+	for i, bookmark := range tops {
+		thru := pageCount
+		if i+1 < len(tops) {
+			thru = tops[i+1].PageFrom - 1
+		}
+		// Dos marcadores en la misma página: el primero queda vacío y se omite
+		if thru < bookmark.PageFrom {
+			continue
+		}
+		sections = append(sections, SplitSection{Title: bookmark.Title, PageFrom: bookmark.PageFrom, PageThru: thru})
+	}
+	if len(sections) == 0 {
+		return nil, ErrNoBookmarks
+	}
+	return sections, nil
+}
+
+// sectionFileName convierte el título de un marcador en un nombre de archivo válido:
+// sin separadores de ruta ni caracteres de control y sin puntos ni espacios en los extremos
+func sectionFileName(title string) string {
+	name := strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || unicode.IsControl(r) {
+			return '-'
+		}
+		return r
+	}, title)
+	name = strings.Trim(name, " .")
+	if name == "" {
+		return "seccion"
+	}
+	return name
+}
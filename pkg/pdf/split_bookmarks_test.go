@@ -0,0 +1,142 @@
+package pdf
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+)
+
+func TestSplitByBookmarksHandler(t *testing.T) {
+	// Arrange
+	userPath := t.TempDir()
+	folderPath := filepath.Join(userPath, "test-folder")
+	os.MkdirAll(folderPath, os.ModePerm)
+	writeTestPDF(t, filepath.Join(folderPath, "1-intro.pdf"), 2)
+	writeTestPDF(t, filepath.Join(folderPath, "2-body.pdf"), 3)
+	if _, err := joinPDFs(context.Background(), userPath, "test-folder", MergeOptions{Bookmarks: true, Cover: "Informe"}); err != nil {
+		t.Fatal(err)
+	}
+	srv := newTestServer(userPath)
+	req, rr := newGenerateRequest(url.Values{"folder": {"test-folder"}})
+
+	// Act
+	srv.SplitByBookmarksHandler(rr, req)
+
+	// Assert
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var resp SplitResponse
+	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	// La portada (página 1) queda fuera de las secciones
+	expected := SplitResponse{Folder: "test-folder-secciones", Sections: []SplitSection{
+		{File: "1-intro.pdf", Title: "intro", PageFrom: 2, PageThru: 3},
+		{File: "2-body.pdf", Title: "body", PageFrom: 4, PageThru: 6},
+	}}
+	if !reflect.DeepEqual(resp, expected) {
+		t.Fatalf("expected %+v, got %+v", expected, resp)
+	}
+	for _, section := range resp.Sections {
+		pages, err := api.PageCountFile(filepath.Join(userPath, resp.Folder, section.File))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := section.PageThru - section.PageFrom + 1; pages != want {
+			t.Errorf("%s: expected %d pages, got %d", section.File, want, pages)
+		}
+	}
+}
+
+func TestSplitByBookmarksHandlerErrors(t *testing.T) {
+	tests := []struct {
+		name           string
+		setup          func(t *testing.T, userPath string)
+		form           url.Values
+		expectedStatus int
+	}{
+		{
+			name:           "Sin PDF unido",
+			setup:          func(t *testing.T, userPath string) {},
+			form:           url.Values{"folder": {"test-folder"}},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "PDF unido sin marcadores",
+			setup: func(t *testing.T, userPath string) {
+				writeTestPDF(t, filepath.Join(userPath, "test-folder.pdf"), 3)
+			},
+			form:           url.Values{"folder": {"test-folder"}},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "La carpeta destino ya existe",
+			setup: func(t *testing.T, userPath string) {
+				folderPath := filepath.Join(userPath, "test-folder")
+				os.MkdirAll(folderPath, os.ModePerm)
+				writeTestPDF(t, filepath.Join(folderPath, "1-intro.pdf"), 1)
+				if _, err := joinPDFs(context.Background(), userPath, "test-folder", MergeOptions{Bookmarks: true}); err != nil {
+					t.Fatal(err)
+				}
+				os.MkdirAll(filepath.Join(userPath, "secciones"), os.ModePerm)
+			},
+			form:           url.Values{"folder": {"test-folder"}, "to": {"secciones"}},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "Destino inválido",
+			setup:          func(t *testing.T, userPath string) {},
+			form:           url.Values{"folder": {"test-folder"}, "to": {"../fuera"}},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			userPath := t.TempDir()
+			tt.setup(t, userPath)
+			srv := newTestServer(userPath)
+			req, rr := newGenerateRequest(tt.form)
+
+			// Act
+			srv.SplitByBookmarksHandler(rr, req)
+
+			// Assert
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("expected %d, got %d: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestSectionFileName(t *testing.T) {
+	tests := []struct {
+		title    string
+		expected string
+	}{
+		{title: "Capítulo 1", expected: "Capítulo 1"},
+		{title: "a/b\\c", expected: "a-b-c"},
+		{title: " .. ", expected: "seccion"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.title, func(t *testing.T) {
+			// Act
+			got := sectionFileName(tt.title)
+
+			// Assert
+			if got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
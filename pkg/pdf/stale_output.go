@@ -0,0 +1,101 @@
+package pdf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// --- Regenerar en la descarga una salida desactualizada ---
+// Con regenerate_if_stale=true DownloadHandler comprueba antes de servir si la salida
+// sigue correspondiendo a su carpeta y, si no, vuelve a unirla. Con manifiesto
+// incremental se usa la misma comprobación que en /generate (fuentes agregadas, borradas
+// o modificadas) y se repiten las opciones de la última unión; sin él, la salida está
+// desactualizada si algún PDF de la carpeta es más reciente. Por defecto la descarga no
+// mira las fuentes, para seguir siendo rápida.
+
+// errStaleOutput envuelve el motivo por el que una salida desactualizada no se pudo regenerar
+var errStaleOutput = errors.New("la salida está desactualizada y no se pudo regenerar")
+
+// staleOutput indica si la salida outputName de folder ya no corresponde a sus fuentes y
+// devuelve las opciones con las que regenerarla. Una salida que no es de joinPDFs para
+// folder (otro nombre sin manifiesto, /merge-folders...) o cuya carpeta ya no existe no
+// se considera desactualizada: no hay de dónde regenerarla.
+func staleOutput(userStoragePath, folder, outputName string) (MergeOptions, bool) {
+	outputPath := mergeOutputPath(userStoragePath, outputName)
+	folderPath := filepath.Join(userStoragePath, folder)
+
+	manifest, err := readMergeManifest(outputPath)
+	if err != nil {
+		if outputName != folder+".pdf" {
+			return MergeOptions{}, false
+		}
+		return MergeOptions{}, newerSource(folderPath, outputPath)
+	}
+	if manifest.Result.Folder != folder {
+		return MergeOptions{}, false
+	}
+	files, err := mergeSourceFiles(folderPath, manifest.Options)
+	if err != nil {
+		// Una carpeta vaciada o con menos archivos que los índices pedidos no se puede
+		// volver a unir, pero la salida ya no la describe
+		return manifest.Options, !os.IsNotExist(err)
+	}
+	sources := make([]string, len(files))
+	for i, name := range files {
+		sources[i] = filepath.Join(folderPath, name)
+	}
+	_, unchanged := unchangedMerge(outputPath, sources, manifest.Options)
+	return manifest.Options, !unchanged
+}
+
+// newerSource indica si algún PDF de folderPath se modificó después que outputPath
+func newerSource(folderPath, outputPath string) bool {
+	output, err := os.Stat(outputPath)
+	if err != nil {
+		return false
+	}
+	files, err := ListFilesWithExtension(folderPath, ".pdf")
+	if err != nil {
+		return false
+	}
+	for _, name := range files {
+		if info, err := os.Stat(filepath.Join(folderPath, name)); err == nil && info.ModTime().After(output.ModTime()) {
+			return true
+		}
+	}
+	return false
+}
+
+// regenerateIfStale vuelve a unir la salida outputName de folder si está desactualizada.
+// Devuelve si se regeneró; los errores de la unión llegan envueltos en errStaleOutput.
+func (s *Server) regenerateIfStale(ctx context.Context, userStoragePath, folder, outputName string) (bool, error) {
+	// La comprobación solo lee: no ocupa un espacio del pool mientras la salida esté al día
+	unlock := rLockFolder(userStoragePath, folder)
+	opts, stale := staleOutput(userStoragePath, folder, outputName)
+	unlock()
+	if !stale {
+		return false, nil
+	}
+
+	// El mismo orden que GenerateHandler: primero el pool y después la carpeta
+	if err := s.merges.acquire(ctx); err != nil {
+		return false, err
+	}
+	defer s.merges.release()
+	unlock = lockFolders(userStoragePath, folder, outputFolder(outputName))
+	defer unlock()
+
+	// Las opciones del manifiesto no guardan contraseñas ni OnProgress; sin manifiesto la
+	// salida es la de nombre por defecto y se une con las opciones por defecto
+	result, err := joinPDFs(ctx, userStoragePath, folder, opts)
+	if err != nil {
+		return false, fmt.Errorf("%w: %w", errStaleOutput, err)
+	}
+	if !result.Unchanged {
+		mergesTotal.Add(1)
+	}
+	return !result.Unchanged, nil
+}
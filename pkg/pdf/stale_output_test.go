@@ -0,0 +1,149 @@
+package pdf
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+)
+
+func TestDownloadHandlerRegenerateIfStale(t *testing.T) {
+	tests := []struct {
+		name                string
+		query               string
+		change              func(t *testing.T, folderPath string)
+		expectedStatus      int
+		expectedRegenerated bool
+		expectedPages       int
+	}{
+		{
+			name:           "Sin cambios se sirve la salida",
+			query:          "folder=test-folder&regenerate_if_stale=true",
+			change:         func(t *testing.T, folderPath string) {},
+			expectedStatus: http.StatusOK,
+			expectedPages:  3,
+		},
+		{
+			name:  "Un archivo nuevo regenera la salida",
+			query: "folder=test-folder&regenerate_if_stale=true",
+			change: func(t *testing.T, folderPath string) {
+				writeTestPDF(t, filepath.Join(folderPath, "3-c.pdf"), 4)
+			},
+			expectedStatus:      http.StatusOK,
+			expectedRegenerated: true,
+			expectedPages:       7,
+		},
+		{
+			name:  "Un archivo borrado regenera la salida",
+			query: "folder=test-folder&regenerate_if_stale=true",
+			change: func(t *testing.T, folderPath string) {
+				os.Remove(filepath.Join(folderPath, "2-b.pdf"))
+			},
+			expectedStatus:      http.StatusOK,
+			expectedRegenerated: true,
+			expectedPages:       2,
+		},
+		{
+			name:  "Por defecto no se comprueban las fuentes",
+			query: "folder=test-folder",
+			change: func(t *testing.T, folderPath string) {
+				writeTestPDF(t, filepath.Join(folderPath, "3-c.pdf"), 4)
+			},
+			expectedStatus: http.StatusOK,
+			expectedPages:  3,
+		},
+		{
+			name:  "Carpeta vaciada no se puede regenerar",
+			query: "folder=test-folder&regenerate_if_stale=true",
+			change: func(t *testing.T, folderPath string) {
+				os.Remove(filepath.Join(folderPath, "1-a.pdf"))
+				os.Remove(filepath.Join(folderPath, "2-b.pdf"))
+			},
+			expectedStatus: http.StatusConflict,
+			expectedPages:  3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			userPath := t.TempDir()
+			folderPath := filepath.Join(userPath, "test-folder")
+			os.MkdirAll(folderPath, os.ModePerm)
+			writeTestPDF(t, filepath.Join(folderPath, "1-a.pdf"), 2)
+			writeTestPDF(t, filepath.Join(folderPath, "2-b.pdf"), 1)
+			if _, err := joinPDFs(context.Background(), userPath, "test-folder", MergeOptions{Bookmarks: true}); err != nil {
+				t.Fatal(err)
+			}
+			tt.change(t, folderPath)
+			srv := newTestServer(userPath)
+			req, rr := NewDownloadRequestBuilder().WithQuery(tt.query).Build()
+
+			// Act
+			srv.DownloadHandler(rr, req)
+
+			// Assert
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("expected %d, got %d: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+			}
+			if regenerated := rr.Header().Get("X-Regenerated") == "true"; regenerated != tt.expectedRegenerated {
+				t.Errorf("expected regenerated=%v", tt.expectedRegenerated)
+			}
+			outputPath := filepath.Join(userPath, "test-folder.pdf")
+			if pages, err := api.PageCountFile(outputPath); err != nil || pages != tt.expectedPages {
+				t.Errorf("expected %d pages, got %d (err: %v)", tt.expectedPages, pages, err)
+			}
+			if !tt.expectedRegenerated {
+				return
+			}
+			// Se repiten las opciones de la última unión
+			file, err := os.Open(outputPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer file.Close()
+			if bookmarks, err := api.Bookmarks(file, nil); err != nil || len(bookmarks) == 0 {
+				t.Errorf("expected the regenerated output to keep its bookmarks, got %v (err: %v)", bookmarks, err)
+			}
+		})
+	}
+}
+
+func TestStaleOutputWithoutManifest(t *testing.T) {
+	tests := []struct {
+		name          string
+		sourceModTime time.Duration // Respecto de la salida
+		expectedStale bool
+	}{
+		{name: "Fuente más reciente que la salida", sourceModTime: time.Hour, expectedStale: true},
+		{name: "Fuentes anteriores a la salida", sourceModTime: -time.Hour, expectedStale: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			userPath := t.TempDir()
+			folderPath := filepath.Join(userPath, "test-folder")
+			os.MkdirAll(folderPath, os.ModePerm)
+			sourcePath := filepath.Join(folderPath, "1-a.pdf")
+			writeTestPDF(t, sourcePath, 1)
+			outputPath := filepath.Join(userPath, "test-folder.pdf")
+			writeTestPDF(t, outputPath, 1)
+			outputTime := time.Now().Add(-24 * time.Hour)
+			os.Chtimes(outputPath, outputTime, outputTime)
+			os.Chtimes(sourcePath, outputTime.Add(tt.sourceModTime), outputTime.Add(tt.sourceModTime))
+
+			// Act
+			_, stale := staleOutput(userPath, "test-folder", "test-folder.pdf")
+
+			// Assert
+			if stale != tt.expectedStale {
+				t.Errorf("expected stale=%v, got %v", tt.expectedStale, stale)
+			}
+		})
+	}
+}
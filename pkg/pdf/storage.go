@@ -0,0 +1,222 @@
+package pdf
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// --- Almacenamiento de archivos ---
+// Storage abstrae las operaciones sobre los archivos de un usuario, con rutas relativas
+// a su espacio (carpeta y nombre). LocalStorage las resuelve en el disco local; otra
+// implementación (p. ej. S3) puede reemplazarla sin tocar los handlers que la usan.
+type Storage interface {
+	// List devuelve los archivos (nunca carpetas) de folder; con recursive incluye las
+	// subcarpetas con rutas relativas a folder separadas por "/". Sin orden garantizado.
+	List(folder string, recursive bool) ([]string, error)
+	// Save guarda el contenido de src como folder/name y devuelve los bytes escritos.
+	// Si falla no queda un archivo a medio escribir.
+	Save(folder, name string, src io.Reader) (int64, error)
+	// Open abre folder/name para leerlo
+	Open(folder, name string) (io.ReadCloser, error)
+	// Delete elimina folder/name
+	Delete(folder, name string) error
+	// Exists indica si folder/name existe
+	Exists(folder, name string) (bool, error)
+	// Stat devuelve el tamaño y la fecha de modificación de folder/name
+	Stat(folder, name string) (fs.FileInfo, error)
+}
+
+// Renamer lo implementan los almacenamientos que pueden mover un archivo dentro del espacio
+// del usuario en una sola operación; moveInStorage lo prefiere a copiar y borrar.
+type Renamer interface {
+	// Rename mueve fromFolder/fromName a toFolder/toName, creando la carpeta destino
+	Rename(fromFolder, fromName, toFolder, toName string) error
+}
+
+// errInvalidPath indica una carpeta o un nombre que saldría del espacio del usuario
+var errInvalidPath = errors.New("ruta no permitida")
+
+// LocalStorage guarda los archivos de un usuario bajo root en el disco local
+type LocalStorage struct {
+	root string
+}
+
+// NewLocalStorage crea un almacenamiento local con raíz en la carpeta del usuario
+func NewLocalStorage(root string) *LocalStorage {
+	return &LocalStorage{root: root}
+}
+
+// path resuelve folder/name dentro de root, rechazando rutas que salen de él o que
+// no están dentro de una carpeta
+func (l *LocalStorage) path(folder, name string) (string, error) {
+	rel := filepath.Join(folder, filepath.FromSlash(name))
+	if folder == "" || !filepath.IsLocal(rel) {
+		return "", errInvalidPath
+	}
+	return filepath.Join(l.root, rel), nil
+}
+
+func (l *LocalStorage) List(folder string, recursive bool) ([]string, error) {
+	dir, err := l.path(folder, "")
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	if !recursive {
+		entries, err := osReadDir(dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				names = append(names, entry.Name())
+			}
+		}
+		return names, nil
+	}
+
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		names = append(names, filepath.ToSlash(rel))
+		return nil
+	})
+	return names, err
+}
+
+func (l *LocalStorage) Save(folder, name string, src io.Reader) (int64, error) {
+	dstPath, err := l.path(folder, name)
+	if err != nil {
+		return 0, err
+	}
+	if err := os.MkdirAll(filepath.Dir(dstPath), os.ModePerm); err != nil {
+		return 0, err
+	}
+
+	// Escribir en un temporal oculto y renombrar para que el archivo aparezca completo
+	tmp, err := os.CreateTemp(filepath.Dir(dstPath), "."+filepath.Base(dstPath)+".*.tmp")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp.Name())
+	written, err := io.Copy(tmp, src)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), dstPath)
+	}
+	return written, err
+}
+
+func (l *LocalStorage) Open(folder, name string) (io.ReadCloser, error) {
+	path, err := l.path(folder, name)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(path)
+}
+
+func (l *LocalStorage) Delete(folder, name string) error {
+	path, err := l.path(folder, name)
+	if err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+func (l *LocalStorage) Rename(fromFolder, fromName, toFolder, toName string) error {
+	fromPath, err := l.path(fromFolder, fromName)
+	if err != nil {
+		return err
+	}
+	toPath, err := l.path(toFolder, toName)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(toPath), os.ModePerm); err != nil {
+		return err
+	}
+	return os.Rename(fromPath, toPath)
+}
+
+func (l *LocalStorage) Exists(folder, name string) (bool, error) {
+	_, err := l.Stat(folder, name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (l *LocalStorage) Stat(folder, name string) (fs.FileInfo, error) {
+	path, err := l.path(folder, name)
+	if err != nil {
+		return nil, err
+	}
+	return os.Stat(path)
+}
+
+// userStorage devuelve el almacenamiento local de la carpeta del usuario autenticado
+func (s *Server) userStorage(r *http.Request) (Storage, error) {
+	userStoragePath, err := s.storagePath(r)
+	if err != nil {
+		return nil, err
+	}
+	return NewLocalStorage(userStoragePath), nil
+}
+
+// countStoredFiles cuenta los archivos subidos a folder, de cualquier extensión (un PDF,
+// una imagen guardada sin convertir, ...). No cuenta los que empiezan por "." (el registro
+// de altas y los .sha256 de checksums): no ocupan un prefijo numérico.
+func countStoredFiles(store Storage, folder string) (int, error) {
+	names, err := store.List(folder, false)
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for _, name := range names {
+		if !strings.HasPrefix(name, ".") {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// listStorageFiles lista los archivos de folder con la extensión ext que coinciden con
+// filter, ordenados como ListFilesWithFilter (o por nivel, como listFilesRecursive)
+func listStorageFiles(store Storage, folder, ext, filter string, recursive bool) ([]string, error) {
+	names, err := store.List(folder, recursive)
+	if err != nil {
+		return nil, err
+	}
+	files := filterFileNames(names, ext, filter)
+	if recursive {
+		sortRelPaths(files)
+	} else {
+		sortByNumber(files)
+	}
+	return files, nil
+}
+
+// filterFileNames conserva los nombres con la extensión ext cuyo nombre base coincide con filter
+func filterFileNames(names []string, ext, filter string) []string {
+	var matched []string
+	for _, name := range names {
+		base := name[strings.LastIndex(name, "/")+1:]
+		if strings.HasSuffix(name, ext) && matchesFilter(base, filter) {
+			matched = append(matched, name)
+		}
+	}
+	return matched
+}
@@ -0,0 +1,320 @@
+package pdf
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memoryStorage implementación de Storage en memoria para probar los handlers sin disco
+type memoryStorage struct {
+	mu    sync.Mutex
+	files map[string][]byte // clave: carpeta/nombre
+}
+
+func newMemoryStorage() *memoryStorage {
+	return &memoryStorage{files: map[string][]byte{}}
+}
+
+// memoryFileInfo FileInfo mínimo para Stat
+type memoryFileInfo struct {
+	name string
+	size int64
+}
+
+func (i memoryFileInfo) Name() string       { return i.name }
+func (i memoryFileInfo) Size() int64        { return i.size }
+func (i memoryFileInfo) Mode() fs.FileMode  { return 0o644 }
+func (i memoryFileInfo) ModTime() time.Time { return time.Time{} }
+func (i memoryFileInfo) IsDir() bool        { return false }
+func (i memoryFileInfo) Sys() any           { return nil }
+
+func (m *memoryStorage) key(folder, name string) (string, error) {
+	key := path.Join(folder, name)
+	if folder == "" || !fs.ValidPath(key) {
+		return "", errInvalidPath
+	}
+	return key, nil
+}
+
+func (m *memoryStorage) List(folder string, recursive bool) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	prefix := folder + "/"
+	var names []string
+	for key := range m.files {
+		rel, ok := strings.CutPrefix(key, prefix)
+		if !ok || (!recursive && strings.Contains(rel, "/")) {
+			continue
+		}
+		names = append(names, rel)
+	}
+	if names == nil {
+		return nil, fs.ErrNotExist
+	}
+	return names, nil
+}
+
+func (m *memoryStorage) Save(folder, name string, src io.Reader) (int64, error) {
+	key, err := m.key(folder, name)
+	if err != nil {
+		return 0, err
+	}
+	data, err := io.ReadAll(src)
+	if err != nil {
+		return 0, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.files[key] = data
+	return int64(len(data)), nil
+}
+
+func (m *memoryStorage) Open(folder, name string) (io.ReadCloser, error) {
+	key, err := m.key(folder, name)
+	if err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	data, ok := m.files[key]
+	if !ok {
+		return nil, fs.ErrNotExist
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (m *memoryStorage) Delete(folder, name string) error {
+	key, err := m.key(folder, name)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.files[key]; !ok {
+		return fs.ErrNotExist
+	}
+	delete(m.files, key)
+	return nil
+}
+
+func (m *memoryStorage) Exists(folder, name string) (bool, error) {
+	_, err := m.Stat(folder, name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (m *memoryStorage) Stat(folder, name string) (fs.FileInfo, error) {
+	key, err := m.key(folder, name)
+	if err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	data, ok := m.files[key]
+	if !ok {
+		return nil, fs.ErrNotExist
+	}
+	return memoryFileInfo{name: path.Base(key), size: int64(len(data))}, nil
+}
+
+// newMemoryStorageServer crea un Server cuyos handlers usan store en lugar del disco
+func newMemoryStorageServer(t *testing.T, store Storage) *Server {
+	srv := newTestServer(t.TempDir())
+	srv.storageFor = func(r *http.Request) (Storage, error) { return store, nil }
+	return srv
+}
+
+func TestListHandlerWithStorage(t *testing.T) {
+	tests := []struct {
+		name          string
+		query         string
+		expectedFiles []string
+	}{
+		{name: "Solo PDFs ordenados por número", query: "folder=docs", expectedFiles: []string{"2-b.pdf", "10-a.pdf"}},
+		{name: "Con filtro", query: "folder=docs&filter=a", expectedFiles: []string{"10-a.pdf"}},
+		{name: "Recursivo", query: "folder=docs&recursive=true", expectedFiles: []string{"2-b.pdf", "10-a.pdf", "sub/1-c.pdf"}},
+		{name: "Carpeta vacía devuelve []", query: "folder=docs&filter=zzz", expectedFiles: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			store := newMemoryStorage()
+			for _, name := range []string{"10-a.pdf", "2-b.pdf", "notas.txt", "sub/1-c.pdf"} {
+				store.Save("docs", name, strings.NewReader("%PDF"))
+			}
+			srv := newMemoryStorageServer(t, store)
+			req := httptest.NewRequest(http.MethodGet, "/list?"+tt.query, nil)
+			rr := httptest.NewRecorder()
+
+			// Act
+			srv.ListHandler(rr, req)
+
+			// Assert
+			if rr.Code != http.StatusOK {
+				t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+			}
+			var files []string
+			json.NewDecoder(rr.Body).Decode(&files)
+			if !reflect.DeepEqual(files, tt.expectedFiles) {
+				t.Errorf("expected %v, got %v", tt.expectedFiles, files)
+			}
+		})
+	}
+}
+
+func TestUploadHandlerWithStorage(t *testing.T) {
+	// Arrange: ya hay un PDF, así que los nuevos continúan la numeración
+	store := newMemoryStorage()
+	store.Save("test-folder", "1-existente.pdf", strings.NewReader("%PDF"))
+	srv := newMemoryStorageServer(t, store)
+	req, rr := NewUploadRequestBuilder().
+		WithFile("a.pdf", []byte("%PDF-a")).
+		WithFile("fotos.zip", zipBytes(t, [][2]string{{"b.pdf", "%PDF-b"}})).
+		Build(t)
+
+	// Act
+	srv.UploadHandler(rr, req)
+
+	// Assert
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	files, _ := listStorageFiles(store, "test-folder", ".pdf", "", false)
+	expected := []string{"1-existente.pdf", "2-a.pdf", "3-b.pdf"}
+	if !reflect.DeepEqual(files, expected) {
+		t.Errorf("expected %v in storage, got %v", expected, files)
+	}
+	if got := string(store.files["test-folder/2-a.pdf"]); got != "%PDF-a" {
+		t.Errorf("unexpected content %q", got)
+	}
+}
+
+func TestDeleteFilesHandlerWithStorage(t *testing.T) {
+	tests := []struct {
+		name           string
+		files          []string
+		expectedStatus int
+		expectedLeft   []string
+	}{
+		{name: "Eliminar un archivo", files: []string{"1-a.pdf"}, expectedStatus: http.StatusOK, expectedLeft: []string{"2-b.pdf"}},
+		{name: "Eliminar todos", files: []string{}, expectedStatus: http.StatusOK, expectedLeft: nil},
+		{name: "Archivo inexistente", files: []string{"9-x.pdf"}, expectedStatus: http.StatusBadRequest, expectedLeft: []string{"1-a.pdf", "2-b.pdf"}},
+		{name: "Ruta fuera de la carpeta", files: []string{"../1-a.pdf"}, expectedStatus: http.StatusBadRequest, expectedLeft: []string{"1-a.pdf", "2-b.pdf"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			store := newMemoryStorage()
+			store.Save("test-folder", "1-a.pdf", strings.NewReader("%PDF"))
+			store.Save("test-folder", "2-b.pdf", strings.NewReader("%PDF"))
+			srv := newMemoryStorageServer(t, store)
+			req, rr := NewDeleteRequestBuilder().WithFiles(tt.files).Build(t)
+
+			// Act
+			srv.DeleteFilesHandler(rr, req)
+
+			// Assert
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("expected %d, got %d: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+			}
+			left, _ := listStorageFiles(store, "test-folder", ".pdf", "", false)
+			if !reflect.DeepEqual(left, tt.expectedLeft) {
+				t.Errorf("expected %v left, got %v", tt.expectedLeft, left)
+			}
+		})
+	}
+}
+
+func TestLocalStorageRejectsPathsOutsideRoot(t *testing.T) {
+	tests := []struct {
+		name   string
+		folder string
+		file   string
+	}{
+		{name: "Carpeta vacía", folder: "", file: "a.pdf"},
+		{name: "Nombre con ..", folder: "docs", file: "../../a.pdf"},
+		{name: "Carpeta con ..", folder: "../otro", file: "a.pdf"},
+		{name: "Ruta absoluta", folder: "/etc", file: "passwd"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewLocalStorage(t.TempDir())
+			if _, err := store.Save(tt.folder, tt.file, strings.NewReader("x")); !errors.Is(err, errInvalidPath) {
+				t.Errorf("expected errInvalidPath, got %v", err)
+			}
+		})
+	}
+}
+
+func TestLocalStorageRename(t *testing.T) {
+	// Arrange
+	store := NewLocalStorage(t.TempDir())
+	store.Save("docs", "1-a.pdf", strings.NewReader("%PDF"))
+
+	// Act
+	err := store.Rename("docs", "1-a.pdf", trashFolder, "docs/1-a.pdf")
+
+	// Assert
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exists, _ := store.Exists("docs", "1-a.pdf"); exists {
+		t.Errorf("expected the source to be gone")
+	}
+	if info, err := store.Stat(trashFolder, "docs/1-a.pdf"); err != nil || info.Size() != 4 {
+		t.Errorf("expected the file at its new path, got %v", err)
+	}
+	if err := store.Rename("docs", "1-a.pdf", "../fuera", "1-a.pdf"); !errors.Is(err, errInvalidPath) {
+		t.Errorf("expected errInvalidPath, got %v", err)
+	}
+}
+
+func TestLocalStorageRoundTrip(t *testing.T) {
+	// Arrange
+	store := NewLocalStorage(t.TempDir())
+
+	// Act
+	written, err := store.Save("docs", "sub/1-a.pdf", strings.NewReader("%PDF"))
+
+	// Assert
+	if err != nil || written != 4 {
+		t.Fatalf("expected 4 bytes saved, got %d, %v", written, err)
+	}
+	if exists, _ := store.Exists("docs", "sub/1-a.pdf"); !exists {
+		t.Errorf("expected the saved file to exist")
+	}
+	if names, _ := store.List("docs", true); !reflect.DeepEqual(names, []string{"sub/1-a.pdf"}) {
+		t.Errorf("expected only the saved file (no temporaries), got %v", names)
+	}
+	rc, err := store.Open("docs", "sub/1-a.pdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(rc)
+	rc.Close()
+	if string(data) != "%PDF" {
+		t.Errorf("unexpected content %q", data)
+	}
+	if err := store.Delete("docs", "sub/1-a.pdf"); err != nil {
+		t.Fatal(err)
+	}
+	if exists, _ := store.Exists("docs", "sub/1-a.pdf"); exists {
+		t.Errorf("expected the file to be deleted")
+	}
+}
@@ -0,0 +1,58 @@
+package pdf
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// --- Archivos intermedios ---
+// Las uniones y post-procesos escriben sus archivos intermedios en una subcarpeta
+// propia de TEMP_DIR (por defecto la del sistema) que se borra al terminar, así no
+// quedan restos en las carpetas de los usuarios.
+var tempRoot = defaultConfig.TempDir
+
+// newTempDir crea una subcarpeta temporal para una petición. Quien la crea debe
+// llamar a cleanup con defer, que la elimina con todo su contenido.
+func newTempDir(prefix string) (dir string, cleanup func(), err error) {
+	if err := os.MkdirAll(tempRoot, os.ModePerm); err != nil {
+		return "", func() {}, err
+	}
+	dir, err = os.MkdirTemp(tempRoot, prefix+"*")
+	if err != nil {
+		return "", func() {}, err
+	}
+	return dir, func() { os.RemoveAll(dir) }, nil
+}
+
+// moveFile mueve src a dst. Si TEMP_DIR está en otro sistema de archivos el rename
+// falla, y entonces se copia junto a dst y se renombra para que dst aparezca completo.
+func moveFile(src, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	tmpPath := filepath.Join(filepath.Dir(dst), "."+filepath.Base(dst)+".tmp")
+	defer os.Remove(tmpPath)
+	out, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(out, in)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, dst); err != nil {
+		return err
+	}
+	return os.Remove(src)
+}
@@ -0,0 +1,64 @@
+package pdf
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestNewTempDir(t *testing.T) {
+	// Arrange
+	originalTempRoot := tempRoot
+	defer func() { tempRoot = originalTempRoot }()
+	tempRoot = filepath.Join(t.TempDir(), "no-existe-aun")
+
+	// Act
+	dir, cleanup, err := newTempDir("merge-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(dir, "intermedio.pdf"), []byte("x"), 0o644)
+
+	// Assert
+	if filepath.Dir(dir) != tempRoot {
+		t.Errorf("expected temp dir inside %s, got %s", tempRoot, dir)
+	}
+	cleanup()
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("expected cleanup to remove %s", dir)
+	}
+}
+
+func TestJoinPDFsUsesTempDir(t *testing.T) {
+	// Arrange
+	originalTempRoot := tempRoot
+	defer func() { tempRoot = originalTempRoot }()
+	tempRoot = t.TempDir()
+
+	userPath := t.TempDir()
+	folderPath := filepath.Join(userPath, "test-folder")
+	os.MkdirAll(folderPath, os.ModePerm)
+	writeTestPDF(t, filepath.Join(folderPath, "1-a.pdf"), 1)
+	writeTestPDF(t, filepath.Join(folderPath, "2-b.pdf"), 1)
+
+	// Act
+	_, err := joinPDFs(context.Background(), userPath, "test-folder", MergeOptions{Bookmarks: true})
+
+	// Assert
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, _ := os.ReadDir(userPath)
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	if !reflect.DeepEqual(names, []string{".test-folder.pdf.manifest", "test-folder", "test-folder.manifest.json", "test-folder.pdf"}) {
+		t.Errorf("expected only the folder, its merge and its manifests in user storage, got %v", names)
+	}
+	if leftovers, _ := os.ReadDir(tempRoot); len(leftovers) != 0 {
+		t.Errorf("expected temp dir to be cleaned up, found %d entries", len(leftovers))
+	}
+}
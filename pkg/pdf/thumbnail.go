@@ -0,0 +1,165 @@
+package pdf
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/tiff" // Imágenes CCITT que pdfcpu exporta como TIFF
+)
+
+// Ancho por defecto de las miniaturas y límites aceptados en ?width=
+var (
+	thumbnailWidth    = defaultConfig.ThumbnailWidth
+	minThumbnailWidth = 16
+	maxThumbnailWidth = 1024
+)
+
+// ErrNoPageImage indica que la primera página no tiene imágenes de las que sacar la miniatura.
+// pdfcpu no rasteriza páginas, así que solo podemos usar las imágenes embebidas (el caso
+// habitual de los documentos escaneados); el contenido vectorial no tiene miniatura.
+var ErrNoPageImage = errors.New("la primera página no contiene imágenes para generar la miniatura")
+
+// ThumbnailHandler: Devuelve una vista previa PNG de la primera página de un PDF de la carpeta.
+// La miniatura se guarda junto al archivo y solo se regenera si el PDF es más nuevo.
+func (s *Server) ThumbnailHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, http.MethodGet)
+		return
+	}
+
+	// Obtener la ruta base de almacenamiento del usuario
+	userStoragePath, err := s.storagePath(r)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Error interno de autenticación")
+		return
+	}
+
+	var problems validationErrors
+	folder := r.URL.Query().Get("folder")
+	problems.checkFolder(folder)
+	if problems.respond(w) {
+		return
+	}
+	filename := r.URL.Query().Get("file")
+	if !validFileName(filename) {
+		writeJSONError(w, http.StatusBadRequest, "Nombre de archivo no válido")
+		return
+	}
+
+	width, err := thumbnailWidthParam(r)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	thumb, err := cachedThumbnail(filepath.Join(userStoragePath, folder), filename, width)
+	if os.IsNotExist(err) {
+		writeJSONError(w, http.StatusNotFound, "Archivo no encontrado: "+filename)
+		return
+	}
+	if errors.Is(err, ErrNoPageImage) {
+		writeJSONError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Error al generar la miniatura: "+err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Content-Length", strconv.Itoa(len(thumb)))
+	w.Write(thumb)
+}
+
+// thumbnailWidthParam lee ?width=; sin él usa THUMBNAIL_WIDTH
+func thumbnailWidthParam(r *http.Request) (int, error) {
+	value := r.URL.Query().Get("width")
+	if value == "" {
+		return thumbnailWidth, nil
+	}
+	width, err := strconv.Atoi(value)
+	if err != nil || width < minThumbnailWidth || width > maxThumbnailWidth {
+		return 0, fmt.Errorf("El ancho debe estar entre %d y %d", minThumbnailWidth, maxThumbnailWidth)
+	}
+	return width, nil
+}
+
+// thumbnailPath ruta de la miniatura en caché: archivo oculto junto al PDF
+func thumbnailPath(folderPath, filename string, width int) string {
+	return filepath.Join(folderPath, fmt.Sprintf(".%s.thumb-%d.png", filename, width))
+}
+
+// cachedThumbnail devuelve la miniatura guardada si sigue vigente o la regenera
+func cachedThumbnail(folderPath, filename string, width int) ([]byte, error) {
+	srcPath := filepath.Join(folderPath, filename)
+	srcInfo, err := os.Stat(srcPath)
+	if err != nil {
+		return nil, err
+	}
+
+	cachePath := thumbnailPath(folderPath, filename, width)
+	if cacheInfo, err := os.Stat(cachePath); err == nil && !cacheInfo.ModTime().Before(srcInfo.ModTime()) {
+		if thumb, err := os.ReadFile(cachePath); err == nil {
+			return thumb, nil
+		}
+	}
+
+	thumb, err := renderThumbnail(srcPath, width)
+	if err != nil {
+		return nil, err
+	}
+	// Si no se puede escribir la caché se sirve igual la miniatura recién generada
+	os.WriteFile(cachePath, thumb, 0o644)
+	return thumb, nil
+}
+
+// renderThumbnail escala la imagen más grande de la primera página al ancho indicado
+func renderThumbnail(srcPath string, width int) ([]byte, error) {
+	ctx, err := readImageContext(srcPath, model.EXTRACTIMAGES)
+	if err != nil {
+		return nil, err
+	}
+	images, err := pdfcpu.ExtractPageImages(ctx, 1, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var src image.Image
+	largest := 0
+	for _, img := range images {
+		if img.IsImgMask {
+			continue
+		}
+		decoded, _, err := image.Decode(img)
+		if err != nil {
+			continue // Formato no soportado por image.Decode
+		}
+		if area := decoded.Bounds().Dx() * decoded.Bounds().Dy(); area > largest {
+			src, largest = decoded, area
+		}
+	}
+	if src == nil {
+		return nil, ErrNoPageImage
+	}
+
+	bounds := src.Bounds()
+	height := max(1, bounds.Dy()*width/bounds.Dx())
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.ApproxBiLinear.Scale(dst, dst.Bounds(), src, bounds, draw.Src, nil)
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, dst); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
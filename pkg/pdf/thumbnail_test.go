@@ -0,0 +1,82 @@
+package pdf
+
+import (
+	"bytes"
+	"context"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newThumbnailRequest(query string) (*http.Request, *httptest.ResponseRecorder) {
+	req := httptest.NewRequest(http.MethodGet, "/thumbnail?"+query, nil)
+	req = req.WithContext(context.WithValue(req.Context(), userCodeKey, "testUser"))
+	return req, httptest.NewRecorder()
+}
+
+func TestThumbnailHandler(t *testing.T) {
+	// Arrange
+	userPath := t.TempDir()
+	folderPath := filepath.Join(userPath, "test-folder")
+	os.MkdirAll(folderPath, os.ModePerm)
+	os.Rename(writeColorImagePDF(t, t.TempDir()), filepath.Join(folderPath, "1-scan.pdf"))
+	writeTestPDF(t, filepath.Join(folderPath, "2-vector.pdf"), 1)
+
+	srv := newTestServer(userPath)
+
+	t.Run("Genera la miniatura con el ancho pedido", func(t *testing.T) {
+		req, rr := newThumbnailRequest("folder=test-folder&file=1-scan.pdf&width=50")
+		srv.ThumbnailHandler(rr, req)
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v (%s)", rr.Code, http.StatusOK, rr.Body.String())
+		}
+		if got := rr.Header().Get("Content-Type"); got != "image/png" {
+			t.Errorf("expected image/png, got %s", got)
+		}
+		img, err := png.Decode(bytes.NewReader(rr.Body.Bytes()))
+		if err != nil {
+			t.Fatalf("expected a PNG body: %v", err)
+		}
+		if img.Bounds().Dx() != 50 {
+			t.Errorf("expected width 50, got %d", img.Bounds().Dx())
+		}
+		if _, err := os.Stat(thumbnailPath(folderPath, "1-scan.pdf", 50)); err != nil {
+			t.Errorf("expected cached thumbnail: %v", err)
+		}
+	})
+
+	t.Run("Regenera la caché cuando el PDF es más nuevo", func(t *testing.T) {
+		cachePath := thumbnailPath(folderPath, "1-scan.pdf", 50)
+		os.WriteFile(cachePath, []byte("stale"), 0o644)
+		old := time.Now().Add(-time.Hour)
+		os.Chtimes(cachePath, old, old)
+
+		req, rr := newThumbnailRequest("folder=test-folder&file=1-scan.pdf&width=50")
+		srv.ThumbnailHandler(rr, req)
+
+		if bytes.Equal(rr.Body.Bytes(), []byte("stale")) {
+			t.Errorf("expected stale cache to be regenerated")
+		}
+	})
+
+	t.Run("PDF sin imágenes", func(t *testing.T) {
+		req, rr := newThumbnailRequest("folder=test-folder&file=2-vector.pdf")
+		srv.ThumbnailHandler(rr, req)
+		if rr.Code != http.StatusUnprocessableEntity {
+			t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusUnprocessableEntity)
+		}
+	})
+
+	t.Run("Ancho fuera de rango", func(t *testing.T) {
+		req, rr := newThumbnailRequest("folder=test-folder&file=1-scan.pdf&width=5000")
+		srv.ThumbnailHandler(rr, req)
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusBadRequest)
+		}
+	})
+}
@@ -0,0 +1,304 @@
+package pdf
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// --- Papelera ---
+// DeleteFilesHandler mueve los archivos a la carpeta oculta .trash del usuario en lugar de
+// borrarlos. Cada uno queda como ".trash/<carpeta>/<borrado en ns>-<nombre>", y esa ruta
+// relativa a .trash es su id para RestoreHandler. Con purge=true se borran directamente.
+// ClearFolderHandler hace lo mismo con los archivos fuente de una carpeta.
+const trashFolder = ".trash"
+
+// Cada cuánto PurgeTrashLoop revisa las papeleras
+var trashPurgeInterval = time.Hour
+
+// Tamaño máximo del cuerpo JSON de RestoreHandler
+const maxRestoreBodyBytes = 4 << 10
+
+// trashEntryName id en la papelera de folder/name borrado en deletedAt
+func trashEntryName(folder, name string, deletedAt time.Time) string {
+	return folder + "/" + strconv.FormatInt(deletedAt.UnixNano(), 10) + "-" + name
+}
+
+// parseTrashEntry descompone un id de la papelera; false si no tiene el formato esperado
+func parseTrashEntry(id string) (TrashItem, bool) {
+	folder, base, ok := strings.Cut(id, "/")
+	if !ok || !validFileName(folder) || !validFileName(base) {
+		return TrashItem{}, false
+	}
+	stamp, name, ok := strings.Cut(base, "-")
+	if !ok || !validFileName(name) {
+		return TrashItem{}, false
+	}
+	ns, err := strconv.ParseInt(stamp, 10, 64)
+	if err != nil {
+		return TrashItem{}, false
+	}
+	return TrashItem{ID: id, Folder: folder, File: name, DeletedAt: time.Unix(0, ns).UTC()}, true
+}
+
+// moveToTrash mueve folder/name a la papelera y devuelve su id en ella
+func moveToTrash(store Storage, folder, name string, now time.Time) (string, error) {
+	id := trashEntryName(folder, name, now)
+	return id, moveInStorage(store, folder, name, trashFolder, id)
+}
+
+// removeFiles mueve names de folder a la papelera, o los borra con purge, y descarta sus
+// sumas de control. Si uno no se puede mover, los que ya se movieron vuelven a la carpeta:
+// o se mueven todos o ninguno. Un borrado con purge no se puede deshacer, así que si uno
+// falla devuelve los que ya se borraron (ver writePartialDelete).
+func removeFiles(ctx context.Context, store Storage, folder string, names []string, purge bool, now time.Time) (purged []string, err error) {
+	purged = []string{}
+	var trashed []string // Ids en la papelera, en el orden de names
+	for _, name := range names {
+		if purge {
+			if err = store.Delete(folder, name); err == nil {
+				purged = append(purged, name)
+				discardChecksumSidecar(store, folder, name)
+			}
+		} else {
+			var id string
+			if id, err = moveToTrash(store, folder, name, now); err == nil {
+				trashed = append(trashed, id)
+			}
+		}
+		if err != nil {
+			for i, id := range trashed {
+				if restoreErr := moveInStorage(store, trashFolder, id, folder, names[i]); restoreErr != nil {
+					logf(ctx, "Error devolviendo %s desde la papelera: %v", names[i], restoreErr)
+				}
+			}
+			return purged, fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	if !purge {
+		for _, name := range names {
+			discardChecksumSidecar(store, folder, name)
+		}
+	}
+	return purged, nil
+}
+
+// writePartialDelete responde 500 con los archivos que removeFiles ya borró con purge
+func writePartialDelete(w http.ResponseWriter, folder string, purged []string, err error) {
+	writeJSON(w, http.StatusInternalServerError, PartialDeleteResponse{
+		ErrorResponse: ErrorResponse{Error: "Error al eliminar archivo " + err.Error(), Status: http.StatusInternalServerError},
+		Folder:        folder,
+		Deleted:       purged,
+	})
+}
+
+// moveInStorage mueve un archivo dentro del espacio del usuario: con un Renamer en una
+// sola operación y, con cualquier otro almacenamiento, copiando y borrando el original.
+func moveInStorage(store Storage, fromFolder, fromName, toFolder, toName string) error {
+	if renamer, ok := store.(Renamer); ok {
+		return renamer.Rename(fromFolder, fromName, toFolder, toName)
+	}
+	src, err := store.Open(fromFolder, fromName)
+	if err != nil {
+		return err
+	}
+	_, err = store.Save(toFolder, toName, src)
+	src.Close()
+	if err != nil {
+		return err
+	}
+	return store.Delete(fromFolder, fromName)
+}
+
+// listTrash devuelve los archivos de la papelera, los más recientes primero. Con folder
+// solo los borrados de esa carpeta.
+func listTrash(store Storage, folder string) ([]TrashItem, error) {
+	items := []TrashItem{}
+	names, err := store.List(trashFolder, true)
+	if errors.Is(err, fs.ErrNotExist) {
+		return items, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		item, ok := parseTrashEntry(name)
+		if !ok || (folder != "" && item.Folder != folder) {
+			continue
+		}
+		if info, err := store.Stat(trashFolder, name); err == nil {
+			item.Size = info.Size()
+		}
+		items = append(items, item)
+	}
+	sort.SliceStable(items, func(i, j int) bool { return items[i].DeletedAt.After(items[j].DeletedAt) })
+	return items, nil
+}
+
+// purgeTrash borra de la papelera lo eliminado hace más de maxAge y devuelve cuántos borró
+func purgeTrash(store Storage, maxAge time.Duration, now time.Time) (int, error) {
+	items, err := listTrash(store, "")
+	if err != nil {
+		return 0, err
+	}
+	purged := 0
+	for _, item := range items {
+		if now.Sub(item.DeletedAt) <= maxAge {
+			continue
+		}
+		if err := store.Delete(trashFolder, item.ID); err != nil {
+			return purged, err
+		}
+		discardChecksumSidecar(store, trashFolder, item.ID)
+		purged++
+	}
+	return purged, nil
+}
+
+// PurgeTrashLoop vacía cada trashPurgeInterval lo que lleva en la papelera de cada usuario
+// más de TRASH_MAX_AGE, hasta que se cancele ctx. Con TRASH_MAX_AGE=0 no hace nada.
+func (s *Server) PurgeTrashLoop(ctx context.Context) {
+	if s.cfg.TrashMaxAge <= 0 {
+		return
+	}
+	ticker := time.NewTicker(trashPurgeInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if err := purgeAllTrash(s.cfg.StorageRoot, s.cfg.TrashMaxAge, now); err != nil {
+				fmt.Println("Error al vaciar la papelera:", err)
+			}
+		}
+	}
+}
+
+// purgeAllTrash aplica purgeTrash a cada usuario de la raíz de almacenamiento
+func purgeAllTrash(root string, maxAge time.Duration, now time.Time) error {
+	entries, err := os.ReadDir(root)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		if _, err := purgeTrash(NewLocalStorage(filepath.Join(root, entry.Name())), maxAge, now); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", entry.Name(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ListTrashHandler: Lista los archivos de la papelera del usuario; con ?folder= solo los
+// borrados de esa carpeta.
+func (s *Server) ListTrashHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, http.MethodGet)
+		return
+	}
+
+	folder := r.URL.Query().Get("folder")
+	if folder != "" && !validFileName(folder) {
+		writeJSONError(w, http.StatusBadRequest, "Nombre de carpeta no válido")
+		return
+	}
+
+	store, err := s.storageFor(r)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Error interno de autenticación")
+		return
+	}
+
+	items, err := listTrash(store, folder)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Error al listar la papelera")
+		return
+	}
+	writeJSON(w, http.StatusOK, TrashListResponse{Folder: folder, Files: items})
+}
+
+// RestoreHandler: Devuelve a su carpeta un archivo de la papelera con {"id"}. Si en la
+// carpeta ya hay un archivo con ese nombre responde 409 en lugar de sobrescribirlo.
+func (s *Server) RestoreHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w, http.MethodPost)
+		return
+	}
+
+	// Obtener la ruta base de almacenamiento del usuario
+	userStoragePath, err := s.storagePath(r)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Error interno de autenticación")
+		return
+	}
+	store, err := s.storageFor(r)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Error interno de autenticación")
+		return
+	}
+
+	if !isJSONRequest(r) {
+		writeJSONError(w, http.StatusBadRequest, "Content-Type debe ser application/json")
+		return
+	}
+	var req RestoreRequest
+	r.Body = http.MaxBytesReader(w, r.Body, maxRestoreBodyBytes)
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Formato JSON inválido")
+		return
+	}
+	item, ok := parseTrashEntry(req.ID)
+	if !ok {
+		writeJSONError(w, http.StatusBadRequest, "Id de la papelera no válido")
+		return
+	}
+
+	unlock := lockFolder(userStoragePath, item.Folder)
+	defer unlock()
+
+	if exists, err := store.Exists(trashFolder, item.ID); err != nil || !exists {
+		writeJSONError(w, http.StatusNotFound, "Archivo no encontrado en la papelera")
+		return
+	}
+	if exists, err := store.Exists(item.Folder, item.File); err != nil || exists {
+		writeJSONError(w, http.StatusConflict, "Ya existe un archivo con ese nombre: "+item.File)
+		return
+	}
+	files, err := listStorageFiles(store, item.Folder, ".pdf", "", false)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		writeJSONError(w, http.StatusInternalServerError, "Error al listar archivos")
+		return
+	}
+	if folderLimitExceeded(w, len(files), 1) {
+		return
+	}
+
+	if err := moveInStorage(store, trashFolder, item.ID, item.Folder, item.File); err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Error al restaurar el archivo")
+		return
+	}
+	// Una caché que quedó con ese nombre sería de otro archivo
+	discardChecksumSidecar(store, item.Folder, item.File)
+	// Vuelve a la carpeta como un archivo agregado ahora
+	if err := recordAdded(store, item.Folder, []string{item.File}, time.Now()); err != nil {
+		logf(r.Context(), "Error registrando la fecha de alta en %s: %v", item.Folder, err)
+	}
+
+	writeJSON(w, http.StatusOK, RestoreResponse{Folder: item.Folder, File: item.File})
+}
@@ -0,0 +1,261 @@
+package pdf
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newRestoreRequest crea una petición JSON para /restore
+func newRestoreRequest(body string) (*http.Request, *httptest.ResponseRecorder) {
+	req := httptest.NewRequest(http.MethodPost, "/restore", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req = req.WithContext(context.WithValue(req.Context(), userCodeKey, "testUser"))
+	return req, httptest.NewRecorder()
+}
+
+// trashTestFile pone en la papelera de userPath una copia de folder/name borrada en deletedAt
+func trashTestFile(t *testing.T, userPath, folder, name, content string, deletedAt time.Time) string {
+	t.Helper()
+	store := NewLocalStorage(userPath)
+	if _, err := store.Save(folder, name, strings.NewReader(content)); err != nil {
+		t.Fatal(err)
+	}
+	id, err := moveToTrash(store, folder, name, deletedAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+func TestListTrashHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		expectedFiles  []string
+	}{
+		{name: "Toda la papelera, lo más reciente primero", query: "", expectedStatus: http.StatusOK, expectedFiles: []string{"2-b.pdf", "1-otro.pdf", "1-a.pdf"}},
+		{name: "Solo una carpeta", query: "?folder=facturas", expectedStatus: http.StatusOK, expectedFiles: []string{"2-b.pdf", "1-a.pdf"}},
+		{name: "Carpeta sin borrados", query: "?folder=nada", expectedStatus: http.StatusOK, expectedFiles: []string{}},
+		{name: "Carpeta no válida", query: "?folder=..", expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			userPath := t.TempDir()
+			now := time.Now()
+			trashTestFile(t, userPath, "facturas", "1-a.pdf", "%PDF-a", now.Add(-3*time.Hour))
+			trashTestFile(t, userPath, "otros", "1-otro.pdf", "%PDF-otro", now.Add(-2*time.Hour))
+			trashTestFile(t, userPath, "facturas", "2-b.pdf", "%PDF-b", now.Add(-time.Hour))
+			srv := newTestServer(userPath)
+			req := httptest.NewRequest(http.MethodGet, "/trash"+tt.query, nil)
+			req = req.WithContext(context.WithValue(req.Context(), userCodeKey, "testUser"))
+			rr := httptest.NewRecorder()
+
+			// Act
+			srv.ListTrashHandler(rr, req)
+
+			// Assert
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("expected %d, got %d: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+			var resp TrashListResponse
+			json.NewDecoder(rr.Body).Decode(&resp)
+			files := []string{}
+			for _, item := range resp.Files {
+				files = append(files, item.File)
+			}
+			if !reflect.DeepEqual(files, tt.expectedFiles) {
+				t.Errorf("expected %v, got %v", tt.expectedFiles, files)
+			}
+		})
+	}
+}
+
+func TestRestoreHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           func(id string) string
+		setup          func(t *testing.T, userPath string)
+		expectedStatus int
+	}{
+		{
+			name:           "Restaura el archivo en su carpeta",
+			body:           func(id string) string { return `{"id":"` + id + `"}` },
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "Ya existe un archivo con ese nombre",
+			body: func(id string) string { return `{"id":"` + id + `"}` },
+			setup: func(t *testing.T, userPath string) {
+				os.WriteFile(filepath.Join(userPath, "facturas", "1-a.pdf"), []byte("%PDF-nuevo"), 0o644)
+			},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "Id que no está en la papelera",
+			body:           func(id string) string { return `{"id":"facturas/1-9-a.pdf"}` },
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "Id fuera de la papelera",
+			body:           func(id string) string { return `{"id":"../facturas/1-a.pdf"}` },
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			userPath := t.TempDir()
+			id := trashTestFile(t, userPath, "facturas", "1-a.pdf", "%PDF-a", time.Now())
+			if tt.setup != nil {
+				tt.setup(t, userPath)
+			}
+			srv := newTestServer(userPath)
+			req, rr := newRestoreRequest(tt.body(id))
+
+			// Act
+			srv.RestoreHandler(rr, req)
+
+			// Assert
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("expected %d, got %d: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+			}
+			trashed, _ := listTrash(NewLocalStorage(userPath), "")
+			if tt.expectedStatus != http.StatusOK {
+				if len(trashed) != 1 {
+					t.Errorf("expected the file to stay in the trash, got %v", trashed)
+				}
+				return
+			}
+			if got, _ := os.ReadFile(filepath.Join(userPath, "facturas", "1-a.pdf")); string(got) != "%PDF-a" {
+				t.Errorf("expected the file to be restored, got %q", got)
+			}
+			if len(trashed) != 0 {
+				t.Errorf("expected the trash to be empty, got %v", trashed)
+			}
+		})
+	}
+}
+
+func TestPurgeTrash(t *testing.T) {
+	// Arrange
+	userPath := t.TempDir()
+	now := time.Now()
+	trashTestFile(t, userPath, "facturas", "1-viejo.pdf", "%PDF-viejo", now.Add(-8*24*time.Hour))
+	trashTestFile(t, userPath, "facturas", "2-reciente.pdf", "%PDF-reciente", now.Add(-time.Hour))
+	store := NewLocalStorage(userPath)
+
+	// Act
+	purged, err := purgeTrash(store, 7*24*time.Hour, now)
+
+	// Assert
+	if err != nil {
+		t.Fatal(err)
+	}
+	if purged != 1 {
+		t.Errorf("expected 1 purged file, got %d", purged)
+	}
+	left, _ := listTrash(store, "")
+	if len(left) != 1 || left[0].File != "2-reciente.pdf" {
+		t.Errorf("expected only the recent file to remain, got %v", left)
+	}
+}
+
+// failingTrashStorage falla al guardar en la papelera el archivo failName
+type failingTrashStorage struct {
+	*memoryStorage
+	failName string
+}
+
+func (f failingTrashStorage) Save(folder, name string, src io.Reader) (int64, error) {
+	if folder == trashFolder && strings.HasSuffix(name, "-"+f.failName) {
+		return 0, errors.New("disco lleno")
+	}
+	return f.memoryStorage.Save(folder, name, src)
+}
+
+func TestDeleteFilesHandlerRestoresTrashedFilesOnFailure(t *testing.T) {
+	// Arrange: el tercer archivo no se puede mover a la papelera
+	store := failingTrashStorage{memoryStorage: newMemoryStorage(), failName: "3-c.pdf"}
+	for _, name := range []string{"1-a.pdf", "2-b.pdf", "3-c.pdf"} {
+		store.Save("test-folder", name, strings.NewReader("%PDF-"+name))
+	}
+	srv := newMemoryStorageServer(t, store)
+	req, rr := NewDeleteRequestBuilder().WithFiles([]string{"1-a.pdf", "2-b.pdf", "3-c.pdf"}).Build(t)
+
+	// Act
+	srv.DeleteFilesHandler(rr, req)
+
+	// Assert: no se borró ninguno y la papelera quedó vacía
+	if rr.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d: %s", rr.Code, rr.Body.String())
+	}
+	left, _ := listStorageFiles(store, "test-folder", ".pdf", "", false)
+	if expected := []string{"1-a.pdf", "2-b.pdf", "3-c.pdf"}; !reflect.DeepEqual(left, expected) {
+		t.Errorf("expected %v left, got %v", expected, left)
+	}
+	if got := string(store.files["test-folder/1-a.pdf"]); got != "%PDF-1-a.pdf" {
+		t.Errorf("expected the restored content, got %q", got)
+	}
+	if items, _ := listTrash(store, ""); len(items) != 0 {
+		t.Errorf("expected an empty trash, got %v", items)
+	}
+}
+
+// failingDeleteStorage falla al borrar el archivo failName de cualquier carpeta
+type failingDeleteStorage struct {
+	*memoryStorage
+	failName string
+}
+
+func (f failingDeleteStorage) Delete(folder, name string) error {
+	if name == f.failName {
+		return errors.New("permiso denegado")
+	}
+	return f.memoryStorage.Delete(folder, name)
+}
+
+func TestDeleteFilesHandlerReportsPartialPurge(t *testing.T) {
+	// Arrange: el segundo archivo no se puede borrar
+	store := failingDeleteStorage{memoryStorage: newMemoryStorage(), failName: "2-b.pdf"}
+	for _, name := range []string{"1-a.pdf", "2-b.pdf", "3-c.pdf"} {
+		store.Save("test-folder", name, strings.NewReader("%PDF-"+name))
+	}
+	srv := newMemoryStorageServer(t, store)
+	req, rr := NewDeleteRequestBuilder().WithFiles([]string{"1-a.pdf", "2-b.pdf", "3-c.pdf"}).WithPurge().Build(t)
+
+	// Act
+	srv.DeleteFilesHandler(rr, req)
+
+	// Assert: la respuesta indica el archivo que ya no se puede recuperar
+	if rr.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var body PartialDeleteResponse
+	if err := json.NewDecoder(rr.Body).Decode(&body); err != nil {
+		t.Fatalf("expected JSON body: %v", err)
+	}
+	if !reflect.DeepEqual(body.Deleted, []string{"1-a.pdf"}) {
+		t.Errorf("expected deleted [1-a.pdf], got %v", body.Deleted)
+	}
+	left, _ := listStorageFiles(store, "test-folder", ".pdf", "", false)
+	if expected := []string{"2-b.pdf", "3-c.pdf"}; !reflect.DeepEqual(left, expected) {
+		t.Errorf("expected %v left, got %v", expected, left)
+	}
+}
@@ -0,0 +1,32 @@
+package pdf
+
+import (
+	"net/http"
+	"time"
+)
+
+// --- Borrado condicional (If-Unmodified-Since) ---
+// Un cliente que borra según un listado viejo podría llevarse un archivo que otra subida
+// reemplazó o agregó después. Con If-Unmodified-Since (la fecha del listado, p. ej. su
+// Last-Modified) DeleteFilesHandler no borra nada si algún archivo cambió desde entonces
+// y responde 412. Sin la cabecera el borrado no se condiciona.
+
+// modifiedSince devuelve el primer archivo de files modificado después de la fecha de
+// If-Unmodified-Since. Sin la cabecera, o con una fecha inválida, no aplica (RFC 9110).
+func modifiedSince(r *http.Request, store Storage, folder string, files []string) (string, bool) {
+	since, err := http.ParseTime(r.Header.Get("If-Unmodified-Since"))
+	if err != nil {
+		return "", false
+	}
+	for _, name := range files {
+		info, err := store.Stat(folder, name)
+		if err != nil {
+			continue
+		}
+		// La cabecera solo tiene precisión de segundos
+		if info.ModTime().Truncate(time.Second).After(since) {
+			return name, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,49 @@
+package pdf
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// --- Subidas comprimidas ---
+// Con Content-Encoding: gzip (o deflate) en la subida, el contenido de cada archivo del
+// formulario viene comprimido; el formulario multipart en sí no. UploadHandler descomprime
+// cada archivo antes de guardarlo.
+
+// Límite de lo que ocupa descomprimido cada archivo de una subida comprimida (MAX_DECOMPRESSED_BYTES)
+var maxDecompressedBytes = defaultConfig.MaxDecompressedBytes
+
+var (
+	errUnsupportedEncoding  = errors.New("Content-Encoding no soportado: use gzip o deflate")
+	errDecompressedTooLarge = errors.New("el archivo descomprimido supera el tamaño máximo permitido")
+)
+
+// uploadContentEncoding devuelve la codificación de los archivos de la subida: "" si no
+// vienen comprimidos, "gzip" o "deflate"
+func uploadContentEncoding(r *http.Request) (string, error) {
+	switch encoding := strings.ToLower(strings.TrimSpace(r.Header.Get("Content-Encoding"))); encoding {
+	case "", "identity":
+		return "", nil
+	case "gzip", "x-gzip":
+		return "gzip", nil
+	case "deflate":
+		return "deflate", nil
+	}
+	return "", errUnsupportedEncoding
+}
+
+// decodingReader devuelve src descomprimido según encoding. En HTTP "deflate" es el
+// formato zlib (RFC 1950), no el flujo deflate sin cabecera.
+func decodingReader(src io.Reader, encoding string) (io.ReadCloser, error) {
+	switch encoding {
+	case "gzip":
+		return gzip.NewReader(src)
+	case "deflate":
+		return zlib.NewReader(src)
+	}
+	return io.NopCloser(src), nil
+}
@@ -0,0 +1,97 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// compressBytes comprime data con el compresor de encoding ("gzip" o "deflate")
+func compressBytes(t *testing.T, data []byte, encoding string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	if encoding == "gzip" {
+		w = gzip.NewWriter(&buf)
+	} else {
+		w = zlib.NewWriter(&buf)
+	}
+	w.Write(data)
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestUploadHandlerContentEncoding(t *testing.T) {
+	tests := []struct {
+		name           string
+		encoding       string
+		fileName       string
+		compress       bool
+		maxBytes       int64
+		expectedStatus int
+		expectedSaved  bool
+	}{
+		{name: "PDF comprimido con gzip", encoding: "gzip", fileName: "a.pdf", compress: true, expectedStatus: http.StatusOK, expectedSaved: true},
+		{name: "PDF comprimido con deflate", encoding: "deflate", fileName: "a.pdf", compress: true, expectedStatus: http.StatusOK, expectedSaved: true},
+		{name: "Sin Content-Encoding se guarda tal cual", encoding: "", fileName: "a.pdf", expectedStatus: http.StatusOK, expectedSaved: true},
+		{name: "Codificación no soportada", encoding: "br", fileName: "a.pdf", expectedStatus: http.StatusUnsupportedMediaType},
+		{name: "ZIP con Content-Encoding", encoding: "gzip", fileName: "a.zip", compress: true, expectedStatus: http.StatusUnsupportedMediaType},
+		{name: "Contenido que no es gzip", encoding: "gzip", fileName: "a.pdf", expectedStatus: http.StatusUnprocessableEntity},
+		{name: "Descomprimido supera el límite", encoding: "gzip", fileName: "a.pdf", compress: true, maxBytes: 16, expectedStatus: http.StatusUnprocessableEntity},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			if tt.maxBytes > 0 {
+				original := maxDecompressedBytes
+				defer func() { maxDecompressedBytes = original }()
+				maxDecompressedBytes = tt.maxBytes
+			}
+			userPath := t.TempDir()
+			pdfPath := filepath.Join(t.TempDir(), "a.pdf")
+			writeTestPDF(t, pdfPath, 1)
+			original, _ := os.ReadFile(pdfPath)
+			content := original
+			if tt.compress {
+				content = compressBytes(t, original, tt.encoding)
+			}
+			srv := newTestServer(userPath)
+			req, rr := NewUploadRequestBuilder().WithFile(tt.fileName, content).Build(t)
+			if tt.encoding != "" {
+				req.Header.Set("Content-Encoding", tt.encoding)
+			}
+
+			// Act
+			srv.UploadHandler(rr, req)
+
+			// Assert
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("expected %d, got %d: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+			}
+			stored, err := os.ReadFile(filepath.Join(userPath, "test-folder", "1-a.pdf"))
+			if !tt.expectedSaved {
+				if err == nil {
+					t.Errorf("expected no file to be stored")
+				}
+				return
+			}
+			if !bytes.Equal(stored, original) {
+				t.Errorf("expected the stored file to match the original PDF (%d bytes), got %d bytes", len(original), len(stored))
+			}
+			var result UploadResult
+			json.NewDecoder(rr.Body).Decode(&result)
+			if len(result.Saved) != 1 || result.Saved[0] != "1-a.pdf" {
+				t.Errorf("expected 1-a.pdf to be saved, got %v", result.Saved)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package pdf
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// --- Extensiones permitidas en las subidas ---
+// ALLOWED_EXTENSIONS decide qué tipos de archivo pueden guardarse en una carpeta; por
+// defecto solo .pdf. Lo que cuenta es lo que se guarda: una imagen con convert=true se
+// guarda como PDF y un ZIP nunca se guarda, solo se extraen sus PDFs. Para guardar las
+// imágenes tal cual hay que agregar su extensión (ej: ".pdf,.png,.jpg").
+
+// Extensiones permitidas, en minúsculas y con el punto (ALLOWED_EXTENSIONS)
+var allowedExtensions = defaultConfig.AllowedExtensions
+
+var extensionRe = regexp.MustCompile(`^\.[a-z0-9]+$`)
+
+// validExtension indica si ext tiene la forma ".pdf"
+func validExtension(ext string) bool {
+	return extensionRe.MatchString(ext)
+}
+
+// normalizeExtensions pasa las extensiones a minúsculas y les agrega el punto si falta,
+// así "PDF" y ".pdf" significan lo mismo en ALLOWED_EXTENSIONS
+func normalizeExtensions(extensions []string) []string {
+	normalized := make([]string, len(extensions))
+	for i, ext := range extensions {
+		ext = strings.ToLower(ext)
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		normalized[i] = ext
+	}
+	return normalized
+}
+
+// checkUploadExtension devuelve el motivo por el que name no se puede subir, o nil.
+// convert indica si las imágenes se van a convertir a PDF.
+func checkUploadExtension(name string, convert bool) error {
+	if isZipFile(name) {
+		return nil
+	}
+	ext := strings.ToLower(filepath.Ext(name))
+	if convert && isImageFile(name) {
+		ext = ".pdf"
+	}
+	if slices.Contains(allowedExtensions, ext) {
+		return nil
+	}
+	if ext == "" {
+		return fmt.Errorf("el archivo no tiene extensión (permitidas: %s)", strings.Join(allowedExtensions, ", "))
+	}
+	return fmt.Errorf("extensión no permitida: %s (permitidas: %s)", ext, strings.Join(allowedExtensions, ", "))
+}
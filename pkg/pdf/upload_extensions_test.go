@@ -0,0 +1,100 @@
+package pdf
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCheckUploadExtension(t *testing.T) {
+	tests := []struct {
+		name        string
+		allowed     []string
+		filename    string
+		convert     bool
+		expectError bool
+	}{
+		{name: "PDF permitido", allowed: []string{".pdf"}, filename: "a.pdf", expectError: false},
+		{name: "Mayúsculas", allowed: []string{".pdf"}, filename: "A.PDF", expectError: false},
+		{name: "Texto no permitido", allowed: []string{".pdf"}, filename: "notas.txt", expectError: true},
+		{name: "Sin extensión", allowed: []string{".pdf"}, filename: "informe", expectError: true},
+		{name: "Imagen que se convierte a PDF", allowed: []string{".pdf"}, filename: "scan.png", convert: true, expectError: false},
+		{name: "Imagen que se guarda tal cual", allowed: []string{".pdf"}, filename: "scan.png", expectError: true},
+		{name: "Imagen permitida expresamente", allowed: []string{".pdf", ".png"}, filename: "scan.png", expectError: false},
+		{name: "El ZIP solo aporta sus PDFs", allowed: []string{".pdf"}, filename: "lote.zip", expectError: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			original := allowedExtensions
+			defer func() { allowedExtensions = original }()
+			allowedExtensions = tt.allowed
+
+			// Act
+			err := checkUploadExtension(tt.filename, tt.convert)
+
+			// Assert
+			if (err != nil) != tt.expectError {
+				t.Errorf("expected error %v, got %v", tt.expectError, err)
+			}
+		})
+	}
+}
+
+func TestNormalizeExtensions(t *testing.T) {
+	got := normalizeExtensions([]string{"PDF", ".Png", ".jpg"})
+	expected := []string{".pdf", ".png", ".jpg"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+}
+
+func TestUploadHandlerRejectsDisallowedExtension(t *testing.T) {
+	// Arrange
+	userPath := t.TempDir()
+	srv := newTestServer(userPath)
+	req, rr := NewUploadRequestBuilder().
+		WithFile("a.pdf", []byte("%PDF-1.4")).
+		WithFile("datos.csv", []byte("a,b")).
+		Build(t)
+
+	// Act
+	srv.UploadHandler(rr, req)
+
+	// Assert
+	assertValidationErrors(t, rr.Code, rr.Body.Bytes(), []string{uploadFieldName})
+	var resp ValidationErrorResponse
+	json.Unmarshal(rr.Body.Bytes(), &resp)
+	if !strings.Contains(resp.Errors[0].Message, "datos.csv") || !strings.Contains(resp.Errors[0].Message, ".csv") {
+		t.Errorf("expected the file and its extension in the reason, got %q", resp.Errors[0].Message)
+	}
+	files, _ := ListFilesWithExtension(filepath.Join(userPath, "test-folder"), ".pdf")
+	if len(files) != 0 {
+		t.Errorf("nothing must be stored when a file is rejected, got %v", files)
+	}
+}
+
+func TestUploadHandlerCountsStoredImages(t *testing.T) {
+	// Arrange
+	original := allowedExtensions
+	defer func() { allowedExtensions = original }()
+	allowedExtensions = []string{".pdf", ".png"}
+	userPath := t.TempDir()
+	srv := newTestServer(userPath)
+	first, firstRR := NewUploadRequestBuilder().WithFile("scan.png", pngBytes(t)).Build(t)
+	srv.UploadHandler(firstRR, first)
+	req, rr := NewUploadRequestBuilder().WithFile("a.pdf", []byte("%PDF-1.4")).Build(t)
+
+	// Act
+	srv.UploadHandler(rr, req)
+
+	// Assert
+	var result UploadResult
+	json.NewDecoder(rr.Body).Decode(&result)
+	if !reflect.DeepEqual(result.Saved, []string{"2-a.pdf"}) {
+		t.Errorf("expected [2-a.pdf] after 1-scan.png, got %v (%s)", result.Saved, firstRR.Body.String())
+	}
+}
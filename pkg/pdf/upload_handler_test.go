@@ -0,0 +1,447 @@
+package pdf
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"image"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+)
+
+// Test Data Builder para las solicitudes de subida multipart
+type UploadRequestBuilder struct {
+	folder string
+	fields map[string]string
+	files  []uploadTestFile
+}
+
+type uploadTestFile struct {
+	field   string
+	name    string
+	content []byte
+}
+
+func NewUploadRequestBuilder() *UploadRequestBuilder {
+	return &UploadRequestBuilder{
+		folder: "test-folder",
+		fields: map[string]string{},
+	}
+}
+
+func (b *UploadRequestBuilder) WithFolder(folder string) *UploadRequestBuilder {
+	b.folder = folder
+	return b
+}
+
+func (b *UploadRequestBuilder) WithField(key, value string) *UploadRequestBuilder {
+	b.fields[key] = value
+	return b
+}
+
+func (b *UploadRequestBuilder) WithFile(name string, content []byte) *UploadRequestBuilder {
+	return b.WithFieldFile("pdfs", name, content)
+}
+
+func (b *UploadRequestBuilder) WithFieldFile(field, name string, content []byte) *UploadRequestBuilder {
+	b.files = append(b.files, uploadTestFile{field: field, name: name, content: content})
+	return b
+}
+
+func (b *UploadRequestBuilder) Build(t *testing.T) (*http.Request, *httptest.ResponseRecorder) {
+	t.Helper()
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	mw.WriteField("folder", b.folder)
+	for key, value := range b.fields {
+		mw.WriteField(key, value)
+	}
+	for _, f := range b.files {
+		part, err := mw.CreateFormFile(f.field, f.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		part.Write(f.content)
+	}
+	mw.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/upload", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	req = req.WithContext(context.WithValue(req.Context(), userCodeKey, "testUser"))
+	return req, httptest.NewRecorder()
+}
+
+// pngBytes devuelve una imagen PNG pequeña para las pruebas de conversión
+func pngBytes(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 10, 10))); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestUploadHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		builder        func(t *testing.T) *UploadRequestBuilder
+		expectedStatus int
+		expectedFiles  []string
+	}{
+		{
+			name: "Subir PDFs agrega el prefijo numérico",
+			builder: func(t *testing.T) *UploadRequestBuilder {
+				return NewUploadRequestBuilder().
+					WithFile("a.pdf", []byte("%PDF-1.4")).
+					WithFile("b.pdf", []byte("%PDF-1.4"))
+			},
+			expectedStatus: http.StatusOK,
+			expectedFiles:  []string{"1-a.pdf", "2-b.pdf"},
+		},
+		{
+			name: "Convertir imagen a PDF con convert=true",
+			builder: func(t *testing.T) *UploadRequestBuilder {
+				return NewUploadRequestBuilder().
+					WithField("convert", "true").
+					WithFile("scan.png", pngBytes(t))
+			},
+			expectedStatus: http.StatusOK,
+			expectedFiles:  []string{"1-scan.pdf"},
+		},
+		{
+			name: "Rechazar archivos que no son PDF ni imagen",
+			builder: func(t *testing.T) *UploadRequestBuilder {
+				return NewUploadRequestBuilder().
+					WithFile("a.pdf", []byte("%PDF-1.4")).
+					WithFile("notes.txt", []byte("hola"))
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedFiles:  []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			userPath := t.TempDir()
+
+			srv := newTestServer(userPath)
+
+			req, rr := tt.builder(t).Build(t)
+
+			// Act
+			srv.UploadHandler(rr, req)
+
+			// Assert
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("handler returned wrong status code: got %v want %v (%s)", rr.Code, tt.expectedStatus, rr.Body.String())
+			}
+			files, _ := ListFilesWithExtension(filepath.Join(userPath, "test-folder"), ".pdf")
+			if len(files) != len(tt.expectedFiles) {
+				t.Fatalf("expected files %v, got %v", tt.expectedFiles, files)
+			}
+			for i, expectedFile := range tt.expectedFiles {
+				if files[i] != expectedFile {
+					t.Errorf("expected file %s at position %d, got %s", expectedFile, i, files[i])
+				}
+			}
+		})
+	}
+}
+
+func TestUploadHandlerConvertedImageIsValidPDF(t *testing.T) {
+	// Arrange
+	userPath := t.TempDir()
+
+	srv := newTestServer(userPath)
+
+	req, rr := NewUploadRequestBuilder().WithField("convert", "true").WithFile("scan.png", pngBytes(t)).Build(t)
+
+	// Act
+	srv.UploadHandler(rr, req)
+
+	// Assert
+	pages, err := api.PageCountFile(filepath.Join(userPath, "test-folder", "1-scan.pdf"))
+	if err != nil || pages != 1 {
+		t.Errorf("expected a single page PDF, got %d pages (err: %v)", pages, err)
+	}
+	if _, err := os.Stat(filepath.Join(userPath, "test-folder", "1-scan.png")); !os.IsNotExist(err) {
+		t.Errorf("the original image must not be stored when converting")
+	}
+}
+
+func TestUploadHandlerFieldName(t *testing.T) {
+	tests := []struct {
+		name          string
+		fieldName     string
+		files         []uploadTestFile
+		expectedFiles []string
+	}{
+		{
+			name:          "Campo por defecto",
+			fieldName:     "pdfs",
+			files:         []uploadTestFile{{field: "pdfs", name: "a.pdf"}},
+			expectedFiles: []string{"1-a.pdf"},
+		},
+		{
+			name:          "Campo configurado",
+			fieldName:     "documento",
+			files:         []uploadTestFile{{field: "documento", name: "a.pdf"}, {field: "otro", name: "b.pdf"}},
+			expectedFiles: []string{"1-a.pdf"},
+		},
+		{
+			name:          "Sin el campo configurado se usan todos los campos",
+			fieldName:     "pdfs",
+			files:         []uploadTestFile{{field: "file", name: "b.pdf"}, {field: "files[]", name: "c.pdf"}},
+			expectedFiles: []string{"1-b.pdf", "2-c.pdf"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			userPath := t.TempDir()
+
+			originalField := uploadFieldName
+			defer func() { uploadFieldName = originalField }()
+			srv := newTestServer(userPath)
+			uploadFieldName = tt.fieldName
+
+			builder := NewUploadRequestBuilder()
+			for _, f := range tt.files {
+				builder.WithFieldFile(f.field, f.name, []byte("%PDF-1.4"))
+			}
+			req, rr := builder.Build(t)
+
+			// Act
+			srv.UploadHandler(rr, req)
+
+			// Assert
+			if rr.Code != http.StatusOK {
+				t.Fatalf("handler returned wrong status code: got %v want %v (%s)", rr.Code, http.StatusOK, rr.Body.String())
+			}
+			files, err := ListFilesWithExtension(filepath.Join(userPath, "test-folder"), ".pdf")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(files, tt.expectedFiles) {
+				t.Errorf("expected %v, got %v", tt.expectedFiles, files)
+			}
+		})
+	}
+}
+
+func TestUploadHandlerPartialFailure(t *testing.T) {
+	// Arrange
+	userPath := t.TempDir()
+
+	srv := newTestServer(userPath)
+
+	req, rr := NewUploadRequestBuilder().
+		WithField("convert", "true").
+		WithFile("a.pdf", []byte("%PDF-1.4")).
+		WithFile("roto.png", []byte("no es una imagen")).
+		WithFile("scan.png", pngBytes(t)).
+		Build(t)
+
+	// Act
+	srv.UploadHandler(rr, req)
+
+	// Assert
+	if rr.Code != http.StatusMultiStatus {
+		t.Fatalf("handler returned wrong status code: got %v want %v (%s)", rr.Code, http.StatusMultiStatus, rr.Body.String())
+	}
+	var result UploadResult
+	if err := json.NewDecoder(rr.Body).Decode(&result); err != nil {
+		t.Fatalf("expected JSON body: %v", err)
+	}
+	if !reflect.DeepEqual(result.Saved, []string{"1-a.pdf", "2-scan.pdf"}) {
+		t.Errorf("unexpected saved files %v", result.Saved)
+	}
+	if len(result.Failed) != 1 || result.Failed[0].Name != "roto.png" || result.Failed[0].Error == "" {
+		t.Errorf("unexpected failed files %+v", result.Failed)
+	}
+	files, _ := ListFilesWithExtension(filepath.Join(userPath, "test-folder"), ".pdf")
+	if !reflect.DeepEqual(files, result.Saved) {
+		t.Errorf("expected only the saved files on disk, got %v", files)
+	}
+}
+
+// zipBytes crea un ZIP en memoria con las entradas indicadas (nombre -> contenido)
+func zipBytes(t *testing.T, entries [][2]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, e := range entries {
+		w, err := zw.Create(e[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(e[1]))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestUploadHandlerZip(t *testing.T) {
+	tests := []struct {
+		name           string
+		entries        [][2]string
+		maxBytes       int64
+		expectedStatus int
+		expectedSaved  []string
+		expectedFailed int
+	}{
+		{
+			name:           "Extrae solo los PDFs en orden numérico",
+			entries:        [][2]string{{"b.pdf", "%PDF-b"}, {"docs/", ""}, {"docs/a.pdf", "%PDF-a"}, {"notes.txt", "hola"}},
+			maxBytes:       1 << 20,
+			expectedStatus: http.StatusOK,
+			expectedSaved:  []string{"1-a.pdf", "2-b.pdf"},
+		},
+		{
+			name:           "Rechaza rutas fuera del ZIP",
+			entries:        [][2]string{{"../evil.pdf", "%PDF"}, {"/abs.pdf", "%PDF"}, {"ok.pdf", "%PDF"}},
+			maxBytes:       1 << 20,
+			expectedStatus: http.StatusMultiStatus,
+			expectedSaved:  []string{"1-ok.pdf"},
+			expectedFailed: 2,
+		},
+		{
+			name:           "Limita el tamaño descomprimido",
+			entries:        [][2]string{{"a.pdf", strings.Repeat("x", 100)}},
+			maxBytes:       10,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedSaved:  []string{},
+			expectedFailed: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			userPath := t.TempDir()
+
+			originalMax := maxZipExtractBytes
+			defer func() { maxZipExtractBytes = originalMax }()
+			srv := newTestServer(userPath)
+			maxZipExtractBytes = tt.maxBytes
+
+			req, rr := NewUploadRequestBuilder().WithFile("lote.zip", zipBytes(t, tt.entries)).Build(t)
+
+			// Act
+			srv.UploadHandler(rr, req)
+
+			// Assert
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("handler returned wrong status code: got %v want %v (%s)", rr.Code, tt.expectedStatus, rr.Body.String())
+			}
+			var result UploadResult
+			if err := json.NewDecoder(rr.Body).Decode(&result); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(result.Saved, tt.expectedSaved) || len(result.Failed) != tt.expectedFailed {
+				t.Errorf("unexpected result %+v", result)
+			}
+			files, _ := ListFilesWithExtension(filepath.Join(userPath, "test-folder"), ".pdf")
+			if len(files) != len(tt.expectedSaved) {
+				t.Errorf("expected %v on disk, got %v", tt.expectedSaved, files)
+			}
+			if _, err := os.Stat(filepath.Join(userPath, "evil.pdf")); !os.IsNotExist(err) {
+				t.Errorf("expected no file outside the folder")
+			}
+		})
+	}
+}
+
+func TestUploadHandlerMaxFilesPerFolder(t *testing.T) {
+	tests := []struct {
+		name           string
+		limit          int
+		builder        func(t *testing.T) *UploadRequestBuilder
+		expectedStatus int
+	}{
+		{
+			name:  "Sin límite configurado",
+			limit: 0,
+			builder: func(t *testing.T) *UploadRequestBuilder {
+				return NewUploadRequestBuilder().WithFile("b.pdf", []byte("%PDF"))
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:  "Dentro del límite",
+			limit: 2,
+			builder: func(t *testing.T) *UploadRequestBuilder {
+				return NewUploadRequestBuilder().WithFile("b.pdf", []byte("%PDF"))
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:  "Supera el límite",
+			limit: 2,
+			builder: func(t *testing.T) *UploadRequestBuilder {
+				return NewUploadRequestBuilder().WithFile("b.pdf", []byte("%PDF")).WithFile("c.pdf", []byte("%PDF"))
+			},
+			expectedStatus: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:  "Los PDFs de un ZIP cuentan uno a uno",
+			limit: 2,
+			builder: func(t *testing.T) *UploadRequestBuilder {
+				return NewUploadRequestBuilder().WithFile("lote.zip", zipBytes(t, [][2]string{{"b.pdf", "%PDF"}, {"c.pdf", "%PDF"}}))
+			},
+			expectedStatus: http.StatusRequestEntityTooLarge,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			userPath := t.TempDir()
+			os.MkdirAll(filepath.Join(userPath, "test-folder"), os.ModePerm)
+			os.WriteFile(filepath.Join(userPath, "test-folder", "1-a.pdf"), []byte("%PDF"), 0o644)
+
+			originalLimit := maxFilesPerFolder
+			defer func() { maxFilesPerFolder = originalLimit }()
+			srv := newTestServer(userPath)
+			maxFilesPerFolder = tt.limit
+
+			req, rr := tt.builder(t).Build(t)
+
+			// Act
+			srv.UploadHandler(rr, req)
+
+			// Assert
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("handler returned wrong status code: got %v want %v (%s)", rr.Code, tt.expectedStatus, rr.Body.String())
+			}
+			if tt.expectedStatus != http.StatusRequestEntityTooLarge {
+				return
+			}
+			var resp FolderLimitResponse
+			if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+				t.Fatal(err)
+			}
+			if resp.Count != 1 || resp.Limit != tt.limit || resp.Error == "" {
+				t.Errorf("unexpected limit response %+v", resp)
+			}
+			if files, _ := ListFilesWithExtension(filepath.Join(userPath, "test-folder"), ".pdf"); len(files) != 1 {
+				t.Errorf("expected no new files to be saved, got %v", files)
+			}
+		})
+	}
+}
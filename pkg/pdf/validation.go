@@ -0,0 +1,51 @@
+package pdf
+
+import (
+	"net/http"
+	"strings"
+)
+
+// validationErrors acumula los problemas de validación de una petición para responderlos
+// todos juntos, en lugar de que el cliente los descubra y corrija de uno en uno
+type validationErrors []FieldError
+
+func (v *validationErrors) add(field, message string) {
+	*v = append(*v, FieldError{Field: field, Message: message})
+}
+
+// checkFolder anota si falta la carpeta o si su nombre no es válido según validFolderName
+func (v *validationErrors) checkFolder(folder string) {
+	switch {
+	case folder == "":
+		v.add("folder", "Falta el nombre de la carpeta")
+	case !validFolderName(folder):
+		v.add("folder", "Nombre de carpeta no válido")
+	}
+}
+
+// validFolderName indica si folder puede ser una carpeta del usuario: no sale de su espacio
+// ni empieza por punto, que queda para carpetas internas como la papelera, ni es la
+// carpeta de las salidas (ver outputDirRoot)
+func validFolderName(folder string) bool {
+	if root := outputDirRoot(); root != "" && folder == root {
+		return false
+	}
+	return validFileName(folder) && !strings.HasPrefix(folder, ".")
+}
+
+// respond responde 400 con todos los problemas si hay alguno y devuelve true si respondió.
+// error resume los mensajes para los clientes que solo leen ese campo.
+func (v validationErrors) respond(w http.ResponseWriter) bool {
+	if len(v) == 0 {
+		return false
+	}
+	messages := make([]string, len(v))
+	for i, problem := range v {
+		messages[i] = problem.Message
+	}
+	writeJSON(w, http.StatusBadRequest, ValidationErrorResponse{
+		ErrorResponse: ErrorResponse{Error: strings.Join(messages, "; "), Status: http.StatusBadRequest},
+		Errors:        v,
+	})
+	return true
+}
@@ -0,0 +1,185 @@
+package pdf
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestGenerateHandlerValidationErrors(t *testing.T) {
+	tests := []struct {
+		name           string
+		form           url.Values
+		expectedFields []string
+	}{
+		{name: "Falta la carpeta", form: url.Values{}, expectedFields: []string{"folder"}},
+		{name: "Carpeta con ruta", form: url.Values{"folder": {"../otra"}}, expectedFields: []string{"folder"}},
+		{name: "Carpeta reservada", form: url.Values{"folder": {".trash"}}, expectedFields: []string{"folder"}},
+		{
+			name: "Todos los problemas juntos",
+			form: url.Values{
+				"output":       {"../fuera"},
+				"mode":         {"interleave"},
+				"bookmarks":    {"true"},
+				"normalize":    {"A3"},
+				"indices":      {"uno"},
+				"callback_url": {"https://example.com/aviso"},
+			},
+			expectedFields: []string{"folder", "output", "bookmarks", "normalize", "indices", "callback_url"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			srv := newTestServer(t.TempDir())
+			req, rr := newGenerateRequest(tt.form)
+
+			// Act
+			srv.GenerateHandler(rr, req)
+
+			// Assert
+			assertValidationErrors(t, rr.Code, rr.Body.Bytes(), tt.expectedFields)
+		})
+	}
+}
+
+func TestUploadHandlerValidationErrors(t *testing.T) {
+	tests := []struct {
+		name           string
+		builder        *UploadRequestBuilder
+		expectedFields []string
+	}{
+		{
+			name:           "Carpeta y tipos inválidos juntos",
+			builder:        NewUploadRequestBuilder().WithFolder("").WithFile("a.txt", []byte("texto")).WithFile("b.exe", []byte("MZ")),
+			expectedFields: []string{"folder", "pdfs", "pdfs"},
+		},
+		{
+			name:           "Carpeta con ruta",
+			builder:        NewUploadRequestBuilder().WithFolder("a/b").WithFile("a.pdf", []byte("%PDF-1.4")),
+			expectedFields: []string{"folder"},
+		},
+		{
+			name:           "Carpeta que empieza por punto",
+			builder:        NewUploadRequestBuilder().WithFolder(".trash").WithFile("a.pdf", []byte("%PDF-1.4")),
+			expectedFields: []string{"folder"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			srv := newTestServer(t.TempDir())
+			req, rr := tt.builder.Build(t)
+
+			// Act
+			srv.UploadHandler(rr, req)
+
+			// Assert
+			assertValidationErrors(t, rr.Code, rr.Body.Bytes(), tt.expectedFields)
+		})
+	}
+}
+
+// assertValidationErrors comprueba un 400 con un error por cada campo esperado, en orden
+func assertValidationErrors(t *testing.T, status int, body []byte, expectedFields []string) {
+	t.Helper()
+	if status != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d: %s", status, body)
+	}
+	var resp ValidationErrorResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		t.Fatalf("expected JSON body: %v", err)
+	}
+	fields := []string{}
+	for _, problem := range resp.Errors {
+		if problem.Message == "" {
+			t.Errorf("expected a message for %s", problem.Field)
+		}
+		fields = append(fields, problem.Field)
+	}
+	if !reflect.DeepEqual(fields, expectedFields) {
+		t.Errorf("expected errors for %v, got %v", expectedFields, fields)
+	}
+	if resp.Error == "" || resp.Status != http.StatusBadRequest {
+		t.Errorf("expected the summary error fields to be set, got %+v", resp.ErrorResponse)
+	}
+}
+
+func TestFolderHandlersRejectFoldersOutsideUser(t *testing.T) {
+	tests := []struct {
+		name    string
+		method  string
+		handler func(s *Server) http.HandlerFunc
+	}{
+		{name: "append", method: http.MethodPost, handler: func(s *Server) http.HandlerFunc { return s.AppendHandler }},
+		{name: "upload-chunk/complete", method: http.MethodPost, handler: func(s *Server) http.HandlerFunc { return s.UploadChunkCompleteHandler }},
+		{name: "clear", method: http.MethodDelete, handler: func(s *Server) http.HandlerFunc { return s.ClearFolderHandler }},
+		{name: "count", method: http.MethodGet, handler: func(s *Server) http.HandlerFunc { return s.CountHandler }},
+		{name: "download", method: http.MethodGet, handler: func(s *Server) http.HandlerFunc { return s.DownloadHandler }},
+		{name: "exists", method: http.MethodGet, handler: func(s *Server) http.HandlerFunc { return s.ExistsHandler }},
+		{name: "extract", method: http.MethodPost, handler: func(s *Server) http.HandlerFunc { return s.ExtractHandler }},
+		{name: "list", method: http.MethodGet, handler: func(s *Server) http.HandlerFunc { return s.ListHandler }},
+		{name: "manifest", method: http.MethodGet, handler: func(s *Server) http.HandlerFunc { return s.ManifestHandler }},
+		{name: "next-index", method: http.MethodGet, handler: func(s *Server) http.HandlerFunc { return s.NextIndexHandler }},
+		{name: "preview", method: http.MethodGet, handler: func(s *Server) http.HandlerFunc { return s.PreviewHandler }},
+		{name: "repair", method: http.MethodPost, handler: func(s *Server) http.HandlerFunc { return s.RepairHandler }},
+		{name: "thumbnail", method: http.MethodGet, handler: func(s *Server) http.HandlerFunc { return s.ThumbnailHandler }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange: otro usuario junto al espacio del que hace la petición
+			root := t.TempDir()
+			userPath := filepath.Join(root, "alex")
+			otherPath := filepath.Join(root, "otro")
+			os.MkdirAll(userPath, os.ModePerm)
+			os.MkdirAll(otherPath, os.ModePerm)
+			writeTestPDF(t, filepath.Join(otherPath, "1-a.pdf"), 1)
+			srv := newTestServer(userPath)
+			form := url.Values{"folder": {"../otro"}, "file": {"1-a.pdf"}, "filename": {"1-a.pdf"}, "upload_id": {"abc"}, "total": {"1"}, "pages": {"1"}}
+			req, rr := newGenerateRequest(form)
+			if tt.method != http.MethodPost {
+				req = httptest.NewRequest(tt.method, "/?"+form.Encode(), nil)
+				req = req.WithContext(context.WithValue(req.Context(), userCodeKey, "testUser"))
+			}
+
+			// Act
+			tt.handler(srv)(rr, req)
+
+			// Assert
+			assertValidationErrors(t, rr.Code, rr.Body.Bytes(), []string{"folder"})
+			if _, err := os.Stat(filepath.Join(otherPath, "1-a.pdf")); err != nil {
+				t.Errorf("expected the other user's file to survive: %v", err)
+			}
+		})
+	}
+}
+
+func TestDeleteFilesHandlerRejectsFolderOutsideUser(t *testing.T) {
+	// Arrange
+	root := t.TempDir()
+	userPath := filepath.Join(root, "alex")
+	otherPath := filepath.Join(root, "otro")
+	os.MkdirAll(userPath, os.ModePerm)
+	os.MkdirAll(otherPath, os.ModePerm)
+	writeTestPDF(t, filepath.Join(otherPath, "1-a.pdf"), 1)
+	srv := newTestServer(userPath)
+	req, rr := NewDeleteRequestBuilder().WithFolder("../otro").WithFiles([]string{"1-a.pdf"}).WithPurge().Build(t)
+
+	// Act
+	srv.DeleteFilesHandler(rr, req)
+
+	// Assert
+	assertValidationErrors(t, rr.Code, rr.Body.Bytes(), []string{"folder"})
+	if _, err := os.Stat(filepath.Join(otherPath, "1-a.pdf")); err != nil {
+		t.Errorf("expected the other user's file to survive: %v", err)
+	}
+}
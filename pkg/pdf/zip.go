@@ -0,0 +1,124 @@
+package pdf
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Límite del total descomprimido de un ZIP subido (MAX_ZIP_EXTRACT_BYTES, por defecto 200 MB)
+var maxZipExtractBytes = defaultConfig.MaxZipExtractBytes
+
+var errZipTooLarge = errors.New("el contenido del ZIP supera el tamaño máximo permitido")
+
+// isZipFile indica si el archivo subido es un ZIP por su extensión
+func isZipFile(name string) bool {
+	return strings.HasSuffix(strings.ToLower(name), ".zip")
+}
+
+// countZipPDFs cuenta las entradas .pdf del ZIP; un ZIP ilegible cuenta como 0 y
+// extractZipPDFs informará el error al procesarlo
+func countZipPDFs(fileHeader *uploadedFile) int {
+	file, err := fileHeader.Open()
+	if err != nil {
+		return 0
+	}
+	defer file.Close()
+
+	archive, err := zip.NewReader(file, fileHeader.Size)
+	if err != nil {
+		return 0
+	}
+	count := 0
+	for _, entry := range archive.File {
+		if !entry.FileInfo().IsDir() && strings.HasSuffix(strings.ToLower(entry.Name), ".pdf") {
+			count++
+		}
+	}
+	return count
+}
+
+// extractZipPDFs guarda los .pdf del ZIP en folder, ordenados por número como en
+// ListFilesWithExtension y con el prefijo a partir de startIndex. Se ignoran carpetas y
+// archivos que no son PDF; las entradas con rutas que salen del ZIP se informan como fallidas.
+func extractZipPDFs(fileHeader *uploadedFile, store Storage, folder string, startIndex int) ([]string, []UploadFailure) {
+	saved := []string{}
+	fail := func(name string, err error) []UploadFailure {
+		return []UploadFailure{{Name: name, Error: err.Error()}}
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return saved, fail(fileHeader.Filename, fmt.Errorf("error al abrir archivo: %w", err))
+	}
+	defer file.Close()
+
+	archive, err := zip.NewReader(file, fileHeader.Size)
+	if err != nil {
+		return saved, fail(fileHeader.Filename, fmt.Errorf("ZIP inválido: %w", err))
+	}
+
+	var entries []*zip.File
+	var failed []UploadFailure
+	var declared uint64
+	for _, entry := range archive.File {
+		if entry.FileInfo().IsDir() || !strings.HasSuffix(strings.ToLower(entry.Name), ".pdf") {
+			continue
+		}
+		// Zip-slip: solo se aceptan rutas relativas que no salen del archivo
+		if !filepath.IsLocal(entry.Name) || !validFileName(path.Base(entry.Name)) {
+			failed = append(failed, UploadFailure{Name: fileHeader.Filename + "/" + entry.Name, Error: "ruta no permitida dentro del ZIP"})
+			continue
+		}
+		declared += entry.UncompressedSize64
+		entries = append(entries, entry)
+	}
+	if declared > uint64(maxZipExtractBytes) {
+		return saved, fail(fileHeader.Filename, errZipTooLarge)
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return lessByNumber(path.Base(entries[i].Name), path.Base(entries[j].Name))
+	})
+
+	remaining := maxZipExtractBytes
+	for _, entry := range entries {
+		filename := prefixedName(path.Base(entry.Name), startIndex+len(saved))
+		written, err := extractZipEntry(entry, store, folder, filename, remaining)
+		if err != nil {
+			failed = append(failed, UploadFailure{Name: fileHeader.Filename + "/" + entry.Name, Error: err.Error()})
+			if errors.Is(err, errZipTooLarge) {
+				break
+			}
+			continue
+		}
+		remaining -= written
+		saved = append(saved, filename)
+	}
+	return saved, failed
+}
+
+// extractZipEntry guarda la entrada como folder/name sin escribir más de limit bytes: el
+// tamaño declarado en el ZIP puede ser falso, así que se controla también al descomprimir
+func extractZipEntry(entry *zip.File, store Storage, folder, name string, limit int64) (int64, error) {
+	src, err := entry.Open()
+	if err != nil {
+		return 0, err
+	}
+	defer src.Close()
+
+	written, err := store.Save(folder, name, io.LimitReader(src, limit+1))
+	if err != nil {
+		return 0, fmt.Errorf("error al guardar archivo: %w", err)
+	}
+	if written > limit {
+		store.Delete(folder, name) // No dejar un archivo truncado
+		return 0, errZipTooLarge
+	}
+	return written, nil
+}